@@ -0,0 +1,207 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/beacon/engine"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto/kzg4844"
+)
+
+// blobCommitmentVersionKZG is the version byte of blob versioned hashes
+// derived from KZG commitments.
+const blobCommitmentVersionKZG uint8 = 0x01
+
+// ExecutionBlock is the execution payload of a beacon block, along with the
+// beacon block fields required for importing it through the engine API.
+type ExecutionBlock struct {
+	Slot            uint64
+	ParentRoot      common.Hash // Parent beacon block root, required post-Cancun
+	Payload         *engine.ExecutableData
+	BlobCommitments []kzg4844.Commitment
+}
+
+// VersionedHashes returns the blob versioned hashes derived from the blob KZG
+// commitments of the block. Nil is returned for pre-Deneb blocks.
+func (b *ExecutionBlock) VersionedHashes() []common.Hash {
+	if b.Payload.BlobGasUsed == nil {
+		return nil
+	}
+	hashes := make([]common.Hash, len(b.BlobCommitments))
+	for i, commitment := range b.BlobCommitments {
+		hashes[i] = sha256.Sum256(commitment[:])
+		hashes[i][0] = blobCommitmentVersionKZG
+	}
+	return hashes
+}
+
+// jsonWithdrawal is the beacon REST API representation of a withdrawal.
+type jsonWithdrawal struct {
+	Index     common.Decimal `json:"index"`
+	Validator common.Decimal `json:"validator_index"`
+	Address   common.Address `json:"address"`
+	Amount    common.Decimal `json:"amount"`
+}
+
+// jsonExecutionPayload is the beacon REST API representation of an execution
+// payload.
+type jsonExecutionPayload struct {
+	ParentHash    common.Hash       `json:"parent_hash"`
+	FeeRecipient  common.Address    `json:"fee_recipient"`
+	StateRoot     common.Hash       `json:"state_root"`
+	ReceiptsRoot  common.Hash       `json:"receipts_root"`
+	LogsBloom     hexutil.Bytes     `json:"logs_bloom"`
+	PrevRandao    common.Hash       `json:"prev_randao"`
+	BlockNumber   common.Decimal    `json:"block_number"`
+	GasLimit      common.Decimal    `json:"gas_limit"`
+	GasUsed       common.Decimal    `json:"gas_used"`
+	Timestamp     common.Decimal    `json:"timestamp"`
+	ExtraData     hexutil.Bytes     `json:"extra_data"`
+	BaseFeePerGas string            `json:"base_fee_per_gas"`
+	BlockHash     common.Hash       `json:"block_hash"`
+	Transactions  []hexutil.Bytes   `json:"transactions"`
+	Withdrawals   *[]jsonWithdrawal `json:"withdrawals,omitempty"`
+	BlobGasUsed   *common.Decimal   `json:"blob_gas_used,omitempty"`
+	ExcessBlobGas *common.Decimal   `json:"excess_blob_gas,omitempty"`
+}
+
+// jsonBeaconBlock is the subset of the beacon REST API representation of a
+// beacon block message which is relevant for the execution layer.
+type jsonBeaconBlock struct {
+	Slot       common.Decimal `json:"slot"`
+	ParentRoot common.Hash    `json:"parent_root"`
+	Body       struct {
+		ExecutionPayload *jsonExecutionPayload `json:"execution_payload"`
+		BlobCommitments  []hexutil.Bytes       `json:"blob_kzg_commitments,omitempty"`
+	} `json:"body"`
+}
+
+// MarshalJSON encodes the block as a beacon block message.
+func (b *ExecutionBlock) MarshalJSON() ([]byte, error) {
+	var (
+		enc     jsonBeaconBlock
+		payload = b.Payload
+	)
+	enc.Slot = common.Decimal(b.Slot)
+	enc.ParentRoot = b.ParentRoot
+	enc.Body.ExecutionPayload = &jsonExecutionPayload{
+		ParentHash:    payload.ParentHash,
+		FeeRecipient:  payload.FeeRecipient,
+		StateRoot:     payload.StateRoot,
+		ReceiptsRoot:  payload.ReceiptsRoot,
+		LogsBloom:     payload.LogsBloom,
+		PrevRandao:    payload.Random,
+		BlockNumber:   common.Decimal(payload.Number),
+		GasLimit:      common.Decimal(payload.GasLimit),
+		GasUsed:       common.Decimal(payload.GasUsed),
+		Timestamp:     common.Decimal(payload.Timestamp),
+		ExtraData:     payload.ExtraData,
+		BaseFeePerGas: payload.BaseFeePerGas.String(),
+		BlockHash:     payload.BlockHash,
+		Transactions:  make([]hexutil.Bytes, len(payload.Transactions)),
+		BlobGasUsed:   (*common.Decimal)(payload.BlobGasUsed),
+		ExcessBlobGas: (*common.Decimal)(payload.ExcessBlobGas),
+	}
+	for i, tx := range payload.Transactions {
+		enc.Body.ExecutionPayload.Transactions[i] = tx
+	}
+	if payload.Withdrawals != nil {
+		withdrawals := make([]jsonWithdrawal, len(payload.Withdrawals))
+		for i, w := range payload.Withdrawals {
+			withdrawals[i] = jsonWithdrawal{
+				Index:     common.Decimal(w.Index),
+				Validator: common.Decimal(w.Validator),
+				Address:   w.Address,
+				Amount:    common.Decimal(w.Amount),
+			}
+		}
+		enc.Body.ExecutionPayload.Withdrawals = &withdrawals
+	}
+	if payload.BlobGasUsed != nil {
+		enc.Body.BlobCommitments = make([]hexutil.Bytes, len(b.BlobCommitments))
+		for i, commitment := range b.BlobCommitments {
+			enc.Body.BlobCommitments[i] = commitment[:]
+		}
+	}
+	return json.Marshal(&enc)
+}
+
+// UnmarshalJSON decodes the block from a beacon block message.
+func (b *ExecutionBlock) UnmarshalJSON(input []byte) error {
+	var dec jsonBeaconBlock
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+	payload := dec.Body.ExecutionPayload
+	if payload == nil {
+		return errors.New("beacon block has no execution payload")
+	}
+	baseFee, ok := new(big.Int).SetString(payload.BaseFeePerGas, 10)
+	if !ok {
+		return fmt.Errorf("invalid base fee %q", payload.BaseFeePerGas)
+	}
+	b.Slot = uint64(dec.Slot)
+	b.ParentRoot = dec.ParentRoot
+	b.Payload = &engine.ExecutableData{
+		ParentHash:    payload.ParentHash,
+		FeeRecipient:  payload.FeeRecipient,
+		StateRoot:     payload.StateRoot,
+		ReceiptsRoot:  payload.ReceiptsRoot,
+		LogsBloom:     payload.LogsBloom,
+		Random:        payload.PrevRandao,
+		Number:        uint64(payload.BlockNumber),
+		GasLimit:      uint64(payload.GasLimit),
+		GasUsed:       uint64(payload.GasUsed),
+		Timestamp:     uint64(payload.Timestamp),
+		ExtraData:     payload.ExtraData,
+		BaseFeePerGas: baseFee,
+		BlockHash:     payload.BlockHash,
+		Transactions:  make([][]byte, len(payload.Transactions)),
+		BlobGasUsed:   (*uint64)(payload.BlobGasUsed),
+		ExcessBlobGas: (*uint64)(payload.ExcessBlobGas),
+	}
+	for i, tx := range payload.Transactions {
+		b.Payload.Transactions[i] = tx
+	}
+	if payload.Withdrawals != nil {
+		b.Payload.Withdrawals = make([]*types.Withdrawal, len(*payload.Withdrawals))
+		for i, w := range *payload.Withdrawals {
+			b.Payload.Withdrawals[i] = &types.Withdrawal{
+				Index:     uint64(w.Index),
+				Validator: uint64(w.Validator),
+				Address:   w.Address,
+				Amount:    uint64(w.Amount),
+			}
+		}
+	}
+	b.BlobCommitments = make([]kzg4844.Commitment, len(dec.Body.BlobCommitments))
+	for i, commitment := range dec.Body.BlobCommitments {
+		if len(commitment) != len(kzg4844.Commitment{}) {
+			return fmt.Errorf("invalid blob commitment %d size %d", i, len(commitment))
+		}
+		copy(b.BlobCommitments[i][:], commitment)
+	}
+	return nil
+}
@@ -0,0 +1,236 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package api implements a client for the light client endpoints of the beacon
+// node REST API.
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/beacon/merkle"
+	"github.com/ethereum/go-ethereum/beacon/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+var (
+	// ErrNotFound is returned if the requested item is not available at the
+	// beacon node.
+	ErrNotFound = errors.New("404 Not Found")
+
+	// ErrInternal is returned if the beacon node failed to serve the request.
+	ErrInternal = errors.New("500 Internal Server Error")
+)
+
+// requestTimeout is the maximum time allowed for a single API request.
+const requestTimeout = 10 * time.Second
+
+// BeaconLightApi requests light client information from a beacon node REST API.
+type BeaconLightApi struct {
+	url           string
+	client        *http.Client
+	customHeaders map[string]string
+}
+
+// NewBeaconLightApi creates a new API client for the beacon node at the given
+// URL. Custom headers are sent along with every request.
+func NewBeaconLightApi(url string, customHeaders map[string]string) *BeaconLightApi {
+	return &BeaconLightApi{
+		url:           strings.TrimSuffix(url, "/"),
+		client:        &http.Client{Timeout: requestTimeout},
+		customHeaders: customHeaders,
+	}
+}
+
+// httpGet sends a GET request to the given path and returns the response body.
+func (api *BeaconLightApi) httpGet(ctx context.Context, path string, params url.Values) ([]byte, error) {
+	uri := api.url + path
+	if len(params) > 0 {
+		uri += "?" + params.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	for k, v := range api.customHeaders {
+		req.Header.Set(k, v)
+	}
+	resp, err := api.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return io.ReadAll(resp.Body)
+	case http.StatusNotFound:
+		return nil, ErrNotFound
+	case http.StatusInternalServerError:
+		return nil, ErrInternal
+	default:
+		return nil, fmt.Errorf("unexpected error from API endpoint %q: status code %d", path, resp.StatusCode)
+	}
+}
+
+// decodeData decodes the data field of a versioned API response.
+func decodeData(resp []byte, data interface{}) error {
+	var enc struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(resp, &enc); err != nil {
+		return err
+	}
+	if len(enc.Data) == 0 {
+		return errors.New("missing data field")
+	}
+	return json.Unmarshal(enc.Data, data)
+}
+
+// GetBootstrap fetches the bootstrap data belonging to the given checkpoint
+// block root. The data is not validated.
+func (api *BeaconLightApi) GetBootstrap(ctx context.Context, checkpoint common.Hash) (*types.BootstrapData, error) {
+	resp, err := api.httpGet(ctx, "/eth/v1/beacon/light_client/bootstrap/"+checkpoint.Hex(), nil)
+	if err != nil {
+		return nil, err
+	}
+	var data types.BootstrapData
+	if err := decodeData(resp, &data); err != nil {
+		return nil, fmt.Errorf("invalid bootstrap data: %w", err)
+	}
+	return &data, nil
+}
+
+// jsonLightClientUpdate is the beacon REST API representation of a light
+// client update.
+type jsonLightClientUpdate struct {
+	AttestedHeader          types.HeaderWithExecProof     `json:"attested_header"`
+	NextSyncCommittee       types.SerializedSyncCommittee `json:"next_sync_committee"`
+	NextSyncCommitteeBranch merkle.Values                 `json:"next_sync_committee_branch"`
+	FinalizedHeader         *types.HeaderWithExecProof    `json:"finalized_header,omitempty"`
+	FinalityBranch          merkle.Values                 `json:"finality_branch,omitempty"`
+	SyncAggregate           types.SyncAggregate           `json:"sync_aggregate"`
+	SignatureSlot           common.Decimal                `json:"signature_slot"`
+}
+
+// GetBestUpdatesAndCommittees fetches the best light client updates for the
+// given range of sync committee periods, along with the next sync committees
+// proven by them. The beacon node may return fewer updates than requested if
+// the later periods are not available yet. The updates are not validated.
+func (api *BeaconLightApi) GetBestUpdatesAndCommittees(ctx context.Context, firstPeriod, count uint64) ([]*types.LightClientUpdate, []*types.SerializedSyncCommittee, error) {
+	params := url.Values{
+		"start_period": {strconv.FormatUint(firstPeriod, 10)},
+		"count":        {strconv.FormatUint(count, 10)},
+	}
+	resp, err := api.httpGet(ctx, "/eth/v1/beacon/light_client/updates", params)
+	if err != nil {
+		return nil, nil, err
+	}
+	var data []struct {
+		Data jsonLightClientUpdate `json:"data"`
+	}
+	if err := json.Unmarshal(resp, &data); err != nil {
+		return nil, nil, fmt.Errorf("invalid light client updates: %w", err)
+	}
+	if uint64(len(data)) > count {
+		return nil, nil, fmt.Errorf("too many updates, have %d, want at most %d", len(data), count)
+	}
+	var (
+		updates    = make([]*types.LightClientUpdate, len(data))
+		committees = make([]*types.SerializedSyncCommittee, len(data))
+	)
+	for i := range data {
+		enc := &data[i].Data
+		if period := enc.AttestedHeader.Header.SyncPeriod(); period != firstPeriod+uint64(i) {
+			return nil, nil, fmt.Errorf("update %d has wrong period %d, want %d", i, period, firstPeriod+uint64(i))
+		}
+		update := &types.LightClientUpdate{
+			AttestedHeader: types.SignedHeader{
+				Header:        enc.AttestedHeader.Header,
+				Signature:     enc.SyncAggregate,
+				SignatureSlot: uint64(enc.SignatureSlot),
+			},
+			NextSyncCommitteeRoot:   enc.NextSyncCommittee.Root(),
+			NextSyncCommitteeBranch: enc.NextSyncCommitteeBranch,
+		}
+		// The finalized header is only useful for the update if it is from the
+		// same period, otherwise it is left out. An all-zero header is sent by
+		// beacon nodes if there is no finality information.
+		if fin := enc.FinalizedHeader; fin != nil && fin.Header != (types.Header{}) && fin.Header.SyncPeriod() == update.AttestedHeader.Header.SyncPeriod() {
+			update.FinalizedHeader = &fin.Header
+			update.FinalityBranch = enc.FinalityBranch
+		}
+		updates[i] = update
+		committees[i] = &enc.NextSyncCommittee
+	}
+	return updates, committees, nil
+}
+
+// GetOptimisticUpdate fetches the latest optimistic update known by the beacon
+// node. The update is not validated.
+func (api *BeaconLightApi) GetOptimisticUpdate(ctx context.Context) (*types.OptimisticUpdate, error) {
+	resp, err := api.httpGet(ctx, "/eth/v1/beacon/light_client/optimistic_update", nil)
+	if err != nil {
+		return nil, err
+	}
+	var update types.OptimisticUpdate
+	if err := decodeData(resp, &update); err != nil {
+		return nil, fmt.Errorf("invalid optimistic update: %w", err)
+	}
+	return &update, nil
+}
+
+// GetFinalityUpdate fetches the latest finality update known by the beacon
+// node. The update is not validated.
+func (api *BeaconLightApi) GetFinalityUpdate(ctx context.Context) (*types.FinalityUpdate, error) {
+	resp, err := api.httpGet(ctx, "/eth/v1/beacon/light_client/finality_update", nil)
+	if err != nil {
+		return nil, err
+	}
+	var update types.FinalityUpdate
+	if err := decodeData(resp, &update); err != nil {
+		return nil, fmt.Errorf("invalid finality update: %w", err)
+	}
+	return &update, nil
+}
+
+// GetExecutionBlock fetches the execution payload of the beacon block with the
+// given block root. The payload is not validated, callers must check the block
+// hash against a trusted execution payload header.
+func (api *BeaconLightApi) GetExecutionBlock(ctx context.Context, blockRoot common.Hash) (*ExecutionBlock, error) {
+	resp, err := api.httpGet(ctx, "/eth/v2/beacon/blocks/"+hexutil.Encode(blockRoot[:]), nil)
+	if err != nil {
+		return nil, err
+	}
+	var data struct {
+		Message ExecutionBlock `json:"message"`
+	}
+	if err := decodeData(resp, &data); err != nil {
+		return nil, fmt.Errorf("invalid beacon block: %w", err)
+	}
+	return &data.Message, nil
+}
@@ -0,0 +1,155 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package api_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/beacon/engine"
+	"github.com/ethereum/go-ethereum/beacon/light/api"
+	"github.com/ethereum/go-ethereum/beacon/params"
+	"github.com/ethereum/go-ethereum/beacon/types"
+	"github.com/ethereum/go-ethereum/common"
+	ctypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto/kzg4844"
+	"github.com/ethereum/go-ethereum/internal/beacontest"
+)
+
+func TestLightApi(t *testing.T) {
+	var (
+		ctx        = context.Background()
+		chain      = beacontest.NewChain()
+		server     = beacontest.NewServer()
+		client     = api.NewBeaconLightApi(server.URL, map[string]string{"X-Test": "1"})
+		blobGas    = uint64(131072)
+		exec       = &types.ExecutionHeader{BlockNumber: 5, BlockHash: common.Hash{5}, BaseFeePerGas: big.NewInt(1e9), ExtraData: []byte{1, 2}, BlobGasUsed: &blobGas, ExcessBlobGas: new(uint64)}
+		checkpoint = chain.NewHeader(100, common.Hash{}, exec, nil)
+	)
+	defer server.Close()
+
+	// Missing items are reported as not found
+	if _, err := client.GetBootstrap(ctx, checkpoint.Header.Hash()); !errors.Is(err, api.ErrNotFound) {
+		t.Fatalf("wrong error for missing bootstrap: %v", err)
+	}
+	if _, err := client.GetOptimisticUpdate(ctx); !errors.Is(err, api.ErrNotFound) {
+		t.Fatalf("wrong error for missing optimistic update: %v", err)
+	}
+	// Bootstrap data is decoded with the execution header intact
+	server.AddBootstrap(chain.Bootstrap(checkpoint))
+	bootstrap, err := client.GetBootstrap(ctx, checkpoint.Header.Hash())
+	if err != nil {
+		t.Fatalf("failed to fetch bootstrap: %v", err)
+	}
+	if err := bootstrap.Validate(); err != nil {
+		t.Fatalf("invalid bootstrap: %v", err)
+	}
+	if bootstrap.Header.Header != checkpoint.Header || bootstrap.Header.PayloadHeader.Root() != exec.Root() {
+		t.Fatal("bootstrap header mismatch")
+	}
+	// Updates are returned for the available periods only
+	var (
+		finalized = chain.NewHeader(150, common.Hash{}, nil, nil)
+		attested  = chain.NewHeader(200, common.Hash{}, nil, &finalized.Header)
+	)
+	update, committee := chain.Update(attested, params.SyncCommitteeSize)
+	update.FinalizedHeader, update.FinalityBranch = &finalized.Header, attested.State.Branch(params.StateIndexFinalBlock)
+	server.AddUpdate(update, committee)
+
+	updates, committees, err := client.GetBestUpdatesAndCommittees(ctx, 0, 4)
+	if err != nil {
+		t.Fatalf("failed to fetch updates: %v", err)
+	}
+	if len(updates) != 1 || len(committees) != 1 {
+		t.Fatalf("wrong number of updates: have %d, want 1", len(updates))
+	}
+	if err := updates[0].Validate(); err != nil {
+		t.Fatalf("invalid update: %v", err)
+	}
+	if *committees[0] != *committee || updates[0].FinalizedHeader == nil || *updates[0].FinalizedHeader != finalized.Header {
+		t.Fatal("update mismatch")
+	}
+	// Optimistic and finality updates
+	server.SetOptimistic(chain.Optimistic(checkpoint, params.SyncCommitteeSize))
+	optimistic, err := client.GetOptimisticUpdate(ctx)
+	if err != nil {
+		t.Fatalf("failed to fetch optimistic update: %v", err)
+	}
+	if err := optimistic.Validate(); err != nil || optimistic.Attested.BlockHash() != exec.BlockHash {
+		t.Fatalf("invalid optimistic update: %v", err)
+	}
+	server.SetFinality(chain.Finality(attested, finalized, params.SyncCommitteeSize))
+	finality, err := client.GetFinalityUpdate(ctx)
+	if err != nil {
+		t.Fatalf("failed to fetch finality update: %v", err)
+	}
+	if err := finality.Validate(); err != nil {
+		t.Fatalf("invalid finality update: %v", err)
+	}
+	if server.Requests("/eth/v1/beacon/light_client/") != 6 {
+		t.Fatalf("wrong request count: %d", server.Requests("/eth/v1/beacon/light_client/"))
+	}
+}
+
+func TestExecutionBlock(t *testing.T) {
+	var (
+		server  = beacontest.NewServer()
+		client  = api.NewBeaconLightApi(server.URL+"/", nil)
+		blobGas = uint64(131072)
+		root    = common.Hash{0xbe}
+		block   = &api.ExecutionBlock{
+			Slot:       12,
+			ParentRoot: common.Hash{0xaa},
+			Payload: &engine.ExecutableData{
+				ParentHash:    common.Hash{1},
+				FeeRecipient:  common.Address{2},
+				LogsBloom:     make([]byte, 256),
+				Number:        100,
+				GasLimit:      30_000_000,
+				Timestamp:     1000,
+				ExtraData:     []byte{},
+				BaseFeePerGas: big.NewInt(1e9),
+				BlockHash:     common.Hash{3},
+				Transactions:  [][]byte{{0x02, 0xc0}},
+				Withdrawals:   []*ctypes.Withdrawal{{Index: 1, Validator: 2, Address: common.Address{4}, Amount: 5}},
+				BlobGasUsed:   &blobGas,
+				ExcessBlobGas: new(uint64),
+			},
+			BlobCommitments: []kzg4844.Commitment{{0xc0}},
+		}
+	)
+	defer server.Close()
+
+	if _, err := client.GetExecutionBlock(context.Background(), root); !errors.Is(err, api.ErrNotFound) {
+		t.Fatalf("wrong error for missing block: %v", err)
+	}
+	server.AddBlock(root, block)
+	have, err := client.GetExecutionBlock(context.Background(), root)
+	if err != nil {
+		t.Fatalf("failed to fetch block: %v", err)
+	}
+	if !reflect.DeepEqual(have, block) {
+		t.Fatalf("block mismatch:\nhave %+v\nwant %+v", have.Payload, block.Payload)
+	}
+	hashes := have.VersionedHashes()
+	if len(hashes) != 1 || hashes[0][0] != 0x01 {
+		t.Fatalf("wrong versioned hashes: %v", hashes)
+	}
+}
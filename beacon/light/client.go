@@ -0,0 +1,202 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package light
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/beacon/light/api"
+	"github.com/ethereum/go-ethereum/beacon/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const (
+	pollInterval      = 2 * time.Second // Time between two polls of the beacon API
+	maxUpdatesRequest = 16              // Maximum number of committee updates requested at once
+)
+
+// HeadEvent is sent when the validated beacon head or finalized header changes.
+type HeadEvent struct {
+	Optimistic types.OptimisticUpdate
+	Finality   *types.FinalityUpdate // Nil if no finality update is known yet
+}
+
+// Client is a beacon chain light client following the sync committee protocol
+// through the beacon REST API. It bootstraps from a trusted checkpoint, keeps
+// the sync committee chain up to date and tracks the validated beacon heads.
+type Client struct {
+	config     Config
+	api        *api.BeaconLightApi
+	committees *CommitteeChain
+	heads      *HeadTracker
+	headFeed   event.Feed
+
+	closeCh chan struct{}
+	wg      sync.WaitGroup
+}
+
+// NewClient creates a new beacon light client using the given beacon API.
+func NewClient(config Config, api *api.BeaconLightApi) *Client {
+	committees := NewCommitteeChain(&config.ChainConfig, config.SignerThreshold)
+	return &Client{
+		config:     config,
+		api:        api,
+		committees: committees,
+		heads:      NewHeadTracker(committees),
+		closeCh:    make(chan struct{}),
+	}
+}
+
+// Start launches the background sync loop of the client.
+func (c *Client) Start() {
+	c.wg.Add(1)
+	go c.loop()
+}
+
+// Stop terminates the sync loop of the client.
+func (c *Client) Stop() {
+	close(c.closeCh)
+	c.wg.Wait()
+}
+
+// SubscribeHeads subscribes to head change notifications.
+func (c *Client) SubscribeHeads(ch chan<- HeadEvent) event.Subscription {
+	return c.headFeed.Subscribe(ch)
+}
+
+// Heads returns the head tracker of the client.
+func (c *Client) Heads() *HeadTracker {
+	return c.heads
+}
+
+// loop polls the beacon API periodically until the client is stopped.
+func (c *Client) loop() {
+	defer c.wg.Done()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-c.closeCh
+		cancel()
+	}()
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+			if err := c.poll(ctx); err != nil && ctx.Err() == nil {
+				log.Warn("Beacon light client sync failed", "err", err)
+			}
+			timer.Reset(pollInterval)
+		case <-c.closeCh:
+			return
+		}
+	}
+}
+
+// poll initializes the committee chain if necessary, then fetches and validates
+// the latest heads, emitting an event if they changed.
+func (c *Client) poll(ctx context.Context) error {
+	if !c.committees.Initialized() {
+		bootstrap, err := c.api.GetBootstrap(ctx, c.config.Checkpoint)
+		if err != nil {
+			return err
+		}
+		if err := c.committees.CheckpointInit(c.config.Checkpoint, bootstrap); err != nil {
+			return err
+		}
+		if err := c.syncCommittees(ctx); err != nil {
+			return err
+		}
+	}
+	var changed bool
+	if update, err := c.api.GetFinalityUpdate(ctx); err == nil {
+		ok, err := c.validate(ctx, uint64(update.SignatureSlot), func() (bool, error) { return c.heads.ValidateFinality(update) })
+		if err != nil {
+			log.Debug("Invalid beacon finality update", "slot", update.Attested.Header.Slot, "err", err)
+		}
+		changed = changed || ok
+	} else if !errors.Is(err, api.ErrNotFound) {
+		return err
+	}
+	if update, err := c.api.GetOptimisticUpdate(ctx); err == nil {
+		ok, err := c.validate(ctx, uint64(update.SignatureSlot), func() (bool, error) { return c.heads.ValidateOptimistic(update) })
+		if err != nil {
+			log.Debug("Invalid beacon optimistic update", "slot", update.Attested.Header.Slot, "err", err)
+		}
+		changed = changed || ok
+	} else if !errors.Is(err, api.ErrNotFound) {
+		return err
+	}
+	if changed {
+		c.sendHead()
+	}
+	return nil
+}
+
+// validate runs the given head validation, syncing the committee chain first if
+// the update is signed in a period after the last known committee.
+func (c *Client) validate(ctx context.Context, signatureSlot uint64, fn func() (bool, error)) (bool, error) {
+	if next, _ := c.committees.NextSyncPeriod(); types.SyncPeriod(signatureSlot) > next {
+		if err := c.syncCommittees(ctx); err != nil {
+			return false, err
+		}
+	}
+	return fn()
+}
+
+// syncCommittees fetches and inserts light client updates until the committee
+// chain reaches the latest period served by the beacon node.
+func (c *Client) syncCommittees(ctx context.Context) error {
+	for {
+		period, _ := c.committees.NextSyncPeriod()
+		updates, committees, err := c.api.GetBestUpdatesAndCommittees(ctx, period, maxUpdatesRequest)
+		if errors.Is(err, api.ErrNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		for i, update := range updates {
+			if err := c.committees.InsertUpdate(update, committees[i]); err != nil {
+				return err
+			}
+		}
+		if len(updates) < maxUpdatesRequest {
+			return nil
+		}
+	}
+}
+
+// sendHead emits the currently validated heads to the subscribers.
+func (c *Client) sendHead() {
+	optimistic, ok := c.heads.ValidatedOptimistic()
+	if !ok {
+		return
+	}
+	ev := HeadEvent{Optimistic: optimistic}
+	if finality, ok := c.heads.ValidatedFinality(); ok {
+		ev.Finality = &finality
+	}
+	log.Debug("New validated beacon head", "slot", optimistic.Attested.Header.Slot, "hash", optimistic.Attested.Header.Hash())
+	c.headFeed.Send(ev)
+}
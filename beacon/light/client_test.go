@@ -0,0 +1,85 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package light
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/beacon/light/api"
+	"github.com/ethereum/go-ethereum/beacon/params"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/internal/beacontest"
+)
+
+// Tests that the client bootstraps from the checkpoint, follows the committee
+// updates across periods and emits the validated heads.
+func TestClientSync(t *testing.T) {
+	var (
+		chain      = beacontest.NewChain()
+		server     = beacontest.NewServer()
+		checkpoint = chain.NewHeader(100, common.Hash{}, nil, nil)
+	)
+	defer server.Close()
+
+	server.AddBootstrap(chain.Bootstrap(checkpoint))
+	server.AddUpdate(chain.Update(chain.NewHeader(200, common.Hash{}, nil, nil), params.SyncCommitteeSize))
+	server.AddUpdate(chain.Update(chain.NewHeader(params.SyncPeriodLength+200, common.Hash{}, nil, nil), params.SyncCommitteeSize))
+
+	var (
+		finalized = chain.NewHeader(2*params.SyncPeriodLength+10, common.Hash{}, nil, nil)
+		attested  = chain.NewHeader(2*params.SyncPeriodLength+80, common.Hash{}, nil, &finalized.Header)
+	)
+	server.SetFinality(chain.Finality(attested, finalized, params.SyncCommitteeSize))
+
+	config := Config{
+		ChainConfig:     *chain.Config,
+		Checkpoint:      checkpoint.Header.Hash(),
+		SignerThreshold: DefaultSignerThreshold,
+	}
+	client := NewClient(config, api.NewBeaconLightApi(server.URL, nil))
+	heads := make(chan HeadEvent, 1)
+	sub := client.SubscribeHeads(heads)
+	defer sub.Unsubscribe()
+
+	client.Start()
+	defer client.Stop()
+
+	select {
+	case ev := <-heads:
+		if ev.Optimistic.Attested.Header != attested.Header {
+			t.Fatalf("wrong optimistic head: have slot %d, want %d", ev.Optimistic.Attested.Header.Slot, attested.Header.Slot)
+		}
+		if ev.Finality == nil || ev.Finality.Finalized.Header != finalized.Header {
+			t.Fatal("wrong finalized head")
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("no head event received")
+	}
+	// A newer optimistic head signed by the same committee is picked up
+	head := chain.NewHeader(2*params.SyncPeriodLength+90, attested.Header.Hash(), nil, nil)
+	server.SetOptimistic(chain.Optimistic(head, params.SyncCommitteeSize))
+
+	select {
+	case ev := <-heads:
+		if ev.Optimistic.Attested.Header != head.Header {
+			t.Fatalf("wrong optimistic head: have slot %d, want %d", ev.Optimistic.Attested.Header.Slot, head.Header.Slot)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("no head event received")
+	}
+}
@@ -0,0 +1,180 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package light implements a beacon chain light client following the sync
+// committee protocol.
+package light
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/beacon/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+var (
+	errNotInitialized      = errors.New("committee chain not initialized")
+	errUnknownCommittee    = errors.New("sync committee unknown")
+	errWrongPeriod         = errors.New("update is not for the next expected period")
+	errCommitteeMismatch   = errors.New("sync committee does not match the update")
+	errCheckpointMismatch  = errors.New("bootstrap header does not match the checkpoint")
+	errInsufficientSigners = errors.New("insufficient signer count")
+	errInvalidSignature    = errors.New("invalid sync committee signature")
+)
+
+// CommitteeChain maintains the chain of sync committees, starting from the
+// committee of a trusted checkpoint and extended period by period by light
+// client updates signed by the previous committee. The chain is kept in memory
+// only, it is rebuilt from the checkpoint after a restart.
+//
+// Sync committee of period N+1 is proven by an update attested by the committee
+// of period N, so the chain can only grow at its head.
+type CommitteeChain struct {
+	lock            sync.RWMutex
+	config          *types.ChainConfig
+	signerThreshold int
+
+	last         uint64                                    // Last period with a known committee
+	committees   map[uint64]*types.SerializedSyncCommittee // Committees by period
+	deserialized map[uint64]*types.SyncCommittee           // Deserialized committees by period
+}
+
+// NewCommitteeChain creates a new, uninitialized committee chain. Signed headers
+// are only accepted if at least signerThreshold committee members signed them.
+func NewCommitteeChain(config *types.ChainConfig, signerThreshold int) *CommitteeChain {
+	return &CommitteeChain{
+		config:          config,
+		signerThreshold: signerThreshold,
+		committees:      make(map[uint64]*types.SerializedSyncCommittee),
+		deserialized:    make(map[uint64]*types.SyncCommittee),
+	}
+}
+
+// CheckpointInit initializes the chain from a trusted checkpoint. The bootstrap
+// data is validated against the checkpoint block root. Any previously known
+// committees are dropped.
+func (s *CommitteeChain) CheckpointInit(checkpoint common.Hash, bootstrap *types.BootstrapData) error {
+	if hash := bootstrap.Header.Header.Hash(); hash != checkpoint {
+		return fmt.Errorf("%w: have %#x, want %#x", errCheckpointMismatch, hash, checkpoint)
+	}
+	if err := bootstrap.Validate(); err != nil {
+		return err
+	}
+	committee, err := bootstrap.Committee.Deserialize()
+	if err != nil {
+		return err
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	period := bootstrap.Header.Header.SyncPeriod()
+	s.last = period
+	s.committees = map[uint64]*types.SerializedSyncCommittee{period: bootstrap.Committee}
+	s.deserialized = map[uint64]*types.SyncCommittee{period: committee}
+
+	log.Info("Initialized beacon committee chain", "checkpoint", checkpoint, "slot", bootstrap.Header.Header.Slot, "period", period)
+	return nil
+}
+
+// Initialized returns whether the chain has been initialized from a checkpoint.
+func (s *CommitteeChain) Initialized() bool {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return len(s.committees) > 0
+}
+
+// NextSyncPeriod returns the period of the next expected update, which is the
+// last period with a known committee. False is returned if the chain is not
+// initialized yet.
+func (s *CommitteeChain) NextSyncPeriod() (uint64, bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if len(s.committees) == 0 {
+		return 0, false
+	}
+	return s.last, true
+}
+
+// InsertUpdate verifies a light client update attested by the committee of the
+// last known period and adds the next committee proven by it to the chain.
+func (s *CommitteeChain) InsertUpdate(update *types.LightClientUpdate, nextCommittee *types.SerializedSyncCommittee) error {
+	if err := update.Validate(); err != nil {
+		return err
+	}
+	if nextCommittee.Root() != update.NextSyncCommitteeRoot {
+		return errCommitteeMismatch
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if len(s.committees) == 0 {
+		return errNotInitialized
+	}
+	period := update.AttestedHeader.Header.SyncPeriod()
+	if period != s.last {
+		return fmt.Errorf("%w: have %d, want %d", errWrongPeriod, period, s.last)
+	}
+	if err := s.verifySignedHeader(update.AttestedHeader); err != nil {
+		return err
+	}
+	committee, err := nextCommittee.Deserialize()
+	if err != nil {
+		return err
+	}
+	s.committees[period+1] = nextCommittee
+	s.deserialized[period+1] = committee
+	s.last = period + 1
+
+	log.Debug("Inserted beacon sync committee", "period", period+1, "signers", update.Score().SignerCount)
+	return nil
+}
+
+// VerifySignedHeader checks whether the header is signed by enough members of
+// the sync committee of the signature period.
+func (s *CommitteeChain) VerifySignedHeader(head types.SignedHeader) error {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.verifySignedHeader(head)
+}
+
+// verifySignedHeader is the lock-free version of VerifySignedHeader.
+func (s *CommitteeChain) verifySignedHeader(head types.SignedHeader) error {
+	if head.SignatureSlot <= head.Header.Slot {
+		return errors.New("signature slot is not newer than the signed header")
+	}
+	period := types.SyncPeriod(head.SignatureSlot)
+	committee := s.deserialized[period]
+	if committee == nil {
+		return fmt.Errorf("%w: period %d", errUnknownCommittee, period)
+	}
+	if count := head.Signature.SignerCount(); count < s.signerThreshold {
+		return fmt.Errorf("%w: have %d, want %d", errInsufficientSigners, count, s.signerThreshold)
+	}
+	root, err := s.config.Forks.SigningRoot(head.Header)
+	if err != nil {
+		return err
+	}
+	if !committee.VerifySignature(root, &head.Signature) {
+		return errInvalidSignature
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package light
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/beacon/params"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/internal/beacontest"
+)
+
+func TestCommitteeChain(t *testing.T) {
+	var (
+		chain      = beacontest.NewChain()
+		checkpoint = chain.NewHeader(100, common.Hash{}, nil, nil)
+		bootstrap  = chain.Bootstrap(checkpoint)
+		committees = NewCommitteeChain(chain.Config, DefaultSignerThreshold)
+	)
+	// Initialization must match the checkpoint
+	if err := committees.CheckpointInit(common.Hash{1}, bootstrap); !errors.Is(err, errCheckpointMismatch) {
+		t.Fatalf("wrong checkpoint accepted: %v", err)
+	}
+	if committees.Initialized() {
+		t.Fatal("committee chain initialized by wrong checkpoint")
+	}
+	if err := committees.CheckpointInit(checkpoint.Header.Hash(), bootstrap); err != nil {
+		t.Fatalf("failed to initialize committee chain: %v", err)
+	}
+	if period, ok := committees.NextSyncPeriod(); !ok || period != 0 {
+		t.Fatalf("wrong next sync period: have %d (%v), want 0", period, ok)
+	}
+	// Heads of the first period are verified by the bootstrap committee
+	head := chain.Optimistic(chain.NewHeader(200, common.Hash{}, nil, nil), DefaultSignerThreshold)
+	if err := committees.VerifySignedHeader(head.SignedHeader()); err != nil {
+		t.Fatalf("failed to verify signed header: %v", err)
+	}
+	weak := chain.Optimistic(chain.NewHeader(200, common.Hash{}, nil, nil), DefaultSignerThreshold-1)
+	if err := committees.VerifySignedHeader(weak.SignedHeader()); !errors.Is(err, errInsufficientSigners) {
+		t.Fatalf("header with insufficient signers accepted: %v", err)
+	}
+	forged := *head
+	forged.Attested.Header.ProposerIndex++
+	if err := committees.VerifySignedHeader(forged.SignedHeader()); !errors.Is(err, errInvalidSignature) {
+		t.Fatalf("forged header accepted: %v", err)
+	}
+	next := chain.Optimistic(chain.NewHeader(params.SyncPeriodLength+10, common.Hash{}, nil, nil), params.SyncCommitteeSize)
+	if err := committees.VerifySignedHeader(next.SignedHeader()); !errors.Is(err, errUnknownCommittee) {
+		t.Fatalf("header of unknown period accepted: %v", err)
+	}
+	// Updates can only be inserted for the next expected period
+	future, futureCommittee := chain.Update(chain.NewHeader(params.SyncPeriodLength+10, common.Hash{}, nil, nil), params.SyncCommitteeSize)
+	if err := committees.InsertUpdate(future, futureCommittee); !errors.Is(err, errWrongPeriod) {
+		t.Fatalf("update of wrong period accepted: %v", err)
+	}
+	update, committee := chain.Update(chain.NewHeader(300, common.Hash{}, nil, nil), params.SyncCommitteeSize)
+	if err := committees.InsertUpdate(update, chain.Committee(5).Serialized()); !errors.Is(err, errCommitteeMismatch) {
+		t.Fatalf("update with wrong committee accepted: %v", err)
+	}
+	if err := committees.InsertUpdate(update, committee); err != nil {
+		t.Fatalf("failed to insert update: %v", err)
+	}
+	if period, _ := committees.NextSyncPeriod(); period != 1 {
+		t.Fatalf("wrong next sync period: have %d, want 1", period)
+	}
+	if err := committees.VerifySignedHeader(next.SignedHeader()); err != nil {
+		t.Fatalf("failed to verify header of the next period: %v", err)
+	}
+	if err := committees.InsertUpdate(future, futureCommittee); err != nil {
+		t.Fatalf("failed to insert update of the next period: %v", err)
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package light
+
+import (
+	"sync"
+
+	"github.com/ethereum/go-ethereum/beacon/types"
+)
+
+// HeadTracker keeps track of the latest validated optimistic and finalized
+// beacon heads. Updates are validated against the committee chain; an update
+// is only accepted if it is newer than the currently tracked one.
+type HeadTracker struct {
+	lock       sync.RWMutex
+	committees *CommitteeChain
+
+	optimistic *types.OptimisticUpdate // Latest validated optimistic update
+	finality   *types.FinalityUpdate   // Latest validated finality update
+}
+
+// NewHeadTracker creates a new head tracker validating heads with the given
+// committee chain.
+func NewHeadTracker(committees *CommitteeChain) *HeadTracker {
+	return &HeadTracker{committees: committees}
+}
+
+// ValidateOptimistic validates the given optimistic update and sets it as the
+// new optimistic head if it is newer than the current one. The returned flag
+// reports whether the head was changed.
+func (h *HeadTracker) ValidateOptimistic(update *types.OptimisticUpdate) (bool, error) {
+	if err := update.Validate(); err != nil {
+		return false, err
+	}
+	if err := h.committees.VerifySignedHeader(update.SignedHeader()); err != nil {
+		return false, err
+	}
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if h.optimistic != nil && update.Attested.Header.Slot <= h.optimistic.Attested.Header.Slot {
+		return false, nil
+	}
+	h.optimistic = update
+	return true, nil
+}
+
+// ValidateFinality validates the given finality update and sets it as the new
+// finalized head if it finalizes a newer header than the current one. The
+// attested header of a valid finality update is also treated as an optimistic
+// update. The returned flag reports whether the finalized head was changed.
+func (h *HeadTracker) ValidateFinality(update *types.FinalityUpdate) (bool, error) {
+	if err := update.Validate(); err != nil {
+		return false, err
+	}
+	if err := h.committees.VerifySignedHeader(update.SignedHeader()); err != nil {
+		return false, err
+	}
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if h.optimistic == nil || update.Attested.Header.Slot > h.optimistic.Attested.Header.Slot {
+		h.optimistic = &types.OptimisticUpdate{
+			Attested:      update.Attested,
+			Signature:     update.Signature,
+			SignatureSlot: update.SignatureSlot,
+		}
+	}
+	if h.finality != nil && update.Finalized.Header.Slot <= h.finality.Finalized.Header.Slot {
+		return false, nil
+	}
+	h.finality = update
+	return true, nil
+}
+
+// ValidatedOptimistic returns the latest validated optimistic update.
+func (h *HeadTracker) ValidatedOptimistic() (types.OptimisticUpdate, bool) {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	if h.optimistic == nil {
+		return types.OptimisticUpdate{}, false
+	}
+	return *h.optimistic, true
+}
+
+// ValidatedFinality returns the latest validated finality update.
+func (h *HeadTracker) ValidatedFinality() (types.FinalityUpdate, bool) {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	if h.finality == nil {
+		return types.FinalityUpdate{}, false
+	}
+	return *h.finality, true
+}
@@ -0,0 +1,75 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package light
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/beacon/params"
+	"github.com/ethereum/go-ethereum/beacon/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/internal/beacontest"
+)
+
+func TestHeadTracker(t *testing.T) {
+	var (
+		chain      = beacontest.NewChain()
+		checkpoint = chain.NewHeader(100, common.Hash{}, nil, nil)
+		committees = NewCommitteeChain(chain.Config, DefaultSignerThreshold)
+		heads      = NewHeadTracker(committees)
+	)
+	if err := committees.CheckpointInit(checkpoint.Header.Hash(), chain.Bootstrap(checkpoint)); err != nil {
+		t.Fatalf("failed to initialize committee chain: %v", err)
+	}
+	exec := &types.ExecutionHeader{BlockNumber: 10, BlockHash: common.Hash{0xaa}, BaseFeePerGas: big.NewInt(7)}
+
+	// Optimistic heads are only accepted if valid and newer
+	head := chain.NewHeader(200, common.Hash{}, exec, nil)
+	if changed, err := heads.ValidateOptimistic(chain.Optimistic(head, params.SyncCommitteeSize)); err != nil || !changed {
+		t.Fatalf("failed to validate optimistic head: changed %v, err %v", changed, err)
+	}
+	if have, _ := heads.ValidatedOptimistic(); have.Attested.BlockHash() != exec.BlockHash {
+		t.Fatalf("wrong execution block hash: have %x, want %x", have.Attested.BlockHash(), exec.BlockHash)
+	}
+	old := chain.Optimistic(chain.NewHeader(150, common.Hash{}, nil, nil), params.SyncCommitteeSize)
+	if changed, err := heads.ValidateOptimistic(old); err != nil || changed {
+		t.Fatalf("older optimistic head accepted: changed %v, err %v", changed, err)
+	}
+	tampered := chain.Optimistic(chain.NewHeader(210, common.Hash{}, exec, nil), params.SyncCommitteeSize)
+	tampered.Attested.PayloadHeader = &types.ExecutionHeader{BlockNumber: 11, BlockHash: common.Hash{0xbb}, BaseFeePerGas: big.NewInt(7)}
+	if _, err := heads.ValidateOptimistic(tampered); err == nil {
+		t.Fatal("optimistic head with invalid execution proof accepted")
+	}
+	// Finality updates also advance the optimistic head
+	finalized := chain.NewHeader(190, common.Hash{}, nil, nil)
+	attested := chain.NewHeader(220, common.Hash{}, nil, &finalized.Header)
+	if changed, err := heads.ValidateFinality(chain.Finality(attested, finalized, params.SyncCommitteeSize)); err != nil || !changed {
+		t.Fatalf("failed to validate finality update: changed %v, err %v", changed, err)
+	}
+	if have, ok := heads.ValidatedFinality(); !ok || have.Finalized.Header != finalized.Header {
+		t.Fatal("wrong finalized header")
+	}
+	if have, _ := heads.ValidatedOptimistic(); have.Attested.Header != attested.Header {
+		t.Fatal("optimistic head not updated by finality update")
+	}
+	// Finality updates with a wrong finalized header are rejected
+	bad := chain.Finality(chain.NewHeader(230, common.Hash{}, nil, &finalized.Header), chain.NewHeader(195, common.Hash{}, nil, nil), params.SyncCommitteeSize)
+	if _, err := heads.ValidateFinality(bad); err == nil {
+		t.Fatal("finality update with invalid proof accepted")
+	}
+}
@@ -19,6 +19,7 @@ package params
 const (
 	EpochLength      = 32
 	SyncPeriodLength = 8192
+	SecondsPerSlot   = 12
 
 	BLSSignatureSize = 96
 	BLSPubkeySize    = 48
@@ -41,4 +42,6 @@ const (
 	StateIndexNextSyncCommittee = 55
 	StateIndexExecPayload       = 56
 	StateIndexExecHead          = 908
+
+	BodyIndexExecPayload = 25
 )
@@ -0,0 +1,233 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/beacon/merkle"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// ExecutionHeader is the header of the execution payload embedded into a
+// beacon block body. The blob gas fields are only present from the Deneb fork
+// on; their absence selects the Capella layout for hashing.
+//
+// See data structure definition here:
+// https://github.com/ethereum/consensus-specs/blob/dev/specs/deneb/beacon-chain.md#executionpayloadheader
+type ExecutionHeader struct {
+	ParentHash       common.Hash
+	FeeRecipient     common.Address
+	StateRoot        common.Hash
+	ReceiptsRoot     common.Hash
+	LogsBloom        [256]byte
+	PrevRandao       common.Hash
+	BlockNumber      uint64
+	GasLimit         uint64
+	GasUsed          uint64
+	Timestamp        uint64
+	ExtraData        []byte
+	BaseFeePerGas    *big.Int
+	BlockHash        common.Hash
+	TransactionsRoot common.Hash
+	WithdrawalsRoot  common.Hash
+	BlobGasUsed      *uint64 // Deneb only
+	ExcessBlobGas    *uint64 // Deneb only
+}
+
+// jsonExecutionHeader is the JSON representation of an execution payload
+// header in the beacon REST API format.
+type jsonExecutionHeader struct {
+	ParentHash       common.Hash     `json:"parent_hash"`
+	FeeRecipient     common.Address  `json:"fee_recipient"`
+	StateRoot        common.Hash     `json:"state_root"`
+	ReceiptsRoot     common.Hash     `json:"receipts_root"`
+	LogsBloom        hexutil.Bytes   `json:"logs_bloom"`
+	PrevRandao       common.Hash     `json:"prev_randao"`
+	BlockNumber      common.Decimal  `json:"block_number"`
+	GasLimit         common.Decimal  `json:"gas_limit"`
+	GasUsed          common.Decimal  `json:"gas_used"`
+	Timestamp        common.Decimal  `json:"timestamp"`
+	ExtraData        hexutil.Bytes   `json:"extra_data"`
+	BaseFeePerGas    string          `json:"base_fee_per_gas"`
+	BlockHash        common.Hash     `json:"block_hash"`
+	TransactionsRoot common.Hash     `json:"transactions_root"`
+	WithdrawalsRoot  common.Hash     `json:"withdrawals_root"`
+	BlobGasUsed      *common.Decimal `json:"blob_gas_used,omitempty"`
+	ExcessBlobGas    *common.Decimal `json:"excess_blob_gas,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (h *ExecutionHeader) MarshalJSON() ([]byte, error) {
+	enc := jsonExecutionHeader{
+		ParentHash:       h.ParentHash,
+		FeeRecipient:     h.FeeRecipient,
+		StateRoot:        h.StateRoot,
+		ReceiptsRoot:     h.ReceiptsRoot,
+		LogsBloom:        h.LogsBloom[:],
+		PrevRandao:       h.PrevRandao,
+		BlockNumber:      common.Decimal(h.BlockNumber),
+		GasLimit:         common.Decimal(h.GasLimit),
+		GasUsed:          common.Decimal(h.GasUsed),
+		Timestamp:        common.Decimal(h.Timestamp),
+		ExtraData:        h.ExtraData,
+		BaseFeePerGas:    "0",
+		BlockHash:        h.BlockHash,
+		TransactionsRoot: h.TransactionsRoot,
+		WithdrawalsRoot:  h.WithdrawalsRoot,
+		BlobGasUsed:      (*common.Decimal)(h.BlobGasUsed),
+		ExcessBlobGas:    (*common.Decimal)(h.ExcessBlobGas),
+	}
+	if h.BaseFeePerGas != nil {
+		enc.BaseFeePerGas = h.BaseFeePerGas.String()
+	}
+	return json.Marshal(&enc)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (h *ExecutionHeader) UnmarshalJSON(input []byte) error {
+	var dec jsonExecutionHeader
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+	if len(dec.LogsBloom) != len(h.LogsBloom) {
+		return fmt.Errorf("invalid logs bloom size %d", len(dec.LogsBloom))
+	}
+	if len(dec.ExtraData) > 32 {
+		return fmt.Errorf("extra data too long (%d bytes)", len(dec.ExtraData))
+	}
+	baseFee, ok := new(big.Int).SetString(dec.BaseFeePerGas, 10)
+	if !ok || baseFee.Sign() < 0 || baseFee.BitLen() > 256 {
+		return fmt.Errorf("invalid base fee %q", dec.BaseFeePerGas)
+	}
+	if (dec.BlobGasUsed == nil) != (dec.ExcessBlobGas == nil) {
+		return errors.New("incomplete blob gas fields")
+	}
+	h.ParentHash = dec.ParentHash
+	h.FeeRecipient = dec.FeeRecipient
+	h.StateRoot = dec.StateRoot
+	h.ReceiptsRoot = dec.ReceiptsRoot
+	copy(h.LogsBloom[:], dec.LogsBloom)
+	h.PrevRandao = dec.PrevRandao
+	h.BlockNumber = uint64(dec.BlockNumber)
+	h.GasLimit = uint64(dec.GasLimit)
+	h.GasUsed = uint64(dec.GasUsed)
+	h.Timestamp = uint64(dec.Timestamp)
+	h.ExtraData = dec.ExtraData
+	h.BaseFeePerGas = baseFee
+	h.BlockHash = dec.BlockHash
+	h.TransactionsRoot = dec.TransactionsRoot
+	h.WithdrawalsRoot = dec.WithdrawalsRoot
+	h.BlobGasUsed = (*uint64)(dec.BlobGasUsed)
+	h.ExcessBlobGas = (*uint64)(dec.ExcessBlobGas)
+	return nil
+}
+
+// Root calculates the SSZ hash tree root of the execution payload header.
+//
+// TODO(zsfelfoldi): Get rid of this when SSZ encoding lands.
+func (h *ExecutionHeader) Root() common.Hash {
+	var (
+		hasher = sha256.New()
+		leaves = make([]merkle.Value, 0, 32)
+	)
+	uintLeaf := func(v uint64) (leaf merkle.Value) {
+		binary.LittleEndian.PutUint64(leaf[:8], v)
+		return leaf
+	}
+	// Basic vectors and lists are packed into chunks and merkleized
+	var bloom [8]merkle.Value
+	for i := range bloom {
+		copy(bloom[i][:], h.LogsBloom[i*32:])
+	}
+	var extra, extraLen merkle.Value
+	copy(extra[:], h.ExtraData)
+	binary.LittleEndian.PutUint64(extraLen[:8], uint64(len(h.ExtraData)))
+
+	var fee, baseFee merkle.Value
+	copy(fee[:], h.FeeRecipient[:])
+	if h.BaseFeePerGas != nil {
+		h.BaseFeePerGas.FillBytes(baseFee[:])
+		reverseBytes(baseFee[:]) // uint256 is little endian in SSZ
+	}
+	leaves = append(leaves,
+		merkle.Value(h.ParentHash),
+		fee,
+		merkle.Value(h.StateRoot),
+		merkle.Value(h.ReceiptsRoot),
+		merkleize(hasher, bloom[:]),
+		merkle.Value(h.PrevRandao),
+		uintLeaf(h.BlockNumber),
+		uintLeaf(h.GasLimit),
+		uintLeaf(h.GasUsed),
+		uintLeaf(h.Timestamp),
+		hashPair(hasher, extra, extraLen),
+		baseFee,
+		merkle.Value(h.BlockHash),
+		merkle.Value(h.TransactionsRoot),
+		merkle.Value(h.WithdrawalsRoot),
+	)
+	if h.BlobGasUsed != nil {
+		leaves = append(leaves, uintLeaf(*h.BlobGasUsed))
+	}
+	if h.ExcessBlobGas != nil {
+		leaves = append(leaves, uintLeaf(*h.ExcessBlobGas))
+	}
+	// Pad the container fields to the next power of two
+	size := 1
+	for size < len(leaves) {
+		size *= 2
+	}
+	for len(leaves) < size {
+		leaves = append(leaves, merkle.Value{})
+	}
+	return common.Hash(merkleize(hasher, leaves))
+}
+
+// merkleize computes the root of a binary tree built from the given leaves,
+// the number of which must be a power of two. The leaves slice is overwritten.
+func merkleize(hasher hash.Hash, leaves []merkle.Value) merkle.Value {
+	for l := len(leaves); l > 1; l /= 2 {
+		for i := 0; i < l/2; i++ {
+			leaves[i] = hashPair(hasher, leaves[i*2], leaves[i*2+1])
+		}
+	}
+	return leaves[0]
+}
+
+// hashPair hashes the concatenation of two tree nodes.
+func hashPair(hasher hash.Hash, a, b merkle.Value) (res merkle.Value) {
+	hasher.Reset()
+	hasher.Write(a[:])
+	hasher.Write(b[:])
+	hasher.Sum(res[:0])
+	return res
+}
+
+// reverseBytes reverses the byte order of the given slice in place.
+func reverseBytes(b []byte) {
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+}
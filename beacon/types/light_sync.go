@@ -0,0 +1,157 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/beacon/merkle"
+	"github.com/ethereum/go-ethereum/beacon/params"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// HeaderWithExecProof is a beacon header along with the execution payload
+// header of the block and a proof of the latter against the block body root.
+// The execution part is absent for pre-Capella headers.
+//
+// See data structure definition here:
+// https://github.com/ethereum/consensus-specs/blob/dev/specs/capella/light-client/sync-protocol.md#lightclientheader
+type HeaderWithExecProof struct {
+	Header        Header           `json:"beacon"`
+	PayloadHeader *ExecutionHeader `json:"execution,omitempty"`
+	PayloadBranch merkle.Values    `json:"execution_branch,omitempty"`
+}
+
+// Validate verifies the execution payload header proof, if present.
+func (h *HeaderWithExecProof) Validate() error {
+	if h.PayloadHeader == nil {
+		return nil
+	}
+	if err := merkle.VerifyProof(h.Header.BodyRoot, params.BodyIndexExecPayload, h.PayloadBranch, merkle.Value(h.PayloadHeader.Root())); err != nil {
+		return fmt.Errorf("invalid execution payload proof: %w", err)
+	}
+	return nil
+}
+
+// BlockHash returns the hash of the execution block belonging to the beacon
+// header, or the zero hash if the execution payload header is unknown.
+func (h *HeaderWithExecProof) BlockHash() common.Hash {
+	if h.PayloadHeader == nil {
+		return common.Hash{}
+	}
+	return h.PayloadHeader.BlockHash
+}
+
+// BootstrapData contains a trusted beacon header along with the sync committee
+// of its period and the proof for the committee. It is used for initializing
+// the light client from a weak subjectivity checkpoint.
+//
+// See data structure definition here:
+// https://github.com/ethereum/consensus-specs/blob/dev/specs/altair/light-client/sync-protocol.md#lightclientbootstrap
+type BootstrapData struct {
+	Header          HeaderWithExecProof      `json:"header"`
+	Committee       *SerializedSyncCommittee `json:"current_sync_committee"`
+	CommitteeBranch merkle.Values            `json:"current_sync_committee_branch"`
+}
+
+// Validate verifies the proof of the sync committee against the header.
+func (b *BootstrapData) Validate() error {
+	if b.Committee == nil {
+		return errors.New("missing sync committee")
+	}
+	if err := b.Header.Validate(); err != nil {
+		return err
+	}
+	if err := merkle.VerifyProof(b.Header.Header.StateRoot, params.StateIndexSyncCommittee, b.CommitteeBranch, merkle.Value(b.Committee.Root())); err != nil {
+		return fmt.Errorf("invalid sync committee proof: %w", err)
+	}
+	return nil
+}
+
+// OptimisticUpdate proves sync committee commitment on the attested beacon
+// header. It also proves the belonging execution payload header.
+//
+// See data structure definition here:
+// https://github.com/ethereum/consensus-specs/blob/dev/specs/altair/light-client/sync-protocol.md#lightclientoptimisticupdate
+type OptimisticUpdate struct {
+	Attested      HeaderWithExecProof `json:"attested_header"`
+	Signature     SyncAggregate       `json:"sync_aggregate"`
+	SignatureSlot common.Decimal      `json:"signature_slot"`
+}
+
+// SignedHeader returns the signed attested header of the update.
+func (u *OptimisticUpdate) SignedHeader() SignedHeader {
+	return SignedHeader{
+		Header:        u.Attested.Header,
+		Signature:     u.Signature,
+		SignatureSlot: uint64(u.SignatureSlot),
+	}
+}
+
+// Validate verifies the internal consistency of the update. The signature is
+// not checked here, that requires the sync committee.
+func (u *OptimisticUpdate) Validate() error {
+	if uint64(u.SignatureSlot) <= u.Attested.Header.Slot {
+		return errors.New("signature slot is not newer than the attested header")
+	}
+	return u.Attested.Validate()
+}
+
+// FinalityUpdate proves a finalized beacon header by a sync committee commitment
+// on an attested beacon header, referring to the finalized header through the
+// beacon state. It also proves the execution payload headers of both.
+//
+// See data structure definition here:
+// https://github.com/ethereum/consensus-specs/blob/dev/specs/altair/light-client/sync-protocol.md#lightclientfinalityupdate
+type FinalityUpdate struct {
+	Attested       HeaderWithExecProof `json:"attested_header"`
+	Finalized      HeaderWithExecProof `json:"finalized_header"`
+	FinalityBranch merkle.Values       `json:"finality_branch"`
+	Signature      SyncAggregate       `json:"sync_aggregate"`
+	SignatureSlot  common.Decimal      `json:"signature_slot"`
+}
+
+// SignedHeader returns the signed attested header of the update.
+func (u *FinalityUpdate) SignedHeader() SignedHeader {
+	return SignedHeader{
+		Header:        u.Attested.Header,
+		Signature:     u.Signature,
+		SignatureSlot: uint64(u.SignatureSlot),
+	}
+}
+
+// Validate verifies the internal consistency of the update. The signature is
+// not checked here, that requires the sync committee.
+func (u *FinalityUpdate) Validate() error {
+	if uint64(u.SignatureSlot) <= u.Attested.Header.Slot {
+		return errors.New("signature slot is not newer than the attested header")
+	}
+	if u.Finalized.Header.Slot > u.Attested.Header.Slot {
+		return errors.New("finalized header is newer than the attested header")
+	}
+	if err := u.Attested.Validate(); err != nil {
+		return err
+	}
+	if err := u.Finalized.Validate(); err != nil {
+		return err
+	}
+	if err := merkle.VerifyProof(u.Attested.Header.StateRoot, params.StateIndexFinalBlock, u.FinalityBranch, merkle.Value(u.Finalized.Header.Hash())); err != nil {
+		return fmt.Errorf("invalid finalized header proof: %w", err)
+	}
+	return nil
+}
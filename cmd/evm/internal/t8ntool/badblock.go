@@ -0,0 +1,540 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package t8ntool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/consensus/clique"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/urfave/cli/v2"
+)
+
+// rpcBatchSize is the maximum number of requests sent in a single batch while
+// exporting a bad block.
+const rpcBatchSize = 100
+
+// badBlockBundle is a self-contained description of a bad block: the block, the
+// chain configuration and a witness of the parent state, consisting of the
+// merkle proofs of all accounts and storage slots touched by the block.
+//
+// The witness is a partial state trie, so the bad block can be re-executed with
+// real state roots. Trie nodes which are only needed to restructure the trie on
+// deletion may be missing from the proofs, in which case the replay fails with a
+// missing trie node error.
+type badBlockBundle struct {
+	Config      *params.ChainConfig                 `json:"config"`
+	Block       hexutil.Bytes                       `json:"block"`
+	Parent      *types.Header                       `json:"parent"`
+	BlockHashes map[math.HexOrDecimal64]common.Hash `json:"blockHashes"`
+	Accounts    []*witnessAccount                   `json:"accounts"`
+
+	// IntermediateRoots are the state roots after each transaction, as computed
+	// by the node the bundle was exported from.
+	IntermediateRoots []common.Hash `json:"intermediateRoots"`
+}
+
+// witnessAccount contains the proofs of an account and its touched storage slots
+// in the parent state.
+type witnessAccount struct {
+	Address common.Address  `json:"address"`
+	Code    hexutil.Bytes   `json:"code,omitempty"`
+	Proof   []hexutil.Bytes `json:"proof"`
+	Storage []witnessSlot   `json:"storage,omitempty"`
+}
+
+type witnessSlot struct {
+	Key   common.Hash     `json:"key"`
+	Proof []hexutil.Bytes `json:"proof"`
+}
+
+// ReplayBadBlock exports a bad block from a running node and/or re-executes a
+// bad block bundle, reporting the first transaction whose post-state root
+// diverges from the one computed by the node.
+func ReplayBadBlock(ctx *cli.Context) error {
+	// Configure the go-ethereum logger
+	glogger := log.NewGlogHandler(log.NewTerminalHandler(os.Stderr, false))
+	glogger.Verbosity(log.FromLegacyLevel(ctx.Int(VerbosityFlag.Name)))
+	log.SetDefault(log.NewLogger(glogger))
+
+	var bundle *badBlockBundle
+	if endpoint := ctx.String(BadBlockRPCFlag.Name); endpoint != "" {
+		var hash common.Hash
+		if ctx.Args().Len() > 0 {
+			if err := hash.UnmarshalText([]byte(ctx.Args().First())); err != nil {
+				return NewError(ErrorConfig, fmt.Errorf("invalid block hash %q: %v", ctx.Args().First(), err))
+			}
+		}
+		client, err := rpc.Dial(endpoint)
+		if err != nil {
+			return NewError(ErrorIO, fmt.Errorf("failed to connect to %s: %v", endpoint, err))
+		}
+		defer client.Close()
+
+		if bundle, err = exportBadBlock(ctx.Context, client, hash); err != nil {
+			return NewError(ErrorIO, err)
+		}
+		if out := ctx.String(OutputBundleFlag.Name); out != "" {
+			data, err := json.MarshalIndent(bundle, "", "  ")
+			if err != nil {
+				return NewError(ErrorJson, fmt.Errorf("failed marshalling bundle: %v", err))
+			}
+			if err := os.WriteFile(out, data, 0644); err != nil {
+				return NewError(ErrorIO, fmt.Errorf("failed writing bundle: %v", err))
+			}
+			log.Info("Wrote bad block bundle", "file", out, "accounts", len(bundle.Accounts))
+		}
+	} else if in := ctx.String(InputBundleFlag.Name); in != "" {
+		bundle = new(badBlockBundle)
+		if err := readFile(in, "bundle", bundle); err != nil {
+			return err
+		}
+	} else {
+		return NewError(ErrorConfig, fmt.Errorf("either --%s or --%s is required", BadBlockRPCFlag.Name, InputBundleFlag.Name))
+	}
+	report, err := bundle.replay()
+	if err != nil {
+		return err
+	}
+	report.print(os.Stdout)
+	return nil
+}
+
+// exportBadBlock collects everything needed to replay a bad block from the node.
+// If hash is zero, the most recent bad block is exported.
+func exportBadBlock(ctx context.Context, client *rpc.Client, hash common.Hash) (*badBlockBundle, error) {
+	var badBlocks []struct {
+		Hash common.Hash   `json:"hash"`
+		RLP  hexutil.Bytes `json:"rlp"`
+	}
+	if err := client.CallContext(ctx, &badBlocks, "debug_getBadBlocks"); err != nil {
+		return nil, fmt.Errorf("failed to retrieve bad blocks: %v", err)
+	}
+	bundle := &badBlockBundle{BlockHashes: make(map[math.HexOrDecimal64]common.Hash)}
+	for _, bad := range badBlocks {
+		if hash == (common.Hash{}) || bad.Hash == hash {
+			hash, bundle.Block = bad.Hash, bad.RLP
+			break
+		}
+	}
+	if bundle.Block == nil {
+		return nil, fmt.Errorf("bad block %x not found", hash)
+	}
+	var block types.Block
+	if err := rlp.DecodeBytes(bundle.Block, &block); err != nil {
+		return nil, fmt.Errorf("invalid bad block rlp: %v", err)
+	}
+	log.Info("Exporting bad block", "number", block.NumberU64(), "hash", hash, "txs", len(block.Transactions()))
+
+	// The chain configuration is only exposed through the node info.
+	var info struct {
+		Protocols struct {
+			Eth *struct {
+				Config *params.ChainConfig `json:"config"`
+			} `json:"eth"`
+		} `json:"protocols"`
+	}
+	if err := client.CallContext(ctx, &info, "admin_nodeInfo"); err != nil {
+		return nil, fmt.Errorf("failed to retrieve chain config, admin API required: %v", err)
+	}
+	if info.Protocols.Eth == nil || info.Protocols.Eth.Config == nil {
+		return nil, errors.New("node doesn't report a chain config")
+	}
+	bundle.Config = info.Protocols.Eth.Config
+
+	if err := client.CallContext(ctx, &bundle.Parent, "eth_getHeaderByHash", block.ParentHash()); err != nil {
+		return nil, fmt.Errorf("failed to retrieve parent header: %v", err)
+	}
+	if bundle.Parent == nil {
+		return nil, fmt.Errorf("parent %x not found", block.ParentHash())
+	}
+	if err := exportBlockHashes(ctx, client, bundle); err != nil {
+		return nil, err
+	}
+	if err := exportWitness(ctx, client, &block, bundle); err != nil {
+		return nil, err
+	}
+	if err := client.CallContext(ctx, &bundle.IntermediateRoots, "debug_intermediateRoots", hash, nil); err != nil {
+		return nil, fmt.Errorf("failed to retrieve intermediate roots: %v", err)
+	}
+	return bundle, nil
+}
+
+// exportBlockHashes retrieves the hashes of the ancestors accessible through the
+// BLOCKHASH opcode.
+func exportBlockHashes(ctx context.Context, client *rpc.Client, bundle *badBlockBundle) error {
+	var (
+		last  = bundle.Parent.Number.Uint64()
+		first = uint64(0)
+	)
+	if last > 255 {
+		first = last - 255
+	}
+	for start := first; start <= last; start += rpcBatchSize {
+		var (
+			end     = min(start+rpcBatchSize-1, last)
+			batch   []rpc.BatchElem
+			headers = make([]struct {
+				Hash common.Hash `json:"hash"`
+			}, end-start+1)
+		)
+		for i := range headers {
+			batch = append(batch, rpc.BatchElem{
+				Method: "eth_getHeaderByNumber",
+				Args:   []interface{}{hexutil.Uint64(start + uint64(i))},
+				Result: &headers[i],
+			})
+		}
+		if err := client.BatchCallContext(ctx, batch); err != nil {
+			return fmt.Errorf("failed to retrieve ancestor headers: %v", err)
+		}
+		for i, elem := range batch {
+			if elem.Error != nil {
+				return fmt.Errorf("failed to retrieve header %d: %v", start+uint64(i), elem.Error)
+			}
+			bundle.BlockHashes[math.HexOrDecimal64(start+uint64(i))] = headers[i].Hash
+		}
+	}
+	if bundle.BlockHashes[math.HexOrDecimal64(last)] != bundle.Parent.Hash() {
+		return errors.New("parent of bad block is not canonical")
+	}
+	return nil
+}
+
+// exportWitness determines the accounts and storage slots touched by the block
+// and retrieves their proofs in the parent state.
+func exportWitness(ctx context.Context, client *rpc.Client, block *types.Block, bundle *badBlockBundle) error {
+	var traces []struct {
+		Result map[common.Address]*struct {
+			Storage map[common.Hash]common.Hash `json:"storage"`
+		} `json:"result"`
+		Error string `json:"error"`
+	}
+	if err := client.CallContext(ctx, &traces, "debug_traceBadBlock", block.Hash(), map[string]interface{}{"tracer": "prestateTracer"}); err != nil {
+		return fmt.Errorf("failed to trace bad block: %v", err)
+	}
+	accounts := make(map[common.Address]*witnessAccount)
+	touch := func(addr common.Address) *witnessAccount {
+		if accounts[addr] == nil {
+			accounts[addr] = &witnessAccount{Address: addr}
+		}
+		return accounts[addr]
+	}
+	for i, trace := range traces {
+		if trace.Error != "" {
+			log.Warn("Failed to trace bad block transaction", "index", i, "err", trace.Error)
+		}
+		for addr, prestate := range trace.Result {
+			account := touch(addr)
+			for key := range prestate.Storage {
+				account.Storage = append(account.Storage, witnessSlot{Key: key})
+			}
+		}
+	}
+	// Add the accounts modified outside of transaction execution.
+	touch(block.Coinbase())
+	for _, uncle := range block.Uncles() {
+		touch(uncle.Coinbase)
+	}
+	for _, w := range block.Withdrawals() {
+		touch(w.Address)
+	}
+	if block.BeaconRoot() != nil {
+		var (
+			account = touch(params.BeaconRootsStorageAddress)
+			index   = block.Time() % 8191 // HISTORY_BUFFER_LENGTH of EIP-4788
+		)
+		account.Storage = append(account.Storage,
+			witnessSlot{Key: common.BigToHash(new(big.Int).SetUint64(index))},
+			witnessSlot{Key: common.BigToHash(new(big.Int).SetUint64(index + 8191))},
+		)
+	}
+	for _, account := range accounts {
+		account.Storage = dedupSlots(account.Storage)
+		bundle.Accounts = append(bundle.Accounts, account)
+	}
+	sort.Slice(bundle.Accounts, func(i, j int) bool {
+		return bundle.Accounts[i].Address.Cmp(bundle.Accounts[j].Address) < 0
+	})
+	// Retrieve the proofs and the code of all touched accounts.
+	parent := rpc.BlockNumberOrHashWithHash(bundle.Parent.Hash(), false)
+	for start := 0; start < len(bundle.Accounts); start += rpcBatchSize {
+		var (
+			accounts = bundle.Accounts[start:min(start+rpcBatchSize, len(bundle.Accounts))]
+			batch    []rpc.BatchElem
+			proofs   = make([]struct {
+				AccountProof []hexutil.Bytes `json:"accountProof"`
+				StorageProof []struct {
+					Proof []hexutil.Bytes `json:"proof"`
+				} `json:"storageProof"`
+			}, len(accounts))
+			codes = make([]hexutil.Bytes, len(accounts))
+		)
+		for i, account := range accounts {
+			keys := make([]string, len(account.Storage))
+			for j, slot := range account.Storage {
+				keys[j] = slot.Key.Hex()
+			}
+			batch = append(batch, rpc.BatchElem{
+				Method: "eth_getProof",
+				Args:   []interface{}{account.Address, keys, parent},
+				Result: &proofs[i],
+			}, rpc.BatchElem{
+				Method: "eth_getCode",
+				Args:   []interface{}{account.Address, parent},
+				Result: &codes[i],
+			})
+		}
+		if err := client.BatchCallContext(ctx, batch); err != nil {
+			return fmt.Errorf("failed to retrieve proofs: %v", err)
+		}
+		for i, account := range accounts {
+			if err := batch[2*i].Error; err != nil {
+				return fmt.Errorf("failed to retrieve proof of %x: %v", account.Address, err)
+			}
+			if err := batch[2*i+1].Error; err != nil {
+				return fmt.Errorf("failed to retrieve code of %x: %v", account.Address, err)
+			}
+			if len(proofs[i].StorageProof) != len(account.Storage) {
+				return fmt.Errorf("wrong number of storage proofs for %x", account.Address)
+			}
+			account.Proof, account.Code = proofs[i].AccountProof, codes[i]
+			for j := range account.Storage {
+				account.Storage[j].Proof = proofs[i].StorageProof[j].Proof
+			}
+		}
+	}
+	return nil
+}
+
+// dedupSlots sorts the slots by key and removes duplicates.
+func dedupSlots(slots []witnessSlot) []witnessSlot {
+	sort.Slice(slots, func(i, j int) bool {
+		return slots[i].Key.Cmp(slots[j].Key) < 0
+	})
+	var unique []witnessSlot
+	for i, slot := range slots {
+		if i == 0 || slot.Key != slots[i-1].Key {
+			unique = append(unique, slot)
+		}
+	}
+	return unique
+}
+
+// badBlockReport is the outcome of replaying a bad block.
+type badBlockReport struct {
+	block  *types.Block
+	result *ExecutionResult
+	roots  []common.Hash // intermediate roots computed by the exporting node
+	txs    []*replayedTx // outcome of each transaction of the block
+
+	// diverged is the index of the first transaction which was rejected or
+	// whose post-state root differs from the one computed by the node, -1 if
+	// there is none.
+	diverged int
+}
+
+// replayedTx is the outcome of replaying a single transaction of a bad block.
+type replayedTx struct {
+	receipt  *types.Receipt // nil if the transaction was rejected
+	root     common.Hash    // post-state root, empty if rejected
+	rejected string         // reason of the rejection
+}
+
+// replay re-executes the bad block on top of the witness of the parent state.
+func (b *badBlockBundle) replay() (*badBlockReport, error) {
+	if b.Config == nil || b.Parent == nil {
+		return nil, NewError(ErrorConfig, errors.New("incomplete bundle"))
+	}
+	block := new(types.Block)
+	if err := rlp.DecodeBytes(b.Block, block); err != nil {
+		return nil, NewError(ErrorRlp, fmt.Errorf("invalid bad block rlp: %v", err))
+	}
+	if block.ParentHash() != b.Parent.Hash() {
+		return nil, NewError(ErrorConfig, fmt.Errorf("parent header %x doesn't match bad block parent %x", b.Parent.Hash(), block.ParentHash()))
+	}
+	// Assemble the parent state from the witness. Trie nodes are stored by their
+	// hash, so nodes not belonging to the parent state are never resolved.
+	db := rawdb.NewMemoryDatabase()
+	for _, account := range b.Accounts {
+		writeProof(db, account.Proof)
+		for _, slot := range account.Storage {
+			writeProof(db, slot.Proof)
+		}
+		if len(account.Code) > 0 {
+			rawdb.WriteCode(db, crypto.Keccak256Hash(account.Code), account.Code)
+		}
+	}
+	statedb, err := state.New(b.Parent.Root, state.NewDatabase(db), nil)
+	if err != nil {
+		return nil, NewError(ErrorEVM, fmt.Errorf("witness doesn't contain parent state: %v", err))
+	}
+	pre, reward, err := b.prestate(block)
+	if err != nil {
+		return nil, err
+	}
+	noTracer := func(int, common.Hash) (vm.EVMLogger, error) { return nil, nil }
+	_, result, err := pre.apply(statedb, true, vm.Config{}, b.Config, block.Transactions(), reward, noTracer)
+	if err != nil {
+		return nil, err
+	}
+	// The receipts and roots only cover the included transactions, map them
+	// back to the transactions of the block.
+	rejected := make(map[int]string)
+	for _, tx := range result.Rejected {
+		rejected[tx.Index] = tx.Err
+	}
+	report := &badBlockReport{block: block, result: result, roots: b.IntermediateRoots, diverged: -1}
+	var included int
+	for i := range block.Transactions() {
+		if err, ok := rejected[i]; ok {
+			report.txs = append(report.txs, &replayedTx{rejected: err})
+			continue
+		}
+		report.txs = append(report.txs, &replayedTx{
+			receipt: result.Receipts[included],
+			root:    result.IntermediateRoots[included],
+		})
+		included++
+	}
+	for i, tx := range report.txs {
+		if tx.receipt == nil || i >= len(b.IntermediateRoots) || tx.root != b.IntermediateRoots[i] {
+			report.diverged = i
+			break
+		}
+	}
+	if report.diverged < 0 && len(b.IntermediateRoots) > len(report.txs) {
+		report.diverged = len(report.txs)
+	}
+	return report, nil
+}
+
+// prestate creates the execution environment of the bad block, along with the
+// block reward (-1 if the consensus engine doesn't pay any).
+func (b *badBlockBundle) prestate(block *types.Block) (*Prestate, int64, error) {
+	header := block.Header()
+	env := stEnv{
+		Coinbase:              header.Coinbase,
+		Difficulty:            header.Difficulty,
+		GasLimit:              header.GasLimit,
+		Number:                header.Number.Uint64(),
+		Timestamp:             header.Time,
+		BlockHashes:           b.BlockHashes,
+		Withdrawals:           block.Withdrawals(),
+		BaseFee:               header.BaseFee,
+		ExcessBlobGas:         header.ExcessBlobGas,
+		ParentBeaconBlockRoot: header.ParentBeaconRoot,
+	}
+	for _, uncle := range block.Uncles() {
+		env.Ommers = append(env.Ommers, ommer{
+			Delta:   header.Number.Uint64() - uncle.Number.Uint64(),
+			Address: uncle.Coinbase,
+		})
+	}
+	reward := int64(-1)
+	switch {
+	case header.Difficulty.Sign() == 0:
+		// Proof-of-stake blocks carry the beacon randomness in the mix digest.
+		env.Random = header.MixDigest.Big()
+	case b.Config.Clique != nil:
+		// Clique pays the fees to the signer of the block.
+		signer, err := clique.New(b.Config.Clique, rawdb.NewMemoryDatabase()).Author(header)
+		if err != nil {
+			return nil, 0, NewError(ErrorConfig, fmt.Errorf("failed to recover clique signer: %v", err))
+		}
+		env.Coinbase = signer
+	default:
+		blockReward := ethash.FrontierBlockReward
+		if b.Config.IsConstantinople(header.Number) {
+			blockReward = ethash.ConstantinopleBlockReward
+		} else if b.Config.IsByzantium(header.Number) {
+			blockReward = ethash.ByzantiumBlockReward
+		}
+		reward = blockReward.Int64()
+	}
+	return &Prestate{Env: env}, reward, nil
+}
+
+// writeProof stores the nodes of a merkle proof in the database.
+func writeProof(db ethdb.KeyValueWriter, proof []hexutil.Bytes) {
+	for _, node := range proof {
+		rawdb.WriteLegacyTrieNode(db, crypto.Keccak256Hash(node), node)
+	}
+}
+
+// print writes a human readable summary of the replay.
+func (r *badBlockReport) print(w io.Writer) {
+	header := r.block.Header()
+	fmt.Fprintf(w, "Replayed bad block %d (%v) with %d transactions\n", header.Number, r.block.Hash(), len(r.block.Transactions()))
+	txs := r.block.Transactions()
+	for i, tx := range r.txs {
+		status := "ok"
+		switch {
+		case i < r.diverged || r.diverged < 0:
+		case i == r.diverged && tx.receipt == nil:
+			status = "DIVERGED, included by node"
+		case i == r.diverged && i < len(r.roots):
+			status = fmt.Sprintf("DIVERGED, node computed %v", r.roots[i])
+		case i == r.diverged:
+			status = "DIVERGED, no root computed by node"
+		default:
+			status = "after divergence"
+		}
+		if tx.receipt == nil {
+			fmt.Fprintf(w, "tx %d %v rejected: %s: %s\n", i, txs[i].Hash(), tx.rejected, status)
+			continue
+		}
+		fmt.Fprintf(w, "tx %d %v gas %d root %v: %s\n", i, txs[i].Hash(), tx.receipt.GasUsed, tx.root, status)
+	}
+	if r.diverged >= len(r.txs) {
+		fmt.Fprintf(w, "Node computed roots for %d transactions, block has %d\n", len(r.roots), len(r.txs))
+	}
+	compare := func(name string, have, want interface{}) {
+		status := "ok"
+		if have != want {
+			status = fmt.Sprintf("MISMATCH, header has %v", want)
+		}
+		fmt.Fprintf(w, "%-13s %v: %s\n", name+":", have, status)
+	}
+	compare("Gas used", uint64(r.result.GasUsed), header.GasUsed)
+	compare("State root", r.result.StateRoot, header.Root)
+	compare("Receipt root", r.result.ReceiptRoot, header.ReceiptHash)
+	compare("Tx root", r.result.TxRoot, header.TxHash)
+	if r.result.Bloom != header.Bloom {
+		fmt.Fprintln(w, "Logs bloom:   MISMATCH")
+	}
+}
@@ -0,0 +1,171 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package t8ntool
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth"
+	"github.com/ethereum/go-ethereum/eth/ethconfig"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	_ "github.com/ethereum/go-ethereum/eth/tracers/native"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+func TestReplayBadBlock(t *testing.T) {
+	var (
+		key, _  = crypto.GenerateKey()
+		addr    = crypto.PubkeyToAddress(key.PublicKey)
+		storer  = common.HexToAddress("0x5703e")
+		genesis = &core.Genesis{
+			Config: params.AllEthashProtocolChanges,
+			Alloc: core.GenesisAlloc{
+				addr: {Balance: big.NewInt(params.Ether)},
+				// Stores the call value in the slot given by the calldata.
+				storer: {Code: common.FromHex("0x3460003555"), Balance: common.Big0},
+			},
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+		signer = types.LatestSigner(genesis.Config)
+	)
+	_, blocks, _ := core.GenerateChainWithGenesis(genesis, ethash.NewFaker(), 2, func(i int, g *core.BlockGen) {
+		for j := 0; j < 2; j++ {
+			tx, _ := types.SignNewTx(key, signer, &types.DynamicFeeTx{
+				Nonce:     g.TxNonce(addr),
+				To:        &storer,
+				Value:     big.NewInt(int64(i + 1)),
+				Gas:       100000,
+				GasFeeCap: g.BaseFee(),
+				Data:      common.BigToHash(big.NewInt(int64(j))).Bytes(),
+			})
+			g.AddTx(tx)
+		}
+		g.AddTx(types.MustSignNewTx(key, signer, &types.DynamicFeeTx{
+			Nonce:     g.TxNonce(addr),
+			To:        &common.Address{byte(i)},
+			Value:     big.NewInt(1),
+			Gas:       params.TxGas,
+			GasFeeCap: g.BaseFee(),
+		}))
+	})
+	// Corrupt the state root of the last block, so the node rejects it.
+	header := blocks[1].Header()
+	header.Root = common.Hash{0xba, 0xd}
+	bad := types.NewBlockWithHeader(header).WithBody(blocks[1].Transactions(), nil)
+
+	stack, err := node.New(&node.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stack.Close()
+	backend, err := eth.New(stack, &ethconfig.Config{Genesis: genesis})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stack.RegisterAPIs(tracers.APIs(backend.APIBackend))
+	if err := stack.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := backend.BlockChain().InsertChain(types.Blocks{blocks[0], bad}); err == nil {
+		t.Fatal("bad block accepted")
+	}
+	client := stack.Attach()
+	defer client.Close()
+
+	exported, err := exportBadBlock(context.Background(), client, common.Hash{})
+	if err != nil {
+		t.Fatalf("failed to export bad block: %v", err)
+	}
+	// The bundle must be self-contained.
+	data, err := json.Marshal(exported)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var bundle badBlockBundle
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		t.Fatal(err)
+	}
+	if len(bundle.IntermediateRoots) != 3 || len(bundle.BlockHashes) != 2 {
+		t.Fatalf("incomplete bundle: %d roots, %d block hashes", len(bundle.IntermediateRoots), len(bundle.BlockHashes))
+	}
+	report, err := bundle.replay()
+	if err != nil {
+		t.Fatalf("failed to replay bad block: %v", err)
+	}
+	if report.diverged != -1 {
+		t.Errorf("replay diverged at transaction %d", report.diverged)
+	}
+	if report.result.StateRoot != blocks[1].Root() {
+		t.Errorf("wrong state root %x, want %x", report.result.StateRoot, blocks[1].Root())
+	}
+	var out bytes.Buffer
+	report.print(&out)
+	if !strings.Contains(out.String(), "MISMATCH, header has "+header.Root.String()) {
+		t.Errorf("state root mismatch not reported:\n%s", out.String())
+	}
+
+	// Transactions the replay rejects are reported as the divergence, and the
+	// transactions after them are matched to the roots of the node by index.
+	txs := blocks[1].Transactions()
+	dup := types.NewBlockWithHeader(header).WithBody(types.Transactions{txs[0], txs[0], txs[1], txs[2]}, nil)
+	original := bundle.Block
+	if bundle.Block, err = rlp.EncodeToBytes(dup); err != nil {
+		t.Fatal(err)
+	}
+	if report, err = bundle.replay(); err != nil {
+		t.Fatalf("failed to replay bad block: %v", err)
+	}
+	if report.diverged != 1 {
+		t.Errorf("wrong divergence %d, want 1", report.diverged)
+	}
+	if len(report.txs) != 4 || report.txs[1].receipt != nil || report.txs[2].root != bundle.IntermediateRoots[1] {
+		t.Errorf("replayed transactions not matched to the block")
+	}
+	out.Reset()
+	report.print(&out)
+	if !strings.Contains(out.String(), "tx 1 "+txs[0].Hash().String()+" rejected") {
+		t.Errorf("rejected transaction not reported:\n%s", out.String())
+	}
+	bundle.Block = original
+
+	// Pretend the node computed a different state after the second transaction.
+	bundle.IntermediateRoots[1] = common.Hash{}
+	if report, err = bundle.replay(); err != nil {
+		t.Fatalf("failed to replay bad block: %v", err)
+	}
+	if report.diverged != 1 {
+		t.Errorf("wrong divergence %d, want 1", report.diverged)
+	}
+
+	// Replays fail if the witness is incomplete.
+	bundle.Accounts = bundle.Accounts[1:]
+	if _, err := bundle.replay(); err == nil {
+		t.Error("replay succeeded with incomplete witness")
+	}
+}
@@ -121,6 +121,18 @@ var (
 		Usage: "`stdin` or file name of where to find the transactions list in RLP form.",
 		Value: "txs.rlp",
 	}
+	InputBundleFlag = &cli.StringFlag{
+		Name:  "input.bundle",
+		Usage: "File name of the bad block bundle to replay.",
+	}
+	OutputBundleFlag = &cli.StringFlag{
+		Name:  "output.bundle",
+		Usage: "If set, the bad block bundle exported from the node will be written to this file.",
+	}
+	BadBlockRPCFlag = &cli.StringFlag{
+		Name:  "rpc",
+		Usage: "Endpoint of the node to export the bad block from. The debug and admin APIs must be enabled.",
+	}
 	SealCliqueFlag = &cli.StringFlag{
 		Name:  "seal.clique",
 		Usage: "Seal block with Clique. `stdin` or file name of where to find the Clique sealing data.",
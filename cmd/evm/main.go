@@ -192,6 +192,19 @@ var blockBuilderCommand = &cli.Command{
 	},
 }
 
+var replayBadBlockCommand = &cli.Command{
+	Name:      "replay-bad-block",
+	Usage:     "exports and replays a bad block, comparing the state root after each transaction",
+	ArgsUsage: "[hash]",
+	Action:    t8ntool.ReplayBadBlock,
+	Flags: []cli.Flag{
+		t8ntool.BadBlockRPCFlag,
+		t8ntool.InputBundleFlag,
+		t8ntool.OutputBundleFlag,
+		t8ntool.VerbosityFlag,
+	},
+}
+
 // vmFlags contains flags related to running the EVM.
 var vmFlags = []cli.Flag{
 	CodeFlag,
@@ -233,6 +246,7 @@ func init() {
 		stateTransitionCommand,
 		transactionCommand,
 		blockBuilderCommand,
+		replayBadBlockCommand,
 	}
 	app.Before = func(ctx *cli.Context) error {
 		flags.MigrateGlobalFlags(ctx)
@@ -20,6 +20,7 @@ import (
 	"bufio"
 	"errors"
 	"fmt"
+	"math/big"
 	"os"
 	"reflect"
 	"runtime"
@@ -32,6 +33,7 @@ import (
 	"github.com/ethereum/go-ethereum/accounts/scwallet"
 	"github.com/ethereum/go-ethereum/accounts/usbwallet"
 	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/eth/builder"
 	"github.com/ethereum/go-ethereum/eth/catalyst"
 	"github.com/ethereum/go-ethereum/eth/downloader"
 	"github.com/ethereum/go-ethereum/eth/ethconfig"
@@ -210,6 +212,15 @@ func makeFullNode(ctx *cli.Context) (*node.Node, ethapi.Backend) {
 		utils.RegisterFullSyncTester(stack, eth, ctx.Path(utils.SyncTargetFlag.Name))
 	}
 
+	// Configure the block builder and the validation of builder submissions
+	// if requested.
+	if ctx.IsSet(utils.BuilderRelaysFlag.Name) {
+		utils.RegisterBuilder(ctx, stack, eth)
+	}
+	if ctx.Bool(utils.BuilderValidationFlag.Name) {
+		builder.RegisterValidationAPI(stack, eth)
+	}
+
 	// Start the dev mode if requested, or launch the engine API for
 	// interacting with external consensus client.
 	if ctx.IsSet(utils.DeveloperFlag.Name) {
@@ -217,8 +228,17 @@ func makeFullNode(ctx *cli.Context) (*node.Node, ethapi.Backend) {
 		if err != nil {
 			utils.Fatalf("failed to register dev mode catalyst service: %v", err)
 		}
+		// Forked chains lack the funded developer account of the dev genesis
+		if cfg.Eth.DevFork != nil && eth.BlockChain().CurrentBlock().Hash() == eth.BlockChain().Genesis().Hash() {
+			if err := simBeacon.SetBalance(cfg.Eth.Miner.Etherbase, new(big.Int).Mul(big.NewInt(1_000_000), big.NewInt(params.Ether))); err != nil {
+				utils.Fatalf("failed to fund developer account: %v", err)
+			}
+		}
 		catalyst.RegisterSimulatedBeaconAPIs(stack, simBeacon)
 		stack.RegisterLifecycle(simBeacon)
+	} else if ctx.IsSet(utils.BeaconApiFlag.Name) {
+		// Drive the chain from the built-in beacon light client
+		utils.RegisterLightSyncer(ctx, stack, eth)
 	} else if cfg.Eth.SyncMode != downloader.LightSync {
 		err := catalyst.Register(stack, eth)
 		if err != nil {
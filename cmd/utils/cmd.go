@@ -23,8 +23,11 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math"
+	"math/big"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"runtime"
 	"strings"
 	"syscall"
@@ -38,9 +41,12 @@ import (
 	"github.com/ethereum/go-ethereum/eth/ethconfig"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/internal/debug"
+	"github.com/ethereum/go-ethereum/internal/era"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
 	"github.com/urfave/cli/v2"
 )
 
@@ -296,6 +302,252 @@ func ExportAppendChain(blockchain *core.BlockChain, fn string, first uint64, las
 	return nil
 }
 
+// ImportHistory imports Era1 files containing historical block information,
+// starting from genesis. Every archive is verified against its accumulator
+// root before any of its blocks are written to the database.
+func ImportHistory(chain *core.BlockChain, dir string, network string) error {
+	if chain.CurrentSnapBlock().Number.BitLen() != 0 {
+		return errors.New("history import only supported when starting from genesis")
+	}
+	entries, err := era.ReadDir(dir, network)
+	if err != nil {
+		return fmt.Errorf("error reading %s: %w", dir, err)
+	}
+	if len(entries) == 0 {
+		return fmt.Errorf("no era1 files found for network %s in %s", network, dir)
+	}
+	var (
+		start    = time.Now()
+		reported = time.Now()
+		imported = 0
+		forker   = core.NewForkChoice(chain, nil)
+	)
+	for _, filename := range entries {
+		err := func() error {
+			e, err := era.Open(filepath.Join(dir, filename))
+			if err != nil {
+				return fmt.Errorf("unable to open era: %w", err)
+			}
+			defer e.Close()
+
+			blocks, receipts, err := verifyHistory(chain, e, filename)
+			if err != nil {
+				return err
+			}
+			// Skip over the genesis, it's already present in the database.
+			if len(blocks) > 0 && blocks[0].NumberU64() == 0 {
+				blocks, receipts = blocks[1:], receipts[1:]
+			}
+			for len(blocks) > 0 {
+				n := importBatchSize
+				if n > len(blocks) {
+					n = len(blocks)
+				}
+				headers := make([]*types.Header, n)
+				for i, block := range blocks[:n] {
+					headers[i] = block.Header()
+				}
+				// The headers are authenticated by the accumulator, skip the
+				// consensus verification and write them directly.
+				if status, err := chain.HeaderChain().InsertHeaderChain(headers, start, forker); err != nil {
+					return fmt.Errorf("error inserting headers %d: %w", headers[0].Number, err)
+				} else if status != core.CanonStatTy {
+					return fmt.Errorf("error inserting headers %d, not canon: %v", headers[0].Number, status)
+				}
+				if _, err := chain.InsertReceiptChain(blocks[:n], receipts[:n], math.MaxUint64); err != nil {
+					return fmt.Errorf("error inserting bodies %d: %w", headers[0].Number, err)
+				}
+				imported += n
+				blocks, receipts = blocks[n:], receipts[n:]
+
+				if time.Since(reported) >= 8*time.Second {
+					log.Info("Importing Era files", "head", headers[n-1].Number, "imported", imported, "elapsed", common.PrettyDuration(time.Since(start)))
+					reported = time.Now()
+				}
+			}
+			return nil
+		}()
+		if err != nil {
+			return err
+		}
+	}
+	log.Info("Imported Era files", "files", len(entries), "blocks", imported, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+// verifyHistory reads all the blocks and receipts of an Era1 archive and checks
+// them against the archive's accumulator root, as well as against the root
+// prefix embedded in the filename and the locally available parent.
+func verifyHistory(chain *core.BlockChain, e *era.Era, filename string) ([]*types.Block, []types.Receipts, error) {
+	want, err := e.Accumulator()
+	if err != nil {
+		return nil, nil, fmt.Errorf("error reading accumulator: %w", err)
+	}
+	if parts := strings.Split(strings.TrimSuffix(filename, ".era1"), "-"); len(parts) != 3 || !strings.HasPrefix(want.Hex()[2:], parts[2]) {
+		return nil, nil, fmt.Errorf("accumulator %x does not match filename %s", want, filename)
+	}
+	td, err := e.InitialTD()
+	if err != nil {
+		return nil, nil, fmt.Errorf("error reading initial total difficulty: %w", err)
+	}
+	if e.Start() > 0 {
+		parent := chain.GetHeaderByNumber(e.Start() - 1)
+		if parent == nil {
+			return nil, nil, fmt.Errorf("missing parent block %d", e.Start()-1)
+		}
+		if ptd := chain.GetTd(parent.Hash(), parent.Number.Uint64()); ptd == nil || ptd.Cmp(td) != 0 {
+			return nil, nil, fmt.Errorf("initial total difficulty mismatch: have %v, want %v", td, ptd)
+		}
+	} else if td.Sign() != 0 {
+		return nil, nil, fmt.Errorf("non-zero initial total difficulty %v", td)
+	}
+	it, err := era.NewIterator(e)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error making era reader: %w", err)
+	}
+	var (
+		blocks   = make([]*types.Block, 0, e.Count())
+		receipts = make([]types.Receipts, 0, e.Count())
+		hashes   = make([]common.Hash, 0, e.Count())
+		tds      = make([]*big.Int, 0, e.Count())
+	)
+	for it.Next() {
+		block, rs, err := it.BlockAndReceipts()
+		if err != nil {
+			return nil, nil, fmt.Errorf("error reading block %d: %w", it.Number(), err)
+		}
+		if block.NumberU64() != it.Number() {
+			return nil, nil, fmt.Errorf("unexpected block number: have %d, want %d", block.NumberU64(), it.Number())
+		}
+		if hash := types.DeriveSha(block.Transactions(), trie.NewStackTrie(nil)); hash != block.TxHash() {
+			return nil, nil, fmt.Errorf("transaction root mismatch in block %d: have %x, want %x", block.NumberU64(), hash, block.TxHash())
+		}
+		if hash := types.CalcUncleHash(block.Uncles()); hash != block.UncleHash() {
+			return nil, nil, fmt.Errorf("uncle root mismatch in block %d: have %x, want %x", block.NumberU64(), hash, block.UncleHash())
+		}
+		if hash := types.DeriveSha(rs, trie.NewStackTrie(nil)); hash != block.ReceiptHash() {
+			return nil, nil, fmt.Errorf("receipt root mismatch in block %d: have %x, want %x", block.NumberU64(), hash, block.ReceiptHash())
+		}
+		have, err := it.TotalDifficulty()
+		if err != nil {
+			return nil, nil, fmt.Errorf("error reading total difficulty %d: %w", it.Number(), err)
+		}
+		td = new(big.Int).Add(td, block.Difficulty())
+		if have.Cmp(td) != 0 {
+			return nil, nil, fmt.Errorf("total difficulty mismatch in block %d: have %v, want %v", block.NumberU64(), have, td)
+		}
+		blocks = append(blocks, block)
+		receipts = append(receipts, rs)
+		hashes = append(hashes, block.Hash())
+		tds = append(tds, td)
+	}
+	if err := it.Error(); err != nil {
+		return nil, nil, fmt.Errorf("error reading era: %w", err)
+	}
+	if uint64(len(blocks)) != e.Count() {
+		return nil, nil, fmt.Errorf("block count mismatch: have %d, want %d", len(blocks), e.Count())
+	}
+	root, err := era.ComputeAccumulator(hashes, tds)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error computing accumulator: %w", err)
+	}
+	if root != want {
+		return nil, nil, fmt.Errorf("accumulator mismatch: have %x, want %x", root, want)
+	}
+	return blocks, receipts, nil
+}
+
+// ExportHistory exports blockchain history into the specified directory,
+// following the Era1 format, with each archive holding step blocks.
+func ExportHistory(bc *core.BlockChain, dir string, first, last, step uint64) error {
+	log.Info("Exporting blockchain history", "dir", dir)
+	if head := bc.CurrentBlock().Number.Uint64(); head < last {
+		log.Warn("Last block beyond head, setting last = head", "head", head, "last", last)
+		last = head
+	}
+	if first > last {
+		return fmt.Errorf("invalid range: first %d, last %d", first, last)
+	}
+	if step == 0 || step > uint64(era.MaxEra1Size) {
+		return fmt.Errorf("invalid step size %d", step)
+	}
+	network := "unknown"
+	if name, ok := params.NetworkNames[bc.Config().ChainID.String()]; ok {
+		network = name
+	}
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return fmt.Errorf("error creating output directory: %w", err)
+	}
+	var (
+		start    = time.Now()
+		reported = time.Now()
+		td       = new(big.Int)
+	)
+	if first > 0 {
+		parent := bc.GetHeaderByNumber(first - 1)
+		if parent == nil {
+			return fmt.Errorf("export failed on #%d: not found", first-1)
+		}
+		td = bc.GetTd(parent.Hash(), parent.Number.Uint64())
+		if td == nil {
+			return fmt.Errorf("export failed on #%d: total difficulty not found", first-1)
+		}
+	}
+	for i := first; i <= last; i += step {
+		err := func() error {
+			filename := filepath.Join(dir, era.Filename(network, int(i/step), common.Hash{}))
+			f, err := os.Create(filename)
+			if err != nil {
+				return fmt.Errorf("could not create era file: %w", err)
+			}
+			defer f.Close()
+
+			w := era.NewBuilder(f)
+			for j := uint64(0); j < step && j <= last-i; j++ {
+				var (
+					n     = i + j
+					block = bc.GetBlockByNumber(n)
+				)
+				if block == nil {
+					return fmt.Errorf("export failed on #%d: not found", n)
+				}
+				if n > 0 && block.Difficulty().Sign() == 0 {
+					return fmt.Errorf("export failed on #%d: post-merge blocks are not supported", n)
+				}
+				receipts := bc.GetReceiptsByHash(block.Hash())
+				if receipts == nil {
+					return fmt.Errorf("export failed on #%d: receipts not found", n)
+				}
+				td = new(big.Int).Add(td, block.Difficulty())
+				if err := w.Add(block, receipts, td); err != nil {
+					return err
+				}
+			}
+			root, err := w.Finalize()
+			if err != nil {
+				return fmt.Errorf("export failed to finalize %d: %w", i/step, err)
+			}
+			if err := f.Close(); err != nil {
+				return err
+			}
+			// Set correct filename with root.
+			if err := os.Rename(filename, filepath.Join(dir, era.Filename(network, int(i/step), root))); err != nil {
+				return err
+			}
+			if time.Since(reported) >= 8*time.Second {
+				log.Info("Exporting blocks", "exported", i, "elapsed", common.PrettyDuration(time.Since(start)))
+				reported = time.Now()
+			}
+			return nil
+		}()
+		if err != nil {
+			return err
+		}
+	}
+	log.Info("Exported blockchain to", "dir", dir)
+	return nil
+}
+
 // ImportPreimages imports a batch of exported hash preimages into the database.
 // It's a part of the deprecated functionality, should be removed in the future.
 func ImportPreimages(db ethdb.Database, fn string) error {
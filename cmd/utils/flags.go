@@ -22,6 +22,7 @@ import (
 	"context"
 	"crypto/ecdsa"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math"
@@ -37,6 +38,9 @@ import (
 
 	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/beacon/light"
+	lightapi "github.com/ethereum/go-ethereum/beacon/light/api"
+	beacontypes "github.com/ethereum/go-ethereum/beacon/types"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/fdlimit"
 	"github.com/ethereum/go-ethereum/common/hexutil"
@@ -48,7 +52,9 @@ import (
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/crypto/kzg4844"
 	"github.com/ethereum/go-ethereum/eth"
+	"github.com/ethereum/go-ethereum/eth/builder"
 	"github.com/ethereum/go-ethereum/eth/catalyst"
+	"github.com/ethereum/go-ethereum/eth/devfork"
 	"github.com/ethereum/go-ethereum/eth/downloader"
 	"github.com/ethereum/go-ethereum/eth/ethconfig"
 	"github.com/ethereum/go-ethereum/eth/filters"
@@ -60,6 +66,7 @@ import (
 	"github.com/ethereum/go-ethereum/graphql"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/internal/flags"
+	"github.com/ethereum/go-ethereum/internal/tracing"
 	"github.com/ethereum/go-ethereum/les"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/metrics"
@@ -78,6 +85,7 @@ import (
 	"github.com/ethereum/go-ethereum/trie/triedb/hashdb"
 	"github.com/ethereum/go-ethereum/trie/triedb/pathdb"
 	pcsclite "github.com/gballet/go-libpcsclite"
+	bls "github.com/protolambda/bls12-381-util"
 	gopsutil "github.com/shirou/gopsutil/mem"
 	"github.com/urfave/cli/v2"
 )
@@ -177,6 +185,16 @@ var (
 		Value:    11500000,
 		Category: flags.DevCategory,
 	}
+	DeveloperForkFlag = &cli.StringFlag{
+		Name:     "dev.fork",
+		Usage:    "RPC endpoint of a node to fork the developer chain off (state is fetched on demand)",
+		Category: flags.DevCategory,
+	}
+	DeveloperForkBlockFlag = &cli.Uint64Flag{
+		Name:     "dev.fork.block",
+		Usage:    "Number of the remote block to fork the developer chain off (default = latest)",
+		Category: flags.DevCategory,
+	}
 
 	IdentityFlag = &cli.StringFlag{
 		Name:     "identity",
@@ -281,6 +299,21 @@ var (
 		Value:    ethconfig.Defaults.StateHistory,
 		Category: flags.StateCategory,
 	}
+	StateHistoryIndexFlag = &cli.BoolFlag{
+		Name:     "history.state.index",
+		Usage:    "Index the state histories to serve historical state reads (path scheme only)",
+		Category: flags.StateCategory,
+	}
+	HistoryPruneFlag = &cli.StringFlag{
+		Name:     "history.prune",
+		Usage:    "Discard block bodies and receipts below the given point at startup ('merge' or a block number)",
+		Category: flags.StateCategory,
+	}
+	LogIndexFlag = &cli.BoolFlag{
+		Name:     "history.logindex",
+		Usage:    "Maintain an exact index of log addresses and topics for faster log filtering",
+		Category: flags.StateCategory,
+	}
 	TransactionHistoryFlag = &cli.Uint64Flag{
 		Name:     "history.transactions",
 		Usage:    "Number of recent blocks to maintain transactions index for (default = about one year, 0 = entire chain)",
@@ -341,10 +374,33 @@ var (
 	}
 	TxPoolRejournalFlag = &cli.DurationFlag{
 		Name:     "txpool.rejournal",
-		Usage:    "Time interval to regenerate the local transaction journal",
+		Usage:    "Time interval to regenerate the transaction journals",
 		Value:    ethconfig.Defaults.TxPool.Rejournal,
 		Category: flags.TxPoolCategory,
 	}
+	TxPoolJournalRemotesFlag = &cli.BoolFlag{
+		Name:     "txpool.journalremotes",
+		Usage:    "Enables journaling remote transactions to refill the pool after a restart",
+		Category: flags.TxPoolCategory,
+	}
+	TxPoolRemoteJournalFlag = &cli.StringFlag{
+		Name:     "txpool.remotejournal",
+		Usage:    "Disk journal for remote transactions to survive node restarts",
+		Value:    ethconfig.Defaults.TxPool.RemoteJournal,
+		Category: flags.TxPoolCategory,
+	}
+	TxPoolRemoteJournalSizeFlag = &cli.Uint64Flag{
+		Name:     "txpool.remotejournal.size",
+		Usage:    "Maximum size of the remote transaction journal in bytes",
+		Value:    ethconfig.Defaults.TxPool.RemoteJournalSize,
+		Category: flags.TxPoolCategory,
+	}
+	TxPoolRemoteJournalAgeFlag = &cli.DurationFlag{
+		Name:     "txpool.remotejournal.age",
+		Usage:    "Maximum age of the remote transactions to journal",
+		Value:    ethconfig.Defaults.TxPool.RemoteJournalAge,
+		Category: flags.TxPoolCategory,
+	}
 	TxPoolPriceLimitFlag = &cli.Uint64Flag{
 		Name:     "txpool.pricelimit",
 		Usage:    "Minimum gas price tip to enforce for acceptance into the pool",
@@ -387,6 +443,11 @@ var (
 		Value:    ethconfig.Defaults.TxPool.Lifetime,
 		Category: flags.TxPoolCategory,
 	}
+	TxPoolBundlesFlag = &cli.BoolFlag{
+		Name:     "txpool.bundles",
+		Usage:    "Enables the private bundle pool and the mev_sendBundle RPC method",
+		Category: flags.TxPoolCategory,
+	}
 	// Blob transaction pool settings
 	BlobPoolDataDirFlag = &cli.StringFlag{
 		Name:     "blobpool.datadir",
@@ -505,6 +566,32 @@ var (
 		Value:    ethconfig.Defaults.Miner.NewPayloadTimeout,
 		Category: flags.MinerCategory,
 	}
+	MinerReportsFlag = &cli.BoolFlag{
+		Name:     "miner.reports",
+		Usage:    "Record why pending transactions are left out of payloads, for miner_getPayloadReport",
+		Category: flags.MinerCategory,
+	}
+	BuilderRelaysFlag = &cli.StringSliceFlag{
+		Name:     "builder.relays",
+		Usage:    "URLs of the relays to submit built payloads to, enables the block builder",
+		Category: flags.MinerCategory,
+	}
+	BuilderSecretKeyFlag = &cli.StringFlag{
+		Name:     "builder.secretkey",
+		Usage:    "BLS secret key of the block builder as hex",
+		Category: flags.MinerCategory,
+	}
+	BuilderIntervalFlag = &cli.DurationFlag{
+		Name:     "builder.interval",
+		Usage:    "Minimum time between submissions of improved payloads to the relays",
+		Value:    builder.DefaultInterval,
+		Category: flags.MinerCategory,
+	}
+	BuilderValidationFlag = &cli.BoolFlag{
+		Name:     "builder.validation",
+		Usage:    "Enable the flashbots namespace for validating builder block submissions",
+		Category: flags.MinerCategory,
+	}
 
 	// Account settings
 	UnlockedAccountFlag = &cli.StringFlag{
@@ -537,6 +624,16 @@ var (
 		Usage:    "Record information useful for VM and contract debugging",
 		Category: flags.VMCategory,
 	}
+	VMTraceFlag = &cli.StringFlag{
+		Name:     "vmtrace",
+		Usage:    "Name of the live tracer which should trace block imports",
+		Category: flags.VMCategory,
+	}
+	VMTraceJsonConfigFlag = &cli.StringFlag{
+		Name:     "vmtrace.jsonconfig",
+		Usage:    "Tracer configuration (JSON)",
+		Category: flags.VMCategory,
+	}
 
 	// API options.
 	RPCGlobalGasCapFlag = &cli.Uint64Flag{
@@ -582,6 +679,45 @@ var (
 		Category: flags.APICategory,
 	}
 
+	// Beacon light client settings
+	BeaconApiFlag = &cli.StringFlag{
+		Name:     "beacon.api",
+		Usage:    "Beacon node REST API URL, enables the built-in beacon light client to drive the chain head",
+		Category: flags.BeaconCategory,
+	}
+	BeaconApiHeaderFlag = &cli.StringSliceFlag{
+		Name:     "beacon.api.header",
+		Usage:    "Pass custom HTTP header fields to the beacon node REST API (\"key:value\", can be given multiple times)",
+		Category: flags.BeaconCategory,
+	}
+	BeaconCheckpointFlag = &cli.StringFlag{
+		Name:     "beacon.checkpoint",
+		Usage:    "Trusted beacon block root (weak subjectivity checkpoint) to start light syncing from",
+		Category: flags.BeaconCategory,
+	}
+	BeaconThresholdFlag = &cli.IntFlag{
+		Name:     "beacon.threshold",
+		Usage:    "Minimum number of sync committee signatures required for accepting a beacon header",
+		Value:    light.DefaultSignerThreshold,
+		Category: flags.BeaconCategory,
+	}
+	BeaconConfigFlag = &cli.PathFlag{
+		Name:      "beacon.config",
+		Usage:     "Beacon chain config YAML file (required for networks without a built-in preset)",
+		TakesFile: true,
+		Category:  flags.BeaconCategory,
+	}
+	BeaconGenesisRootFlag = &cli.StringFlag{
+		Name:     "beacon.genesis.gvroot",
+		Usage:    "Beacon chain genesis validators root (required with --beacon.config)",
+		Category: flags.BeaconCategory,
+	}
+	BeaconGenesisTimeFlag = &cli.Uint64Flag{
+		Name:     "beacon.genesis.time",
+		Usage:    "Beacon chain genesis time (required with --beacon.config)",
+		Category: flags.BeaconCategory,
+	}
+
 	// Logging and debug settings
 	EthStatsURLFlag = &cli.StringFlag{
 		Name:     "ethstats",
@@ -733,6 +869,23 @@ var (
 		Value:    node.DefaultConfig.BatchResponseMaxSize,
 		Category: flags.APICategory,
 	}
+	RPCRateLimitFlag = &cli.Float64Flag{
+		Name:     "rpc.ratelimit",
+		Usage:    "Cost units each RPC client may spend per second, identified by IP or JWT subject (0 = no limit)",
+		Value:    node.DefaultConfig.RPCRateLimit,
+		Category: flags.APICategory,
+	}
+	RPCRateLimitBurstFlag = &cli.IntFlag{
+		Name:     "rpc.ratelimit.burst",
+		Usage:    "Maximum number of cost units an RPC client may spend at once",
+		Value:    node.DefaultConfig.RPCRateLimitBurst,
+		Category: flags.APICategory,
+	}
+	RPCMethodCostsFlag = &cli.StringFlag{
+		Name:     "rpc.ratelimit.costs",
+		Usage:    "Comma separated list of RPC method cost weights overriding the defaults, e.g. 'eth_getLogs=50,debug_*=100'",
+		Category: flags.APICategory,
+	}
 	EnablePersonal = &cli.BoolFlag{
 		Name:     "rpc.enabledeprecatedpersonal",
 		Usage:    "Enables the (deprecated) personal namespace",
@@ -867,6 +1020,25 @@ var (
 		Category: flags.MetricsCategory,
 	}
 
+	// Tracing flags
+	TracingEnabledFlag = &cli.BoolFlag{
+		Name:     "tracing",
+		Usage:    "Enable distributed tracing of RPC calls and block processing",
+		Category: flags.MetricsCategory,
+	}
+	TracingEndpointFlag = &cli.StringFlag{
+		Name:     "tracing.endpoint",
+		Usage:    "OpenTelemetry collector OTLP/HTTP endpoint to export trace spans to",
+		Value:    "http://localhost:4318",
+		Category: flags.MetricsCategory,
+	}
+	TracingSampleRatioFlag = &cli.Float64Flag{
+		Name:     "tracing.sample-ratio",
+		Usage:    "Fraction of locally started traces to record (traces of remote callers follow their sampling decision)",
+		Value:    1.0,
+		Category: flags.MetricsCategory,
+	}
+
 	// MetricsHTTPFlag defines the endpoint for a stand-alone metrics HTTP endpoint.
 	// Since the pprof service enables sensitive/vulnerable behavior, this allows a user
 	// to enable a public-OK metrics endpoint without having to worry about ALSO exposing
@@ -1173,6 +1345,28 @@ func setHTTP(ctx *cli.Context, cfg *node.Config) {
 	if ctx.IsSet(BatchResponseMaxSize.Name) {
 		cfg.BatchResponseMaxSize = ctx.Int(BatchResponseMaxSize.Name)
 	}
+
+	if ctx.IsSet(RPCRateLimitFlag.Name) {
+		cfg.RPCRateLimit = ctx.Float64(RPCRateLimitFlag.Name)
+	}
+	if ctx.IsSet(RPCRateLimitBurstFlag.Name) {
+		cfg.RPCRateLimitBurst = ctx.Int(RPCRateLimitBurstFlag.Name)
+	}
+	if ctx.IsSet(RPCMethodCostsFlag.Name) {
+		costs := make(map[string]int, len(cfg.RPCMethodCosts))
+		for method, cost := range cfg.RPCMethodCosts {
+			costs[method] = cost
+		}
+		for _, entry := range SplitAndTrim(ctx.String(RPCMethodCostsFlag.Name)) {
+			method, value, ok := strings.Cut(entry, "=")
+			cost, err := strconv.Atoi(value)
+			if !ok || method == "" || err != nil || cost < 0 {
+				Fatalf("Invalid RPC method cost %q, expected <method>=<cost>", entry)
+			}
+			costs[method] = cost
+		}
+		cfg.RPCMethodCosts = costs
+	}
 }
 
 // setGraphQL creates the GraphQL listener interface string from the set
@@ -1536,6 +1730,18 @@ func setTxPool(ctx *cli.Context, cfg *legacypool.Config) {
 	if ctx.IsSet(TxPoolRejournalFlag.Name) {
 		cfg.Rejournal = ctx.Duration(TxPoolRejournalFlag.Name)
 	}
+	if ctx.IsSet(TxPoolJournalRemotesFlag.Name) {
+		cfg.JournalRemotes = ctx.Bool(TxPoolJournalRemotesFlag.Name)
+	}
+	if ctx.IsSet(TxPoolRemoteJournalFlag.Name) {
+		cfg.RemoteJournal = ctx.String(TxPoolRemoteJournalFlag.Name)
+	}
+	if ctx.IsSet(TxPoolRemoteJournalSizeFlag.Name) {
+		cfg.RemoteJournalSize = ctx.Uint64(TxPoolRemoteJournalSizeFlag.Name)
+	}
+	if ctx.IsSet(TxPoolRemoteJournalAgeFlag.Name) {
+		cfg.RemoteJournalAge = ctx.Duration(TxPoolRemoteJournalAgeFlag.Name)
+	}
 	if ctx.IsSet(TxPoolPriceLimitFlag.Name) {
 		cfg.PriceLimit = ctx.Uint64(TxPoolPriceLimitFlag.Name)
 	}
@@ -1575,6 +1781,9 @@ func setMiner(ctx *cli.Context, cfg *miner.Config) {
 	if ctx.IsSet(MinerNewPayloadTimeout.Name) {
 		cfg.NewPayloadTimeout = ctx.Duration(MinerNewPayloadTimeout.Name)
 	}
+	if ctx.IsSet(MinerReportsFlag.Name) {
+		cfg.Reports = ctx.Bool(MinerReportsFlag.Name)
+	}
 }
 
 func setRequiredBlocks(ctx *cli.Context, cfg *ethconfig.Config) {
@@ -1713,6 +1922,23 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	if ctx.IsSet(StateHistoryFlag.Name) {
 		cfg.StateHistory = ctx.Uint64(StateHistoryFlag.Name)
 	}
+	if ctx.IsSet(StateHistoryIndexFlag.Name) {
+		cfg.StateHistoryIndex = ctx.Bool(StateHistoryIndexFlag.Name)
+	}
+	if ctx.IsSet(HistoryPruneFlag.Name) {
+		cfg.HistoryPrune = ctx.String(HistoryPruneFlag.Name)
+		if cfg.HistoryPrune != core.HistoryPruneMerge {
+			if _, err := strconv.ParseUint(cfg.HistoryPrune, 10, 64); err != nil {
+				Fatalf("Invalid %s value %q, want %q or a block number", HistoryPruneFlag.Name, cfg.HistoryPrune, core.HistoryPruneMerge)
+			}
+		}
+	}
+	if ctx.IsSet(LogIndexFlag.Name) {
+		cfg.LogIndex = ctx.Bool(LogIndexFlag.Name)
+	}
+	if ctx.IsSet(TxPoolBundlesFlag.Name) {
+		cfg.EnableBundles = ctx.Bool(TxPoolBundlesFlag.Name)
+	}
 	// Parse state scheme, abort the process if it's not compatible.
 	chaindb := tryMakeReadOnlyDatabase(ctx, stack)
 	scheme, err := ParseStateScheme(ctx, chaindb)
@@ -1769,6 +1995,12 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 		// TODO(fjl): force-enable this in --dev mode
 		cfg.EnablePreimageRecording = ctx.Bool(VMEnableDebugFlag.Name)
 	}
+	if ctx.IsSet(VMTraceFlag.Name) {
+		if name := ctx.String(VMTraceFlag.Name); name != "" {
+			cfg.VMTrace = name
+			cfg.VMTraceJsonConfig = ctx.String(VMTraceJsonConfigFlag.Name)
+		}
+	}
 
 	if ctx.IsSet(RPCGlobalGasCapFlag.Name) {
 		cfg.RPCGasCap = ctx.Uint64(RPCGlobalGasCapFlag.Name)
@@ -1870,7 +2102,17 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 
 		// Create a new developer genesis block or reuse existing one
 		cfg.Genesis = core.DeveloperGenesisBlock(ctx.Uint64(DeveloperGasLimitFlag.Name), developer.Address)
-		if ctx.IsSet(DataDirFlag.Name) {
+		if ctx.IsSet(DeveloperForkFlag.Name) {
+			// The genesis is derived from the remote block instead
+			cfg.Genesis = nil
+			cfg.DevFork = makeDevFork(ctx)
+			if cfg.StateScheme != rawdb.HashScheme {
+				Fatalf("--%s requires the hash state scheme", DeveloperForkFlag.Name)
+			}
+			if !ctx.IsSet(NetworkIdFlag.Name) {
+				cfg.NetworkId = cfg.DevFork.Config().ChainID.Uint64()
+			}
+		} else if ctx.IsSet(DataDirFlag.Name) {
 			chaindb := tryMakeReadOnlyDatabase(ctx, stack)
 			if rawdb.ReadCanonicalHash(chaindb, 0) != (common.Hash{}) {
 				cfg.Genesis = nil // fallback to db content
@@ -1895,6 +2137,20 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	}
 }
 
+// makeDevFork retrieves the remote block to fork the developer chain off.
+func makeDevFork(ctx *cli.Context) *devfork.Fork {
+	var number *big.Int
+	if ctx.IsSet(DeveloperForkBlockFlag.Name) {
+		number = new(big.Int).SetUint64(ctx.Uint64(DeveloperForkBlockFlag.Name))
+	}
+	fork, err := devfork.Dial(context.Background(), ctx.String(DeveloperForkFlag.Name), number)
+	if err != nil {
+		Fatalf("Failed to retrieve block to fork: %v", err)
+	}
+	log.Info("Forking remote chain", "number", fork.Header().Number, "hash", fork.Hash(), "chainid", fork.Config().ChainID)
+	return fork
+}
+
 // SetDNSDiscoveryDefaults configures DNS discovery with the given URL if
 // no URLs are set.
 func SetDNSDiscoveryDefaults(cfg *ethconfig.Config, genesis common.Hash) {
@@ -1965,6 +2221,89 @@ func RegisterFilterAPI(stack *node.Node, backend ethapi.Backend, ethcfg *ethconf
 	return filterSystem
 }
 
+// MakeBeaconChainConfig assembles the beacon chain configuration from the command
+// line flags.
+func MakeBeaconChainConfig(ctx *cli.Context) beacontypes.ChainConfig {
+	var config beacontypes.ChainConfig
+	switch {
+	case ctx.IsSet(BeaconConfigFlag.Name):
+		if !ctx.IsSet(BeaconGenesisRootFlag.Name) || !ctx.IsSet(BeaconGenesisTimeFlag.Name) {
+			Fatalf("Custom beacon chain config requires --%s and --%s", BeaconGenesisRootFlag.Name, BeaconGenesisTimeFlag.Name)
+		}
+		root, err := hexutil.Decode(ctx.String(BeaconGenesisRootFlag.Name))
+		if err != nil || len(root) != common.HashLength {
+			Fatalf("Invalid beacon genesis validators root %q", ctx.String(BeaconGenesisRootFlag.Name))
+		}
+		// The genesis validators root is needed for computing the fork domains,
+		// so it has to be set before loading the forks.
+		config.GenesisValidatorsRoot = common.BytesToHash(root)
+		config.GenesisTime = ctx.Uint64(BeaconGenesisTimeFlag.Name)
+		if err := config.LoadForks(ctx.Path(BeaconConfigFlag.Name)); err != nil {
+			Fatalf("Could not load beacon chain config: %v", err)
+		}
+	case ctx.Bool(SepoliaFlag.Name):
+		config = *light.SepoliaConfig
+	case ctx.Bool(HoleskyFlag.Name):
+		config = *light.HoleskyConfig
+	case ctx.Bool(MainnetFlag.Name) || !IsNetworkPreset(ctx):
+		config = *light.MainnetConfig
+	default:
+		Fatalf("No beacon chain preset for the selected network, use --%s", BeaconConfigFlag.Name)
+	}
+	return config
+}
+
+// MakeBeaconLightConfig assembles the beacon light client configuration from
+// the command line flags.
+func MakeBeaconLightConfig(ctx *cli.Context) light.Config {
+	config := light.Config{ChainConfig: MakeBeaconChainConfig(ctx)}
+	if !ctx.IsSet(BeaconCheckpointFlag.Name) {
+		Fatalf("Beacon light client requires a trusted checkpoint (--%s)", BeaconCheckpointFlag.Name)
+	}
+	checkpoint, err := hexutil.Decode(ctx.String(BeaconCheckpointFlag.Name))
+	if err != nil || len(checkpoint) != common.HashLength {
+		Fatalf("Invalid beacon checkpoint %q", ctx.String(BeaconCheckpointFlag.Name))
+	}
+	config.Checkpoint = common.BytesToHash(checkpoint)
+	config.SignerThreshold = ctx.Int(BeaconThresholdFlag.Name)
+	return config
+}
+
+// RegisterLightSyncer adds the beacon light client syncer service into node.
+func RegisterLightSyncer(ctx *cli.Context, stack *node.Node, eth *eth.Ethereum) {
+	headers := make(map[string]string)
+	for _, header := range ctx.StringSlice(BeaconApiHeaderFlag.Name) {
+		kv := strings.SplitN(header, ":", 2)
+		if len(kv) != 2 {
+			Fatalf("Invalid beacon API header flag %q, expected \"key:value\"", header)
+		}
+		headers[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+	beacon := lightapi.NewBeaconLightApi(ctx.String(BeaconApiFlag.Name), headers)
+	catalyst.RegisterLightSyncer(stack, eth, MakeBeaconLightConfig(ctx), beacon)
+}
+
+// RegisterBuilder adds the block builder service into node.
+func RegisterBuilder(ctx *cli.Context, stack *node.Node, eth *eth.Ethereum) {
+	key, err := hexutil.Decode(ctx.String(BuilderSecretKeyFlag.Name))
+	if err != nil || len(key) != 32 {
+		Fatalf("Invalid builder secret key (--%s)", BuilderSecretKeyFlag.Name)
+	}
+	sk := new(bls.SecretKey)
+	if err := sk.Deserialize((*[32]byte)(key)); err != nil {
+		Fatalf("Invalid builder secret key: %v", err)
+	}
+	config := builder.Config{
+		Relays:    ctx.StringSlice(BuilderRelaysFlag.Name),
+		SecretKey: sk,
+		Beacon:    MakeBeaconChainConfig(ctx),
+		Interval:  ctx.Duration(BuilderIntervalFlag.Name),
+	}
+	if err := builder.Register(stack, eth, config); err != nil {
+		Fatalf("Failed to register the block builder: %v", err)
+	}
+}
+
 // RegisterFullSyncTester adds the full-sync tester service into node.
 func RegisterFullSyncTester(stack *node.Node, eth *eth.Ethereum, path string) {
 	blob, err := os.ReadFile(path)
@@ -1983,6 +2322,25 @@ func RegisterFullSyncTester(stack *node.Node, eth *eth.Ethereum, path string) {
 	log.Info("Registered full-sync tester", "number", block.NumberU64(), "hash", block.Hash())
 }
 
+// SetupTracing enables the export of trace spans if requested. The returned
+// function stops the exporter, flushing the pending spans.
+func SetupTracing(ctx *cli.Context) func() {
+	if !ctx.Bool(TracingEnabledFlag.Name) {
+		return func() {}
+	}
+	endpoint := ctx.String(TracingEndpointFlag.Name)
+	exporter, err := tracing.NewOTLPExporter(endpoint,
+		tracing.String("service.name", "geth"),
+		tracing.String("service.version", params.VersionWithMeta),
+	)
+	if err != nil {
+		Fatalf("Invalid tracing endpoint %q: %v", endpoint, err)
+	}
+	ratio := ctx.Float64(TracingSampleRatioFlag.Name)
+	log.Info("Enabling trace export", "endpoint", endpoint, "ratio", ratio)
+	return tracing.Setup(exporter, ratio)
+}
+
 func SetupMetrics(ctx *cli.Context) {
 	if metrics.Enabled {
 		log.Info("Enabling metrics collection")
@@ -2183,6 +2541,7 @@ func MakeChain(ctx *cli.Context, stack *node.Node, readonly bool) (*core.BlockCh
 		Preimages:           ctx.Bool(CachePreimagesFlag.Name),
 		StateScheme:         scheme,
 		StateHistory:        ctx.Uint64(StateHistoryFlag.Name),
+		StateHistoryIndex:   ctx.Bool(StateHistoryIndexFlag.Name),
 	}
 	if cache.TrieDirtyDisabled && !cache.Preimages {
 		cache.Preimages = true
@@ -2203,6 +2562,19 @@ func MakeChain(ctx *cli.Context, stack *node.Node, readonly bool) (*core.BlockCh
 		cache.TrieDirtyLimit = ctx.Int(CacheFlag.Name) * ctx.Int(CacheGCFlag.Name) / 100
 	}
 	vmcfg := vm.Config{EnablePreimageRecording: ctx.Bool(VMEnableDebugFlag.Name)}
+	if ctx.IsSet(VMTraceFlag.Name) {
+		if name := ctx.String(VMTraceFlag.Name); name != "" {
+			var config json.RawMessage
+			if ctx.IsSet(VMTraceJsonConfigFlag.Name) {
+				config = json.RawMessage(ctx.String(VMTraceJsonConfigFlag.Name))
+			}
+			t, err := tracers.LiveDirectory.New(name, config)
+			if err != nil {
+				Fatalf("Failed to create tracer %q: %v", name, err)
+			}
+			vmcfg.Tracer = t
+		}
+	}
 
 	// Disable transaction indexing/unindexing by default.
 	chain, err := core.NewBlockChain(chainDb, cache, gspec, nil, engine, vmcfg, nil, nil)
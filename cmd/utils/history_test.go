@@ -0,0 +1,230 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package utils
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/internal/era"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+var (
+	count uint64 = 128
+	step  uint64 = 16
+)
+
+func TestHistoryImportAndExport(t *testing.T) {
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		address = crypto.PubkeyToAddress(key.PublicKey)
+		genesis = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc:  core.GenesisAlloc{address: {Balance: big.NewInt(1000000000000000000)}},
+		}
+		signer = types.LatestSigner(genesis.Config)
+	)
+
+	// Generate chain.
+	db, blocks, _ := core.GenerateChainWithGenesis(genesis, ethash.NewFaker(), int(count), func(i int, g *core.BlockGen) {
+		if i == 0 {
+			return
+		}
+		tx, err := types.SignNewTx(key, signer, &types.DynamicFeeTx{
+			ChainID:    genesis.Config.ChainID,
+			Nonce:      uint64(i - 1),
+			GasTipCap:  common.Big0,
+			GasFeeCap:  g.PrevBlock(0).BaseFee(),
+			Gas:        50000,
+			To:         &common.Address{0xaa},
+			Value:      big.NewInt(int64(i)),
+			Data:       nil,
+			AccessList: nil,
+		})
+		if err != nil {
+			t.Fatalf("error creating tx: %v", err)
+		}
+		g.AddTx(tx)
+	})
+
+	// Initialize BlockChain.
+	chain, err := core.NewBlockChain(db, nil, genesis, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("unable to initialize chain: %v", err)
+	}
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("error inserting chain: %v", err)
+	}
+
+	// Make temp directory for era files.
+	dir := t.TempDir()
+
+	// Export history to temp directory.
+	if err := ExportHistory(chain, dir, 0, count, step); err != nil {
+		t.Fatalf("error exporting history: %v", err)
+	}
+
+	// Read the exported archives.
+	entries, err := era.ReadDir(dir, "mainnet")
+	if err != nil {
+		t.Fatalf("error reading era dir: %v", err)
+	}
+	if want := int((count + step) / step); len(entries) != want {
+		t.Fatalf("unexpected number of era files: have %d, want %d", len(entries), want)
+	}
+
+	// Verify each Era.
+	for i, filename := range entries {
+		func() {
+			e, err := era.Open(filepath.Join(dir, filename))
+			if err != nil {
+				t.Fatalf("error opening era file: %v", err)
+			}
+			defer e.Close()
+			var (
+				hashes []common.Hash
+				tds    []*big.Int
+			)
+			it, err := era.NewIterator(e)
+			if err != nil {
+				t.Fatalf("error making era reader: %v", err)
+			}
+			for j := 0; it.Next(); j++ {
+				n := uint64(i)*step + uint64(j)
+				if err := it.Error(); err != nil {
+					t.Fatalf("error reading block entry %d: %v", n, err)
+				}
+				block, receipts, err := it.BlockAndReceipts()
+				if err != nil {
+					t.Fatalf("error reading block entry %d: %v", n, err)
+				}
+				want := chain.GetBlockByNumber(n)
+				if want, got := uint64(j)+uint64(i)*step, block.NumberU64(); want != got {
+					t.Fatalf("blocks out of order: want %d, got %d", want, got)
+				}
+				if want.Hash() != block.Hash() {
+					t.Fatalf("block hash mismatch %d: want %s, got %s", n, want.Hash().Hex(), block.Hash().Hex())
+				}
+				if got := types.DeriveSha(receipts, trie.NewStackTrie(nil)); got != want.ReceiptHash() {
+					t.Fatalf("receipt root %d mismatch: want %s, got %s", n, want.ReceiptHash(), got)
+				}
+				td, err := it.TotalDifficulty()
+				if err != nil {
+					t.Fatalf("error reading td %d: %v", n, err)
+				}
+				if want := chain.GetTd(block.Hash(), n); want.Cmp(td) != 0 {
+					t.Fatalf("td mismatch %d: want %v, got %v", n, want, td)
+				}
+				hashes = append(hashes, block.Hash())
+				tds = append(tds, td)
+			}
+			if err := it.Error(); err != nil {
+				t.Fatalf("iterator error: %v", err)
+			}
+			want, err := e.Accumulator()
+			if err != nil {
+				t.Fatalf("error reading accumulator: %v", err)
+			}
+			if got, err := era.ComputeAccumulator(hashes, tds); err != nil || got != want {
+				t.Fatalf("invalid accumulator: want %s, got %s (err %v)", want, got, err)
+			}
+		}()
+	}
+
+	// Now import Era.
+	freezer := t.TempDir()
+	db2, err := rawdb.NewDatabaseWithFreezer(rawdb.NewMemoryDatabase(), freezer, "", false)
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	t.Cleanup(func() {
+		db2.Close()
+	})
+
+	imported, err := core.NewBlockChain(db2, nil, genesis, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("unable to initialize chain: %v", err)
+	}
+	defer imported.Stop()
+
+	if err := ImportHistory(imported, dir, "mainnet"); err != nil {
+		t.Fatalf("failed to import chain: %v", err)
+	}
+	if have, want := imported.CurrentSnapBlock().Number.Uint64(), count; have != want {
+		t.Fatalf("imported chain does not match expected, have %d, want %d", have, want)
+	}
+	for n := uint64(1); n <= count; n++ {
+		want := chain.GetBlockByNumber(n)
+		have := imported.GetBlockByNumber(n)
+		if have == nil || have.Hash() != want.Hash() {
+			t.Fatalf("imported block %d mismatch", n)
+		}
+		if got := types.DeriveSha(imported.GetReceiptsByHash(want.Hash()), trie.NewStackTrie(nil)); got != want.ReceiptHash() {
+			t.Fatalf("imported receipts %d mismatch", n)
+		}
+	}
+}
+
+func TestHistoryImportTampered(t *testing.T) {
+	var (
+		genesis = &core.Genesis{Config: params.TestChainConfig}
+		dir     = t.TempDir()
+	)
+	db, blocks, _ := core.GenerateChainWithGenesis(genesis, ethash.NewFaker(), 20, nil)
+	chain, err := core.NewBlockChain(db, nil, genesis, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("unable to initialize chain: %v", err)
+	}
+	defer chain.Stop()
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("error inserting chain: %v", err)
+	}
+	if err := ExportHistory(chain, dir, 0, 20, 32); err != nil {
+		t.Fatalf("error exporting history: %v", err)
+	}
+	entries, err := era.ReadDir(dir, "mainnet")
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("unexpected era files: %v, err %v", entries, err)
+	}
+	// Rename the archive, pretending it commits to a different accumulator.
+	if err := os.Rename(filepath.Join(dir, entries[0]), filepath.Join(dir, era.Filename("mainnet", 0, common.Hash{0xff}))); err != nil {
+		t.Fatal(err)
+	}
+	db2 := rawdb.NewMemoryDatabase()
+	imported, err := core.NewBlockChain(db2, nil, genesis, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("unable to initialize chain: %v", err)
+	}
+	defer imported.Stop()
+	if err := ImportHistory(imported, dir, "mainnet"); err == nil {
+		t.Fatal("expected import failure for mismatched accumulator")
+	}
+	if head := imported.CurrentSnapBlock().Number.Uint64(); head != 0 {
+		t.Fatalf("unexpected import of unverified history, head %d", head)
+	}
+}
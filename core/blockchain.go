@@ -18,6 +18,7 @@
 package core
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -42,6 +43,7 @@ import (
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/internal/syncx"
+	"github.com/ethereum/go-ethereum/internal/tracing"
 	"github.com/ethereum/go-ethereum/internal/version"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/metrics"
@@ -142,10 +144,15 @@ type CacheConfig struct {
 	SnapshotLimit       int           // Memory allowance (MB) to use for caching snapshot entries in memory
 	Preimages           bool          // Whether to store preimage of trie key to the disk
 	StateHistory        uint64        // Number of blocks from head whose state histories are reserved.
+	StateHistoryIndex   bool          // Whether to index the state histories for historical state reads
 	StateScheme         string        // Scheme used to store ethereum states and merkle tree nodes on top
 
 	SnapshotNoBuild bool // Whether the background generation is allowed
 	SnapshotWait    bool // Wait for snapshot construction on startup. TODO(karalabe): This is a dirty hack for testing, nuke it
+
+	// StateWrapper optionally wraps the state database of the chain, e.g. to
+	// retrieve state which is missing locally from elsewhere.
+	StateWrapper func(state.Database) state.Database
 }
 
 // triedbConfig derives the configures for trie database.
@@ -158,9 +165,10 @@ func (c *CacheConfig) triedbConfig() *trie.Config {
 	}
 	if c.StateScheme == rawdb.PathScheme {
 		config.PathDB = &pathdb.Config{
-			StateHistory:   c.StateHistory,
-			CleanCacheSize: c.TrieCleanLimit * 1024 * 1024,
-			DirtyCacheSize: c.TrieDirtyLimit * 1024 * 1024,
+			StateHistory:      c.StateHistory,
+			StateHistoryIndex: c.StateHistoryIndex,
+			CleanCacheSize:    c.TrieCleanLimit * 1024 * 1024,
+			DirtyCacheSize:    c.TrieDirtyLimit * 1024 * 1024,
 		}
 	}
 	return config
@@ -258,6 +266,34 @@ type BlockChain struct {
 	processor  Processor // Block transaction processor interface
 	forker     *ForkChoice
 	vmConfig   vm.Config
+	logger     BlockchainLogger // Live tracer receiving the chain processing events
+}
+
+// BlockchainLogger is used to collect traces during chain processing, as opposed
+// to re-executing already imported blocks. Please make a copy of the referenced
+// types if you intend to retain them.
+type BlockchainLogger interface {
+	vm.EVMLogger
+	state.StateLogger
+
+	// OnBlockStart is called before executing `block`. `td` is the total
+	// difficulty prior to `block`.
+	OnBlockStart(block *types.Block, td *big.Int, finalized *types.Header, safe *types.Header)
+
+	// OnBlockEnd is called after executing and validating the block. A non-nil
+	// error means the block was rejected.
+	OnBlockEnd(err error)
+
+	// OnGenesisBlock is called once when the chain is initialised on top of
+	// the genesis block, allowing the tracer to learn the initial allocation.
+	OnGenesisBlock(genesis *types.Block, alloc GenesisAlloc)
+
+	// OnTxStart is called before executing a transaction of the block.
+	OnTxStart(env *vm.EVM, tx *types.Transaction, from common.Address)
+
+	// OnTxEnd is called after a transaction of the block has been executed.
+	// The receipt is nil if the transaction could not be applied.
+	OnTxEnd(receipt *types.Receipt, err error)
 }
 
 // NewBlockChain returns a fully initialised block chain using information
@@ -302,9 +338,18 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, genesis *Genesis
 		engine:        engine,
 		vmConfig:      vmConfig,
 	}
+	// Live tracers are only invoked on block import. Pull them out of the
+	// shared vm config, so that it doesn't leak into the miner or the RPC.
+	if logger, ok := vmConfig.Tracer.(BlockchainLogger); ok {
+		bc.logger = logger
+		bc.vmConfig.Tracer = nil
+	}
 	bc.flushInterval.Store(int64(cacheConfig.TrieTimeLimit))
 	bc.forker = NewForkChoice(bc, shouldPreserve)
 	bc.stateCache = state.NewDatabaseWithNodeDB(bc.db, bc.triedb)
+	if cacheConfig.StateWrapper != nil {
+		bc.stateCache = cacheConfig.StateWrapper(bc.stateCache)
+	}
 	bc.validator = NewBlockValidator(chainConfig, bc, engine)
 	bc.prefetcher = newStatePrefetcher(chainConfig, bc, engine)
 	bc.processor = NewStateProcessor(chainConfig, bc, engine)
@@ -314,9 +359,12 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, genesis *Genesis
 	if err != nil {
 		return nil, err
 	}
-	bc.genesisBlock = bc.GetBlockByNumber(0)
+	genesisHeader := bc.hc.genesisHeader
+	bc.genesisBlock = bc.GetBlock(genesisHeader.Hash(), genesisHeader.Number.Uint64())
 	if bc.genesisBlock == nil {
-		return nil, ErrNoGenesis
+		// The genesis body might be pruned along with the chain history,
+		// it's empty by definition so reconstruct the block from the header.
+		bc.genesisBlock = types.NewBlockWithHeader(genesisHeader)
 	}
 
 	bc.currentBlock.Store(nil)
@@ -337,6 +385,17 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, genesis *Genesis
 	if err := bc.loadLastState(); err != nil {
 		return nil, err
 	}
+	// Let the live tracer know about the initial allocation if the chain is
+	// starting out from the genesis block.
+	if bc.logger != nil && bc.CurrentBlock().Number.Uint64() == 0 {
+		if genesis == nil {
+			genesis, err = ReadGenesis(db)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load genesis state for live tracing: %w", err)
+			}
+		}
+		bc.logger.OnGenesisBlock(bc.genesisBlock, genesis.Alloc)
+	}
 	// Make sure the state associated with the block is available
 	head := bc.CurrentBlock()
 	if !bc.HasState(head.Root) {
@@ -499,6 +558,10 @@ func (bc *BlockChain) loadLastState() error {
 	}
 	// Make sure the entire head block is available
 	headBlock := bc.GetBlockByHash(head)
+	if headBlock == nil && head == bc.genesisBlock.Hash() {
+		// The genesis body might be pruned along with the chain history.
+		headBlock = bc.genesisBlock
+	}
 	if headBlock == nil {
 		// Corrupt or empty database, init from scratch
 		log.Warn("Head block missing, resetting chain", "hash", head)
@@ -1021,7 +1084,7 @@ func (bc *BlockChain) Stop() {
 			triedb := bc.triedb
 
 			for _, offset := range []uint64{0, 1, TriesInMemory - 1} {
-				if number := bc.CurrentBlock().Number.Uint64(); number > offset {
+				if number := bc.CurrentBlock().Number.Uint64(); number > bc.genesisBlock.NumberU64()+offset {
 					recent := bc.GetBlockByNumber(number - offset)
 
 					log.Info("Writing cached state to disk", "block", recent.Number(), "hash", recent.Hash(), "root", recent.Root())
@@ -1400,8 +1463,8 @@ func (bc *BlockChain) writeKnownBlock(block *types.Block) error {
 }
 
 // writeBlockWithState writes block, metadata and corresponding state data to the
-// database.
-func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts []*types.Receipt, state *state.StateDB) error {
+// database. The context is only used to attach trace spans to.
+func (bc *BlockChain) writeBlockWithState(ctx context.Context, block *types.Block, receipts []*types.Receipt, state *state.StateDB) error {
 	// Calculate the total difficulty of the block
 	ptd := bc.GetTd(block.ParentHash(), block.NumberU64()-1)
 	if ptd == nil {
@@ -1423,7 +1486,16 @@ func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts []*types.
 		log.Crit("Failed to write block into disk", "err", err)
 	}
 	// Commit all cached state changes into underlying memory database.
+	_, span := tracing.Start(ctx, "trie.commit")
 	root, err := state.Commit(block.NumberU64(), bc.chainConfig.IsEIP158(block.Number()))
+	span.SetAttributes(
+		tracing.Duration("state.account_commits", state.AccountCommits),
+		tracing.Duration("state.storage_commits", state.StorageCommits),
+		tracing.Duration("state.snapshot_commits", state.SnapshotCommits),
+		tracing.Duration("triedb.commits", state.TrieDBCommits),
+	)
+	span.SetError(err)
+	span.End()
 	if err != nil {
 		return err
 	}
@@ -1495,13 +1567,13 @@ func (bc *BlockChain) WriteBlockAndSetHead(block *types.Block, receipts []*types
 	}
 	defer bc.chainmu.Unlock()
 
-	return bc.writeBlockAndSetHead(block, receipts, logs, state, emitHeadEvent)
+	return bc.writeBlockAndSetHead(context.Background(), block, receipts, logs, state, emitHeadEvent)
 }
 
 // writeBlockAndSetHead is the internal implementation of WriteBlockAndSetHead.
 // This function expects the chain mutex to be held.
-func (bc *BlockChain) writeBlockAndSetHead(block *types.Block, receipts []*types.Receipt, logs []*types.Log, state *state.StateDB, emitHeadEvent bool) (status WriteStatus, err error) {
-	if err := bc.writeBlockWithState(block, receipts, state); err != nil {
+func (bc *BlockChain) writeBlockAndSetHead(ctx context.Context, block *types.Block, receipts []*types.Receipt, logs []*types.Log, state *state.StateDB, emitHeadEvent bool) (status WriteStatus, err error) {
+	if err := bc.writeBlockWithState(ctx, block, receipts, state); err != nil {
 		return NonStatTy, err
 	}
 	currentBlock := bc.CurrentBlock()
@@ -1790,12 +1862,22 @@ func (bc *BlockChain) insertChain(chain types.Blocks, setHead bool) (int, error)
 
 		// Retrieve the parent block and it's state to execute on top
 		start := time.Now()
+		ctx, blockSpan := tracing.Start(context.Background(), "core.insertBlock",
+			tracing.Uint64("block.number", block.NumberU64()),
+			tracing.String("block.hash", block.Hash().Hex()),
+			tracing.Int("block.txs", len(block.Transactions())),
+		)
 		parent := it.previous()
 		if parent == nil {
 			parent = bc.GetHeader(block.ParentHash(), block.NumberU64()-1)
 		}
+		_, span := tracing.Start(ctx, "state.open", tracing.String("state.root", parent.Root.Hex()))
 		statedb, err := state.New(parent.Root, bc.stateCache, bc.snaps)
+		span.SetError(err)
+		span.End()
 		if err != nil {
+			blockSpan.SetError(err)
+			blockSpan.End()
 			return it.index, err
 		}
 
@@ -1821,22 +1903,50 @@ func (bc *BlockChain) insertChain(chain types.Blocks, setHead bool) (int, error)
 			}
 		}
 
+		// Hook up the live tracer, if any, to the processing of this block
+		vmConfig := bc.vmConfig
+		if bc.logger != nil {
+			td := bc.GetTd(block.ParentHash(), block.NumberU64()-1)
+			bc.logger.OnBlockStart(block, td, bc.CurrentFinalBlock(), bc.CurrentSafeBlock())
+
+			statedb.SetLogger(bc.logger)
+			vmConfig.Tracer = bc.logger
+		}
 		// Process block using the parent state as reference point
 		pstart := time.Now()
-		receipts, logs, usedGas, err := bc.processor.Process(block, statedb, bc.vmConfig)
+		_, span = tracing.Start(ctx, "evm.execute")
+		receipts, logs, usedGas, err := bc.processor.Process(block, statedb, vmConfig)
+		span.SetAttributes(
+			tracing.Uint64("evm.gas_used", usedGas),
+			tracing.Duration("state.account_reads", statedb.AccountReads+statedb.SnapshotAccountReads),
+			tracing.Duration("state.storage_reads", statedb.StorageReads+statedb.SnapshotStorageReads),
+		)
+		span.SetError(err)
+		span.End()
 		if err != nil {
+			bc.traceBlockEnd(err)
 			bc.reportBlock(block, receipts, err)
 			followupInterrupt.Store(true)
+			blockSpan.SetError(err)
+			blockSpan.End()
 			return it.index, err
 		}
 		ptime := time.Since(pstart)
 
 		vstart := time.Now()
-		if err := bc.validator.ValidateState(block, statedb, receipts, usedGas); err != nil {
+		_, span = tracing.Start(ctx, "core.validateState")
+		err = bc.validator.ValidateState(block, statedb, receipts, usedGas)
+		span.SetError(err)
+		span.End()
+		if err != nil {
+			bc.traceBlockEnd(err)
 			bc.reportBlock(block, receipts, err)
 			followupInterrupt.Store(true)
+			blockSpan.SetError(err)
+			blockSpan.End()
 			return it.index, err
 		}
+		bc.traceBlockEnd(nil)
 		vtime := time.Since(vstart)
 		proctime := time.Since(start) // processing + validation
 
@@ -1863,11 +1973,13 @@ func (bc *BlockChain) insertChain(chain types.Blocks, setHead bool) (int, error)
 		)
 		if !setHead {
 			// Don't set the head, only insert the block
-			err = bc.writeBlockWithState(block, receipts, statedb)
+			err = bc.writeBlockWithState(ctx, block, receipts, statedb)
 		} else {
-			status, err = bc.writeBlockAndSetHead(block, receipts, logs, statedb, false)
+			status, err = bc.writeBlockAndSetHead(ctx, block, receipts, logs, statedb, false)
 		}
 		followupInterrupt.Store(true)
+		blockSpan.SetError(err)
+		blockSpan.End()
 		if err != nil {
 			return it.index, err
 		}
@@ -1945,6 +2057,14 @@ func (bc *BlockChain) insertChain(chain types.Blocks, setHead bool) (int, error)
 	return it.index, err
 }
 
+// traceBlockEnd notifies the live tracer, if any, that the processing of the
+// current block has finished with the given result.
+func (bc *BlockChain) traceBlockEnd(err error) {
+	if bc.logger != nil {
+		bc.logger.OnBlockEnd(err)
+	}
+}
+
 // insertSideChain is called when an import batch hits upon a pruned ancestor
 // error, which happens when a sidechain with a sufficiently old fork-block is
 // found.
@@ -2449,19 +2569,25 @@ func (bc *BlockChain) indexBlocks(tail *uint64, head uint64, done chan struct{})
 		return
 	}
 
+	// The block bodies below the history cutoff are pruned, they can't
+	// be indexed anymore.
+	cutoff := bc.HistoryPruningCutoff()
+
 	// The tail flag is not existent, it means the node is just initialized
 	// and all blocks(may from ancient store) are not indexed yet.
 	if tail == nil {
-		from := uint64(0)
-		if bc.txLookupLimit != 0 && head >= bc.txLookupLimit {
+		from := cutoff
+		if bc.txLookupLimit != 0 && head >= bc.txLookupLimit && head-bc.txLookupLimit+1 > from {
 			from = head - bc.txLookupLimit + 1
 		}
-		rawdb.IndexTransactions(bc.db, from, head+1, bc.quit)
+		if from <= head {
+			rawdb.IndexTransactions(bc.db, from, head+1, bc.quit)
+		}
 		return
 	}
 	// The tail flag is existent, but the whole chain is required to be indexed.
 	if bc.txLookupLimit == 0 || head < bc.txLookupLimit {
-		if *tail > 0 {
+		if *tail > cutoff {
 			// It can happen when chain is rewound to a historical point which
 			// is even lower than the indexes tail, recap the indexing target
 			// to new head to avoid reading non-existent block bodies.
@@ -2469,14 +2595,21 @@ func (bc *BlockChain) indexBlocks(tail *uint64, head uint64, done chan struct{})
 			if end > head+1 {
 				end = head + 1
 			}
-			rawdb.IndexTransactions(bc.db, 0, end, bc.quit)
+			if end > cutoff {
+				rawdb.IndexTransactions(bc.db, cutoff, end, bc.quit)
+			}
 		}
 		return
 	}
 	// Update the transaction index to the new chain state
-	if head-bc.txLookupLimit+1 < *tail {
+	if from := head - bc.txLookupLimit + 1; from < *tail {
 		// Reindex a part of missing indices and rewind index tail to HEAD-limit
-		rawdb.IndexTransactions(bc.db, head-bc.txLookupLimit+1, *tail, bc.quit)
+		if from < cutoff {
+			from = cutoff
+		}
+		if from < *tail {
+			rawdb.IndexTransactions(bc.db, from, *tail, bc.quit)
+		}
 	} else {
 		// Unindex a part of stale indices and forward index tail to HEAD-limit
 		rawdb.UnindexTransactions(bc.db, *tail, head-bc.txLookupLimit+1, bc.quit)
@@ -329,6 +329,13 @@ func (bc *BlockChain) StateAt(root common.Hash) (*state.StateDB, error) {
 	return state.New(root, bc.stateCache, bc.snaps)
 }
 
+// HistoricState returns a read-only state at a particular point in time, which
+// is no longer maintained by the trie database but can be reconstructed from
+// the indexed state histories. It's only supported by the path-based scheme.
+func (bc *BlockChain) HistoricState(root common.Hash) (*state.StateDB, error) {
+	return state.New(root, state.NewHistoricDatabase(bc.stateCache), nil)
+}
+
 // Config retrieves the chain's fork configuration.
 func (bc *BlockChain) Config() *params.ChainConfig { return bc.chainConfig }
 
@@ -387,6 +394,22 @@ func (bc *BlockChain) TrieDB() *trie.Database {
 	return bc.triedb
 }
 
+// HistoryPruningCutoff returns the number of the first block whose body and
+// receipts are still available, the history below it has been pruned. Chains
+// forked off another network have no history before their genesis.
+func (bc *BlockChain) HistoryPruningCutoff() uint64 {
+	cutoff := bc.genesisBlock.NumberU64()
+	if tail, err := bc.db.Tail(); err == nil && tail > cutoff {
+		cutoff = tail
+	}
+	return cutoff
+}
+
+// HeaderChain returns the underlying header chain.
+func (bc *BlockChain) HeaderChain() *HeaderChain {
+	return bc.hc
+}
+
 // SubscribeRemovedLogsEvent registers a subscription of RemovedLogsEvent.
 func (bc *BlockChain) SubscribeRemovedLogsEvent(ch chan<- RemovedLogsEvent) event.Subscription {
 	return bc.scope.Track(bc.rmLogsFeed.Subscribe(ch))
@@ -22,6 +22,7 @@ import (
 	"math/big"
 	"math/rand"
 	"os"
+	"reflect"
 	"sync"
 	"testing"
 	"time"
@@ -2238,9 +2239,9 @@ func testSideImport(t *testing.T, numCanonBlocksInSidechain, blocksBetweenCommon
 //	[ Cn, Cn+1, Cc, Sn+3 ... Sm]
 //	^    ^    ^  pruned
 func TestPrunedImportSide(t *testing.T) {
-	//glogger := log.NewGlogHandler(log.StreamHandler(os.Stdout, log.TerminalFormat(false)))
-	//glogger.Verbosity(3)
-	//log.Root().SetHandler(log.Handler(glogger))
+	//glogger := log.NewGlogHandler(log.NewTerminalHandler(os.Stdout, false))
+	//glogger.Verbosity(log.LevelInfo)
+	//log.SetDefault(log.NewLogger(glogger))
 	testSideImport(t, 3, 3, -1)
 	testSideImport(t, 3, -3, -1)
 	testSideImport(t, 10, 0, -1)
@@ -2249,9 +2250,9 @@ func TestPrunedImportSide(t *testing.T) {
 }
 
 func TestPrunedImportSideWithMerging(t *testing.T) {
-	//glogger := log.NewGlogHandler(log.StreamHandler(os.Stdout, log.TerminalFormat(false)))
-	//glogger.Verbosity(3)
-	//log.Root().SetHandler(log.Handler(glogger))
+	//glogger := log.NewGlogHandler(log.NewTerminalHandler(os.Stdout, false))
+	//glogger.Verbosity(log.LevelInfo)
+	//log.SetDefault(log.NewLogger(glogger))
 	testSideImport(t, 3, 3, 0)
 	testSideImport(t, 3, -3, 0)
 	testSideImport(t, 10, 0, 0)
@@ -3913,7 +3914,7 @@ func TestSetCanonical(t *testing.T) {
 }
 
 func testSetCanonical(t *testing.T, scheme string) {
-	//log.Root().SetHandler(log.LvlFilterHandler(log.LvlDebug, log.StreamHandler(os.Stderr, log.TerminalFormat(true))))
+	//log.SetDefault(log.NewLogger(log.NewTerminalHandlerWithLevel(os.Stderr, log.LevelDebug, true)))
 
 	var (
 		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
@@ -4716,3 +4717,189 @@ func TestEIP3651(t *testing.T) {
 		t.Fatalf("sender balance incorrect: expected %d, got %d", expected, actual)
 	}
 }
+
+// liveTracer is a BlockchainLogger recording the sequence of chain events.
+type liveTracer struct {
+	*logger.StructLogger
+	events []string
+}
+
+func (t *liveTracer) OnBlockStart(block *types.Block, td *big.Int, finalized *types.Header, safe *types.Header) {
+	t.events = append(t.events, fmt.Sprintf("block start %d", block.NumberU64()))
+}
+
+func (t *liveTracer) OnBlockEnd(err error) {
+	t.events = append(t.events, fmt.Sprintf("block end %v", err))
+}
+
+func (t *liveTracer) OnGenesisBlock(genesis *types.Block, alloc GenesisAlloc) {
+	t.events = append(t.events, fmt.Sprintf("genesis %d", len(alloc)))
+}
+
+func (t *liveTracer) OnTxStart(env *vm.EVM, tx *types.Transaction, from common.Address) {
+	t.events = append(t.events, fmt.Sprintf("tx start %x", from))
+}
+
+func (t *liveTracer) OnTxEnd(receipt *types.Receipt, err error) {
+	t.events = append(t.events, fmt.Sprintf("tx end %d %v", receipt.Status, err))
+}
+
+func (t *liveTracer) OnBalanceChange(addr common.Address, prev, new *big.Int) {
+	t.events = append(t.events, fmt.Sprintf("balance %x", addr))
+}
+
+func (t *liveTracer) OnNonceChange(addr common.Address, prev, new uint64) {
+	t.events = append(t.events, fmt.Sprintf("nonce %x %d->%d", addr, prev, new))
+}
+
+func (t *liveTracer) OnCodeChange(addr common.Address, prevCodeHash common.Hash, prevCode []byte, codeHash common.Hash, code []byte) {
+	t.events = append(t.events, fmt.Sprintf("code %x", addr))
+}
+
+func (t *liveTracer) OnStorageChange(addr common.Address, slot common.Hash, prev, new common.Hash) {
+	t.events = append(t.events, fmt.Sprintf("storage %x %x->%x", addr, prev, new))
+}
+
+func (t *liveTracer) OnLog(log *types.Log) {
+	t.events = append(t.events, fmt.Sprintf("log %x", log.Address))
+}
+
+// Tests that a live tracer attached to the chain receives the block, transaction
+// and state change events of the imported blocks.
+func TestLiveTracing(t *testing.T) {
+	var (
+		aa     = common.HexToAddress("0x000000000000000000000000000000000000aaaa")
+		engine = beacon.NewFaker()
+
+		key, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr   = crypto.PubkeyToAddress(key.PublicKey)
+		funds  = big.NewInt(params.Ether)
+		config = *params.AllEthashProtocolChanges
+		gspec  = &Genesis{
+			Config: &config,
+			Alloc: GenesisAlloc{
+				addr: {Balance: funds},
+				// The address 0xAAAA stores 1 into slot 0 and emits an empty log
+				aa: {
+					Code: []byte{
+						byte(vm.PUSH1), 1,
+						byte(vm.PUSH1), 0,
+						byte(vm.SSTORE),
+						byte(vm.PUSH1), 0,
+						byte(vm.DUP1),
+						byte(vm.LOG0),
+					},
+					Balance: big.NewInt(0),
+				},
+			},
+		}
+	)
+	gspec.Config.TerminalTotalDifficulty = common.Big0
+	gspec.Config.TerminalTotalDifficultyPassed = true
+	gspec.Config.ShanghaiTime = u64(0)
+	signer := types.LatestSigner(gspec.Config)
+
+	_, blocks, _ := GenerateChainWithGenesis(gspec, engine, 1, func(i int, b *BlockGen) {
+		tx, _ := types.SignNewTx(key, signer, &types.DynamicFeeTx{
+			ChainID:   gspec.Config.ChainID,
+			Nonce:     0,
+			To:        &aa,
+			Gas:       100000,
+			GasFeeCap: b.header.BaseFee,
+		})
+		b.AddTx(tx)
+	})
+	tracer := &liveTracer{StructLogger: logger.NewStructLogger(nil)}
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), nil, gspec, nil, engine, vm.Config{Tracer: tracer}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if chain.GetVMConfig().Tracer != nil {
+		t.Fatal("live tracer leaked into the shared vm config")
+	}
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("block %d: failed to insert into chain: %v", n, err)
+	}
+	want := []string{
+		"genesis 2",
+		"block start 1",
+		fmt.Sprintf("tx start %x", addr),
+		fmt.Sprintf("balance %x", addr),
+		fmt.Sprintf("nonce %x 0->1", addr),
+		fmt.Sprintf("storage %x %x->%x", aa, common.Hash{}, common.BigToHash(common.Big1)),
+		fmt.Sprintf("log %x", aa),
+		fmt.Sprintf("balance %x", addr),
+		"tx end 1 <nil>",
+		"block end <nil>",
+	}
+	if !reflect.DeepEqual(tracer.events, want) {
+		t.Fatalf("event mismatch:\nhave: %v\nwant: %v", tracer.events, want)
+	}
+}
+
+// Tests that the states which are no longer maintained by the path-based trie
+// database can still be accessed from the indexed state histories.
+func TestHistoricalStateAccess(t *testing.T) {
+	var (
+		key, _  = crypto.GenerateKey()
+		addr    = crypto.PubkeyToAddress(key.PublicKey)
+		dest    = common.HexToAddress("0xdeadbeef")
+		counter = common.HexToAddress("0x000000000000000000000000000000000000aaaa")
+		gspec   = &Genesis{
+			Config: params.TestChainConfig,
+			Alloc: GenesisAlloc{
+				addr: {Balance: big.NewInt(params.Ether)},
+				// Stores the current block number into slot 0
+				counter: {Code: []byte{byte(vm.NUMBER), byte(vm.PUSH1), 0, byte(vm.SSTORE)}},
+			},
+		}
+		signer = types.LatestSigner(gspec.Config)
+	)
+	_, blocks, _ := GenerateChainWithGenesis(gspec, ethash.NewFaker(), 2*TriesInMemory, func(i int, b *BlockGen) {
+		tx, _ := types.SignNewTx(key, signer, &types.LegacyTx{Nonce: uint64(2 * i), To: &dest, Value: big.NewInt(1), Gas: params.TxGas, GasPrice: b.BaseFee()})
+		b.AddTx(tx)
+		tx, _ = types.SignNewTx(key, signer, &types.LegacyTx{Nonce: uint64(2*i + 1), To: &counter, Gas: 100000, GasPrice: b.BaseFee()})
+		b.AddTx(tx)
+	})
+	db, err := rawdb.NewDatabaseWithFreezer(rawdb.NewMemoryDatabase(), t.TempDir(), "", false)
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	config := DefaultCacheConfigWithScheme(rawdb.PathScheme)
+	config.StateHistoryIndex = true
+	chain, err := NewBlockChain(db, config, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("block %d: failed to insert into chain: %v", n, err)
+	}
+	for _, block := range blocks[:TriesInMemory/2] {
+		if chain.HasState(block.Root()) {
+			t.Fatalf("block %d: state unexpectedly maintained", block.NumberU64())
+		}
+		statedb, err := chain.HistoricState(block.Root())
+		if err != nil {
+			t.Fatalf("block %d: failed to open historical state: %v", block.NumberU64(), err)
+		}
+		number := block.NumberU64()
+		if have, want := statedb.GetBalance(dest), new(big.Int).SetUint64(number); have.Cmp(want) != 0 {
+			t.Errorf("block %d: balance mismatch: have %v, want %v", number, have, want)
+		}
+		if have, want := statedb.GetNonce(addr), 2*number; have != want {
+			t.Errorf("block %d: nonce mismatch: have %d, want %d", number, have, want)
+		}
+		if have, want := statedb.GetState(counter, common.Hash{}), common.BigToHash(block.Number()); have != want {
+			t.Errorf("block %d: storage mismatch: have %x, want %x", number, have, want)
+		}
+		if code := statedb.GetCode(counter); len(code) != 4 {
+			t.Errorf("block %d: code mismatch: have %x", number, code)
+		}
+	}
+}
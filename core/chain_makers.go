@@ -111,6 +111,9 @@ func (b *BlockGen) addTx(bc *BlockChain, vmConfig vm.Config, tx *types.Transacti
 	}
 	b.txs = append(b.txs, tx)
 	b.receipts = append(b.receipts, receipt)
+	if b.header.BlobGasUsed != nil {
+		*b.header.BlobGasUsed += receipt.BlobGasUsed
+	}
 }
 
 // AddTx adds a transaction to the generated block. If no coinbase has
@@ -317,6 +320,11 @@ func GenerateChain(config *params.ChainConfig, parent *types.Block, engine conse
 		if config.DAOForkSupport && config.DAOForkBlock != nil && config.DAOForkBlock.Cmp(b.header.Number) == 0 {
 			misc.ApplyDAOHardFork(statedb)
 		}
+		// Store the beacon root before any transactions, as block processing does.
+		if beaconRoot := b.header.ParentBeaconRoot; beaconRoot != nil {
+			vmenv := vm.NewEVM(NewEVMBlockContext(b.header, nil, &b.header.Coinbase), vm.TxContext{}, statedb, config, vm.Config{})
+			ProcessBeaconBlockRoot(*beaconRoot, vmenv, statedb)
+		}
 		// Execute any user modifications to the block
 		if gen != nil {
 			gen(i, b)
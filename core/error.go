@@ -105,3 +105,16 @@ var (
 	// blob gas fee of the block.
 	ErrBlobFeeCapTooLow = errors.New("max fee per blob gas less than block blob gas fee")
 )
+
+// ErrPrunedHistory is returned if the requested block bodies or receipts are
+// below the history cutoff and have been pruned from the local database.
+var ErrPrunedHistory error = prunedHistoryError{}
+
+// prunedHistoryError is the error type of ErrPrunedHistory. It carries the
+// JSON-RPC error code reported for unavailable history as per EIP-4444.
+type prunedHistoryError struct{}
+
+func (prunedHistoryError) Error() string { return "pruned history unavailable" }
+
+// ErrorCode returns the JSON-RPC error code for pruned history.
+func (prunedHistoryError) ErrorCode() int { return 4444 }
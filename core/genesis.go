@@ -86,11 +86,10 @@ func ReadGenesis(db ethdb.Database) (*Genesis, error) {
 	if genesis.Config == nil {
 		return nil, errors.New("genesis config missing from db")
 	}
-	genesisBlock := rawdb.ReadBlock(db, stored, 0)
-	if genesisBlock == nil {
+	genesisHeader := rawdb.ReadGenesisHeader(db)
+	if genesisHeader == nil {
 		return nil, errors.New("genesis block missing from db")
 	}
-	genesisHeader := genesisBlock.Header()
 	genesis.Nonce = genesisHeader.Nonce.Uint64()
 	genesis.Timestamp = genesisHeader.Time
 	genesis.ExtraData = genesisHeader.Extra
@@ -331,7 +330,7 @@ func SetupGenesisBlockWithOverride(db ethdb.Database, triedb *trie.Database, gen
 	// state database is not initialized yet. It can happen that the node
 	// is initialized with an external ancient store. Commit genesis state
 	// in this case.
-	header := rawdb.ReadHeader(db, stored, 0)
+	header := rawdb.ReadGenesisHeader(db)
 	if header.Root != types.EmptyRootHash && !triedb.Initialized(header.Root) {
 		if genesis == nil {
 			genesis = DefaultGenesisBlock()
@@ -92,7 +92,7 @@ func NewHeaderChain(chainDb ethdb.Database, config *params.ChainConfig, engine c
 		rand:          mrand.New(mrand.NewSource(seed.Int64())),
 		engine:        engine,
 	}
-	hc.genesisHeader = hc.GetHeaderByNumber(0)
+	hc.genesisHeader = rawdb.ReadGenesisHeader(chainDb)
 	if hc.genesisHeader == nil {
 		return nil, ErrNoGenesis
 	}
@@ -576,6 +576,11 @@ func (hc *HeaderChain) setHead(headBlock uint64, headTime uint64, updateFn Updat
 		// startup, so failing hard there is ok.
 		log.Crit("Rejecting genesis rewind via timestamp", "target", headTime, "genesis", hc.genesisHeader.Time)
 	}
+	// Chains forked off another network number their genesis after the forked
+	// block, don't rewind into the headers of the remote chain below it.
+	if headTime == 0 && headBlock < hc.genesisHeader.Number.Uint64() {
+		headBlock = hc.genesisHeader.Number.Uint64()
+	}
 	var (
 		parentHash common.Hash
 		batch      = hc.chainDb.NewBatch()
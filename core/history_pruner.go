@@ -0,0 +1,116 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// HistoryPruneMerge is the history prune point denoting the first block after
+// the transition to proof-of-stake.
+const HistoryPruneMerge = "merge"
+
+// ResolveHistoryPrunePoint resolves the block number below which the chain
+// history is to be pruned. The point is either HistoryPruneMerge or a decimal
+// block number.
+func ResolveHistoryPrunePoint(db ethdb.Reader, point string) (uint64, error) {
+	if point == HistoryPruneMerge {
+		return FindMergeBlock(db)
+	}
+	number, err := strconv.ParseUint(point, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid history prune point %q, want %q or a block number", point, HistoryPruneMerge)
+	}
+	return number, nil
+}
+
+// FindMergeBlock returns the number of the first proof-of-stake block in the
+// canonical chain, found by searching the headers for the first block without
+// difficulty.
+func FindMergeBlock(db ethdb.Reader) (uint64, error) {
+	head := rawdb.ReadHeadHeader(db)
+	if head == nil {
+		return 0, errors.New("head header is not available")
+	}
+	if head.Difficulty.Sign() != 0 {
+		return 0, errors.New("chain has not transitioned to proof-of-stake")
+	}
+	var missing bool
+	number := sort.Search(int(head.Number.Uint64()+1), func(i int) bool {
+		hash := rawdb.ReadCanonicalHash(db, uint64(i))
+		if hash == (common.Hash{}) {
+			missing = true
+			return false
+		}
+		header := rawdb.ReadHeader(db, hash, uint64(i))
+		if header == nil {
+			missing = true
+			return false
+		}
+		return header.Difficulty.Sign() == 0
+	})
+	if missing {
+		return 0, errors.New("canonical headers are missing")
+	}
+	return uint64(number), nil
+}
+
+// PruneHistory discards the block bodies and receipts of the blocks below the
+// given number, along with their transaction indexes. Only the chain segment in
+// the ancient store can be pruned, the target is capped accordingly. The new
+// history cutoff is returned.
+func PruneHistory(db ethdb.Database, target uint64) (uint64, error) {
+	frozen, err := db.Ancients()
+	if err != nil {
+		return 0, err
+	}
+	tail, err := db.Tail()
+	if err != nil {
+		return 0, err
+	}
+	if target > frozen {
+		log.Warn("Capping history prune point to ancient store", "target", target, "frozen", frozen)
+		target = frozen
+	}
+	if target <= tail {
+		log.Info("Chain history already pruned", "cutoff", tail)
+		return tail, nil
+	}
+	// Drop the transaction indexes first, the block bodies are needed to
+	// resolve the transaction hashes. A missing index tail doesn't guarantee
+	// the absence of indexes, unindex the entire range in that case.
+	var from uint64
+	if txTail := rawdb.ReadTxIndexTail(db); txTail != nil {
+		from = *txTail
+	}
+	if from < target {
+		rawdb.UnindexTransactions(db, from, target, nil)
+	}
+	if _, err := db.TruncateTail(target); err != nil {
+		return 0, err
+	}
+	log.Info("Pruned chain history", "cutoff", target)
+	return target, nil
+}
@@ -0,0 +1,145 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the block bodies and receipts below the prune point are discarded
+// from the ancient store, while the headers are retained and the chain can be
+// reopened afterwards.
+func TestPruneHistory(t *testing.T) {
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		address = crypto.PubkeyToAddress(key.PublicKey)
+		gspec   = &Genesis{
+			Config:  params.TestChainConfig,
+			Alloc:   GenesisAlloc{address: {Balance: big.NewInt(params.Ether)}},
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+		signer = types.LatestSigner(gspec.Config)
+	)
+	_, blocks, receipts := GenerateChainWithGenesis(gspec, ethash.NewFaker(), 32, func(i int, block *BlockGen) {
+		tx, err := types.SignTx(types.NewTransaction(block.TxNonce(address), common.Address{0x01}, big.NewInt(1000), params.TxGas, block.header.BaseFee, nil), signer, key)
+		if err != nil {
+			panic(err)
+		}
+		block.AddTx(tx)
+	})
+	db, err := rawdb.NewDatabaseWithFreezer(rawdb.NewMemoryDatabase(), t.TempDir(), "", false)
+	if err != nil {
+		t.Fatalf("failed to create temp freezer db: %v", err)
+	}
+	defer db.Close()
+
+	chain, err := NewBlockChain(db, nil, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	headers := make([]*types.Header, len(blocks))
+	for i, block := range blocks {
+		headers[i] = block.Header()
+	}
+	if n, err := chain.InsertHeaderChain(headers); err != nil {
+		t.Fatalf("failed to insert header %d: %v", n, err)
+	}
+	if n, err := chain.InsertReceiptChain(blocks, receipts, uint64(len(blocks))); err != nil {
+		t.Fatalf("failed to insert receipt %d: %v", n, err)
+	}
+	chain.Stop()
+
+	if cutoff, err := PruneHistory(db, 16); err != nil || cutoff != 16 {
+		t.Fatalf("failed to prune history: cutoff %d, err %v", cutoff, err)
+	}
+	// Pruning again below the cutoff should be a noop, beyond the ancient
+	// store it should be capped.
+	if cutoff, err := PruneHistory(db, 8); err != nil || cutoff != 16 {
+		t.Fatalf("unexpected re-pruning result: cutoff %d, err %v", cutoff, err)
+	}
+	for _, block := range blocks {
+		var (
+			number = block.NumberU64()
+			hash   = block.Hash()
+			pruned = number < 16
+		)
+		if rawdb.ReadHeader(db, hash, number) == nil {
+			t.Fatalf("header %d missing", number)
+		}
+		if have := rawdb.ReadBody(db, hash, number) == nil; have != pruned {
+			t.Fatalf("block %d: body pruned %v, want %v", number, have, pruned)
+		}
+		if have := rawdb.ReadRawReceipts(db, hash, number) == nil; have != pruned {
+			t.Fatalf("block %d: receipts pruned %v, want %v", number, have, pruned)
+		}
+		if have := rawdb.ReadTxLookupEntry(db, block.Transactions()[0].Hash()) == nil; pruned && !have {
+			t.Fatalf("block %d: transaction index not pruned", number)
+		}
+	}
+	// Reopen the chain, the genesis body is pruned as well.
+	chain, err = NewBlockChain(db, nil, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to reopen chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if cutoff := chain.HistoryPruningCutoff(); cutoff != 16 {
+		t.Fatalf("unexpected history cutoff: have %d, want 16", cutoff)
+	}
+	if chain.Genesis().Hash() != chain.GetHeaderByNumber(0).Hash() {
+		t.Fatal("genesis block mismatch")
+	}
+	if chain.GetBlockByNumber(10) != nil {
+		t.Fatal("pruned block is still available")
+	}
+	if chain.GetBlockByNumber(20) == nil {
+		t.Fatal("block above the cutoff is missing")
+	}
+}
+
+func TestResolveHistoryPrunePoint(t *testing.T) {
+	db := rawdb.NewMemoryDatabase()
+	if number, err := ResolveHistoryPrunePoint(db, "1000"); err != nil || number != 1000 {
+		t.Fatalf("unexpected prune point: %d, err %v", number, err)
+	}
+	if _, err := ResolveHistoryPrunePoint(db, "bogus"); err == nil {
+		t.Fatal("expected error for invalid prune point")
+	}
+	// Pre-merge chain, the merge block can't be resolved.
+	gspec := &Genesis{Config: params.TestChainConfig, BaseFee: big.NewInt(params.InitialBaseFee)}
+	db, blocks, _ := GenerateChainWithGenesis(gspec, ethash.NewFaker(), 4, nil)
+	chain, err := NewBlockChain(db, nil, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	if _, err := ResolveHistoryPrunePoint(db, HistoryPruneMerge); err == nil {
+		t.Fatal("expected error for pre-merge chain")
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+const (
+	// logIndexThrottling is the time to wait between processing two consecutive
+	// index sections. It's useful during chain upgrades to prevent disk overload.
+	logIndexThrottling = 100 * time.Millisecond
+)
+
+// logIndexEntry identifies a single log index entry within a section.
+type logIndexEntry struct {
+	position uint8
+	value    common.Hash
+}
+
+// LogIndexer implements a core.ChainIndexer, building up an index which maps the
+// addresses and topics of logs to the numbers of the blocks containing them,
+// permitting fast log filtering without the false positives of bloom filters.
+type LogIndexer struct {
+	size    uint64                     // section size to generate the log index for
+	db      ethdb.Database             // database instance to write index data and metadata into
+	section uint64                     // Section is the section number being processed currently
+	entries map[logIndexEntry][]uint64 // Blocks containing each address and topic of the section
+}
+
+// NewLogIndexer returns a chain indexer that generates the log index for the
+// canonical chain.
+func NewLogIndexer(db ethdb.Database, size, confirms uint64) *ChainIndexer {
+	backend := &LogIndexer{
+		db:   db,
+		size: size,
+	}
+	table := rawdb.NewTable(db, string(rawdb.LogIndexTablePrefix))
+
+	return NewChainIndexer(db, table, backend, size, confirms, logIndexThrottling, "logindex")
+}
+
+// Reset implements core.ChainIndexerBackend, starting a new log index section.
+// Any previously stored index of the section is dropped, unwinding the effects
+// of reorgs.
+func (b *LogIndexer) Reset(ctx context.Context, section uint64, lastSectionHead common.Hash) error {
+	rawdb.DeleteLogIndex(b.db, section, section+1)
+	b.section, b.entries = section, make(map[logIndexEntry][]uint64)
+	return nil
+}
+
+// Process implements core.ChainIndexerBackend, adding the addresses and topics
+// of a new block's logs into the index.
+func (b *LogIndexer) Process(ctx context.Context, header *types.Header) error {
+	if header.Bloom == (types.Bloom{}) {
+		return nil
+	}
+	number, hash := header.Number.Uint64(), header.Hash()
+
+	logs := rawdb.ReadLogs(b.db, hash, number)
+	if logs == nil {
+		// The receipts below the history cutoff are pruned, their logs can't be
+		// retrieved by filters either
+		if tail, err := b.db.Tail(); err == nil && number < tail {
+			return nil
+		}
+		return fmt.Errorf("receipts of block #%d [%x..] not found", number, hash[:4])
+	}
+	for _, txLogs := range logs {
+		for _, log := range txLogs {
+			b.add(rawdb.LogIndexAddress, common.BytesToHash(log.Address.Bytes()), number)
+			for i, topic := range log.Topics {
+				b.add(uint8(i+1), topic, number)
+			}
+		}
+	}
+	return nil
+}
+
+// add records that the given value appears at the given position in a block.
+func (b *LogIndexer) add(position uint8, value common.Hash, number uint64) {
+	key := logIndexEntry{position, value}
+	if blocks := b.entries[key]; len(blocks) > 0 && blocks[len(blocks)-1] == number {
+		return
+	}
+	b.entries[key] = append(b.entries[key], number)
+}
+
+// Commit implements core.ChainIndexerBackend, writing the log index section out
+// into the database.
+func (b *LogIndexer) Commit() error {
+	batch := b.db.NewBatch()
+	for key, blocks := range b.entries {
+		rawdb.WriteLogIndex(batch, b.section, key.position, key.value, blocks)
+		if batch.ValueSize() > ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+	}
+	b.entries = nil
+	return batch.Write()
+}
+
+// Prune implements core.ChainIndexerBackend, deleting the log index of all the
+// sections below the threshold.
+func (b *LogIndexer) Prune(threshold uint64) error {
+	rawdb.DeleteLogIndex(b.db, 0, threshold)
+	return nil
+}
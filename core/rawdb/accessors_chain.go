@@ -965,6 +965,23 @@ func ReadHeadHeader(db ethdb.Reader) *types.Header {
 	return ReadHeader(db, headHeaderHash, *headHeaderNumber)
 }
 
+// ReadGenesisHeader returns the header of the genesis block. The genesis is always
+// the canonical block zero, but chains forked off another network may number it
+// after the forked block.
+func ReadGenesisHeader(db ethdb.Reader) *types.Header {
+	genesisHash := ReadCanonicalHash(db, 0)
+	if genesisHash == (common.Hash{}) {
+		return nil
+	}
+	// Frozen blocks lack the hash to number mapping, but they are numbered from
+	// zero anyway.
+	var genesisNumber uint64
+	if number := ReadHeaderNumber(db, genesisHash); number != nil {
+		genesisNumber = *number
+	}
+	return ReadHeader(db, genesisHash, genesisNumber)
+}
+
 // ReadHeadBlock returns the current canonical head block.
 func ReadHeadBlock(db ethdb.Reader) *types.Block {
 	headBlockHash := ReadHeadBlockHash(db)
@@ -18,6 +18,7 @@ package rawdb
 
 import (
 	"bytes"
+	"encoding/binary"
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -179,3 +180,98 @@ func DeleteBloombits(db ethdb.Database, bit uint, from uint64, to uint64) {
 		log.Crit("Failed to delete bloom bits", "err", it.Error())
 	}
 }
+
+// ReadImpersonatedSender retrieves the account a development chain transaction
+// was sent on behalf of by impersonation.
+func ReadImpersonatedSender(db ethdb.KeyValueReader, hash common.Hash) (common.Address, bool) {
+	data, _ := db.Get(impersonatedSenderKey(hash))
+	if len(data) != common.AddressLength {
+		return common.Address{}, false
+	}
+	return common.BytesToAddress(data), true
+}
+
+// WriteImpersonatedSender stores the account a development chain transaction was
+// sent on behalf of by impersonation.
+func WriteImpersonatedSender(db ethdb.KeyValueWriter, hash common.Hash, sender common.Address) {
+	if err := db.Put(impersonatedSenderKey(hash), sender.Bytes()); err != nil {
+		log.Crit("Failed to store impersonated sender", "err", err)
+	}
+}
+
+// LogIndexAddress is the position under which the log addresses are stored in
+// the log index. Log topics are stored under their index plus one.
+const LogIndexAddress uint8 = 0
+
+// ReadLogIndex retrieves the numbers of the blocks in the given section which
+// contain logs with the given value at the given position, in ascending order.
+// Addresses are stored left-padded to 32 bytes.
+func ReadLogIndex(db ethdb.KeyValueReader, section uint64, position uint8, value common.Hash) []uint64 {
+	data, _ := db.Get(logIndexKey(section, position, value))
+	if len(data) == 0 {
+		return nil
+	}
+	var (
+		blocks []uint64
+		number uint64
+	)
+	for len(data) > 0 {
+		delta, n := binary.Uvarint(data)
+		if n <= 0 {
+			log.Error("Invalid log index entry", "section", section, "position", position, "value", value)
+			return nil
+		}
+		number += delta
+		blocks = append(blocks, number)
+		data = data[n:]
+	}
+	return blocks
+}
+
+// WriteLogIndex stores the numbers of the blocks in the given section which
+// contain logs with the given value at the given position. The block numbers
+// must be in ascending order.
+func WriteLogIndex(db ethdb.KeyValueWriter, section uint64, position uint8, value common.Hash, blocks []uint64) {
+	var (
+		data = make([]byte, 0, len(blocks)*binary.MaxVarintLen16)
+		prev uint64
+	)
+	for _, number := range blocks {
+		data = binary.AppendUvarint(data, number-prev)
+		prev = number
+	}
+	if err := db.Put(logIndexKey(section, position, value), data); err != nil {
+		log.Crit("Failed to store log index", "err", err)
+	}
+}
+
+// DeleteLogIndex removes all log index entries belonging to the given section
+// range.
+func DeleteLogIndex(db ethdb.KeyValueStore, from uint64, to uint64) {
+	start, end := logIndexKey(from, 0, common.Hash{}), logIndexKey(to, 0, common.Hash{})
+	it := db.NewIterator(nil, start)
+	defer it.Release()
+
+	batch := db.NewBatch()
+	for it.Next() {
+		if bytes.Compare(it.Key(), end) >= 0 {
+			break
+		}
+		if len(it.Key()) != len(logIndexPrefix)+9+common.HashLength {
+			continue
+		}
+		batch.Delete(it.Key())
+		if batch.ValueSize() > ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				log.Crit("Failed to delete log index", "err", err)
+			}
+			batch.Reset()
+		}
+	}
+	if it.Error() != nil {
+		log.Crit("Failed to iterate log index", "err", it.Error())
+	}
+	if err := batch.Write(); err != nil {
+		log.Crit("Failed to delete log index", "err", err)
+	}
+}
@@ -154,3 +154,33 @@ func TestDeleteBloomBits(t *testing.T) {
 	check(1, 1, params.MainnetGenesisHash, true)
 	check(1, 1, params.SepoliaGenesisHash, true)
 }
+
+func TestLogIndexStorage(t *testing.T) {
+	db := NewMemoryDatabase()
+	blocks := []uint64{3, 4, 300, 4095}
+	for s := uint64(0); s < 3; s++ {
+		WriteLogIndex(db, s, LogIndexAddress, params.MainnetGenesisHash, blocks)
+		WriteLogIndex(db, s, 1, params.MainnetGenesisHash, blocks[:1])
+	}
+	check := func(section uint64, position uint8, want []uint64) {
+		have := ReadLogIndex(db, section, position, params.MainnetGenesisHash)
+		if len(have) != len(want) {
+			t.Fatalf("section %d position %d: log index mismatch: have %v, want %v", section, position, have, want)
+		}
+		for i := range have {
+			if have[i] != want[i] {
+				t.Fatalf("section %d position %d: log index mismatch: have %v, want %v", section, position, have, want)
+			}
+		}
+	}
+	check(0, LogIndexAddress, blocks)
+	check(0, 1, blocks[:1])
+	check(0, 2, nil)
+
+	// Check the deletion of a section range.
+	DeleteLogIndex(db, 0, 2)
+	check(0, LogIndexAddress, nil)
+	check(1, 1, nil)
+	check(2, LogIndexAddress, blocks)
+	check(2, 1, blocks[:1])
+}
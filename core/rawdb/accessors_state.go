@@ -160,6 +160,155 @@ func DeleteTrieJournal(db ethdb.KeyValueWriter) {
 	}
 }
 
+// ReadStateHistoryIndexHead retrieves the id of the latest indexed state history.
+func ReadStateHistoryIndexHead(db ethdb.KeyValueReader) *uint64 {
+	data, _ := db.Get(stateHistoryIndexHeadKey)
+	if len(data) != 8 {
+		return nil
+	}
+	number := binary.BigEndian.Uint64(data)
+	return &number
+}
+
+// WriteStateHistoryIndexHead stores the id of the latest indexed state history.
+func WriteStateHistoryIndexHead(db ethdb.KeyValueWriter, number uint64) {
+	if err := db.Put(stateHistoryIndexHeadKey, encodeBlockNumber(number)); err != nil {
+		log.Crit("Failed to store the state history index head", "err", err)
+	}
+}
+
+// DeleteStateHistoryIndexHead removes the id of the latest indexed state history.
+func DeleteStateHistoryIndexHead(db ethdb.KeyValueWriter) {
+	if err := db.Delete(stateHistoryIndexHeadKey); err != nil {
+		log.Crit("Failed to delete the state history index head", "err", err)
+	}
+}
+
+// ReadAccountHistoryIndex retrieves the chunk of state history ids in which
+// the specified account was modified.
+func ReadAccountHistoryIndex(db ethdb.KeyValueReader, address common.Address, chunk uint32) []byte {
+	data, _ := db.Get(stateHistoryAccountIndexKey(address, chunk))
+	return data
+}
+
+// WriteAccountHistoryIndex stores the chunk of state history ids in which the
+// specified account was modified.
+func WriteAccountHistoryIndex(db ethdb.KeyValueWriter, address common.Address, chunk uint32, data []byte) {
+	if err := db.Put(stateHistoryAccountIndexKey(address, chunk), data); err != nil {
+		log.Crit("Failed to store account history index", "err", err)
+	}
+}
+
+// DeleteAccountHistoryIndex removes the chunk of state history ids in which
+// the specified account was modified.
+func DeleteAccountHistoryIndex(db ethdb.KeyValueWriter, address common.Address, chunk uint32) {
+	if err := db.Delete(stateHistoryAccountIndexKey(address, chunk)); err != nil {
+		log.Crit("Failed to delete account history index", "err", err)
+	}
+}
+
+// IterateAccountHistoryIndex returns an iterator over the history index chunks
+// of the specified account, starting at the given chunk.
+func IterateAccountHistoryIndex(db ethdb.Iteratee, address common.Address, chunk uint32) ethdb.Iterator {
+	var start [4]byte
+	binary.BigEndian.PutUint32(start[:], chunk)
+	prefix := append(common.CopyBytes(stateHistoryAccountIndexPrefix), address.Bytes()...)
+	return NewKeyLengthIterator(db.NewIterator(prefix, start[:]), len(prefix)+4)
+}
+
+// ReadStorageHistoryIndex retrieves the chunk of state history ids in which
+// the specified storage slot was modified.
+func ReadStorageHistoryIndex(db ethdb.KeyValueReader, address common.Address, slot common.Hash, chunk uint32) []byte {
+	data, _ := db.Get(stateHistoryStorageIndexKey(address, slot, chunk))
+	return data
+}
+
+// WriteStorageHistoryIndex stores the chunk of state history ids in which the
+// specified storage slot was modified.
+func WriteStorageHistoryIndex(db ethdb.KeyValueWriter, address common.Address, slot common.Hash, chunk uint32, data []byte) {
+	if err := db.Put(stateHistoryStorageIndexKey(address, slot, chunk), data); err != nil {
+		log.Crit("Failed to store storage history index", "err", err)
+	}
+}
+
+// DeleteStorageHistoryIndex removes the chunk of state history ids in which
+// the specified storage slot was modified.
+func DeleteStorageHistoryIndex(db ethdb.KeyValueWriter, address common.Address, slot common.Hash, chunk uint32) {
+	if err := db.Delete(stateHistoryStorageIndexKey(address, slot, chunk)); err != nil {
+		log.Crit("Failed to delete storage history index", "err", err)
+	}
+}
+
+// IterateStorageHistoryIndex returns an iterator over the history index chunks
+// of the specified storage slot, starting at the given chunk.
+func IterateStorageHistoryIndex(db ethdb.Iteratee, address common.Address, slot common.Hash, chunk uint32) ethdb.Iterator {
+	var start [4]byte
+	binary.BigEndian.PutUint32(start[:], chunk)
+	prefix := append(common.CopyBytes(stateHistoryStorageIndexPrefix), address.Bytes()...)
+	prefix = append(prefix, slot.Bytes()...)
+	return NewKeyLengthIterator(db.NewIterator(prefix, start[:]), len(prefix)+4)
+}
+
+// ReadWipeHistoryIndex retrieves the chunk of state history ids in which the
+// storage of the specified account was wiped without being fully recorded.
+func ReadWipeHistoryIndex(db ethdb.KeyValueReader, address common.Address, chunk uint32) []byte {
+	data, _ := db.Get(stateHistoryWipeIndexKey(address, chunk))
+	return data
+}
+
+// WriteWipeHistoryIndex stores the chunk of state history ids in which the
+// storage of the specified account was wiped without being fully recorded.
+func WriteWipeHistoryIndex(db ethdb.KeyValueWriter, address common.Address, chunk uint32, data []byte) {
+	if err := db.Put(stateHistoryWipeIndexKey(address, chunk), data); err != nil {
+		log.Crit("Failed to store wipe history index", "err", err)
+	}
+}
+
+// DeleteWipeHistoryIndex removes the chunk of state history ids in which the
+// storage of the specified account was wiped without being fully recorded.
+func DeleteWipeHistoryIndex(db ethdb.KeyValueWriter, address common.Address, chunk uint32) {
+	if err := db.Delete(stateHistoryWipeIndexKey(address, chunk)); err != nil {
+		log.Crit("Failed to delete wipe history index", "err", err)
+	}
+}
+
+// IterateWipeHistoryIndex returns an iterator over the wipe history index
+// chunks of the specified account, starting at the given chunk.
+func IterateWipeHistoryIndex(db ethdb.Iteratee, address common.Address, chunk uint32) ethdb.Iterator {
+	var start [4]byte
+	binary.BigEndian.PutUint32(start[:], chunk)
+	prefix := append(common.CopyBytes(stateHistoryWipeIndexPrefix), address.Bytes()...)
+	return NewKeyLengthIterator(db.NewIterator(prefix, start[:]), len(prefix)+4)
+}
+
+// DeleteStateHistoryIndex wipes all the state history indexes along with the
+// index head from the database.
+func DeleteStateHistoryIndex(db ethdb.KeyValueStore) error {
+	batch := db.NewBatch()
+	for _, prefix := range [][]byte{stateHistoryAccountIndexPrefix, stateHistoryStorageIndexPrefix, stateHistoryWipeIndexPrefix} {
+		it := db.NewIterator(prefix, nil)
+		for it.Next() {
+			if err := batch.Delete(it.Key()); err != nil {
+				it.Release()
+				return err
+			}
+			if batch.ValueSize() >= ethdb.IdealBatchSize {
+				if err := batch.Write(); err != nil {
+					it.Release()
+					return err
+				}
+				batch.Reset()
+			}
+		}
+		it.Release()
+		if err := it.Error(); err != nil {
+			return err
+		}
+	}
+	DeleteStateHistoryIndexHead(batch)
+	return batch.Write()
+}
+
 // ReadStateHistoryMeta retrieves the metadata corresponding to the specified
 // state history. Compute the position of state history in freezer by minus
 // one since the id of first state history starts from one(zero for initial
@@ -295,7 +295,7 @@ func ReadStateScheme(db ethdb.Reader) string {
 	// In a hash-based scheme, the genesis state is consistently stored
 	// on the disk. To assess the scheme of the persistent state, it
 	// suffices to inspect the scheme of the genesis state.
-	header := ReadHeader(db, ReadCanonicalHash(db, 0), 0)
+	header := ReadGenesisHeader(db)
 	if header == nil {
 		return "" // empty datadir
 	}
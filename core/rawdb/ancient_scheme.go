@@ -46,6 +46,15 @@ var chainFreezerNoSnappy = map[string]bool{
 	ChainFreezerDifficultyTable: true,
 }
 
+// chainFreezerRetained lists the ancient-tables which are exempt from tail
+// truncation. Pruning the chain history only discards the block bodies and
+// receipts, the headers, hashes and difficulties are retained.
+var chainFreezerRetained = map[string]bool{
+	ChainFreezerHeaderTable:     true,
+	ChainFreezerHashTable:       true,
+	ChainFreezerDifficultyTable: true,
+}
+
 const (
 	// stateHistoryTableSize defines the maximum size of freezer data files.
 	stateHistoryTableSize = 2 * 1000 * 1000 * 1000
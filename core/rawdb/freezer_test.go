@@ -334,6 +334,69 @@ func TestFreezerConcurrentReadonly(t *testing.T) {
 	}
 }
 
+func TestFreezerRetainedTables(t *testing.T) {
+	var (
+		tables   = map[string]bool{"a": true, "b": true}
+		retained = map[string]bool{"a": true}
+		dir      = t.TempDir()
+	)
+	f, err := newFreezer(dir, "", false, 2049, tables, retained)
+	if err != nil {
+		t.Fatal("can't open freezer", err)
+	}
+	_, err = f.ModifyAncients(func(op ethdb.AncientWriteOp) error {
+		for i := uint64(0); i < 10; i++ {
+			if err := op.AppendRaw("a", i, getChunk(32, int(i))); err != nil {
+				return err
+			}
+			if err := op.AppendRaw("b", i, getChunk(32, int(i))); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	// Truncate the tail, only the non-retained tables should be affected.
+	if _, err := f.TruncateTail(5); err != nil {
+		t.Fatal("truncate tail failed", err)
+	}
+	checkRetained := func(f *Freezer) {
+		t.Helper()
+
+		if tail, _ := f.Tail(); tail != 5 {
+			t.Fatalf("unexpected tail: have %d, want 5", tail)
+		}
+		if _, err := f.Ancient("a", 0); err != nil {
+			t.Fatalf("retained item is missing: %v", err)
+		}
+		if _, err := f.Ancient("b", 4); err == nil {
+			t.Fatal("truncated item is still readable")
+		}
+		if _, err := f.Ancient("b", 5); err != nil {
+			t.Fatalf("item above tail is missing: %v", err)
+		}
+	}
+	checkRetained(f)
+	require.NoError(t, f.Close())
+
+	// Reopen the freezer, the differing tails should be accepted in both
+	// writable and readonly mode.
+	f, err = newFreezer(dir, "", false, 2049, tables, retained)
+	if err != nil {
+		t.Fatal("can't reopen freezer", err)
+	}
+	checkRetained(f)
+	require.NoError(t, f.Close())
+
+	f, err = newFreezer(dir, "", true, 2049, tables, retained)
+	if err != nil {
+		t.Fatal("can't reopen readonly freezer", err)
+	}
+	checkRetained(f)
+	require.NoError(t, f.Close())
+}
+
 func newFreezerForTesting(t *testing.T, tables map[string]bool) (*Freezer, string) {
 	t.Helper()
 
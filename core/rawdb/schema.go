@@ -76,6 +76,9 @@ var (
 	// trieJournalKey tracks the in-memory trie node layers across restarts.
 	trieJournalKey = []byte("TrieJournal")
 
+	// stateHistoryIndexHeadKey tracks the id of the latest indexed state history.
+	stateHistoryIndexHeadKey = []byte("StateHistoryIndexHead")
+
 	// txIndexTailKey tracks the oldest block whose transactions have been indexed.
 	txIndexTailKey = []byte("TransactionIndexTail")
 
@@ -112,6 +115,15 @@ var (
 	trieNodeStoragePrefix = []byte("O") // trieNodeStoragePrefix + accountHash + hexPath -> trie node
 	stateIDPrefix         = []byte("L") // stateIDPrefix + state root -> state id
 
+	// State history indexes of the path-based storage scheme.
+	stateHistoryAccountIndexPrefix = []byte("ma") // stateHistoryAccountIndexPrefix + address + chunk (uint32 big endian) -> state history ids
+	stateHistoryStorageIndexPrefix = []byte("ms") // stateHistoryStorageIndexPrefix + address + slot hash + chunk (uint32 big endian) -> state history ids
+	stateHistoryWipeIndexPrefix    = []byte("mw") // stateHistoryWipeIndexPrefix + address + chunk (uint32 big endian) -> ids of state histories with incomplete storage wipes
+
+	logIndexPrefix = []byte("ml") // logIndexPrefix + section (uint64 big endian) + position (uint8) + value -> block numbers
+
+	impersonatedSenderPrefix = []byte("dev-sender-") // impersonatedSenderPrefix + tx hash -> impersonated sender of a development chain transaction
+
 	PreimagePrefix = []byte("secure-key-")       // PreimagePrefix + hash -> preimage
 	configPrefix   = []byte("ethereum-config-")  // config prefix for the db
 	genesisPrefix  = []byte("ethereum-genesis-") // genesis state prefix for the db
@@ -119,6 +131,9 @@ var (
 	// BloomBitsIndexPrefix is the data table of a chain indexer to track its progress
 	BloomBitsIndexPrefix = []byte("iB")
 
+	// LogIndexTablePrefix is the data table of the log indexer to track its progress
+	LogIndexTablePrefix = []byte("iL")
+
 	ChtPrefix           = []byte("chtRootV2-") // ChtPrefix + chtNum (uint64 big endian) -> trie root hash
 	ChtTablePrefix      = []byte("cht-")
 	ChtIndexTablePrefix = []byte("chtIndexV2-")
@@ -256,6 +271,49 @@ func stateIDKey(root common.Hash) []byte {
 	return append(stateIDPrefix, root.Bytes()...)
 }
 
+// stateHistoryAccountIndexKey = stateHistoryAccountIndexPrefix + address + chunk (uint32 big endian)
+func stateHistoryAccountIndexKey(address common.Address, chunk uint32) []byte {
+	buf := make([]byte, len(stateHistoryAccountIndexPrefix)+common.AddressLength+4)
+	n := copy(buf, stateHistoryAccountIndexPrefix)
+	n += copy(buf[n:], address.Bytes())
+	binary.BigEndian.PutUint32(buf[n:], chunk)
+	return buf
+}
+
+// stateHistoryWipeIndexKey = stateHistoryWipeIndexPrefix + address + chunk (uint32 big endian)
+func stateHistoryWipeIndexKey(address common.Address, chunk uint32) []byte {
+	buf := make([]byte, len(stateHistoryWipeIndexPrefix)+common.AddressLength+4)
+	n := copy(buf, stateHistoryWipeIndexPrefix)
+	n += copy(buf[n:], address.Bytes())
+	binary.BigEndian.PutUint32(buf[n:], chunk)
+	return buf
+}
+
+// stateHistoryStorageIndexKey = stateHistoryStorageIndexPrefix + address + slot hash + chunk (uint32 big endian)
+func stateHistoryStorageIndexKey(address common.Address, slot common.Hash, chunk uint32) []byte {
+	buf := make([]byte, len(stateHistoryStorageIndexPrefix)+common.AddressLength+common.HashLength+4)
+	n := copy(buf, stateHistoryStorageIndexPrefix)
+	n += copy(buf[n:], address.Bytes())
+	n += copy(buf[n:], slot.Bytes())
+	binary.BigEndian.PutUint32(buf[n:], chunk)
+	return buf
+}
+
+// logIndexKey = logIndexPrefix + section (uint64 big endian) + position (uint8) + value
+func logIndexKey(section uint64, position uint8, value common.Hash) []byte {
+	buf := make([]byte, len(logIndexPrefix)+8+1+common.HashLength)
+	n := copy(buf, logIndexPrefix)
+	binary.BigEndian.PutUint64(buf[n:], section)
+	buf[n+8] = position
+	copy(buf[n+9:], value.Bytes())
+	return buf
+}
+
+// impersonatedSenderKey = impersonatedSenderPrefix + hash
+func impersonatedSenderKey(hash common.Hash) []byte {
+	return append(impersonatedSenderPrefix, hash.Bytes()...)
+}
+
 // accountTrieNodeKey = trieNodeAccountPrefix + nodePath.
 func accountTrieNodeKey(path []byte) []byte {
 	return append(trieNodeAccountPrefix, path...)
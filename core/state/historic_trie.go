@@ -0,0 +1,156 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/ethereum/go-ethereum/trie/triedb/pathdb"
+	"github.com/ethereum/go-ethereum/trie/trienode"
+)
+
+// errHistoricTrieReadOnly is returned if a historical state is attempted to
+// be mutated or proven.
+var errHistoricTrieReadOnly = errors.New("historical state is read-only")
+
+// historicDB is a state database serving historical states, which are no longer
+// maintained as tries, from the state histories of the path-based trie database.
+type historicDB struct {
+	Database
+}
+
+// NewHistoricDatabase wraps the given state database to open the tries of the
+// historical states from the state histories. The returned tries are read-only.
+func NewHistoricDatabase(db Database) Database {
+	return &historicDB{Database: db}
+}
+
+// OpenTrie opens the historical account trie with the given state root.
+func (db *historicDB) OpenTrie(root common.Hash) (Trie, error) {
+	reader, err := db.TrieDB().HistoricReader(root)
+	if err != nil {
+		return nil, err
+	}
+	return newHistoricTrie(reader, root), nil
+}
+
+// OpenStorageTrie opens the historical storage trie of an account.
+func (db *historicDB) OpenStorageTrie(stateRoot common.Hash, address common.Address, root common.Hash) (Trie, error) {
+	reader, err := db.TrieDB().HistoricReader(stateRoot)
+	if err != nil {
+		return nil, err
+	}
+	return newHistoricTrie(reader, root), nil
+}
+
+// CopyTrie returns the given trie, historic tries are immutable.
+func (db *historicDB) CopyTrie(t Trie) Trie {
+	if t, ok := t.(*historicTrie); ok {
+		return t
+	}
+	return db.Database.CopyTrie(t)
+}
+
+// historicTrie is a read-only Trie implementation serving the accounts and
+// storage slots of a historical state from the path-based state histories.
+// The same trie is used for both the account and the storage tries, as the
+// values are looked up by address.
+type historicTrie struct {
+	reader *pathdb.HistoricalStateReader
+	root   common.Hash // Root of the trie, account or storage one
+}
+
+// newHistoricTrie constructs a historic trie with the given root.
+func newHistoricTrie(reader *pathdb.HistoricalStateReader, root common.Hash) *historicTrie {
+	return &historicTrie{reader: reader, root: root}
+}
+
+// GetKey returns nil, preimages are not tracked.
+func (t *historicTrie) GetKey([]byte) []byte {
+	return nil
+}
+
+// GetStorage returns the value for key stored in the storage of the account.
+func (t *historicTrie) GetStorage(addr common.Address, key []byte) ([]byte, error) {
+	enc, err := t.reader.Storage(addr, crypto.Keccak256Hash(key))
+	if err != nil || len(enc) == 0 {
+		return nil, err
+	}
+	_, content, _, err := rlp.Split(enc)
+	return content, err
+}
+
+// GetAccount retrieves the account with the given address, nil is returned
+// if the account was not present.
+func (t *historicTrie) GetAccount(address common.Address) (*types.StateAccount, error) {
+	blob, err := t.reader.Account(address)
+	if err != nil || len(blob) == 0 {
+		return nil, err
+	}
+	return types.FullAccount(blob)
+}
+
+// UpdateStorage implements Trie, historical states are read-only.
+func (t *historicTrie) UpdateStorage(addr common.Address, key, value []byte) error {
+	return errHistoricTrieReadOnly
+}
+
+// UpdateAccount implements Trie, historical states are read-only.
+func (t *historicTrie) UpdateAccount(address common.Address, account *types.StateAccount) error {
+	return errHistoricTrieReadOnly
+}
+
+// UpdateContractCode implements Trie, historical states are read-only.
+func (t *historicTrie) UpdateContractCode(address common.Address, codeHash common.Hash, code []byte) error {
+	return errHistoricTrieReadOnly
+}
+
+// DeleteStorage implements Trie, historical states are read-only.
+func (t *historicTrie) DeleteStorage(addr common.Address, key []byte) error {
+	return errHistoricTrieReadOnly
+}
+
+// DeleteAccount implements Trie, historical states are read-only.
+func (t *historicTrie) DeleteAccount(address common.Address) error {
+	return errHistoricTrieReadOnly
+}
+
+// Hash returns the root hash of the trie.
+func (t *historicTrie) Hash() common.Hash {
+	return t.root
+}
+
+// Commit implements Trie, historical states are read-only.
+func (t *historicTrie) Commit(collectLeaf bool) (common.Hash, *trienode.NodeSet, error) {
+	return common.Hash{}, nil, errHistoricTrieReadOnly
+}
+
+// NodeIterator implements Trie, historical states have no trie nodes.
+func (t *historicTrie) NodeIterator(startKey []byte) (trie.NodeIterator, error) {
+	return nil, errHistoricTrieReadOnly
+}
+
+// Prove implements Trie, historical states have no trie nodes.
+func (t *historicTrie) Prove(key []byte, proofDb ethdb.KeyValueWriter) error {
+	return errHistoricTrieReadOnly
+}
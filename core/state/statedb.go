@@ -42,6 +42,17 @@ const (
 	storageDeleteLimit = 512 * 1024 * 1024
 )
 
+// StateLogger is used to collect state update traces from EVM transaction
+// execution. The hooks are invoked as the mutations are applied, before the
+// changes are finalised, and are not re-invoked if a change is reverted.
+type StateLogger interface {
+	OnBalanceChange(addr common.Address, prev, new *big.Int)
+	OnNonceChange(addr common.Address, prev, new uint64)
+	OnCodeChange(addr common.Address, prevCodeHash common.Hash, prevCode []byte, codeHash common.Hash, code []byte)
+	OnStorageChange(addr common.Address, slot common.Hash, prev, new common.Hash)
+	OnLog(log *types.Log)
+}
+
 type revision struct {
 	id           int
 	journalIndex int
@@ -138,6 +149,8 @@ type StateDB struct {
 
 	// Testing hooks
 	onCommit func(states *triestate.Set) // Hook invoked when commit is performed
+
+	logger StateLogger // Optional hooks for state mutation tracing
 }
 
 // New creates a new state from a given trie.
@@ -206,6 +219,11 @@ func (s *StateDB) Error() error {
 	return s.dbErr
 }
 
+// SetLogger sets the logger which is notified about every state mutation.
+func (s *StateDB) SetLogger(l StateLogger) {
+	s.logger = l
+}
+
 func (s *StateDB) AddLog(log *types.Log) {
 	s.journal.append(addLogChange{txhash: s.thash})
 
@@ -214,6 +232,10 @@ func (s *StateDB) AddLog(log *types.Log) {
 	log.Index = s.logSize
 	s.logs[s.thash] = append(s.logs[s.thash], log)
 	s.logSize++
+
+	if s.logger != nil {
+		s.logger.OnLog(log)
+	}
 }
 
 // GetLogs returns the logs matching the specified transaction hash, and annotates
@@ -452,6 +474,9 @@ func (s *StateDB) SelfDestruct(addr common.Address) {
 		prev:        stateObject.selfDestructed,
 		prevbalance: new(big.Int).Set(stateObject.Balance()),
 	})
+	if s.logger != nil && stateObject.Balance().Sign() > 0 {
+		s.logger.OnBalanceChange(addr, stateObject.Balance(), new(big.Int))
+	}
 	stateObject.markSelfdestructed()
 	stateObject.data.Balance = new(big.Int)
 }
@@ -956,6 +956,32 @@ func (p *BlobPool) reinject(addr common.Address, txhash common.Hash) {
 	p.stored += uint64(meta.size)
 }
 
+// Clear implements txpool.SubPool, removing all tracked transactions from the
+// pool and releasing the reserved accounts.
+func (p *BlobPool) Clear() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for _, id := range p.lookup {
+		if err := p.store.Delete(id); err != nil {
+			log.Warn("Failed to delete blob transaction", "id", id, "err", err)
+		}
+	}
+	for addr := range p.index {
+		p.reserve(addr, false)
+	}
+	p.lookup = make(map[common.Hash]uint64)
+	p.index = make(map[common.Address][]*blobTxMeta)
+	p.spent = make(map[common.Address]*uint256.Int)
+	p.stored = 0
+
+	// The eviction heap tracks the index by reference, only drop the accounts
+	p.evict.addrs = nil
+	p.evict.index = make(map[common.Address]int)
+
+	p.updateStorageMetrics()
+}
+
 // SetGasTip implements txpool.SubPool, allowing the blob pool's gas requirements
 // to be kept in sync with the main transacion pool's gas requirements.
 func (p *BlobPool) SetGasTip(tip *big.Int) {
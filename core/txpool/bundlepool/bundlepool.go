@@ -0,0 +1,396 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package bundlepool implements a transaction subpool for private bundles.
+package bundlepool
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// txMaxSize is the maximum size a single transaction of a bundle can have, the
+// same limit the legacy pool enforces for regular transactions.
+const txMaxSize = 128 * 1024
+
+var (
+	// ErrEmptyBundle is returned if a bundle contains no transactions.
+	ErrEmptyBundle = errors.New("empty bundle")
+
+	// ErrBundleTooLarge is returned if a bundle contains more transactions than
+	// permitted by the pool.
+	ErrBundleTooLarge = errors.New("bundle too large")
+
+	// ErrStaleBundle is returned if a bundle targets a block which is already
+	// part of the chain.
+	ErrStaleBundle = errors.New("bundle targets past block")
+
+	// ErrFutureBundle is returned if a bundle targets a block too far ahead of
+	// the head of the chain.
+	ErrFutureBundle = errors.New("bundle targets block too far in the future")
+
+	// ErrInvalidTimestamps is returned if the timestamp range of a bundle is
+	// empty.
+	ErrInvalidTimestamps = errors.New("invalid bundle timestamp range")
+
+	// errDirectAdd is returned if transactions are added individually, instead
+	// of as part of a bundle.
+	errDirectAdd = errors.New("bundle pool only accepts bundles")
+)
+
+var (
+	bundlesGauge  = metrics.NewRegisteredGauge("bundlepool/bundles", nil)
+	evictionMeter = metrics.NewRegisteredMeter("bundlepool/evicted", nil)
+)
+
+// BlockChain defines the minimal set of methods needed to back a bundle pool
+// with a chain. Exists to allow mocking the live chain out of tests.
+type BlockChain interface {
+	// Config retrieves the chain's fork configuration.
+	Config() *params.ChainConfig
+
+	// CurrentBlock returns the current head of the chain.
+	CurrentBlock() *types.Header
+
+	// StateAt returns a state database for a given root hash (generally the head).
+	StateAt(root common.Hash) (*state.StateDB, error)
+}
+
+// pooledBundle is a bundle tracked by the pool, along with its price.
+type pooledBundle struct {
+	bundle *txpool.Bundle
+	price  *big.Int // Average effective tip per gas at admission
+}
+
+// BundlePool is a subpool holding atomic transaction bundles for the local block
+// producer.
+//
+// The transactions of bundles are private: they are invisible to the lookup
+// methods of the subpool interface and never announced to the transaction
+// subscribers, so they are not gossiped to the network. Nonces and balances are
+// only checked against the head state, bundles are fully validated by simulating
+// them during block production.
+type BundlePool struct {
+	config Config
+	chain  BlockChain
+	signer types.Signer
+
+	head    *types.Header            // Current head of the chain
+	bundles []*pooledBundle          // Bundles in arrival order
+	known   map[common.Hash]struct{} // Hashes of the tracked bundles
+	lock    sync.RWMutex             // Mutex protecting the pool fields
+}
+
+// New creates a new bundle pool. The pool is not operational until Init is
+// called by the main transaction pool.
+func New(config Config, chain BlockChain) *BundlePool {
+	return &BundlePool{
+		config: config.sanitize(),
+		chain:  chain,
+		signer: types.LatestSigner(chain.Config()),
+		known:  make(map[common.Hash]struct{}),
+	}
+}
+
+// Filter implements txpool.SubPool. Bundles can only be added via AddBundle, so
+// no transactions are accepted from the main pool.
+func (p *BundlePool) Filter(tx *types.Transaction) bool {
+	return false
+}
+
+// Init implements txpool.SubPool. Bundles don't reserve the accounts of their
+// senders, since they are included atomically ahead of the regular transactions.
+func (p *BundlePool) Init(gasTip *big.Int, head *types.Header, reserve txpool.AddressReserver) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.head = head
+	return nil
+}
+
+// Close implements txpool.SubPool, dropping all bundles.
+func (p *BundlePool) Close() error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.bundles = nil
+	p.known = make(map[common.Hash]struct{})
+	bundlesGauge.Update(0)
+	return nil
+}
+
+// Reset implements txpool.SubPool, dropping all bundles which targeted a block
+// at or below the new head.
+func (p *BundlePool) Reset(oldHead, newHead *types.Header) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.head = newHead
+
+	bundles := p.bundles[:0]
+	for _, pooled := range p.bundles {
+		if pooled.bundle.BlockNumber > newHead.Number.Uint64() {
+			bundles = append(bundles, pooled)
+		} else {
+			delete(p.known, pooled.bundle.Hash())
+		}
+	}
+	for i := len(bundles); i < len(p.bundles); i++ {
+		p.bundles[i] = nil
+	}
+	if dropped := len(p.bundles) - len(bundles); dropped > 0 {
+		log.Debug("Dropped stale bundles", "count", dropped, "head", newHead.Number)
+	}
+	p.bundles = bundles
+	bundlesGauge.Update(int64(len(p.bundles)))
+}
+
+// SetGasTip implements txpool.SubPool. Bundles usually pay the block producer
+// directly, so the minimum tip is not enforced.
+func (p *BundlePool) SetGasTip(tip *big.Int) {}
+
+// Clear implements txpool.SubPool, dropping all bundles.
+func (p *BundlePool) Clear() {
+	p.Close()
+}
+
+// Has implements txpool.SubPool. Bundled transactions are private, so it always
+// returns false.
+func (p *BundlePool) Has(hash common.Hash) bool {
+	return false
+}
+
+// Get implements txpool.SubPool. Bundled transactions are private, so it always
+// returns nil.
+func (p *BundlePool) Get(hash common.Hash) *types.Transaction {
+	return nil
+}
+
+// Add implements txpool.SubPool. Individual transactions are rejected, bundles
+// must be added via AddBundle.
+func (p *BundlePool) Add(txs []*types.Transaction, local bool, sync bool) []error {
+	errs := make([]error, len(txs))
+	for i := range txs {
+		errs[i] = errDirectAdd
+	}
+	return errs
+}
+
+// Pending implements txpool.SubPool. Bundles are retrieved separately via
+// Bundles, so no pending transactions are reported.
+func (p *BundlePool) Pending(enforceTips bool) map[common.Address][]*txpool.LazyTransaction {
+	return nil
+}
+
+// SubscribeTransactions implements txpool.SubPool. Bundled transactions are
+// never announced, so the subscription doesn't deliver any events.
+func (p *BundlePool) SubscribeTransactions(ch chan<- core.NewTxsEvent) event.Subscription {
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		<-quit
+		return nil
+	})
+}
+
+// Nonce implements txpool.SubPool. Bundles don't affect the pool nonces, so the
+// zero nonce is returned, deferring to the other subpools.
+func (p *BundlePool) Nonce(addr common.Address) uint64 {
+	return 0
+}
+
+// Stats implements txpool.SubPool. Bundled transactions are not counted as
+// pending or queued.
+func (p *BundlePool) Stats() (int, int) {
+	return 0, 0
+}
+
+// Content implements txpool.SubPool. Bundled transactions are private, so no
+// content is reported.
+func (p *BundlePool) Content() (map[common.Address][]*types.Transaction, map[common.Address][]*types.Transaction) {
+	return make(map[common.Address][]*types.Transaction), make(map[common.Address][]*types.Transaction)
+}
+
+// ContentFrom implements txpool.SubPool. Bundled transactions are private, so no
+// content is reported.
+func (p *BundlePool) ContentFrom(addr common.Address) ([]*types.Transaction, []*types.Transaction) {
+	return []*types.Transaction{}, []*types.Transaction{}
+}
+
+// Locals implements txpool.SubPool. The bundle pool has no local accounts.
+func (p *BundlePool) Locals() []common.Address {
+	return []common.Address{}
+}
+
+// Status implements txpool.SubPool. Bundled transactions are private, so their
+// status is unknown.
+func (p *BundlePool) Status(hash common.Hash) txpool.TxStatus {
+	return txpool.TxStatusUnknown
+}
+
+// AddBundle implements txpool.BundlePool, validating the bundle and adding it to
+// the pool. If the pool is full, the cheapest bundle is evicted to make room, or
+// the new one is rejected if it doesn't pay more.
+func (p *BundlePool) AddBundle(bundle *txpool.Bundle) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if err := p.validateBundle(bundle); err != nil {
+		return err
+	}
+	hash := bundle.Hash()
+	if _, ok := p.known[hash]; ok {
+		return txpool.ErrAlreadyKnown
+	}
+	price := bundlePrice(bundle, p.head.BaseFee)
+	if len(p.bundles) >= p.config.MaxBundles {
+		cheapest := 0
+		for i, pooled := range p.bundles {
+			if pooled.price.Cmp(p.bundles[cheapest].price) < 0 {
+				cheapest = i
+			}
+		}
+		evicted := p.bundles[cheapest]
+		if price.Cmp(evicted.price) <= 0 {
+			return fmt.Errorf("%w: bundle price %v, pool minimum %v", txpool.ErrUnderpriced, price, evicted.price)
+		}
+		delete(p.known, evicted.bundle.Hash())
+		copy(p.bundles[cheapest:], p.bundles[cheapest+1:])
+		p.bundles[len(p.bundles)-1] = nil
+		p.bundles = p.bundles[:len(p.bundles)-1]
+		evictionMeter.Mark(1)
+		log.Debug("Evicted cheapest bundle", "hash", evicted.bundle.Hash(), "block", evicted.bundle.BlockNumber, "price", evicted.price)
+	}
+	p.bundles = append(p.bundles, &pooledBundle{bundle: bundle, price: price})
+	p.known[hash] = struct{}{}
+	bundlesGauge.Update(int64(len(p.bundles)))
+
+	log.Debug("Added bundle", "hash", hash, "txs", len(bundle.Txs), "block", bundle.BlockNumber)
+	return nil
+}
+
+// validateBundle checks whether a bundle is acceptable for the pool. The
+// contained transactions are checked against the current head and its state.
+// Balances are not tracked across the bundle, so every sender needs to be able
+// to pay for its transactions upfront.
+func (p *BundlePool) validateBundle(bundle *txpool.Bundle) error {
+	if len(bundle.Txs) == 0 {
+		return ErrEmptyBundle
+	}
+	if len(bundle.Txs) > p.config.MaxBundleTxs {
+		return fmt.Errorf("%w: %d transactions, limit %d", ErrBundleTooLarge, len(bundle.Txs), p.config.MaxBundleTxs)
+	}
+	if bundle.BlockNumber <= p.head.Number.Uint64() {
+		return fmt.Errorf("%w: block %d, head %d", ErrStaleBundle, bundle.BlockNumber, p.head.Number)
+	}
+	if bundle.BlockNumber > p.head.Number.Uint64()+p.config.MaxFutureBlocks {
+		return fmt.Errorf("%w: block %d, head %d", ErrFutureBundle, bundle.BlockNumber, p.head.Number)
+	}
+	if bundle.MaxTimestamp != 0 && bundle.MaxTimestamp < bundle.MinTimestamp {
+		return fmt.Errorf("%w: min %d, max %d", ErrInvalidTimestamps, bundle.MinTimestamp, bundle.MaxTimestamp)
+	}
+	opts := &txpool.ValidationOptions{
+		Config: p.chain.Config(),
+		Accept: 0 |
+			1<<types.LegacyTxType |
+			1<<types.AccessListTxType |
+			1<<types.DynamicFeeTxType,
+		MaxSize: txMaxSize,
+		MinTip:  new(big.Int),
+	}
+	for i, tx := range bundle.Txs {
+		if err := txpool.ValidateTransaction(tx, p.head, p.signer, opts); err != nil {
+			return fmt.Errorf("transaction %d (%x): %w", i, tx.Hash(), err)
+		}
+	}
+	// Reject bundles which can never be executed on top of the head state,
+	// because a nonce is already used or a sender can't afford its transaction.
+	statedb, err := p.chain.StateAt(p.head.Root)
+	if err != nil {
+		return err
+	}
+	nonces := make(map[common.Address]uint64)
+	for i, tx := range bundle.Txs {
+		from, _ := types.Sender(p.signer, tx) // already validated above
+		next, ok := nonces[from]
+		if !ok {
+			next = statedb.GetNonce(from)
+		}
+		if tx.Nonce() < next {
+			return fmt.Errorf("transaction %d (%x): %w: next nonce %v, tx nonce %v", i, tx.Hash(), core.ErrNonceTooLow, next, tx.Nonce())
+		}
+		nonces[from] = tx.Nonce() + 1
+
+		if balance, cost := statedb.GetBalance(from), tx.Cost(); balance.Cmp(cost) < 0 {
+			return fmt.Errorf("transaction %d (%x): %w: balance %v, tx cost %v", i, tx.Hash(), core.ErrInsufficientFunds, balance, cost)
+		}
+	}
+	return nil
+}
+
+// bundlePrice calculates the average effective tip per gas the transactions of
+// a bundle pay at the given base fee. Direct payments to the fee recipient are
+// only known after simulation, so they are not accounted for.
+func bundlePrice(bundle *txpool.Bundle, baseFee *big.Int) *big.Int {
+	var (
+		fees = new(big.Int)
+		gas  uint64
+	)
+	for _, tx := range bundle.Txs {
+		if tip := tx.EffectiveGasTipValue(baseFee); tip.Sign() > 0 {
+			fees.Add(fees, tip.Mul(tip, new(big.Int).SetUint64(tx.Gas())))
+		}
+		gas += tx.Gas()
+	}
+	if gas == 0 {
+		return fees
+	}
+	return fees.Div(fees, new(big.Int).SetUint64(gas))
+}
+
+// Bundles implements txpool.BundlePool, retrieving the bundles eligible for
+// inclusion in a block with the given number and timestamp, the best paying
+// ones first.
+func (p *BundlePool) Bundles(number uint64, time uint64) []*txpool.Bundle {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	var eligible []*pooledBundle
+	for _, pooled := range p.bundles {
+		if pooled.bundle.Valid(number, time) {
+			eligible = append(eligible, pooled)
+		}
+	}
+	sort.SliceStable(eligible, func(i, j int) bool {
+		return eligible[i].price.Cmp(eligible[j].price) > 0
+	})
+	bundles := make([]*txpool.Bundle, len(eligible))
+	for i, pooled := range eligible {
+		bundles[i] = pooled.bundle
+	}
+	return bundles
+}
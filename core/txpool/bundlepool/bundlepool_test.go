@@ -0,0 +1,274 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package bundlepool
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+type testBlockChain struct {
+	head    *types.Header
+	heads   event.Feed
+	statedb *state.StateDB
+}
+
+func (bc *testBlockChain) Config() *params.ChainConfig {
+	return params.TestChainConfig
+}
+
+func (bc *testBlockChain) CurrentBlock() *types.Header {
+	return bc.head
+}
+
+func (bc *testBlockChain) StateAt(common.Hash) (*state.StateDB, error) {
+	return bc.statedb, nil
+}
+
+func (bc *testBlockChain) SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription {
+	return bc.heads.Subscribe(ch)
+}
+
+// newTestChain creates a chain with the given head number, funding the accounts
+// of the given keys.
+func newTestChain(number int64, funded ...*ecdsa.PrivateKey) *testBlockChain {
+	statedb, _ := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	for _, key := range funded {
+		statedb.AddBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(params.Ether))
+	}
+	return &testBlockChain{
+		head: &types.Header{
+			Number:   big.NewInt(number),
+			GasLimit: 30_000_000,
+			BaseFee:  big.NewInt(params.InitialBaseFee),
+		},
+		statedb: statedb,
+	}
+}
+
+func bundleTx(nonce uint64, key *ecdsa.PrivateKey) *types.Transaction {
+	return pricedBundleTx(nonce, 0, key)
+}
+
+func pricedBundleTx(nonce uint64, tip int64, key *ecdsa.PrivateKey) *types.Transaction {
+	return types.MustSignNewTx(key, types.LatestSigner(params.TestChainConfig), &types.DynamicFeeTx{
+		ChainID:   params.TestChainConfig.ChainID,
+		Nonce:     nonce,
+		GasTipCap: big.NewInt(tip),
+		GasFeeCap: big.NewInt(params.InitialBaseFee + tip),
+		Gas:       params.TxGas,
+		To:        &common.Address{0x01},
+		Value:     big.NewInt(1),
+	})
+}
+
+func newTestPool(t *testing.T, chain *testBlockChain, config Config) *BundlePool {
+	pool := New(config, chain)
+	if err := pool.Init(big.NewInt(1), chain.head, nil); err != nil {
+		t.Fatalf("failed to init pool: %v", err)
+	}
+	return pool
+}
+
+func TestAddBundle(t *testing.T) {
+	var (
+		key, _   = crypto.GenerateKey()
+		used, _  = crypto.GenerateKey()
+		broke, _ = crypto.GenerateKey()
+		chain    = newTestChain(10, key, used)
+	)
+	chain.statedb.SetNonce(crypto.PubkeyToAddress(used.PublicKey), 1)
+
+	pool := newTestPool(t, chain, Config{MaxBundles: 2, MaxBundleTxs: 2})
+	defer pool.Close()
+
+	var (
+		blobTx   = types.NewTx(&types.BlobTx{Gas: params.TxGas})
+		unsigned = types.NewTx(&types.DynamicFeeTx{ChainID: params.TestChainConfig.ChainID, Gas: params.TxGas})
+	)
+
+	tests := []struct {
+		bundle *txpool.Bundle
+		err    error
+	}{
+		{&txpool.Bundle{BlockNumber: 11}, ErrEmptyBundle},
+		{&txpool.Bundle{Txs: types.Transactions{bundleTx(0, key), bundleTx(1, key), bundleTx(2, key)}, BlockNumber: 11}, ErrBundleTooLarge},
+		{&txpool.Bundle{Txs: types.Transactions{bundleTx(0, key)}, BlockNumber: 10}, ErrStaleBundle},
+		{&txpool.Bundle{Txs: types.Transactions{bundleTx(0, key)}, BlockNumber: 10 + DefaultConfig.MaxFutureBlocks + 1}, ErrFutureBundle},
+		{&txpool.Bundle{Txs: types.Transactions{bundleTx(0, key)}, BlockNumber: 1 << 63}, ErrFutureBundle},
+		{&txpool.Bundle{Txs: types.Transactions{bundleTx(0, key)}, BlockNumber: 11, MinTimestamp: 20, MaxTimestamp: 10}, ErrInvalidTimestamps},
+		{&txpool.Bundle{Txs: types.Transactions{blobTx}, BlockNumber: 11}, core.ErrTxTypeNotSupported},
+		{&txpool.Bundle{Txs: types.Transactions{unsigned}, BlockNumber: 11}, txpool.ErrInvalidSender},
+		{&txpool.Bundle{Txs: types.Transactions{bundleTx(0, used)}, BlockNumber: 11}, core.ErrNonceTooLow},
+		{&txpool.Bundle{Txs: types.Transactions{bundleTx(0, key), bundleTx(0, key)}, BlockNumber: 11}, core.ErrNonceTooLow},
+		{&txpool.Bundle{Txs: types.Transactions{bundleTx(0, broke)}, BlockNumber: 11}, core.ErrInsufficientFunds},
+		{&txpool.Bundle{Txs: types.Transactions{bundleTx(0, key)}, BlockNumber: 11}, nil},
+		{&txpool.Bundle{Txs: types.Transactions{bundleTx(0, key)}, BlockNumber: 11}, txpool.ErrAlreadyKnown},
+		{&txpool.Bundle{Txs: types.Transactions{bundleTx(0, key), bundleTx(1, key)}, BlockNumber: 12}, nil},
+	}
+	for i, test := range tests {
+		if err := pool.AddBundle(test.bundle); !errors.Is(err, test.err) {
+			t.Errorf("test %d: wrong error: have %v, want %v", i, err, test.err)
+		}
+	}
+}
+
+// Tests that a full pool evicts its cheapest bundles to make room for better
+// paying ones, and rejects bundles which don't pay more.
+func TestBundleEviction(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+
+	chain := newTestChain(10, key)
+	pool := newTestPool(t, chain, Config{MaxBundles: 4, MaxBundleTxs: 1, MaxFutureBlocks: 2})
+	defer pool.Close()
+
+	// Bundles targeting far future blocks are rejected, so they can't linger.
+	for i := uint64(0); i < 8; i++ {
+		bundle := &txpool.Bundle{Txs: types.Transactions{bundleTx(i, key)}, BlockNumber: 13 + i<<56}
+		if err := pool.AddBundle(bundle); !errors.Is(err, ErrFutureBundle) {
+			t.Fatalf("far future bundle %d: wrong error: have %v, want %v", i, err, ErrFutureBundle)
+		}
+	}
+	var bundles []*txpool.Bundle
+	for i := uint64(0); i < 6; i++ {
+		bundles = append(bundles, &txpool.Bundle{Txs: types.Transactions{pricedBundleTx(i, int64(i), key)}, BlockNumber: 12})
+	}
+	// Fill the pool with mid priced bundles, cheaper ones are rejected once full.
+	for i := 1; i < 5; i++ {
+		if err := pool.AddBundle(bundles[i]); err != nil {
+			t.Fatalf("bundle %d rejected: %v", i, err)
+		}
+	}
+	if err := pool.AddBundle(bundles[0]); !errors.Is(err, txpool.ErrUnderpriced) {
+		t.Fatalf("underpriced bundle: wrong error: have %v, want %v", err, txpool.ErrUnderpriced)
+	}
+	// A better paying bundle evicts the cheapest one.
+	if err := pool.AddBundle(bundles[5]); err != nil {
+		t.Fatalf("overpriced bundle rejected: %v", err)
+	}
+	have := pool.Bundles(12, 0)
+	if len(have) != 4 {
+		t.Fatalf("wrong number of bundles: have %d, want 4", len(have))
+	}
+	for i, bundle := range have {
+		if bundle.Hash() != bundles[5-i].Hash() {
+			t.Errorf("bundle %d: not the best paying ones retained in price order", i)
+		}
+	}
+	// Evicted bundles can't return unless they outbid the pooled ones.
+	if err := pool.AddBundle(bundles[1]); !errors.Is(err, txpool.ErrUnderpriced) {
+		t.Fatalf("evicted bundle: wrong error: have %v, want %v", err, txpool.ErrUnderpriced)
+	}
+}
+
+func TestBundleSelection(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+
+	chain := newTestChain(10, key)
+	pool := newTestPool(t, chain, DefaultConfig)
+	defer pool.Close()
+
+	var (
+		a = &txpool.Bundle{Txs: types.Transactions{bundleTx(0, key)}, BlockNumber: 11}
+		b = &txpool.Bundle{Txs: types.Transactions{bundleTx(1, key)}, BlockNumber: 11, MinTimestamp: 100, MaxTimestamp: 200}
+		c = &txpool.Bundle{Txs: types.Transactions{bundleTx(2, key)}, BlockNumber: 12}
+	)
+	for _, bundle := range []*txpool.Bundle{a, b, c} {
+		if err := pool.AddBundle(bundle); err != nil {
+			t.Fatalf("failed to add bundle: %v", err)
+		}
+	}
+	check := func(number, time uint64, want ...*txpool.Bundle) {
+		t.Helper()
+
+		have := pool.Bundles(number, time)
+		if len(have) != len(want) {
+			t.Fatalf("block %d, time %d: wrong number of bundles: have %d, want %d", number, time, len(have), len(want))
+		}
+		for i := range want {
+			if have[i].Hash() != want[i].Hash() {
+				t.Errorf("block %d, time %d: bundle %d mismatch", number, time, i)
+			}
+		}
+	}
+	check(11, 50, a)
+	check(11, 150, a, b)
+	check(11, 250, a)
+	check(12, 150, c)
+
+	// Bundles for included blocks are dropped on reset.
+	newHead := newTestChain(11).head
+	pool.Reset(chain.head, newHead)
+	check(11, 150)
+	check(12, 150, c)
+
+	if err := pool.AddBundle(a); !errors.Is(err, ErrStaleBundle) {
+		t.Fatalf("stale bundle accepted: %v", err)
+	}
+}
+
+// Tests that bundled transactions are invisible through the main transaction
+// pool, so they are neither served nor announced to peers.
+func TestBundlesPrivate(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+
+	chain := newTestChain(10, key)
+	pool, err := txpool.New(big.NewInt(1), chain, []txpool.SubPool{New(DefaultConfig, chain)})
+	if err != nil {
+		t.Fatalf("failed to create pool: %v", err)
+	}
+	defer pool.Close()
+
+	txs := make(chan core.NewTxsEvent, 1)
+	sub := pool.SubscribeNewTxsEvent(txs)
+	defer sub.Unsubscribe()
+
+	tx := bundleTx(0, key)
+	if err := pool.AddBundle(&txpool.Bundle{Txs: types.Transactions{tx}, BlockNumber: 11}); err != nil {
+		t.Fatalf("failed to add bundle: %v", err)
+	}
+	if pool.Has(tx.Hash()) || pool.Get(tx.Hash()) != nil || pool.Status(tx.Hash()) != txpool.TxStatusUnknown {
+		t.Fatal("bundled transaction visible in pool")
+	}
+	if pending := pool.Pending(false); len(pending) != 0 {
+		t.Fatalf("bundled transaction pending: %v", pending)
+	}
+	if errs := pool.Add([]*types.Transaction{bundleTx(1, key)}, false, true); errs[0] == nil {
+		t.Fatal("regular transaction accepted by bundle pool")
+	}
+	select {
+	case ev := <-txs:
+		t.Fatalf("bundled transaction announced: %v", ev.Txs)
+	case <-time.After(100 * time.Millisecond):
+	}
+	if bundles := pool.Bundles(11, 0); len(bundles) != 1 {
+		t.Fatalf("wrong number of bundles: have %d, want 1", len(bundles))
+	}
+}
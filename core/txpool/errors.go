@@ -54,4 +54,8 @@ var (
 	// ErrFutureReplacePending is returned if a future transaction replaces a pending
 	// transaction. Future transactions should only be able to replace other future transactions.
 	ErrFutureReplacePending = errors.New("future transaction tries to replace pending")
+
+	// ErrBundlesUnsupported is returned if a bundle is submitted to a pool not
+	// configured with a bundle subpool.
+	ErrBundlesUnsupported = errors.New("bundles not supported")
 )
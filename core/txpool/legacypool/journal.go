@@ -21,6 +21,7 @@ import (
 	"io"
 	"io/fs"
 	"os"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -179,3 +180,151 @@ func (journal *journal) close() error {
 	}
 	return err
 }
+
+// remoteJournalEntry is a transaction stored in the remote journal, along with
+// the time it was first seen by the pool.
+type remoteJournalEntry struct {
+	Time uint64 // Unix timestamp the transaction was first seen at
+	Tx   *types.Transaction
+}
+
+// remoteJournal is a periodically regenerated snapshot of the remote transactions
+// of the pool, allowing the pool to be refilled after a node restart instead of
+// waiting for the network to gossip the transactions again.
+//
+// Unlike the local journal, the remote one is capped both in size and in age of
+// the contained transactions, and it is never appended to. Reloaded transactions
+// keep the time they were first seen before the restart, so the age cap holds
+// across any number of restarts.
+type remoteJournal struct {
+	path    string                    // Filesystem path to store the transactions at
+	maxSize uint64                    // Maximum total size of the journaled transactions
+	maxAge  time.Duration             // Maximum time since a journaled transaction was first seen
+	seen    map[common.Hash]time.Time // First-seen times of the journaled transactions
+}
+
+// newRemoteJournal creates a new remote transaction journal.
+func newRemoteJournal(path string, maxSize uint64, maxAge time.Duration) *remoteJournal {
+	return &remoteJournal{
+		path:    path,
+		maxSize: maxSize,
+		maxAge:  maxAge,
+		seen:    make(map[common.Hash]time.Time),
+	}
+}
+
+// load parses a remote transaction journal dump from disk, loading the contents
+// which are not yet too old into the specified pool. A corrupt or truncated
+// journal is loaded up to the first invalid entry.
+func (journal *remoteJournal) load(add func([]*types.Transaction) []error) error {
+	input, err := os.Open(journal.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+
+	var (
+		stream = rlp.NewStream(input, 0)
+		now    = time.Now()
+
+		total, stale, dropped int
+		failure               error
+		batch                 types.Transactions
+	)
+	loadBatch := func(txs types.Transactions) {
+		for _, err := range add(txs) {
+			if err != nil {
+				log.Trace("Failed to add journaled remote transaction", "err", err)
+				dropped++
+			}
+		}
+	}
+	for {
+		entry := new(remoteJournalEntry)
+		if err = stream.Decode(entry); err != nil {
+			if err != io.EOF {
+				failure = err
+			}
+			break
+		}
+		total++
+
+		seen := time.Unix(int64(entry.Time), 0)
+		if now.Sub(seen) > journal.maxAge {
+			stale++
+			continue
+		}
+		journal.seen[entry.Tx.Hash()] = seen
+		if batch = append(batch, entry.Tx); batch.Len() > 1024 {
+			loadBatch(batch)
+			batch = batch[:0]
+		}
+	}
+	if batch.Len() > 0 {
+		loadBatch(batch)
+	}
+	log.Info("Loaded remote transaction journal", "transactions", total, "stale", stale, "dropped", dropped)
+
+	return failure
+}
+
+// rotate regenerates the remote transaction journal from the given transactions,
+// grouped by account and sorted by nonce, with the more important groups first
+// (see LegacyPool.remotes).
+// Transactions first seen longer ago than the age cap are skipped. Once the size cap is reached,
+// the remaining transactions of the account and all lower priority accounts are
+// omitted.
+func (journal *remoteJournal) rotate(all []types.Transactions) error {
+	replacement, err := os.OpenFile(journal.path+".new", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	var (
+		now       = time.Now()
+		seen      = make(map[common.Hash]time.Time)
+		size      uint64
+		journaled int
+	)
+	write := func() error {
+		for _, txs := range all {
+			for _, tx := range txs {
+				// Transactions reloaded from the journal were first seen before
+				// they were added to the pool.
+				first := tx.Time()
+				if t, ok := journal.seen[tx.Hash()]; ok && t.Before(first) {
+					first = t
+				}
+				if now.Sub(first) > journal.maxAge {
+					continue
+				}
+				if size+tx.Size() > journal.maxSize {
+					return nil
+				}
+				size += tx.Size()
+				entry := &remoteJournalEntry{Time: uint64(first.Unix()), Tx: tx}
+				if err := rlp.Encode(replacement, entry); err != nil {
+					return err
+				}
+				seen[tx.Hash()] = first
+				journaled++
+			}
+		}
+		return nil
+	}
+	if err := write(); err != nil {
+		replacement.Close()
+		os.Remove(journal.path + ".new")
+		return err
+	}
+	replacement.Close()
+
+	if err = os.Rename(journal.path+".new", journal.path); err != nil {
+		return err
+	}
+	journal.seen = seen
+	log.Debug("Regenerated remote transaction journal", "transactions", journaled, "size", common.StorageSize(size))
+	return nil
+}
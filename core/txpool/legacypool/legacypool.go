@@ -127,7 +127,12 @@ type Config struct {
 	Locals    []common.Address // Addresses that should be treated by default as local
 	NoLocals  bool             // Whether local transaction handling should be disabled
 	Journal   string           // Journal of local transactions to survive node restarts
-	Rejournal time.Duration    // Time interval to regenerate the local transaction journal
+	Rejournal time.Duration    // Time interval to regenerate the transaction journals
+
+	JournalRemotes    bool          // Whether remote transactions should be journaled too
+	RemoteJournal     string        // Journal of remote transactions to survive node restarts
+	RemoteJournalSize uint64        // Maximum size of the remote transaction journal in bytes
+	RemoteJournalAge  time.Duration // Maximum age of the remote transactions to journal
 
 	PriceLimit uint64 // Minimum gas price to enforce for acceptance into the pool
 	PriceBump  uint64 // Minimum price bump percentage to replace an already existing transaction (nonce)
@@ -145,6 +150,10 @@ var DefaultConfig = Config{
 	Journal:   "transactions.rlp",
 	Rejournal: time.Hour,
 
+	RemoteJournal:     "remotetransactions.rlp",
+	RemoteJournalSize: 64 * 1024 * 1024,
+	RemoteJournalAge:  3 * time.Hour,
+
 	PriceLimit: 1,
 	PriceBump:  10,
 
@@ -164,6 +173,14 @@ func (config *Config) sanitize() Config {
 		log.Warn("Sanitizing invalid txpool journal time", "provided", conf.Rejournal, "updated", time.Second)
 		conf.Rejournal = time.Second
 	}
+	if conf.JournalRemotes && conf.RemoteJournalSize < 1 {
+		log.Warn("Sanitizing invalid txpool remote journal size", "provided", conf.RemoteJournalSize, "updated", DefaultConfig.RemoteJournalSize)
+		conf.RemoteJournalSize = DefaultConfig.RemoteJournalSize
+	}
+	if conf.JournalRemotes && conf.RemoteJournalAge < 1 {
+		log.Warn("Sanitizing invalid txpool remote journal age", "provided", conf.RemoteJournalAge, "updated", DefaultConfig.RemoteJournalAge)
+		conf.RemoteJournalAge = DefaultConfig.RemoteJournalAge
+	}
 	if conf.PriceLimit < 1 {
 		log.Warn("Sanitizing invalid txpool price limit", "provided", conf.PriceLimit, "updated", DefaultConfig.PriceLimit)
 		conf.PriceLimit = DefaultConfig.PriceLimit
@@ -216,8 +233,9 @@ type LegacyPool struct {
 	currentState  *state.StateDB               // Current state in the blockchain head
 	pendingNonces *noncer                      // Pending state tracking virtual nonces
 
-	locals  *accountSet // Set of local transaction to exempt from eviction rules
-	journal *journal    // Journal of local transaction to back up to disk
+	locals        *accountSet    // Set of local transaction to exempt from eviction rules
+	journal       *journal       // Journal of local transaction to back up to disk
+	remoteJournal *remoteJournal // Journal of remote transactions to back up to disk
 
 	reserve txpool.AddressReserver       // Address reserver to ensure exclusivity across subpools
 	pending map[common.Address]*list     // All currently processable transactions
@@ -274,6 +292,9 @@ func New(config Config, chain BlockChain) *LegacyPool {
 	if !config.NoLocals && config.Journal != "" {
 		pool.journal = newTxJournal(config.Journal)
 	}
+	if config.JournalRemotes && config.RemoteJournal != "" {
+		pool.remoteJournal = newRemoteJournal(config.RemoteJournal, config.RemoteJournalSize, config.RemoteJournalAge)
+	}
 	return pool
 }
 
@@ -314,6 +335,16 @@ func (pool *LegacyPool) Init(gasTip *big.Int, head *types.Header, reserve txpool
 			log.Warn("Failed to rotate transaction journal", "err", err)
 		}
 	}
+	// If remote journaling is enabled, refill the pool with the transactions
+	// known before the restart, subjecting them to the usual validation
+	if pool.remoteJournal != nil {
+		add := func(txs []*types.Transaction) []error {
+			return pool.Add(txs, false, true)
+		}
+		if err := pool.remoteJournal.load(add); err != nil {
+			log.Warn("Failed to load remote transaction journal", "err", err)
+		}
+	}
 	pool.wg.Add(1)
 	go pool.loop()
 	return nil
@@ -385,6 +416,7 @@ func (pool *LegacyPool) loop() {
 				}
 				pool.mu.Unlock()
 			}
+			pool.rotateRemoteJournal()
 		}
 	}
 }
@@ -401,6 +433,7 @@ func (pool *LegacyPool) Close() error {
 	if pool.journal != nil {
 		pool.journal.close()
 	}
+	pool.rotateRemoteJournal()
 	log.Info("Transaction pool stopped")
 	return nil
 }
@@ -439,6 +472,34 @@ func (pool *LegacyPool) SetGasTip(tip *big.Int) {
 	log.Info("Legacy pool tip threshold updated", "tip", tip)
 }
 
+// Clear implements txpool.SubPool, removing all tracked transactions from the
+// pool and releasing the reserved accounts.
+func (pool *LegacyPool) Clear() {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	for addr := range pool.pending {
+		pool.reserve(addr, false)
+	}
+	for addr := range pool.queue {
+		if _, ok := pool.pending[addr]; !ok {
+			pool.reserve(addr, false)
+		}
+	}
+	pool.all = newLookup()
+	pool.priced = newPricedList(pool.all)
+	pool.pending = make(map[common.Address]*list)
+	pool.queue = make(map[common.Address]*list)
+	pool.beats = make(map[common.Address]time.Time)
+	pool.pendingNonces = newNoncer(pool.currentState)
+
+	if pool.journal != nil {
+		if err := pool.journal.rotate(pool.local()); err != nil {
+			log.Warn("Failed to rotate local tx journal", "err", err)
+		}
+	}
+}
+
 // Nonce returns the next nonce of an account, with all transactions executable
 // by the pool already applied on top.
 func (pool *LegacyPool) Nonce(addr common.Address) uint64 {
@@ -571,6 +632,46 @@ func (pool *LegacyPool) local() map[common.Address]types.Transactions {
 	return txs
 }
 
+// remotes retrieves all currently known remote transactions, grouped by origin
+// account and sorted by nonce. The pending transactions precede the queued ones,
+// and within both the groups are sorted by the effective tip of their first
+// transaction, highest first. The returned transaction set is a copy and can be
+// freely modified by calling code.
+func (pool *LegacyPool) remotes() []types.Transactions {
+	var pending, queued []types.Transactions
+	for addr, list := range pool.pending {
+		if !pool.locals.contains(addr) && !list.Empty() {
+			pending = append(pending, list.Flatten())
+		}
+	}
+	for addr, list := range pool.queue {
+		if !pool.locals.contains(addr) && !list.Empty() {
+			queued = append(queued, list.Flatten())
+		}
+	}
+	baseFee := pool.priced.urgent.baseFee
+	for _, groups := range [][]types.Transactions{pending, queued} {
+		sort.SliceStable(groups, func(i, j int) bool {
+			return groups[i][0].EffectiveGasTipCmp(groups[j][0], baseFee) > 0
+		})
+	}
+	return append(pending, queued...)
+}
+
+// rotateRemoteJournal regenerates the remote transaction journal, if enabled.
+func (pool *LegacyPool) rotateRemoteJournal() {
+	if pool.remoteJournal == nil {
+		return
+	}
+	pool.mu.RLock()
+	remotes := pool.remotes()
+	pool.mu.RUnlock()
+
+	if err := pool.remoteJournal.rotate(remotes); err != nil {
+		log.Warn("Failed to rotate remote tx journal", "err", err)
+	}
+}
+
 // validateTxBasics checks whether a transaction is valid according to the consensus
 // rules, but does not check state-dependent validation such as sufficient balance.
 // This check is meant as an early check which only needs to be performed once,
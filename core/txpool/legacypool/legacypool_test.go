@@ -24,6 +24,7 @@ import (
 	"math/big"
 	"math/rand"
 	"os"
+	"path/filepath"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -38,6 +39,7 @@ import (
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/trie"
 )
 
@@ -2415,6 +2417,238 @@ func testJournaling(t *testing.T, nolocals bool) {
 	pool.Close()
 }
 
+// Tests that remote transactions are journaled if enabled, and reloaded through
+// the usual validation on restart.
+func TestRemoteJournaling(t *testing.T) {
+	t.Parallel()
+
+	journal := filepath.Join(t.TempDir(), "remotes.rlp")
+
+	statedb, _ := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := newTestBlockChain(params.TestChainConfig, 1000000, statedb, new(event.Feed))
+
+	config := testTxPoolConfig
+	config.JournalRemotes = true
+	config.RemoteJournal = journal
+
+	restart := func(pool *LegacyPool) *LegacyPool {
+		if pool != nil {
+			pool.Close()
+		}
+		pool = New(config, blockchain)
+		pool.Init(new(big.Int).SetUint64(config.PriceLimit), blockchain.CurrentBlock(), makeAddressReserver())
+		return pool
+	}
+	pool := restart(nil)
+
+	local, _ := crypto.GenerateKey()
+	remote, _ := crypto.GenerateKey()
+	testAddBalance(pool, crypto.PubkeyToAddress(local.PublicKey), big.NewInt(1000000000))
+	testAddBalance(pool, crypto.PubkeyToAddress(remote.PublicKey), big.NewInt(1000000000))
+
+	// Add pending and queued remote transactions, along with a local one which
+	// is not part of the remote journal
+	if err := pool.addLocal(pricedTransaction(0, 100000, big.NewInt(1), local)); err != nil {
+		t.Fatalf("failed to add local transaction: %v", err)
+	}
+	for _, nonce := range []uint64{0, 1, 3} {
+		if err := pool.addRemoteSync(pricedTransaction(nonce, 100000, big.NewInt(1), remote)); err != nil {
+			t.Fatalf("failed to add remote transaction %d: %v", nonce, err)
+		}
+	}
+	pool = restart(pool)
+	if pending, queued := pool.Stats(); pending != 2 || queued != 1 {
+		t.Fatalf("wrong pool content after restart: pending %d, queued %d, want 2, 1", pending, queued)
+	}
+	if err := validatePoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+	// Journaled transactions are validated against the new state when loaded
+	statedb.SetNonce(crypto.PubkeyToAddress(remote.PublicKey), 1)
+	pool = restart(pool)
+	if pending, queued := pool.Stats(); pending != 1 || queued != 1 {
+		t.Fatalf("wrong pool content after nonce bump: pending %d, queued %d, want 1, 1", pending, queued)
+	}
+	// Truncated journals are loaded up to the first broken entry
+	pool.Close()
+	info, err := os.Stat(journal)
+	if err != nil {
+		t.Fatalf("failed to stat journal: %v", err)
+	}
+	if err := os.Truncate(journal, info.Size()-10); err != nil {
+		t.Fatalf("failed to truncate journal: %v", err)
+	}
+	pool = restart(nil)
+	if pending, queued := pool.Stats(); pending+queued != 1 {
+		t.Fatalf("wrong pool content after truncation: pending %d, queued %d, want 1 in total", pending, queued)
+	}
+	pool.Close()
+
+	// Garbage in place of the journal is ignored
+	if err := os.WriteFile(journal, []byte("not a transaction journal"), 0644); err != nil {
+		t.Fatalf("failed to corrupt journal: %v", err)
+	}
+	pool = restart(nil)
+	if pending, queued := pool.Stats(); pending+queued != 0 {
+		t.Fatalf("wrong pool content after corruption: pending %d, queued %d, want 0", pending, queued)
+	}
+	pool.Close()
+}
+
+// Tests that the remote journal respects its size and age caps.
+func TestRemoteJournalCaps(t *testing.T) {
+	t.Parallel()
+
+	var (
+		key, _ = crypto.GenerateKey()
+		txs    = types.Transactions{
+			pricedTransaction(0, 100000, big.NewInt(1), key),
+			pricedTransaction(1, 100000, big.NewInt(1), key),
+			pricedTransaction(2, 100000, big.NewInt(1), key),
+		}
+		path = filepath.Join(t.TempDir(), "remotes.rlp")
+	)
+	load := func(journal *remoteJournal) (loaded types.Transactions) {
+		err := journal.load(func(txs []*types.Transaction) []error {
+			loaded = append(loaded, txs...)
+			return make([]error, len(txs))
+		})
+		if err != nil {
+			t.Fatalf("failed to load journal: %v", err)
+		}
+		return loaded
+	}
+	// Only the transactions fitting into the size cap are journaled
+	journal := newRemoteJournal(path, 2*txs[0].Size(), time.Hour)
+	if err := journal.rotate([]types.Transactions{txs}); err != nil {
+		t.Fatalf("failed to rotate journal: %v", err)
+	}
+	if loaded := load(journal); len(loaded) != 2 || loaded[0].Hash() != txs[0].Hash() || loaded[1].Hash() != txs[1].Hash() {
+		t.Fatalf("wrong transactions loaded: %d", len(loaded))
+	}
+	// Transactions exceeding the age cap are not reloaded
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("failed to create journal: %v", err)
+	}
+	for i, tx := range txs {
+		seen := time.Now().Add(-time.Duration(i) * time.Hour)
+		if err := rlp.Encode(file, &remoteJournalEntry{Time: uint64(seen.Unix()), Tx: tx}); err != nil {
+			t.Fatalf("failed to write journal entry: %v", err)
+		}
+	}
+	file.Close()
+
+	journal = newRemoteJournal(path, 1024*1024, 90*time.Minute)
+	if loaded := load(journal); len(loaded) != 2 || loaded[1].Hash() != txs[1].Hash() {
+		t.Fatalf("wrong transactions loaded: %d", len(loaded))
+	}
+}
+
+// Tests that the remote transactions are journaled best paying accounts first,
+// so the size cap truncates the least valuable ones.
+func TestRemoteJournalPriority(t *testing.T) {
+	t.Parallel()
+
+	pool, _ := setupPool()
+	defer pool.Close()
+
+	var (
+		keys   = make([]*ecdsa.PrivateKey, 4)
+		prices = []int64{2, 5, 1, 3}
+	)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+		testAddBalance(pool, crypto.PubkeyToAddress(keys[i].PublicKey), big.NewInt(1000000000))
+
+		// The first two accounts are pending, the other two queued
+		nonce := uint64(0)
+		if i >= 2 {
+			nonce = 1
+		}
+		if err := pool.addRemoteSync(pricedTransaction(nonce, 100000, big.NewInt(prices[i]), keys[i])); err != nil {
+			t.Fatalf("failed to add remote transaction %d: %v", i, err)
+		}
+	}
+	remotes := pool.remotes()
+	if len(remotes) != len(keys) {
+		t.Fatalf("wrong number of remote groups: have %d, want %d", len(remotes), len(keys))
+	}
+	for i, want := range []int{1, 0, 3, 2} {
+		from, _ := types.Sender(pool.signer, remotes[i][0])
+		if from != crypto.PubkeyToAddress(keys[want].PublicKey) {
+			t.Errorf("group %d: wrong account: have %x, want %x", i, from, crypto.PubkeyToAddress(keys[want].PublicKey))
+		}
+	}
+}
+
+// Tests that reloaded remote transactions keep the time they were first seen
+// across restarts, so the age cap can't be evaded by restarting often.
+func TestRemoteJournalFirstSeen(t *testing.T) {
+	t.Parallel()
+
+	var (
+		key, _ = crypto.GenerateKey()
+		tx     = pricedTransaction(0, 100000, big.NewInt(1), key)
+		path   = filepath.Join(t.TempDir(), "remotes.rlp")
+		seen   = time.Now().Add(-time.Hour)
+	)
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("failed to create journal: %v", err)
+	}
+	if err := rlp.Encode(file, &remoteJournalEntry{Time: uint64(seen.Unix()), Tx: tx}); err != nil {
+		t.Fatalf("failed to write journal entry: %v", err)
+	}
+	file.Close()
+
+	// Restart twice, the reloaded transactions being seen anew by the pool.
+	for i := 0; i < 2; i++ {
+		journal := newRemoteJournal(path, 1024*1024, 2*time.Hour)
+		var loaded types.Transactions
+		err := journal.load(func(txs []*types.Transaction) []error {
+			for _, tx := range txs {
+				var decoded types.Transaction
+				blob, _ := tx.MarshalBinary()
+				decoded.UnmarshalBinary(blob)
+				loaded = append(loaded, &decoded)
+			}
+			return make([]error, len(txs))
+		})
+		if err != nil || len(loaded) != 1 {
+			t.Fatalf("cycle %d: failed to load journal: %v, %d transactions", i, err, len(loaded))
+		}
+		if err := journal.rotate([]types.Transactions{loaded}); err != nil {
+			t.Fatalf("cycle %d: failed to rotate journal: %v", i, err)
+		}
+		input, err := os.Open(path)
+		if err != nil {
+			t.Fatalf("cycle %d: failed to open journal: %v", i, err)
+		}
+		entry := new(remoteJournalEntry)
+		err = rlp.Decode(input, entry)
+		input.Close()
+		if err != nil {
+			t.Fatalf("cycle %d: failed to decode journal: %v", i, err)
+		}
+		if entry.Time != uint64(seen.Unix()) {
+			t.Fatalf("cycle %d: first-seen time reset: have %d, want %d", i, entry.Time, seen.Unix())
+		}
+		if _, err := os.Stat(path + ".new"); !os.IsNotExist(err) {
+			t.Fatalf("cycle %d: temporary journal left behind", i)
+		}
+	}
+	// Once the original first-seen time exceeds the age cap, it's dropped.
+	journal := newRemoteJournal(path, 1024*1024, 30*time.Minute)
+	err = journal.load(func(txs []*types.Transaction) []error {
+		t.Fatalf("stale transactions loaded: %d", len(txs))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to load journal: %v", err)
+	}
+}
+
 // TestStatusCheck tests that the pool can correctly retrieve the
 // pending status of individual transactions.
 func TestStatusCheck(t *testing.T) {
@@ -2628,3 +2862,44 @@ func BenchmarkMultiAccountBatchInsert(b *testing.B) {
 		pool.addRemotesSync([]*types.Transaction{tx})
 	}
 }
+
+// Tests that clearing the pool drops all transactions, local or remote, and
+// releases the account reservations.
+func TestClear(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupPool()
+	defer pool.Close()
+
+	remote, _ := crypto.GenerateKey()
+	for _, k := range []*ecdsa.PrivateKey{key, remote} {
+		testAddBalance(pool, crypto.PubkeyToAddress(k.PublicKey), big.NewInt(1000000))
+	}
+	if err := pool.addLocal(transaction(0, 100000, key)); err != nil {
+		t.Fatalf("failed to add local transaction: %v", err)
+	}
+	if err := pool.addRemoteSync(transaction(0, 100000, remote)); err != nil {
+		t.Fatalf("failed to add remote transaction: %v", err)
+	}
+	if err := pool.addRemoteSync(transaction(2, 100000, remote)); err != nil {
+		t.Fatalf("failed to add queued transaction: %v", err)
+	}
+	if pending, queued := pool.Stats(); pending != 2 || queued != 1 {
+		t.Fatalf("wrong pool size before clearing: pending %d, queued %d", pending, queued)
+	}
+	pool.Clear()
+
+	if pending, queued := pool.Stats(); pending != 0 || queued != 0 {
+		t.Fatalf("wrong pool size after clearing: pending %d, queued %d", pending, queued)
+	}
+	if err := validatePoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+	// The accounts can be reserved again by adding the same transactions.
+	if err := pool.addLocal(transaction(0, 100000, key)); err != nil {
+		t.Fatalf("failed to re-add local transaction: %v", err)
+	}
+	if err := pool.addRemoteSync(transaction(0, 100000, remote)); err != nil {
+		t.Fatalf("failed to re-add remote transaction: %v", err)
+	}
+}
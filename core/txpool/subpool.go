@@ -23,6 +23,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/event"
 )
 
@@ -83,6 +84,10 @@ type SubPool interface {
 	// transaction, and drops all transactions below this threshold.
 	SetGasTip(tip *big.Int)
 
+	// Clear removes all tracked transactions from the subpool, releasing the
+	// reserved accounts. It is meant for simulated chains, not live operation.
+	Clear()
+
 	// Has returns an indicator whether subpool has a transaction cached with the
 	// given hash.
 	Has(hash common.Hash) bool
@@ -125,3 +130,63 @@ type SubPool interface {
 	// identified by their hashes.
 	Status(hash common.Hash) TxStatus
 }
+
+// Bundle is an ordered list of transactions which must be included atomically,
+// in the given order and at the top of a block, or not at all.
+type Bundle struct {
+	Txs               types.Transactions // Transactions to include, in order
+	BlockNumber       uint64             // Block number the bundle is valid for
+	MinTimestamp      uint64             // Earliest block timestamp, 0 if unbounded
+	MaxTimestamp      uint64             // Latest block timestamp, 0 if unbounded
+	RevertingTxHashes []common.Hash      // Transactions which are allowed to revert
+}
+
+// Hash returns the identifier of the bundle, derived from the hashes of the
+// contained transactions.
+func (b *Bundle) Hash() common.Hash {
+	hashes := make([]byte, 0, len(b.Txs)*common.HashLength)
+	for _, tx := range b.Txs {
+		hashes = append(hashes, tx.Hash().Bytes()...)
+	}
+	return crypto.Keccak256Hash(hashes)
+}
+
+// AllowsRevert reports whether the transaction with the given hash may fail
+// without invalidating the entire bundle.
+func (b *Bundle) AllowsRevert(hash common.Hash) bool {
+	for _, allowed := range b.RevertingTxHashes {
+		if allowed == hash {
+			return true
+		}
+	}
+	return false
+}
+
+// Valid reports whether the bundle may be included in a block with the given
+// number and timestamp.
+func (b *Bundle) Valid(number, time uint64) bool {
+	if b.BlockNumber != number {
+		return false
+	}
+	if b.MinTimestamp != 0 && time < b.MinTimestamp {
+		return false
+	}
+	if b.MaxTimestamp != 0 && time > b.MaxTimestamp {
+		return false
+	}
+	return true
+}
+
+// BundlePool is a subpool maintaining transaction bundles. The transactions of
+// a bundle are private to the local block producer: they are neither returned
+// by the generic SubPool accessors, nor announced to the network.
+type BundlePool interface {
+	SubPool
+
+	// AddBundle validates a bundle and adds it to the pool.
+	AddBundle(bundle *Bundle) error
+
+	// Bundles retrieves the bundles eligible for inclusion in a block with the
+	// given number and timestamp.
+	Bundles(number uint64, time uint64) []*Bundle
+}
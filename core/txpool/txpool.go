@@ -72,6 +72,9 @@ type TxPool struct {
 
 	subs event.SubscriptionScope // Subscription scope to unscubscribe all on shutdown
 	quit chan chan error         // Quit channel to tear down the head updater
+	term chan struct{}           // Termination channel to detect a closed pool
+
+	sync chan chan error // Testing / simulator channel to block until internal reset is done
 }
 
 // New creates a new transaction pool to gather, sort and filter inbound
@@ -86,6 +89,8 @@ func New(gasTip *big.Int, chain BlockChain, subpools []SubPool) (*TxPool, error)
 		subpools:     subpools,
 		reservations: make(map[common.Address]SubPool),
 		quit:         make(chan chan error),
+		term:         make(chan struct{}),
+		sync:         make(chan chan error),
 	}
 	for i, subpool := range subpools {
 		if err := subpool.Init(gasTip, head, pool.reserver(i, subpool)); err != nil {
@@ -172,6 +177,9 @@ func (p *TxPool) Close() error {
 // outside blockchain events as well as for various reporting and transaction
 // eviction events.
 func (p *TxPool) loop(head *types.Header, chain BlockChain) {
+	// Close the termination marker when the pool stops
+	defer close(p.term)
+
 	// Subscribe to chain head events to trigger subpool resets
 	var (
 		newHeadCh  = make(chan core.ChainHeadEvent)
@@ -188,13 +196,16 @@ func (p *TxPool) loop(head *types.Header, chain BlockChain) {
 	var (
 		resetBusy = make(chan struct{}, 1) // Allow 1 reset to run concurrently
 		resetDone = make(chan *types.Header)
+
+		resetForced bool       // Whether a forced reset was requested, only used in simulator mode
+		resetWaiter chan error // Channel waiting on a forced reset, only used in simulator mode
 	)
 	var errc chan error
 	for errc == nil {
 		// Something interesting might have happened, run a reset if there is
 		// one needed but none is running. The resetter will run on its own
 		// goroutine to allow chain head events to be consumed contiguously.
-		if newHead != oldHead {
+		if newHead != oldHead || resetForced {
 			// Try to inject a busy marker and start a reset if successful
 			select {
 			case resetBusy <- struct{}{}:
@@ -206,8 +217,12 @@ func (p *TxPool) loop(head *types.Header, chain BlockChain) {
 					resetDone <- newHead
 				}(oldHead, newHead)
 
+				// A forced reset is fulfilled by the one just started
+				resetForced = false
+
 			default:
-				// Reset already running, wait until it finishes
+				// Reset already running, wait until it finishes. A forced reset
+				// is kept pending and started after the running one.
 			}
 		}
 		// Wait for the next chain head event or a previous reset finish
@@ -221,11 +236,29 @@ func (p *TxPool) loop(head *types.Header, chain BlockChain) {
 			oldHead = head
 			<-resetBusy
 
+			// Notify the syncer if the reset it requested is done
+			if resetWaiter != nil && !resetForced {
+				resetWaiter <- nil
+				resetWaiter = nil
+			}
+
 		case errc = <-p.quit:
 			// Termination requested, break out on the next loop round
+
+		case syncc := <-p.sync:
+			// Transaction pool is being requested to sync up
+			if resetWaiter != nil {
+				syncc <- errors.New("already syncing")
+				continue
+			}
+			resetForced = true
+			resetWaiter = syncc
 		}
 	}
-	// Notify the closer of termination (no error possible for now)
+	// Notify the syncer and the closer of termination
+	if resetWaiter != nil {
+		resetWaiter <- errors.New("pool already terminated")
+	}
 	errc <- nil
 }
 
@@ -237,6 +270,28 @@ func (p *TxPool) SetGasTip(tip *big.Int) {
 	}
 }
 
+// Clear removes all tracked transactions from the pool. It is meant for simulated
+// chains, not live operation.
+func (p *TxPool) Clear() {
+	for _, subpool := range p.subpools {
+		subpool.Clear()
+	}
+}
+
+// Sync runs a reset of all subpools and waits for it to finish, so transactions
+// added before are promoted to pending. It is meant for tests and simulated chains,
+// where blocks are produced right after adding transactions; live pools reset in
+// the background.
+func (p *TxPool) Sync() error {
+	sync := make(chan error)
+	select {
+	case p.sync <- sync:
+		return <-sync
+	case <-p.term:
+		return errors.New("pool already terminated")
+	}
+}
+
 // Has returns an indicator whether the pool has a transaction cached with the
 // given hash.
 func (p *TxPool) Has(hash common.Hash) bool {
@@ -403,6 +458,28 @@ func (p *TxPool) Locals() []common.Address {
 	return flat
 }
 
+// AddBundle validates a transaction bundle and hands it to the bundle subpool.
+func (p *TxPool) AddBundle(bundle *Bundle) error {
+	for _, subpool := range p.subpools {
+		if pool, ok := subpool.(BundlePool); ok {
+			return pool.AddBundle(bundle)
+		}
+	}
+	return ErrBundlesUnsupported
+}
+
+// Bundles retrieves the bundles eligible for inclusion in a block with the given
+// number and timestamp.
+func (p *TxPool) Bundles(number uint64, time uint64) []*Bundle {
+	var bundles []*Bundle
+	for _, subpool := range p.subpools {
+		if pool, ok := subpool.(BundlePool); ok {
+			bundles = append(bundles, pool.Bundles(number, time)...)
+		}
+	}
+	return bundles
+}
+
 // Status returns the known status (unknown/pending/queued) of a transaction
 // identified by their hashes.
 func (p *TxPool) Status(hash common.Hash) TxStatus {
@@ -53,6 +53,9 @@ func MakeSigner(config *params.ChainConfig, blockNumber *big.Int, blockTime uint
 	default:
 		signer = FrontierSigner{}
 	}
+	if config.Impersonation != nil {
+		signer = impersonationSigner{signer, config.Impersonation}
+	}
 	return signer
 }
 
@@ -64,6 +67,14 @@ func MakeSigner(config *params.ChainConfig, blockNumber *big.Int, blockTime uint
 // Use this in transaction-handling code where the current block number is unknown. If you
 // have the current block number available, use MakeSigner instead.
 func LatestSigner(config *params.ChainConfig) Signer {
+	signer := latestSigner(config)
+	if config.Impersonation != nil {
+		signer = impersonationSigner{signer, config.Impersonation}
+	}
+	return signer
+}
+
+func latestSigner(config *params.ChainConfig) Signer {
 	if config.ChainID != nil {
 		if config.CancunTime != nil {
 			return NewCancunSigner(config.ChainID)
@@ -533,6 +544,31 @@ func (fs FrontierSigner) Hash(tx *Transaction) common.Hash {
 	})
 }
 
+// impersonationSigner wraps the signer of a development chain, resolving the
+// senders of transactions sent on behalf of impersonated accounts via the oracle
+// of the chain. These transactions carry a placeholder signature with S set to
+// one and the sender address in R, which real signatures don't produce.
+type impersonationSigner struct {
+	Signer
+	oracle params.ImpersonationOracle
+}
+
+func (s impersonationSigner) Equal(s2 Signer) bool {
+	if other, ok := s2.(impersonationSigner); ok {
+		s2 = other.Signer
+	}
+	return s.Signer.Equal(s2)
+}
+
+func (s impersonationSigner) Sender(tx *Transaction) (common.Address, error) {
+	if _, r, ss := tx.RawSignatureValues(); ss.Cmp(common.Big1) == 0 && r.BitLen() <= 8*common.AddressLength {
+		if addr, ok := s.oracle.ImpersonatedSender(tx.Hash()); ok {
+			return addr, nil
+		}
+	}
+	return s.Signer.Sender(tx)
+}
+
 func decodeSignature(sig []byte) (r, s, v *big.Int) {
 	if len(sig) != crypto.SignatureLength {
 		panic(fmt.Sprintf("wrong size for signature: got %d, want %d", len(sig), crypto.SignatureLength))
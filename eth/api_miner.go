@@ -20,8 +20,10 @@ import (
 	"math/big"
 	"time"
 
+	"github.com/ethereum/go-ethereum/beacon/engine"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/miner"
 )
 
 // MinerAPI provides an API to control the miner.
@@ -83,3 +85,9 @@ func (api *MinerAPI) SetEtherbase(etherbase common.Address) bool {
 func (api *MinerAPI) SetRecommitInterval(interval int) {
 	api.e.Miner().SetRecommitInterval(time.Duration(interval) * time.Millisecond)
 }
+
+// GetPayloadReport returns which pending transactions were left out of a recently
+// built payload, and why.
+func (api *MinerAPI) GetPayloadReport(id engine.PayloadID) (*miner.PayloadReport, error) {
+	return api.e.Miner().PayloadReport(id)
+}
@@ -0,0 +1,389 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package builder implements a block builder submitting the payloads built by the
+// miner to the relays of the MEV-boost ecosystem, as well as an API for relays to
+// validate the blocks submitted by other builders.
+//
+// For every forkchoice update with payload attributes, the builder looks up the
+// proposer of the slot at the relays and keeps submitting the improving payload
+// as a signed bid until shortly after the slot started. The fees of the payload
+// go to the fee recipient of the payload attributes. If the proposer registered a
+// different fee recipient, or a gas limit the payload doesn't follow, the builder
+// builds a separate payload with the registered preferences, which is submitted
+// instead.
+package builder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/beacon/engine"
+	"github.com/ethereum/go-ethereum/beacon/merkle"
+	"github.com/ethereum/go-ethereum/beacon/params"
+	"github.com/ethereum/go-ethereum/beacon/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/eth"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/miner"
+	"github.com/ethereum/go-ethereum/node"
+	bls "github.com/protolambda/bls12-381-util"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	// submitWindow is the time after the start of a slot until which blocks are
+	// submitted for it.
+	submitWindow = 4 * time.Second
+
+	// relayTimeout is the time allowed for a single request to a relay.
+	relayTimeout = 2 * time.Second
+
+	// DefaultInterval is the default minimum time between submissions.
+	DefaultInterval = 500 * time.Millisecond
+)
+
+var (
+	submissionMeter = metrics.NewRegisteredMeter("builder/submissions", nil)
+	failureMeter    = metrics.NewRegisteredMeter("builder/failures", nil)
+)
+
+// Config contains the settings of the builder.
+type Config struct {
+	Relays    []string          // URLs of the relays to submit blocks to
+	SecretKey *bls.SecretKey    // BLS key signing the bids of the builder
+	Beacon    types.ChainConfig // Beacon chain config, used for slots and the signature domain
+	Interval  time.Duration     // Minimum time between submissions of improved payloads
+}
+
+// Builder submits the payloads built by the miner to relays.
+type Builder struct {
+	eth    *eth.Ethereum
+	config Config
+	relays []*relay
+	pubkey BLSPubkey
+	domain merkle.Value
+
+	built map[engine.PayloadID]struct{} // payloads started for registered preferences
+	lock  sync.Mutex
+
+	sub     event.Subscription
+	closeCh chan struct{}
+	wg      sync.WaitGroup
+}
+
+// New creates a builder on top of the given node.
+func New(eth *eth.Ethereum, config Config) (*Builder, error) {
+	if len(config.Relays) == 0 {
+		return nil, errors.New("no relays configured")
+	}
+	if config.SecretKey == nil {
+		return nil, errors.New("builder secret key missing")
+	}
+	if len(config.Beacon.Forks) == 0 {
+		return nil, errors.New("beacon chain forks missing")
+	}
+	if config.Interval <= 0 {
+		config.Interval = DefaultInterval
+	}
+	pubkey, err := bls.SkToPk(config.SecretKey)
+	if err != nil {
+		return nil, err
+	}
+	b := &Builder{
+		eth:     eth,
+		config:  config,
+		pubkey:  pubkey.Serialize(),
+		domain:  builderDomain(config.Beacon.Forks[0].Version),
+		built:   make(map[engine.PayloadID]struct{}),
+		closeCh: make(chan struct{}),
+	}
+	for _, url := range config.Relays {
+		b.relays = append(b.relays, newRelay(url))
+	}
+	return b, nil
+}
+
+// Register adds the builder service into the node.
+func Register(stack *node.Node, backend *eth.Ethereum, config Config) error {
+	b, err := New(backend, config)
+	if err != nil {
+		return err
+	}
+	stack.RegisterLifecycle(b)
+	return nil
+}
+
+// Pubkey returns the public key of the builder.
+func (b *Builder) Pubkey() BLSPubkey {
+	return b.pubkey
+}
+
+// Start implements node.Lifecycle, starting to submit the built payloads.
+func (b *Builder) Start() error {
+	payloads := make(chan miner.NewPayloadEvent, 16)
+	b.sub = b.eth.Miner().SubscribeNewPayloads(payloads)
+
+	b.wg.Add(1)
+	go b.loop(payloads)
+	log.Info("Started block builder", "pubkey", common.Bytes2Hex(b.pubkey[:]), "relays", len(b.relays))
+	return nil
+}
+
+// Stop implements node.Lifecycle, terminating the submissions.
+func (b *Builder) Stop() error {
+	b.sub.Unsubscribe()
+	close(b.closeCh)
+	b.wg.Wait()
+	return nil
+}
+
+// loop starts the submissions of each new payload.
+func (b *Builder) loop(payloads chan miner.NewPayloadEvent) {
+	defer b.wg.Done()
+
+	for {
+		select {
+		case ev := <-payloads:
+			b.wg.Add(1)
+			go func() {
+				defer b.wg.Done()
+				b.submitPayload(ev)
+			}()
+		case <-b.sub.Err():
+			return
+		case <-b.closeCh:
+			return
+		}
+	}
+}
+
+// slot returns the beacon chain slot of the given block time.
+func (b *Builder) slot(timestamp uint64) (uint64, error) {
+	genesis := b.config.Beacon.GenesisTime
+	if timestamp < genesis || (timestamp-genesis)%params.SecondsPerSlot != 0 {
+		return 0, fmt.Errorf("timestamp %d is not a slot start", timestamp)
+	}
+	return (timestamp - genesis) / params.SecondsPerSlot, nil
+}
+
+// target is a relay which the proposer of a slot registered with.
+type target struct {
+	relay        *relay
+	registration *ValidatorRegistration
+}
+
+// preference is the fee recipient and gas limit a proposer registered with.
+type preference struct {
+	feeRecipient common.Address
+	gasLimit     uint64
+}
+
+// submitPayload keeps submitting a payload to the relays as it improves, until
+// the submission window of its slot is over.
+func (b *Builder) submitPayload(ev miner.NewPayloadEvent) {
+	slot, err := b.slot(ev.Args.Timestamp)
+	if err != nil {
+		log.Warn("Not submitting payload", "err", err)
+		return
+	}
+	parent := b.eth.BlockChain().GetHeaderByHash(ev.Args.Parent)
+	if parent == nil {
+		log.Warn("Not submitting payload", "err", "unknown parent", "parent", ev.Args.Parent)
+		return
+	}
+	deadline := time.Unix(int64(ev.Args.Timestamp), 0).Add(submitWindow)
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+
+	targets, preferences := b.targets(ctx, slot, ev.Args.FeeRecipient, ev.Payload.GasLimit(), parent.GasLimit)
+	if b.ownPayload(ev.Args.Id()) {
+		defer b.releasePayload(ev.Args.Id())
+	} else {
+		// The proposer expects the fees at the fee recipient it registered with
+		// the relays, and the gas limit to move towards its registered one. Build
+		// separate payloads following them.
+		for _, pref := range preferences {
+			b.buildPayload(ev.Args, pref)
+		}
+	}
+	if len(targets) == 0 {
+		return
+	}
+	var (
+		timer     = time.NewTimer(0)
+		submitted common.Hash
+	)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+			if env := ev.Payload.Best(); env != nil && env.ExecutionPayload.BlockHash != submitted {
+				b.submit(ctx, slot, env, targets)
+				submitted = env.ExecutionPayload.BlockHash
+			}
+			timer.Reset(b.config.Interval)
+		case <-ev.Payload.Done():
+			return
+		case <-ctx.Done():
+			return
+		case <-b.closeCh:
+			return
+		}
+	}
+}
+
+// buildPayload starts building a payload with the same attributes as the given
+// one, but paying the fees to the registered fee recipient and targeting the
+// registered gas limit. Like any other, the payload is posted to the builder once
+// its building started.
+func (b *Builder) buildPayload(args *miner.BuildPayloadArgs, pref preference) {
+	own := *args
+	own.FeeRecipient = pref.feeRecipient
+	own.GasLimit = pref.gasLimit
+	id := own.Id()
+
+	b.lock.Lock()
+	if _, ok := b.built[id]; ok {
+		b.lock.Unlock()
+		return
+	}
+	b.built[id] = struct{}{}
+	b.lock.Unlock()
+
+	log.Debug("Building payload for registered preferences", "id", id, "feeRecipient", pref.feeRecipient, "gasLimit", pref.gasLimit)
+	if _, err := b.eth.Miner().BuildPayload(&own); err != nil {
+		log.Warn("Failed to build payload for registered preferences", "feeRecipient", pref.feeRecipient, "gasLimit", pref.gasLimit, "err", err)
+		b.releasePayload(id)
+	}
+}
+
+// ownPayload reports whether the payload was started by the builder itself.
+func (b *Builder) ownPayload(id engine.PayloadID) bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	_, ok := b.built[id]
+	return ok
+}
+
+// releasePayload forgets a payload started by the builder.
+func (b *Builder) releasePayload(id engine.PayloadID) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	delete(b.built, id)
+}
+
+// targets returns the relays which the proposer of the slot registered with using
+// the given fee recipient and a gas limit the payload follows, along with the other
+// preferences it registered. Relays require the gas limit to move from the one of
+// the parent towards the registered one.
+func (b *Builder) targets(ctx context.Context, slot uint64, feeRecipient common.Address, gasLimit uint64, parentGasLimit uint64) ([]*target, []preference) {
+	var (
+		targets []*target
+		others  []preference
+		lock    sync.Mutex
+		wg      sync.WaitGroup
+	)
+	for _, r := range b.relays {
+		wg.Add(1)
+		go func(r *relay) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(ctx, relayTimeout)
+			defer cancel()
+
+			reg, err := r.registration(ctx, slot)
+			switch {
+			case err != nil:
+				log.Warn("Failed to retrieve proposer from relay", "relay", r.url, "slot", slot, "err", err)
+			case reg == nil:
+				log.Debug("Proposer not registered with relay", "relay", r.url, "slot", slot)
+			case reg.Entry.Message.FeeRecipient != feeRecipient || core.CalcGasLimit(parentGasLimit, uint64(reg.Entry.Message.GasLimit)) != gasLimit:
+				msg := reg.Entry.Message
+				log.Debug("Payload differs from registration", "relay", r.url, "slot", slot, "feeRecipient", feeRecipient, "gasLimit", gasLimit, "registeredFeeRecipient", msg.FeeRecipient, "registeredGasLimit", msg.GasLimit)
+				pref := preference{feeRecipient: msg.FeeRecipient, gasLimit: uint64(msg.GasLimit)}
+				lock.Lock()
+				if !slices.Contains(others, pref) {
+					others = append(others, pref)
+				}
+				lock.Unlock()
+			default:
+				lock.Lock()
+				targets = append(targets, &target{relay: r, registration: reg})
+				lock.Unlock()
+			}
+		}(r)
+	}
+	wg.Wait()
+	return targets, others
+}
+
+// submit signs a bid for the payload and submits it to the relays.
+func (b *Builder) submit(ctx context.Context, slot uint64, env *engine.ExecutionPayloadEnvelope, targets []*target) {
+	var (
+		data    = env.ExecutionPayload
+		payload = newExecutionPayload(data)
+		wg      sync.WaitGroup
+	)
+	for _, t := range targets {
+		trace := &BidTrace{
+			Slot:                 common.Decimal(slot),
+			ParentHash:           data.ParentHash,
+			BlockHash:            data.BlockHash,
+			BuilderPubkey:        b.pubkey,
+			ProposerPubkey:       t.registration.Entry.Message.Pubkey,
+			ProposerFeeRecipient: t.registration.Entry.Message.FeeRecipient,
+			GasLimit:             common.Decimal(data.GasLimit),
+			GasUsed:              common.Decimal(data.GasUsed),
+			Value:                (*math.Decimal256)(new(big.Int).Set(env.BlockValue)),
+		}
+		submission := &SubmitBlockRequest{
+			Message:          trace,
+			ExecutionPayload: payload,
+			Signature:        signBidTrace(trace, b.domain, b.config.SecretKey),
+		}
+		if data.BlobGasUsed != nil {
+			submission.BlobsBundle = env.BlobsBundle
+		}
+		wg.Add(1)
+		go func(r *relay) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(ctx, relayTimeout)
+			defer cancel()
+
+			submissionMeter.Mark(1)
+			if err := r.submitBlock(ctx, submission); err != nil {
+				failureMeter.Mark(1)
+				log.Warn("Failed to submit block to relay", "relay", r.url, "slot", slot, "hash", data.BlockHash, "err", err)
+				return
+			}
+			log.Info("Submitted block to relay", "relay", r.url, "slot", slot, "number", data.Number, "hash", data.BlockHash, "value", env.BlockValue)
+		}(t.relay)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,403 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package builder
+
+import (
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/beacon/engine"
+	"github.com/ethereum/go-ethereum/beacon/params"
+	beacontypes "github.com/ethereum/go-ethereum/beacon/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth"
+	"github.com/ethereum/go-ethereum/eth/catalyst"
+	"github.com/ethereum/go-ethereum/eth/downloader"
+	"github.com/ethereum/go-ethereum/eth/ethconfig"
+	"github.com/ethereum/go-ethereum/miner"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/p2p"
+	bls "github.com/protolambda/bls12-381-util"
+)
+
+var (
+	testKey, _   = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	testAddr     = crypto.PubkeyToAddress(testKey.PublicKey)
+	testGasLimit = uint64(30_000_000)
+
+	testForkVersion  = []byte{0, 0, 0, 0x42}
+	testFeeRecipient = common.HexToAddress("0xfee")
+	testProposer     = BLSPubkey{0x01, 0x02, 0x03}
+)
+
+// fakeRelay stands in for a relay, serving the registration of a single proposer
+// and collecting the block submissions.
+type fakeRelay struct {
+	*httptest.Server
+	slot         uint64
+	registered   bool
+	feeRecipient common.Address
+	gasLimit     uint64
+	submissions  chan *SubmitBlockRequest
+}
+
+func newFakeRelay(t *testing.T, slot uint64, registered bool) *fakeRelay {
+	return newFakeRelayWithRecipient(t, slot, registered, testFeeRecipient)
+}
+
+func newFakeRelayWithRecipient(t *testing.T, slot uint64, registered bool, feeRecipient common.Address) *fakeRelay {
+	r := &fakeRelay{
+		slot:         slot,
+		registered:   registered,
+		feeRecipient: feeRecipient,
+		gasLimit:     testGasLimit,
+		submissions:  make(chan *SubmitBlockRequest, 100),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(validatorsPath, func(w http.ResponseWriter, req *http.Request) {
+		registrations := []*ValidatorRegistration{}
+		if r.registered {
+			reg := new(ValidatorRegistration)
+			reg.Slot = common.Decimal(r.slot)
+			reg.Entry.Message.FeeRecipient = r.feeRecipient
+			reg.Entry.Message.GasLimit = common.Decimal(r.gasLimit)
+			reg.Entry.Message.Pubkey = testProposer
+			registrations = append(registrations, reg)
+		}
+		json.NewEncoder(w).Encode(registrations)
+	})
+	mux.HandleFunc(submitPath, func(w http.ResponseWriter, req *http.Request) {
+		submission := new(SubmitBlockRequest)
+		if err := json.NewDecoder(req.Body).Decode(submission); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]interface{}{"code": 400, "message": err.Error()})
+			return
+		}
+		r.submissions <- submission
+	})
+	r.Server = httptest.NewServer(mux)
+	t.Cleanup(r.Close)
+	return r
+}
+
+func startEthService(t *testing.T) (*node.Node, *eth.Ethereum) {
+	t.Helper()
+
+	n, err := node.New(&node.Config{
+		P2P: p2p.Config{
+			ListenAddr:  "0.0.0.0:0",
+			NoDiscovery: true,
+			MaxPeers:    25,
+		}})
+	if err != nil {
+		t.Fatal("can't create node:", err)
+	}
+	ethcfg := &ethconfig.Config{
+		Genesis:        core.DeveloperGenesisBlock(testGasLimit, testAddr),
+		SyncMode:       downloader.FullSync,
+		TrieTimeout:    time.Minute,
+		TrieDirtyCache: 256,
+		TrieCleanCache: 256,
+		Miner: miner.Config{
+			GasCeil:  testGasLimit,
+			GasPrice: big.NewInt(1),
+			Recommit: 100 * time.Millisecond,
+		},
+	}
+	ethservice, err := eth.New(n, ethcfg)
+	if err != nil {
+		t.Fatal("can't create eth service:", err)
+	}
+	if err := n.Start(); err != nil {
+		t.Fatal("can't start node:", err)
+	}
+	t.Cleanup(func() { n.Close() })
+	ethservice.SetSynced()
+	return n, ethservice
+}
+
+func newTestBuilder(t *testing.T, ethservice *eth.Ethereum, timestamp, slot uint64, relays ...string) *Builder {
+	var key [32]byte
+	key[31] = 1
+	sk := new(bls.SecretKey)
+	if err := sk.Deserialize(&key); err != nil {
+		t.Fatal(err)
+	}
+	config := Config{
+		Relays:    relays,
+		SecretKey: sk,
+		Beacon:    beacontypes.ChainConfig{GenesisTime: timestamp - slot*params.SecondsPerSlot},
+		Interval:  50 * time.Millisecond,
+	}
+	config.Beacon.AddFork("GENESIS", 0, testForkVersion)
+	b, err := New(ethservice, config)
+	if err != nil {
+		t.Fatal("can't create builder:", err)
+	}
+	if err := b.Start(); err != nil {
+		t.Fatal("can't start builder:", err)
+	}
+	t.Cleanup(func() { b.Stop() })
+	return b
+}
+
+// buildPayload starts building a payload on top of the current head, as done
+// by a consensus client sending payload attributes.
+func buildPayload(t *testing.T, ethservice *eth.Ethereum, timestamp uint64) {
+	head := ethservice.BlockChain().CurrentBlock().Hash()
+	api := catalyst.NewConsensusAPI(ethservice)
+	_, err := api.ForkchoiceUpdatedV2(engine.ForkchoiceStateV1{
+		HeadBlockHash:      head,
+		SafeBlockHash:      head,
+		FinalizedBlockHash: head,
+	}, &engine.PayloadAttributes{
+		Timestamp:             timestamp,
+		SuggestedFeeRecipient: testFeeRecipient,
+		Withdrawals:           []*types.Withdrawal{{Index: 0, Validator: 1, Address: testFeeRecipient, Amount: 10}},
+	})
+	if err != nil {
+		t.Fatal("can't start building payload:", err)
+	}
+}
+
+func sendTransaction(t *testing.T, ethservice *eth.Ethereum, nonce uint64) {
+	signer := types.LatestSigner(ethservice.BlockChain().Config())
+	tx := types.MustSignNewTx(testKey, signer, &types.DynamicFeeTx{
+		ChainID:   ethservice.BlockChain().Config().ChainID,
+		Nonce:     nonce,
+		To:        &common.Address{0xaa},
+		Value:     big.NewInt(1),
+		Gas:       21000,
+		GasFeeCap: big.NewInt(2_000_000_000),
+		GasTipCap: big.NewInt(1_000_000_000),
+	})
+	if errs := ethservice.TxPool().Add([]*types.Transaction{tx}, true, true); errs[0] != nil {
+		t.Fatal("can't add transaction:", errs[0])
+	}
+}
+
+// waitSubmission waits for a submission with a non-zero value.
+func waitSubmission(t *testing.T, relay *fakeRelay) *SubmitBlockRequest {
+	t.Helper()
+
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case sub := <-relay.submissions:
+			if (*big.Int)(sub.Message.Value).Sign() > 0 {
+				return sub
+			}
+		case <-timeout:
+			t.Fatal("no block submitted")
+		}
+	}
+}
+
+func TestBuilderSubmission(t *testing.T) {
+	var (
+		_, ethservice = startEthService(t)
+		timestamp     = uint64(time.Now().Unix())
+		slot          = uint64(100)
+		relay         = newFakeRelay(t, slot, true)
+		unregistered  = newFakeRelay(t, slot, false)
+		b             = newTestBuilder(t, ethservice, timestamp, slot, relay.URL, unregistered.URL)
+	)
+	sendTransaction(t, ethservice, 0)
+	buildPayload(t, ethservice, timestamp)
+
+	sub := waitSubmission(t, relay)
+	msg, payload := sub.Message, sub.ExecutionPayload
+	if uint64(msg.Slot) != slot || msg.ProposerPubkey != testProposer || msg.BuilderPubkey != b.Pubkey() {
+		t.Errorf("wrong bid trace: %+v", msg)
+	}
+	if msg.ProposerFeeRecipient != testFeeRecipient || payload.FeeRecipient != testFeeRecipient {
+		t.Errorf("wrong fee recipient: bid %x, payload %x", msg.ProposerFeeRecipient, payload.FeeRecipient)
+	}
+	if msg.BlockHash != payload.BlockHash || msg.GasUsed != payload.GasUsed || uint64(payload.Timestamp) != timestamp {
+		t.Errorf("bid trace doesn't match payload: %+v", msg)
+	}
+	if len(payload.Transactions) != 1 || len(payload.Withdrawals) != 1 || sub.BlobsBundle != nil {
+		t.Errorf("wrong payload contents: %d txs, %d withdrawals", len(payload.Transactions), len(payload.Withdrawals))
+	}
+	if !verifyBidTrace(msg, builderDomain(testForkVersion), sub.Signature) {
+		t.Error("invalid bid signature")
+	}
+	if verifyBidTrace(msg, builderDomain([]byte{1}), sub.Signature) {
+		t.Error("bid signature valid in wrong domain")
+	}
+	select {
+	case <-unregistered.submissions:
+		t.Error("block submitted to relay without proposer registration")
+	default:
+	}
+}
+
+// Tests that payloads pay the fee recipient registered by the proposer, even if
+// the consensus client requested a different one.
+func TestBuilderRegisteredFeeRecipient(t *testing.T) {
+	var (
+		_, ethservice = startEthService(t)
+		timestamp     = uint64(time.Now().Unix())
+		slot          = uint64(100)
+		registered    = common.HexToAddress("0xbeef")
+		relay         = newFakeRelayWithRecipient(t, slot, true, registered)
+		_             = newTestBuilder(t, ethservice, timestamp, slot, relay.URL)
+	)
+	sendTransaction(t, ethservice, 0)
+	buildPayload(t, ethservice, timestamp)
+
+	sub := waitSubmission(t, relay)
+	msg, payload := sub.Message, sub.ExecutionPayload
+	if msg.ProposerFeeRecipient != registered || payload.FeeRecipient != registered {
+		t.Errorf("wrong fee recipient: bid %x, payload %x, want %x", msg.ProposerFeeRecipient, payload.FeeRecipient, registered)
+	}
+	if len(payload.Transactions) != 1 || len(payload.Withdrawals) != 1 || uint64(payload.Timestamp) != timestamp {
+		t.Errorf("wrong payload contents: %d txs, %d withdrawals", len(payload.Transactions), len(payload.Withdrawals))
+	}
+	// The bid can be validated against the registration.
+	blob, _ := json.Marshal(sub)
+	req := new(BuilderBlockValidationRequest)
+	if err := json.Unmarshal(blob, &req.SubmitBlockRequest); err != nil {
+		t.Fatal(err)
+	}
+	req.RegisteredGasLimit = testGasLimit
+	api := &ValidationAPI{eth: ethservice}
+	if err := api.ValidateBuilderSubmissionV2(req); err != nil {
+		t.Fatalf("submission rejected: %v", err)
+	}
+}
+
+// Tests that payloads follow the gas limit registered by the proposer, even if it
+// differs from the gas ceiling of the miner.
+func TestBuilderRegisteredGasLimit(t *testing.T) {
+	var (
+		_, ethservice = startEthService(t)
+		timestamp     = uint64(time.Now().Unix())
+		slot          = uint64(100)
+		registered    = testGasLimit / 2
+		relay         = newFakeRelay(t, slot, true)
+	)
+	relay.gasLimit = registered
+	newTestBuilder(t, ethservice, timestamp, slot, relay.URL)
+
+	sendTransaction(t, ethservice, 0)
+	buildPayload(t, ethservice, timestamp)
+
+	sub := waitSubmission(t, relay)
+	msg, payload := sub.Message, sub.ExecutionPayload
+	parent := ethservice.BlockChain().CurrentBlock()
+	if want := core.CalcGasLimit(parent.GasLimit, registered); uint64(payload.GasLimit) != want || uint64(msg.GasLimit) != want {
+		t.Errorf("wrong gas limit: bid %d, payload %d, want %d", msg.GasLimit, payload.GasLimit, want)
+	}
+	if msg.ProposerFeeRecipient != testFeeRecipient || payload.FeeRecipient != testFeeRecipient {
+		t.Errorf("wrong fee recipient: bid %x, payload %x", msg.ProposerFeeRecipient, payload.FeeRecipient)
+	}
+	// The bid can be validated against the registration.
+	blob, _ := json.Marshal(sub)
+	req := new(BuilderBlockValidationRequest)
+	if err := json.Unmarshal(blob, &req.SubmitBlockRequest); err != nil {
+		t.Fatal(err)
+	}
+	req.RegisteredGasLimit = registered
+	api := &ValidationAPI{eth: ethservice}
+	if err := api.ValidateBuilderSubmissionV2(req); err != nil {
+		t.Fatalf("submission rejected: %v", err)
+	}
+}
+
+func TestBuilderSlot(t *testing.T) {
+	_, ethservice := startEthService(t)
+	relay := newFakeRelay(t, 10, true)
+	b := newTestBuilder(t, ethservice, 1000+10*params.SecondsPerSlot, 10, relay.URL)
+
+	if slot, err := b.slot(1000 + 12*params.SecondsPerSlot); err != nil || slot != 12 {
+		t.Errorf("wrong slot: have %d (%v), want 12", slot, err)
+	}
+	for _, timestamp := range []uint64{999, 1001} {
+		if _, err := b.slot(timestamp); err == nil {
+			t.Errorf("timestamp %d: expected error", timestamp)
+		}
+	}
+}
+
+func TestValidateSubmission(t *testing.T) {
+	var (
+		_, ethservice = startEthService(t)
+		timestamp     = uint64(time.Now().Unix())
+		relay         = newFakeRelay(t, 1, true)
+		_             = newTestBuilder(t, ethservice, timestamp, 1, relay.URL)
+		api           = &ValidationAPI{eth: ethservice}
+	)
+	sendTransaction(t, ethservice, 0)
+	buildPayload(t, ethservice, timestamp)
+	sub := waitSubmission(t, relay)
+
+	valid := func() *BuilderBlockValidationRequest {
+		// Round-trip the submission to get an independent copy.
+		blob, _ := json.Marshal(sub)
+		req := new(BuilderBlockValidationRequest)
+		if err := json.Unmarshal(blob, &req.SubmitBlockRequest); err != nil {
+			t.Fatal(err)
+		}
+		req.RegisteredGasLimit = testGasLimit
+		return req
+	}
+	if err := api.ValidateBuilderSubmissionV2(valid()); err != nil {
+		t.Fatalf("valid submission rejected: %v", err)
+	}
+	tests := []struct {
+		name   string
+		modify func(req *BuilderBlockValidationRequest)
+		err    string
+	}{
+		{"value", func(req *BuilderBlockValidationRequest) {
+			req.Message.Value = (*math.Decimal256)(new(big.Int).Add((*big.Int)(req.Message.Value), big.NewInt(1)))
+		}, "insufficient proposer payment"},
+		{"fee recipient", func(req *BuilderBlockValidationRequest) {
+			req.Message.ProposerFeeRecipient = testAddr
+		}, "insufficient proposer payment"},
+		{"block hash", func(req *BuilderBlockValidationRequest) {
+			req.Message.BlockHash = common.Hash{1}
+		}, "block hash mismatch"},
+		{"gas limit", func(req *BuilderBlockValidationRequest) {
+			req.RegisteredGasLimit = testGasLimit / 2
+		}, "incorrect gas limit"},
+		{"state root", func(req *BuilderBlockValidationRequest) {
+			req.ExecutionPayload.StateRoot = common.Hash{1}
+		}, "blockhash mismatch"},
+		{"deneb fields", func(req *BuilderBlockValidationRequest) {
+			req.ParentBeaconBlockRoot = new(common.Hash)
+		}, "unexpected Deneb fields"},
+	}
+	for _, test := range tests {
+		req := valid()
+		test.modify(req)
+		err := api.ValidateBuilderSubmissionV2(req)
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: wrong error: have %v, want %q", test.name, err, test.err)
+		}
+	}
+}
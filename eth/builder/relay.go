@@ -0,0 +1,105 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package builder
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const (
+	validatorsPath = "/relay/v1/builder/validators"
+	submitPath     = "/relay/v1/builder/blocks"
+
+	maxErrorSize = 4096 // maximum size of relay error messages read
+)
+
+// relay is a client of the builder API of a relay.
+type relay struct {
+	url    string
+	client *http.Client
+}
+
+func newRelay(url string) *relay {
+	return &relay{
+		url:    strings.TrimRight(url, "/"),
+		client: new(http.Client),
+	}
+}
+
+// registration returns the registration of the validator proposing in the given
+// slot, or nil if the proposer is not registered with the relay.
+func (r *relay) registration(ctx context.Context, slot uint64) (*ValidatorRegistration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.url+validatorsPath, nil)
+	if err != nil {
+		return nil, err
+	}
+	var registrations []*ValidatorRegistration
+	if err := r.do(req, &registrations); err != nil {
+		return nil, err
+	}
+	for _, reg := range registrations {
+		if uint64(reg.Slot) == slot {
+			return reg, nil
+		}
+	}
+	return nil, nil
+}
+
+// submitBlock submits a block to the relay.
+func (r *relay) submitBlock(ctx context.Context, submission *SubmitBlockRequest) error {
+	body, err := json.Marshal(submission)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.url+submitPath, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return r.do(req, nil)
+}
+
+// do sends a request to the relay, decoding the response into result if it's
+// not nil.
+func (r *relay) do(req *http.Request, result interface{}) error {
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var msg struct {
+			Message string `json:"message"`
+		}
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorSize))
+		if json.Unmarshal(body, &msg) == nil && msg.Message != "" {
+			return fmt.Errorf("relay error %d: %s", resp.StatusCode, msg.Message)
+		}
+		return fmt.Errorf("relay error %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	}
+	if result == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
@@ -0,0 +1,280 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package builder
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/beacon/engine"
+	"github.com/ethereum/go-ethereum/beacon/merkle"
+	"github.com/ethereum/go-ethereum/beacon/params"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/types"
+	bls "github.com/protolambda/bls12-381-util"
+)
+
+// builderDomainType is the signature domain type of the builder API, see
+// https://github.com/ethereum/builder-specs/blob/main/specs/bellatrix/builder.md#domain-types
+var builderDomainType = [4]byte{0, 0, 0, 1}
+
+// BLSPubkey is the serialized BLS public key of a builder or validator.
+type BLSPubkey [params.BLSPubkeySize]byte
+
+func (p BLSPubkey) MarshalText() ([]byte, error) {
+	return hexutil.Bytes(p[:]).MarshalText()
+}
+
+func (p *BLSPubkey) UnmarshalText(input []byte) error {
+	return hexutil.UnmarshalFixedText("BLSPubkey", input, p[:])
+}
+
+// BLSSignature is a serialized BLS signature.
+type BLSSignature [params.BLSSignatureSize]byte
+
+func (s BLSSignature) MarshalText() ([]byte, error) {
+	return hexutil.Bytes(s[:]).MarshalText()
+}
+
+func (s *BLSSignature) UnmarshalText(input []byte) error {
+	return hexutil.UnmarshalFixedText("BLSSignature", input, s[:])
+}
+
+// BidTrace is the signed part of a block submission, describing the bid of the
+// builder for a slot.
+type BidTrace struct {
+	Slot                 common.Decimal   `json:"slot"`
+	ParentHash           common.Hash      `json:"parent_hash"`
+	BlockHash            common.Hash      `json:"block_hash"`
+	BuilderPubkey        BLSPubkey        `json:"builder_pubkey"`
+	ProposerPubkey       BLSPubkey        `json:"proposer_pubkey"`
+	ProposerFeeRecipient common.Address   `json:"proposer_fee_recipient"`
+	GasLimit             common.Decimal   `json:"gas_limit"`
+	GasUsed              common.Decimal   `json:"gas_used"`
+	Value                *math.Decimal256 `json:"value"`
+}
+
+// HashTreeRoot calculates the SSZ hash tree root of the bid trace.
+func (b *BidTrace) HashTreeRoot() common.Hash {
+	var values [16]merkle.Value // values corresponding to indices 16 to 31 of the tree
+	binary.LittleEndian.PutUint64(values[0][:8], uint64(b.Slot))
+	values[1] = merkle.Value(b.ParentHash)
+	values[2] = merkle.Value(b.BlockHash)
+	values[3] = hashPubkey(b.BuilderPubkey)
+	values[4] = hashPubkey(b.ProposerPubkey)
+	copy(values[5][:], b.ProposerFeeRecipient[:])
+	binary.LittleEndian.PutUint64(values[6][:8], uint64(b.GasLimit))
+	binary.LittleEndian.PutUint64(values[7][:8], uint64(b.GasUsed))
+	if b.Value != nil {
+		// SSZ encodes integers in little endian order
+		value := math.PaddedBigBytes((*big.Int)(b.Value), 32)
+		for i := range value {
+			values[8][i] = value[len(value)-1-i]
+		}
+	}
+	hasher := sha256.New()
+	for n := len(values); n > 1; n /= 2 {
+		for i := 0; i < n/2; i++ {
+			hasher.Reset()
+			hasher.Write(values[i*2][:])
+			hasher.Write(values[i*2+1][:])
+			hasher.Sum(values[i][:0])
+		}
+	}
+	return common.Hash(values[0])
+}
+
+// hashPubkey calculates the SSZ hash tree root of a public key.
+func hashPubkey(pubkey BLSPubkey) (root merkle.Value) {
+	var chunks [64]byte
+	copy(chunks[:], pubkey[:])
+	return sha256.Sum256(chunks[:])
+}
+
+// builderDomain returns the signature domain of the builder API. It is derived
+// from the genesis fork version of the beacon chain and a zero validators root.
+func builderDomain(genesisForkVersion []byte) merkle.Value {
+	var (
+		forkData merkle.Value
+		domain   merkle.Value
+	)
+	copy(forkData[:], genesisForkVersion)
+	forkDataRoot := sha256.Sum256(append(forkData[:], make([]byte, 32)...))
+	copy(domain[:], builderDomainType[:])
+	copy(domain[4:], forkDataRoot[:28])
+	return domain
+}
+
+// signingRoot calculates the message signed by the builder for a bid trace.
+func signingRoot(trace *BidTrace, domain merkle.Value) common.Hash {
+	root := trace.HashTreeRoot()
+	return sha256.Sum256(append(root[:], domain[:]...))
+}
+
+// signBidTrace signs the bid trace with the secret key of the builder.
+func signBidTrace(trace *BidTrace, domain merkle.Value, sk *bls.SecretKey) BLSSignature {
+	root := signingRoot(trace, domain)
+	return bls.Sign(sk, root[:]).Serialize()
+}
+
+// verifyBidTrace checks the signature of the builder on the bid trace.
+func verifyBidTrace(trace *BidTrace, domain merkle.Value, signature BLSSignature) bool {
+	var (
+		pubkey = new(bls.Pubkey)
+		sig    = new(bls.Signature)
+		key    = [params.BLSPubkeySize]byte(trace.BuilderPubkey)
+		raw    = [params.BLSSignatureSize]byte(signature)
+	)
+	if err := pubkey.Deserialize(&key); err != nil {
+		return false
+	}
+	if err := sig.Deserialize(&raw); err != nil {
+		return false
+	}
+	root := signingRoot(trace, domain)
+	return bls.Verify(pubkey, root[:], sig)
+}
+
+// Withdrawal is a validator withdrawal in the encoding of the beacon API.
+type Withdrawal struct {
+	Index          common.Decimal `json:"index"`
+	ValidatorIndex common.Decimal `json:"validator_index"`
+	Address        common.Address `json:"address"`
+	Amount         common.Decimal `json:"amount"`
+}
+
+// ExecutionPayload is an execution payload in the encoding of the beacon API.
+// The blob gas fields are only present since Deneb.
+type ExecutionPayload struct {
+	ParentHash    common.Hash      `json:"parent_hash"`
+	FeeRecipient  common.Address   `json:"fee_recipient"`
+	StateRoot     common.Hash      `json:"state_root"`
+	ReceiptsRoot  common.Hash      `json:"receipts_root"`
+	LogsBloom     hexutil.Bytes    `json:"logs_bloom"`
+	PrevRandao    common.Hash      `json:"prev_randao"`
+	BlockNumber   common.Decimal   `json:"block_number"`
+	GasLimit      common.Decimal   `json:"gas_limit"`
+	GasUsed       common.Decimal   `json:"gas_used"`
+	Timestamp     common.Decimal   `json:"timestamp"`
+	ExtraData     hexutil.Bytes    `json:"extra_data"`
+	BaseFeePerGas *math.Decimal256 `json:"base_fee_per_gas"`
+	BlockHash     common.Hash      `json:"block_hash"`
+	Transactions  []hexutil.Bytes  `json:"transactions"`
+	Withdrawals   []*Withdrawal    `json:"withdrawals"`
+	BlobGasUsed   *common.Decimal  `json:"blob_gas_used,omitempty"`
+	ExcessBlobGas *common.Decimal  `json:"excess_blob_gas,omitempty"`
+}
+
+// newExecutionPayload converts an engine API payload into the beacon API encoding.
+func newExecutionPayload(data *engine.ExecutableData) *ExecutionPayload {
+	payload := &ExecutionPayload{
+		ParentHash:    data.ParentHash,
+		FeeRecipient:  data.FeeRecipient,
+		StateRoot:     data.StateRoot,
+		ReceiptsRoot:  data.ReceiptsRoot,
+		LogsBloom:     data.LogsBloom,
+		PrevRandao:    data.Random,
+		BlockNumber:   common.Decimal(data.Number),
+		GasLimit:      common.Decimal(data.GasLimit),
+		GasUsed:       common.Decimal(data.GasUsed),
+		Timestamp:     common.Decimal(data.Timestamp),
+		ExtraData:     data.ExtraData,
+		BaseFeePerGas: (*math.Decimal256)(data.BaseFeePerGas),
+		BlockHash:     data.BlockHash,
+		Transactions:  make([]hexutil.Bytes, len(data.Transactions)),
+		Withdrawals:   make([]*Withdrawal, len(data.Withdrawals)),
+	}
+	for i, tx := range data.Transactions {
+		payload.Transactions[i] = tx
+	}
+	for i, w := range data.Withdrawals {
+		payload.Withdrawals[i] = &Withdrawal{
+			Index:          common.Decimal(w.Index),
+			ValidatorIndex: common.Decimal(w.Validator),
+			Address:        w.Address,
+			Amount:         common.Decimal(w.Amount),
+		}
+	}
+	if data.BlobGasUsed != nil {
+		payload.BlobGasUsed = (*common.Decimal)(data.BlobGasUsed)
+		payload.ExcessBlobGas = (*common.Decimal)(data.ExcessBlobGas)
+	}
+	return payload
+}
+
+// executableData converts the payload into the engine API encoding.
+func (p *ExecutionPayload) executableData() *engine.ExecutableData {
+	data := &engine.ExecutableData{
+		ParentHash:    p.ParentHash,
+		FeeRecipient:  p.FeeRecipient,
+		StateRoot:     p.StateRoot,
+		ReceiptsRoot:  p.ReceiptsRoot,
+		LogsBloom:     p.LogsBloom,
+		Random:        p.PrevRandao,
+		Number:        uint64(p.BlockNumber),
+		GasLimit:      uint64(p.GasLimit),
+		GasUsed:       uint64(p.GasUsed),
+		Timestamp:     uint64(p.Timestamp),
+		ExtraData:     p.ExtraData,
+		BaseFeePerGas: (*big.Int)(p.BaseFeePerGas),
+		BlockHash:     p.BlockHash,
+		Transactions:  make([][]byte, len(p.Transactions)),
+		Withdrawals:   make([]*types.Withdrawal, len(p.Withdrawals)),
+		BlobGasUsed:   (*uint64)(p.BlobGasUsed),
+		ExcessBlobGas: (*uint64)(p.ExcessBlobGas),
+	}
+	for i, tx := range p.Transactions {
+		data.Transactions[i] = tx
+	}
+	for i, w := range p.Withdrawals {
+		data.Withdrawals[i] = &types.Withdrawal{
+			Index:     uint64(w.Index),
+			Validator: uint64(w.ValidatorIndex),
+			Address:   w.Address,
+			Amount:    uint64(w.Amount),
+		}
+	}
+	return data
+}
+
+// SubmitBlockRequest is a block submitted by the builder to a relay. The blobs
+// bundle is only present since Deneb.
+type SubmitBlockRequest struct {
+	Message          *BidTrace             `json:"message"`
+	ExecutionPayload *ExecutionPayload     `json:"execution_payload"`
+	BlobsBundle      *engine.BlobsBundleV1 `json:"blobs_bundle,omitempty"`
+	Signature        BLSSignature          `json:"signature"`
+}
+
+// ValidatorRegistration is the registration of a validator scheduled to propose
+// a block, as served by the relays.
+type ValidatorRegistration struct {
+	Slot           common.Decimal `json:"slot"`
+	ValidatorIndex common.Decimal `json:"validator_index"`
+	Entry          struct {
+		Message struct {
+			FeeRecipient common.Address `json:"fee_recipient"`
+			GasLimit     common.Decimal `json:"gas_limit"`
+			Timestamp    common.Decimal `json:"timestamp"`
+			Pubkey       BLSPubkey      `json:"pubkey"`
+		} `json:"message"`
+		Signature BLSSignature `json:"signature"`
+	} `json:"entry"`
+}
@@ -0,0 +1,175 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package builder
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/beacon/engine"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto/kzg4844"
+	"github.com/ethereum/go-ethereum/eth"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// BuilderBlockValidationRequest is a block submitted by a builder, along with the
+// parameters of the proposer needed for validating it.
+type BuilderBlockValidationRequest struct {
+	SubmitBlockRequest
+	RegisteredGasLimit    uint64       `json:"registered_gas_limit,string"`
+	ParentBeaconBlockRoot *common.Hash `json:"parent_beacon_block_root,omitempty"`
+}
+
+// ValidationAPI allows relays to validate the blocks submitted by builders.
+type ValidationAPI struct {
+	eth *eth.Ethereum
+}
+
+// RegisterValidationAPI adds the block validation API into the node, in the
+// flashbots namespace.
+func RegisterValidationAPI(stack *node.Node, backend *eth.Ethereum) {
+	stack.RegisterAPIs([]rpc.API{{
+		Namespace: "flashbots",
+		Service:   &ValidationAPI{eth: backend},
+	}})
+}
+
+// ValidateBuilderSubmissionV2 validates a Capella block submission.
+func (api *ValidationAPI) ValidateBuilderSubmissionV2(req *BuilderBlockValidationRequest) error {
+	if req.ExecutionPayload == nil {
+		return errors.New("missing execution payload")
+	}
+	if req.ExecutionPayload.BlobGasUsed != nil || req.ExecutionPayload.ExcessBlobGas != nil || req.ParentBeaconBlockRoot != nil {
+		return errors.New("unexpected Deneb fields in Capella submission")
+	}
+	return api.validate(req, nil, nil)
+}
+
+// ValidateBuilderSubmissionV3 validates a Deneb block submission.
+func (api *ValidationAPI) ValidateBuilderSubmissionV3(req *BuilderBlockValidationRequest) error {
+	if req.ExecutionPayload == nil {
+		return errors.New("missing execution payload")
+	}
+	if req.BlobsBundle == nil {
+		return errors.New("missing blobs bundle")
+	}
+	if req.ParentBeaconBlockRoot == nil {
+		return errors.New("missing parent beacon block root")
+	}
+	bundle := req.BlobsBundle
+	if len(bundle.Commitments) != len(bundle.Proofs) || len(bundle.Commitments) != len(bundle.Blobs) {
+		return errors.New("inconsistent blobs bundle")
+	}
+	sidecar := new(types.BlobTxSidecar)
+	for i := range bundle.Commitments {
+		var (
+			blob       kzg4844.Blob
+			commitment kzg4844.Commitment
+			proof      kzg4844.Proof
+		)
+		if len(bundle.Blobs[i]) != len(blob) || len(bundle.Commitments[i]) != len(commitment) || len(bundle.Proofs[i]) != len(proof) {
+			return fmt.Errorf("invalid blob %d encoding", i)
+		}
+		copy(blob[:], bundle.Blobs[i])
+		copy(commitment[:], bundle.Commitments[i])
+		copy(proof[:], bundle.Proofs[i])
+		if err := kzg4844.VerifyBlobProof(blob, commitment, proof); err != nil {
+			return fmt.Errorf("invalid blob %d: %v", i, err)
+		}
+		sidecar.Blobs = append(sidecar.Blobs, blob)
+		sidecar.Commitments = append(sidecar.Commitments, commitment)
+		sidecar.Proofs = append(sidecar.Proofs, proof)
+	}
+	hashes := sidecar.BlobHashes()
+	return api.validate(req, hashes, req.ParentBeaconBlockRoot)
+}
+
+// validate checks that a submitted block is valid on top of the local chain,
+// matches the bid, and pays the bid value to the fee recipient of the proposer.
+// The signature of the builder is not verified, that's up to the relay.
+func (api *ValidationAPI) validate(req *BuilderBlockValidationRequest, versionedHashes []common.Hash, beaconRoot *common.Hash) error {
+	msg := req.Message
+	if msg == nil || msg.Value == nil {
+		return errors.New("missing bid trace")
+	}
+	block, err := engine.ExecutableDataToBlock(*req.ExecutionPayload.executableData(), versionedHashes, beaconRoot)
+	if err != nil {
+		return err
+	}
+	switch {
+	case msg.BlockHash != block.Hash():
+		return fmt.Errorf("block hash mismatch: bid %x, block %x", msg.BlockHash, block.Hash())
+	case msg.ParentHash != block.ParentHash():
+		return fmt.Errorf("parent hash mismatch: bid %x, block %x", msg.ParentHash, block.ParentHash())
+	case uint64(msg.GasLimit) != block.GasLimit():
+		return fmt.Errorf("gas limit mismatch: bid %d, block %d", msg.GasLimit, block.GasLimit())
+	case uint64(msg.GasUsed) != block.GasUsed():
+		return fmt.Errorf("gas used mismatch: bid %d, block %d", msg.GasUsed, block.GasUsed())
+	}
+	chain := api.eth.BlockChain()
+	parent := chain.GetHeader(block.ParentHash(), block.NumberU64()-1)
+	if parent == nil {
+		return fmt.Errorf("unknown parent %x", block.ParentHash())
+	}
+	if want := core.CalcGasLimit(parent.GasLimit, req.RegisteredGasLimit); block.GasLimit() != want {
+		return fmt.Errorf("incorrect gas limit: have %d, want %d", block.GasLimit(), want)
+	}
+	if err := chain.Engine().VerifyHeader(chain, block.Header()); err != nil {
+		return err
+	}
+	if err := chain.Validator().ValidateBody(block); err != nil {
+		return err
+	}
+	statedb, err := chain.StateAt(parent.Root)
+	if err != nil {
+		return err
+	}
+	recipient := msg.ProposerFeeRecipient
+	before := statedb.GetBalance(recipient)
+
+	receipts, _, usedGas, err := chain.Processor().Process(block, statedb, vm.Config{})
+	if err != nil {
+		return err
+	}
+	if err := chain.Validator().ValidateState(block, statedb, receipts, usedGas); err != nil {
+		return err
+	}
+	// The proposer payment is the balance increase of its fee recipient, apart
+	// from the withdrawals.
+	payment := new(big.Int).Sub(statedb.GetBalance(recipient), before)
+	for _, w := range block.Withdrawals() {
+		if w.Address == recipient {
+			payment.Sub(payment, withdrawalValue(w))
+		}
+	}
+	if value := (*big.Int)(msg.Value); payment.Cmp(value) < 0 {
+		return fmt.Errorf("insufficient proposer payment: have %v, want %v", payment, value)
+	}
+	return nil
+}
+
+// withdrawalValue returns the amount of a withdrawal in wei.
+func withdrawalValue(w *types.Withdrawal) *big.Int {
+	return new(big.Int).Mul(new(big.Int).SetUint64(w.Amount), big.NewInt(params.GWei))
+}
@@ -799,6 +799,11 @@ func (api *ConsensusAPI) GetPayloadBodiesByRangeV1(start, count hexutil.Uint64)
 	if last > current {
 		last = current
 	}
+	// The block bodies below the history cutoff are pruned, refuse to serve
+	// them rather than responding with missing bodies.
+	if cutoff := api.eth.BlockChain().HistoryPruningCutoff(); uint64(start) < cutoff && uint64(start) <= last {
+		return nil, engine.PrunedHistory.With(fmt.Errorf("requested range starts below history cutoff %d", cutoff))
+	}
 	bodies := make([]*engine.ExecutionPayloadBodyV1, 0, uint64(count))
 	for i := uint64(start); i <= last; i++ {
 		block := api.eth.BlockChain().GetBlockByNumber(i)
@@ -0,0 +1,188 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package catalyst
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/beacon/engine"
+	"github.com/ethereum/go-ethereum/beacon/light"
+	lightapi "github.com/ethereum/go-ethereum/beacon/light/api"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/eth"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/node"
+)
+
+// blockFetchTimeout is the maximum time allowed for fetching a beacon block.
+const blockFetchTimeout = 10 * time.Second
+
+// LightSyncer is an auxiliary service that drives Geth through the engine API
+// from the heads validated by a beacon light client, allowing it to follow the
+// chain without a full consensus client attached. The execution payloads of the
+// new heads are fetched from the same beacon API the light client uses, and are
+// verified against the execution block hashes proven by the sync committee.
+type LightSyncer struct {
+	api    *ConsensusAPI
+	beacon *lightapi.BeaconLightApi
+	client *light.Client
+
+	lastHead common.Hash // Execution block hash of the last head sent to the engine API
+	closed   chan struct{}
+	wg       sync.WaitGroup
+}
+
+// NewLightSyncer creates a syncer driving the given backend from a beacon light
+// client using the given beacon API.
+func NewLightSyncer(backend *eth.Ethereum, config light.Config, beacon *lightapi.BeaconLightApi) *LightSyncer {
+	return &LightSyncer{
+		api:    newConsensusAPIWithoutHeartbeat(backend),
+		beacon: beacon,
+		client: light.NewClient(config, beacon),
+		closed: make(chan struct{}),
+	}
+}
+
+// RegisterLightSyncer registers the beacon light client syncer service into the
+// node stack for launching and stopping the service controlled by node.
+func RegisterLightSyncer(stack *node.Node, backend *eth.Ethereum, config light.Config, beacon *lightapi.BeaconLightApi) *LightSyncer {
+	syncer := NewLightSyncer(backend, config, beacon)
+	stack.RegisterLifecycle(syncer)
+	return syncer
+}
+
+// Start launches the light client and the head processing loop.
+func (s *LightSyncer) Start() error {
+	heads := make(chan light.HeadEvent, 16)
+	sub := s.client.SubscribeHeads(heads)
+	s.client.Start()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer sub.Unsubscribe()
+
+		for {
+			select {
+			case head := <-heads:
+				if err := s.updateHead(head); err != nil {
+					log.Warn("Failed to update head from beacon light client", "err", err)
+				}
+			case <-s.closed:
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop stops the light client and the head processing loop.
+func (s *LightSyncer) Stop() error {
+	close(s.closed)
+	s.wg.Wait()
+	s.client.Stop()
+	return nil
+}
+
+// updateHead imports the execution payload of the new beacon head if it is not
+// available locally yet and updates the forkchoice accordingly.
+func (s *LightSyncer) updateHead(head light.HeadEvent) error {
+	hash := head.Optimistic.Attested.BlockHash()
+	if hash == (common.Hash{}) {
+		return nil // pre-Capella head without execution payload header
+	}
+	var finalized common.Hash
+	if head.Finality != nil {
+		finalized = head.Finality.Finalized.BlockHash()
+	}
+	if hash == s.lastHead {
+		return s.forkchoiceUpdated(hash, finalized)
+	}
+	number := head.Optimistic.Attested.PayloadHeader.BlockNumber
+	if !s.api.eth.BlockChain().HasBlock(hash, number) {
+		if err := s.newPayload(head); err != nil {
+			return err
+		}
+	}
+	if err := s.forkchoiceUpdated(hash, finalized); err != nil {
+		return err
+	}
+	s.lastHead = hash
+	return nil
+}
+
+// newPayload fetches the execution payload of the beacon head and feeds it into
+// the engine API.
+func (s *LightSyncer) newPayload(head light.HeadEvent) error {
+	var (
+		header = head.Optimistic.Attested.Header
+		hash   = head.Optimistic.Attested.BlockHash()
+	)
+	ctx, cancel := context.WithTimeout(context.Background(), blockFetchTimeout)
+	defer cancel()
+
+	block, err := s.beacon.GetExecutionBlock(ctx, header.Hash())
+	if err != nil {
+		return fmt.Errorf("failed to fetch beacon block %d: %w", header.Slot, err)
+	}
+	// The payload is only trusted if it matches the block hash proven by the
+	// sync committee. The block hash itself commits to all payload fields.
+	if block.Payload.BlockHash != hash {
+		return fmt.Errorf("execution block hash mismatch: have %x, want %x", block.Payload.BlockHash, hash)
+	}
+	var status engine.PayloadStatusV1
+	switch {
+	case block.Payload.BlobGasUsed != nil:
+		status, err = s.api.NewPayloadV3(*block.Payload, block.VersionedHashes(), &header.ParentRoot)
+	case block.Payload.Withdrawals != nil:
+		status, err = s.api.NewPayloadV2(*block.Payload)
+	default:
+		status, err = s.api.NewPayloadV1(*block.Payload)
+	}
+	if err != nil {
+		return err
+	}
+	if status.Status == engine.INVALID {
+		var reason string
+		if status.ValidationError != nil {
+			reason = *status.ValidationError
+		}
+		return fmt.Errorf("invalid execution payload %d (%x): %s", block.Payload.Number, hash, reason)
+	}
+	log.Debug("Submitted execution payload from beacon light client", "number", block.Payload.Number, "hash", hash, "status", status.Status)
+	return nil
+}
+
+// forkchoiceUpdated sets the head and the finalized block through the engine API.
+func (s *LightSyncer) forkchoiceUpdated(head, finalized common.Hash) error {
+	update := engine.ForkchoiceStateV1{
+		HeadBlockHash:      head,
+		SafeBlockHash:      finalized,
+		FinalizedBlockHash: finalized,
+	}
+	resp, err := s.api.ForkchoiceUpdatedV2(update, nil)
+	if err != nil {
+		return err
+	}
+	if resp.PayloadStatus.Status == engine.INVALID {
+		return fmt.Errorf("forkchoice update to %x rejected", head)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package catalyst
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/beacon/engine"
+	"github.com/ethereum/go-ethereum/beacon/light"
+	lightapi "github.com/ethereum/go-ethereum/beacon/light/api"
+	"github.com/ethereum/go-ethereum/beacon/params"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth"
+	"github.com/ethereum/go-ethereum/internal/beacontest"
+)
+
+// Tests that the light syncer imports the execution payloads of the heads
+// validated by the beacon light client and updates the forkchoice.
+func TestLightSyncer(t *testing.T) {
+	genesis, preMergeBlocks := generateMergeChain(10, false)
+	n, ethservice := startEthService(t, genesis, preMergeBlocks)
+	defer n.Close()
+
+	var (
+		api        = newConsensusAPIWithoutHeartbeat(ethservice)
+		chain      = beacontest.NewChain()
+		server     = beacontest.NewServer()
+		checkpoint = chain.NewHeader(100, common.Hash{}, nil, nil)
+	)
+	defer server.Close()
+	server.AddBootstrap(chain.Bootstrap(checkpoint))
+
+	// Assemble two payloads on top of the local chain without importing them,
+	// and serve them from the fake beacon node.
+	payload1 := getNewPayload(t, api, ethservice.BlockChain().CurrentBlock(), nil)
+	block1, err := engine.ExecutableDataToBlock(*payload1, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to convert payload: %v", err)
+	}
+	head1 := chain.NewHeader(110, checkpoint.Header.Hash(), beacontest.ExecutionHeader(block1), nil)
+	server.AddBlock(head1.Header.Hash(), &lightapi.ExecutionBlock{Slot: 110, ParentRoot: checkpoint.Header.Hash(), Payload: payload1})
+	server.SetOptimistic(chain.Optimistic(head1, params.SyncCommitteeSize))
+
+	syncer := NewLightSyncer(ethservice, light.Config{
+		ChainConfig:     *chain.Config,
+		Checkpoint:      checkpoint.Header.Hash(),
+		SignerThreshold: light.DefaultSignerThreshold,
+	}, lightapi.NewBeaconLightApi(server.URL, nil))
+	if err := syncer.Start(); err != nil {
+		t.Fatalf("failed to start light syncer: %v", err)
+	}
+	defer syncer.Stop()
+
+	waitForHead(t, ethservice, block1.Hash())
+
+	// The second head finalizes the first one
+	payload2 := getNewPayload(t, api, block1.Header(), nil)
+	block2, err := engine.ExecutableDataToBlock(*payload2, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to convert payload: %v", err)
+	}
+	head2 := chain.NewHeader(120, head1.Header.Hash(), beacontest.ExecutionHeader(block2), &head1.Header)
+	server.AddBlock(head2.Header.Hash(), &lightapi.ExecutionBlock{Slot: 120, ParentRoot: head1.Header.Hash(), Payload: payload2})
+	server.SetFinality(chain.Finality(head2, head1, params.SyncCommitteeSize))
+
+	waitForHead(t, ethservice, block2.Hash())
+	if final := ethservice.BlockChain().CurrentFinalBlock(); final == nil || final.Hash() != block1.Hash() {
+		t.Fatalf("finalized block not updated: have %v, want %x", final, block1.Hash())
+	}
+	// Heads with a payload not matching the proven block hash are ignored
+	payload3 := getNewPayload(t, api, block2.Header(), nil)
+	block3, err := engine.ExecutableDataToBlock(*payload3, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to convert payload: %v", err)
+	}
+	forged := *payload3
+	forged.BlockHash = common.Hash{0xff}
+	head3 := chain.NewHeader(130, head2.Header.Hash(), beacontest.ExecutionHeader(block3), nil)
+	server.AddBlock(head3.Header.Hash(), &lightapi.ExecutionBlock{Slot: 130, ParentRoot: head2.Header.Hash(), Payload: &forged})
+	server.SetOptimistic(chain.Optimistic(head3, params.SyncCommitteeSize))
+
+	for deadline := time.Now().Add(20 * time.Second); server.Requests("/eth/v2/beacon/blocks/") < 3; time.Sleep(50 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("forged payload not requested")
+		}
+	}
+	if head := ethservice.BlockChain().CurrentBlock(); head.Hash() != block2.Hash() {
+		t.Fatalf("head changed to forged payload: %d (%x)", head.Number, head.Hash())
+	}
+}
+
+// waitForHead waits until the chain head becomes the given block.
+func waitForHead(t *testing.T, ethservice *eth.Ethereum, hash common.Hash) {
+	t.Helper()
+
+	var head *types.Header
+	for deadline := time.Now().Add(20 * time.Second); time.Now().Before(deadline); time.Sleep(50 * time.Millisecond) {
+		if head = ethservice.BlockChain().CurrentBlock(); head.Hash() == hash {
+			return
+		}
+	}
+	t.Fatalf("chain head not updated: have %d (%x), want %x", head.Number, head.Hash(), hash)
+}
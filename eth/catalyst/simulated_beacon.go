@@ -19,17 +19,25 @@ package catalyst
 import (
 	"crypto/rand"
 	"errors"
+	"fmt"
+	"math/big"
+	"slices"
 	"sync"
 	"time"
 
 	"github.com/ethereum/go-ethereum/beacon/engine"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/misc/eip1559"
+	"github.com/ethereum/go-ethereum/consensus/misc/eip4844"
 	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/eth"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/node"
 	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
 )
 
 const devEpochLength = 32
@@ -78,7 +86,20 @@ type SimulatedBeacon struct {
 
 	engineAPI          *ConsensusAPI
 	curForkchoiceState engine.ForkchoiceStateV1
-	lastBlockTime      uint64
+	sealLock           sync.Mutex // lock serializing block production
+
+	devLock      sync.Mutex    // lock protecting the dev mode controls below
+	timeOffset   int64         // seconds added to the wall clock time of new blocks
+	snapshots    []devSnapshot // chain snapshots which can be reverted to
+	lastSnapshot uint64        // id of the latest snapshot taken
+	impersonator *impersonator // wallet signing for impersonated accounts
+}
+
+// devSnapshot is a recorded chain head which can be reverted to.
+type devSnapshot struct {
+	id         uint64
+	head       common.Hash
+	timeOffset int64
 }
 
 func NewSimulatedBeacon(period uint64, eth *eth.Ethereum) (*SimulatedBeacon, error) {
@@ -95,19 +116,25 @@ func NewSimulatedBeacon(period uint64, eth *eth.Ethereum) (*SimulatedBeacon, err
 	engineAPI := newConsensusAPIWithoutHeartbeat(eth)
 
 	// if genesis block, send forkchoiceUpdated to trigger transition to PoS
-	if block.Number.Sign() == 0 {
+	if block.Hash() == eth.BlockChain().Genesis().Hash() {
 		if _, err := engineAPI.ForkchoiceUpdatedV2(current, nil); err != nil {
 			return nil, err
 		}
 	}
+	// Register the wallet of impersonated accounts, for use by eth_sendTransaction,
+	// and let the signers of the chain resolve their transactions.
+	impersonator := newImpersonator(eth.ChainDb())
+	eth.AccountManager().AddBackend(impersonator)
+	eth.BlockChain().Config().Impersonation = impersonator
+
 	return &SimulatedBeacon{
 		eth:                eth,
 		period:             period,
 		shutdownCh:         make(chan struct{}),
 		engineAPI:          engineAPI,
-		lastBlockTime:      block.Time,
 		curForkchoiceState: current,
 		withdrawals:        withdrawalQueue{make(chan *types.Withdrawal, 20)},
+		impersonator:       impersonator,
 	}, nil
 }
 
@@ -133,23 +160,61 @@ func (c *SimulatedBeacon) Stop() error {
 	return nil
 }
 
+// timestamp returns the time of the next block, which is the wall clock time
+// shifted by the dev mode time offset.
+func (c *SimulatedBeacon) timestamp() uint64 {
+	c.devLock.Lock()
+	defer c.devLock.Unlock()
+
+	return uint64(time.Now().Unix() + c.timeOffset)
+}
+
 // sealBlock initiates payload building for a new block and creates a new block
-// with the completed payload.
-func (c *SimulatedBeacon) sealBlock(withdrawals []*types.Withdrawal) error {
-	tstamp := uint64(time.Now().Unix())
-	if tstamp <= c.lastBlockTime {
-		tstamp = c.lastBlockTime + 1
+// with the completed payload. The timestamp is raised above the one of the
+// parent if necessary.
+func (c *SimulatedBeacon) sealBlock(withdrawals []*types.Withdrawal, tstamp uint64) error {
+	c.sealLock.Lock()
+	defer c.sealLock.Unlock()
+
+	return c.seal(withdrawals, tstamp)
+}
+
+// sealPending seals a new block if there are executable transactions. Events of
+// new transactions may be stale, e.g. if the pool was cleared since.
+func (c *SimulatedBeacon) sealPending() error {
+	c.sealLock.Lock()
+	defer c.sealLock.Unlock()
+
+	if pending, _ := c.eth.TxPool().Stats(); pending == 0 {
+		return nil
 	}
+	return c.seal(c.withdrawals.gatherPending(10), c.timestamp())
+}
+
+// seal is the implementation of sealBlock. The caller must hold sealLock.
+func (c *SimulatedBeacon) seal(withdrawals []*types.Withdrawal, tstamp uint64) error {
 	c.feeRecipientLock.Lock()
 	feeRecipient := c.feeRecipient
 	c.feeRecipientLock.Unlock()
 
 	// Reset to CurrentBlock in case of the chain was rewound
-	if header := c.eth.BlockChain().CurrentBlock(); c.curForkchoiceState.HeadBlockHash != header.Hash() {
+	header := c.eth.BlockChain().CurrentBlock()
+	if c.curForkchoiceState.HeadBlockHash != header.Hash() {
 		finalizedHash := c.finalizedBlockHash(header.Number.Uint64())
 		c.setCurrentState(header.Hash(), *finalizedHash)
 	}
-
+	if tstamp <= header.Time {
+		tstamp = header.Time + 1
+	}
+	// Make sure all transactions added so far are executable by the miner
+	if err := c.eth.TxPool().Sync(); err != nil {
+		return err
+	}
+	// Cancun blocks commit to the beacon root of their parent
+	var beaconRoot *common.Hash
+	if c.eth.BlockChain().Config().IsCancun(new(big.Int).Add(header.Number, common.Big1), tstamp) {
+		beaconRoot = &common.Hash{}
+	}
 	var random [32]byte
 	rand.Read(random[:])
 	fcResponse, err := c.engineAPI.ForkchoiceUpdatedV2(c.curForkchoiceState, &engine.PayloadAttributes{
@@ -157,6 +222,7 @@ func (c *SimulatedBeacon) sealBlock(withdrawals []*types.Withdrawal) error {
 		SuggestedFeeRecipient: feeRecipient,
 		Withdrawals:           withdrawals,
 		Random:                random,
+		BeaconRoot:            beaconRoot,
 	})
 	if err != nil {
 		return err
@@ -182,16 +248,47 @@ func (c *SimulatedBeacon) sealBlock(withdrawals []*types.Withdrawal) error {
 		}
 	}
 
+	var (
+		blobHashes   = make([]common.Hash, 0)
+		impersonated types.Transactions
+	)
+	for _, enc := range payload.Transactions {
+		tx := new(types.Transaction)
+		if err := tx.UnmarshalBinary(enc); err != nil {
+			return err
+		}
+		blobHashes = append(blobHashes, tx.BlobHashes()...)
+		if c.impersonator.signed(tx.Hash()) {
+			impersonated = append(impersonated, tx)
+		}
+	}
+	// Persist the senders of impersonated transactions, which can't be recovered
+	// from the payload, before the chain processes it.
+	if len(impersonated) > 0 {
+		c.impersonator.persist(impersonated)
+	}
 	// Mark the payload as canon
-	if _, err = c.engineAPI.NewPayloadV2(*payload); err != nil {
+	var status engine.PayloadStatusV1
+	if beaconRoot == nil {
+		status, err = c.engineAPI.NewPayloadV2(*payload)
+	} else {
+		status, err = c.engineAPI.NewPayloadV3(*payload, blobHashes, beaconRoot)
+	}
+	if err != nil {
 		return err
 	}
+	if status.Status == engine.INVALID {
+		var reason string
+		if status.ValidationError != nil {
+			reason = *status.ValidationError
+		}
+		return fmt.Errorf("invalid payload: %s", reason)
+	}
 	c.setCurrentState(payload.BlockHash, finalizedHash)
 	// Mark the block containing the payload as canonical
 	if _, err = c.engineAPI.ForkchoiceUpdatedV2(c.curForkchoiceState, nil); err != nil {
 		return err
 	}
-	c.lastBlockTime = payload.Timestamp
 	return nil
 }
 
@@ -209,12 +306,11 @@ func (c *SimulatedBeacon) loopOnDemand() {
 			return
 		case w := <-c.withdrawals.pending:
 			withdrawals := append(c.withdrawals.gatherPending(9), w)
-			if err := c.sealBlock(withdrawals); err != nil {
+			if err := c.sealBlock(withdrawals, c.timestamp()); err != nil {
 				log.Warn("Error performing sealing work", "err", err)
 			}
 		case <-newTxs:
-			withdrawals := c.withdrawals.gatherPending(10)
-			if err := c.sealBlock(withdrawals); err != nil {
+			if err := c.sealPending(); err != nil {
 				log.Warn("Error performing sealing work", "err", err)
 			}
 		}
@@ -230,7 +326,7 @@ func (c *SimulatedBeacon) loop() {
 			return
 		case <-timer.C:
 			withdrawals := c.withdrawals.gatherPending(10)
-			if err := c.sealBlock(withdrawals); err != nil {
+			if err := c.sealBlock(withdrawals, c.timestamp()); err != nil {
 				log.Warn("Error performing sealing work", "err", err)
 			} else {
 				timer.Reset(time.Second * time.Duration(c.period))
@@ -239,6 +335,238 @@ func (c *SimulatedBeacon) loop() {
 	}
 }
 
+// Commit seals a block on demand, including the pending transactions and queued
+// withdrawals, and returns the hash of the new head.
+func (c *SimulatedBeacon) Commit() common.Hash {
+	withdrawals := c.withdrawals.gatherPending(10)
+	if err := c.sealBlock(withdrawals, c.timestamp()); err != nil {
+		log.Warn("Error performing sealing work", "err", err)
+	}
+	return c.eth.BlockChain().CurrentBlock().Hash()
+}
+
+// Rollback drops all pending transactions, reverting to the state of the
+// current head.
+func (c *SimulatedBeacon) Rollback() {
+	c.eth.TxPool().Clear()
+	c.impersonator.clear()
+}
+
+// Fork rewinds the chain to the given ancestor block. Subsequent blocks are
+// built on top of it, which can be used to simulate reorgs. Forking requires
+// the transaction pool to be empty.
+func (c *SimulatedBeacon) Fork(parentHash common.Hash) error {
+	if err := c.eth.TxPool().Sync(); err != nil {
+		return err
+	}
+	if pending, queued := c.eth.TxPool().Stats(); pending+queued != 0 {
+		return errors.New("pending block dirty")
+	}
+	parent := c.eth.BlockChain().GetBlockByHash(parentHash)
+	if parent == nil {
+		return errors.New("parent not found")
+	}
+	return c.eth.BlockChain().SetHead(parent.NumberU64())
+}
+
+// AdjustTime seals a new empty block, whose timestamp is later than the one of
+// the current head by the given duration.
+func (c *SimulatedBeacon) AdjustTime(adjustment time.Duration) error {
+	if err := c.eth.TxPool().Sync(); err != nil {
+		return err
+	}
+	if pending, _ := c.eth.TxPool().Stats(); pending != 0 {
+		return errors.New("could not adjust time on non-empty block")
+	}
+	parent := c.eth.BlockChain().CurrentBlock()
+	withdrawals := c.withdrawals.gatherPending(10)
+	return c.sealBlock(withdrawals, parent.Time+uint64(adjustment/time.Second))
+}
+
+// Snapshot records the current chain head and time offset, returning an id which
+// can be passed to Revert.
+func (c *SimulatedBeacon) Snapshot() uint64 {
+	c.devLock.Lock()
+	defer c.devLock.Unlock()
+
+	c.lastSnapshot++
+	c.snapshots = append(c.snapshots, devSnapshot{
+		id:         c.lastSnapshot,
+		head:       c.eth.BlockChain().CurrentBlock().Hash(),
+		timeOffset: c.timeOffset,
+	})
+	return c.lastSnapshot
+}
+
+// Revert rewinds the chain to the given snapshot and drops all pending
+// transactions. The snapshot and all later ones are removed. False is returned
+// if the snapshot doesn't exist.
+func (c *SimulatedBeacon) Revert(id uint64) (bool, error) {
+	c.sealLock.Lock()
+	defer c.sealLock.Unlock()
+	c.devLock.Lock()
+	defer c.devLock.Unlock()
+
+	index := slices.IndexFunc(c.snapshots, func(snap devSnapshot) bool { return snap.id == id })
+	if index < 0 {
+		return false, nil
+	}
+	snap := c.snapshots[index]
+
+	chain := c.eth.BlockChain()
+	header := chain.GetHeaderByHash(snap.head)
+	if header == nil || chain.GetCanonicalHash(header.Number.Uint64()) != snap.head {
+		return false, errors.New("snapshot block is not canonical")
+	}
+	if err := chain.SetHead(header.Number.Uint64()); err != nil {
+		return false, err
+	}
+	// The pool reinjects the transactions of the dropped blocks, wait for the
+	// reset before clearing it.
+	if err := c.eth.TxPool().Sync(); err != nil {
+		return false, err
+	}
+	c.eth.TxPool().Clear()
+	c.impersonator.clear()
+
+	c.snapshots = c.snapshots[:index]
+	c.timeOffset = snap.timeOffset
+	return true, nil
+}
+
+// IncreaseTime moves the clock of future blocks forward by the given number of
+// seconds. It returns the total time offset.
+func (c *SimulatedBeacon) IncreaseTime(seconds uint64) int64 {
+	c.devLock.Lock()
+	defer c.devLock.Unlock()
+
+	c.timeOffset += int64(seconds)
+	return c.timeOffset
+}
+
+// Mine seals a new block with the pending transactions and returns its hash. If
+// a timestamp is given, the block is sealed at that time and the clock of future
+// blocks continues from there.
+func (c *SimulatedBeacon) Mine(timestamp *uint64) (common.Hash, error) {
+	c.sealLock.Lock()
+	defer c.sealLock.Unlock()
+
+	tstamp := c.timestamp()
+	if timestamp != nil {
+		if parent := c.eth.BlockChain().CurrentBlock(); *timestamp <= parent.Time {
+			return common.Hash{}, fmt.Errorf("timestamp %d not after parent timestamp %d", *timestamp, parent.Time)
+		}
+		tstamp = *timestamp
+
+		c.devLock.Lock()
+		c.timeOffset = int64(tstamp) - time.Now().Unix()
+		c.devLock.Unlock()
+	}
+	if err := c.seal(c.withdrawals.gatherPending(10), tstamp); err != nil {
+		return common.Hash{}, err
+	}
+	return c.eth.BlockChain().CurrentBlock().Hash(), nil
+}
+
+// SetBalance sets the balance of an account, sealing a new block with the change.
+func (c *SimulatedBeacon) SetBalance(addr common.Address, balance *big.Int) error {
+	return c.editState(func(state *state.StateDB) {
+		state.SetBalance(addr, balance)
+	})
+}
+
+// SetCode sets the code of an account, sealing a new block with the change.
+func (c *SimulatedBeacon) SetCode(addr common.Address, code []byte) error {
+	return c.editState(func(state *state.StateDB) {
+		state.SetCode(addr, code)
+	})
+}
+
+// SetStorageAt sets a storage slot of an account, sealing a new block with the
+// change.
+func (c *SimulatedBeacon) SetStorageAt(addr common.Address, slot, value common.Hash) error {
+	return c.editState(func(state *state.StateDB) {
+		state.SetState(addr, slot, value)
+	})
+}
+
+// editState seals a new block without transactions, whose state is modified
+// directly. As the modification can't be expressed by transactions, the block is
+// written to the chain along with its state, instead of being processed via the
+// engine API. Other nodes can't validate such blocks.
+func (c *SimulatedBeacon) editState(edit func(*state.StateDB)) error {
+	c.sealLock.Lock()
+	defer c.sealLock.Unlock()
+
+	c.feeRecipientLock.Lock()
+	feeRecipient := c.feeRecipient
+	c.feeRecipientLock.Unlock()
+
+	var (
+		chain  = c.eth.BlockChain()
+		config = chain.Config()
+		parent = chain.CurrentBlock()
+	)
+	statedb, err := chain.StateAt(parent.Root)
+	if err != nil {
+		return err
+	}
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Coinbase:   feeRecipient,
+		Difficulty: common.Big0,
+		Number:     new(big.Int).Add(parent.Number, common.Big1),
+		GasLimit:   parent.GasLimit,
+		Time:       max(c.timestamp(), parent.Time+1),
+	}
+	rand.Read(header.MixDigest[:])
+	if config.IsLondon(header.Number) {
+		header.BaseFee = eip1559.CalcBaseFee(config, parent)
+	}
+	var withdrawals []*types.Withdrawal
+	if config.IsShanghai(header.Number, header.Time) {
+		withdrawals = make([]*types.Withdrawal, 0)
+	}
+	if config.IsCancun(header.Number, header.Time) {
+		var excessBlobGas uint64
+		if config.IsCancun(parent.Number, parent.Time) {
+			excessBlobGas = eip4844.CalcExcessBlobGas(*parent.ExcessBlobGas, *parent.BlobGasUsed)
+		}
+		header.ExcessBlobGas, header.BlobGasUsed = &excessBlobGas, new(uint64)
+		header.ParentBeaconRoot = new(common.Hash)
+
+		vmenv := vm.NewEVM(core.NewEVMBlockContext(header, chain, nil), vm.TxContext{}, statedb, config, vm.Config{})
+		core.ProcessBeaconBlockRoot(*header.ParentBeaconRoot, vmenv, statedb)
+	}
+	edit(statedb)
+	header.Root = statedb.IntermediateRoot(config.IsEIP158(header.Number))
+
+	block := types.NewBlockWithWithdrawals(header, nil, nil, nil, withdrawals, trie.NewStackTrie(nil))
+	if _, err := chain.WriteBlockAndSetHead(block, nil, nil, statedb, true); err != nil {
+		return err
+	}
+	finalizedHash := c.finalizedBlockHash(block.NumberU64())
+	if finalizedHash == nil {
+		return errors.New("chain rewind interrupted calculation of finalized block hash")
+	}
+	c.setCurrentState(block.Hash(), *finalizedHash)
+	return nil
+}
+
+// ImpersonateAccount allows sending transactions on behalf of the given account
+// via eth_sendTransaction, without having its key. The senders of these
+// transactions are persisted when they are sealed, so they are only known to the
+// node which sent them.
+func (c *SimulatedBeacon) ImpersonateAccount(addr common.Address) {
+	c.impersonator.add(addr)
+}
+
+// StopImpersonatingAccount revokes the impersonation of the given account. The
+// transactions already sent on its behalf are unaffected.
+func (c *SimulatedBeacon) StopImpersonatingAccount(addr common.Address) {
+	c.impersonator.remove(addr)
+}
+
 // finalizedBlockHash returns the block hash of the finalized block corresponding to the given number
 // or nil if doesn't exist in the chain.
 func (c *SimulatedBeacon) finalizedBlockHash(number uint64) *common.Hash {
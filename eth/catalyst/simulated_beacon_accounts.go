@@ -0,0 +1,260 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package catalyst
+
+import (
+	"fmt"
+	"math/big"
+	"slices"
+	"sync"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// impersonator is an account backend with a single wallet, which signs the
+// transactions of impersonated accounts without their keys. Instead of a
+// signature, the transactions carry the sender address. Their senders are only
+// accepted as recorded by the impersonator, so impersonated transactions from
+// other sources are rejected as invalid.
+//
+// The impersonator is the impersonation oracle of the chain config, so all the
+// signers of the chain resolve the recorded senders. The senders of sealed
+// transactions are persisted in the database.
+type impersonator struct {
+	db       ethdb.Database
+	accounts map[common.Address]struct{}
+	sent     map[common.Hash]common.Address // transactions signed, until sealed
+	lock     sync.RWMutex
+}
+
+func newImpersonator(db ethdb.Database) *impersonator {
+	return &impersonator{
+		db:       db,
+		accounts: make(map[common.Address]struct{}),
+		sent:     make(map[common.Hash]common.Address),
+	}
+}
+
+// ImpersonatedSender implements params.ImpersonationOracle, returning the sender
+// of a transaction sent on behalf of an impersonated account.
+func (imp *impersonator) ImpersonatedSender(hash common.Hash) (common.Address, bool) {
+	imp.lock.RLock()
+	addr, ok := imp.sent[hash]
+	imp.lock.RUnlock()
+
+	if ok {
+		return addr, true
+	}
+	return rawdb.ReadImpersonatedSender(imp.db, hash)
+}
+
+// impersonatedSignature returns the placeholder signature of transactions sent
+// on behalf of an account. The address is stored in R and S is set to one, which
+// real signatures don't produce.
+func impersonatedSignature(addr common.Address) []byte {
+	sig := make([]byte, crypto.SignatureLength)
+	copy(sig[32-common.AddressLength:32], addr[:])
+	sig[63] = 1
+	return sig
+}
+
+// impersonatedSigner is the signer of transactions sent on behalf of impersonated
+// accounts. It only accepts the transactions signed by the impersonator. It's
+// used to cache the senders of signed transactions, for the transaction pool
+// which was created with the signer before the impersonator existed.
+type impersonatedSigner struct {
+	types.Signer
+	imp *impersonator
+}
+
+// Equal implements types.Signer. The sender recorded by this signer is accepted
+// by all signers of the chain.
+func (s impersonatedSigner) Equal(s2 types.Signer) bool {
+	id := s2.ChainID()
+	return id != nil && id.Cmp(s.ChainID()) == 0
+}
+
+// Sender implements types.Signer, returning the impersonated account of the
+// transactions signed by the impersonator.
+func (s impersonatedSigner) Sender(tx *types.Transaction) (common.Address, error) {
+	s.imp.lock.RLock()
+	addr, ok := s.imp.sent[tx.Hash()]
+	s.imp.lock.RUnlock()
+
+	if !ok {
+		return s.Signer.Sender(tx)
+	}
+	return addr, nil
+}
+
+// recordSender caches the sender of a transaction sent on behalf of an
+// impersonated account, which is used by all signers of the chain instead of
+// recovering it from the signature.
+func (imp *impersonator) recordSender(tx *types.Transaction) {
+	if imp.signed(tx.Hash()) {
+		types.Sender(impersonatedSigner{types.LatestSignerForChainID(tx.ChainId()), imp}, tx)
+	}
+}
+
+// signed reports whether the transaction with the given hash was signed by the
+// impersonator and not sealed yet.
+func (imp *impersonator) signed(hash common.Hash) bool {
+	imp.lock.RLock()
+	defer imp.lock.RUnlock()
+	_, ok := imp.sent[hash]
+	return ok
+}
+
+// persist stores the senders of the given transactions signed by the impersonator
+// in the database when they are sealed, so they outlive the node.
+func (imp *impersonator) persist(txs types.Transactions) {
+	imp.lock.Lock()
+	defer imp.lock.Unlock()
+
+	batch := imp.db.NewBatch()
+	for _, tx := range txs {
+		if addr, ok := imp.sent[tx.Hash()]; ok {
+			rawdb.WriteImpersonatedSender(batch, tx.Hash(), addr)
+			delete(imp.sent, tx.Hash())
+		}
+	}
+	if err := batch.Write(); err != nil {
+		log.Crit("Failed to persist impersonated senders", "err", err)
+	}
+}
+
+// clear drops the senders of the transactions which weren't sealed, after they
+// were removed from the transaction pool.
+func (imp *impersonator) clear() {
+	imp.lock.Lock()
+	defer imp.lock.Unlock()
+	imp.sent = make(map[common.Hash]common.Address)
+}
+
+func (imp *impersonator) add(addr common.Address) {
+	imp.lock.Lock()
+	defer imp.lock.Unlock()
+	imp.accounts[addr] = struct{}{}
+}
+
+func (imp *impersonator) remove(addr common.Address) {
+	imp.lock.Lock()
+	defer imp.lock.Unlock()
+	delete(imp.accounts, addr)
+}
+
+// Wallets implements accounts.Backend, returning the impersonator itself.
+func (imp *impersonator) Wallets() []accounts.Wallet {
+	return []accounts.Wallet{imp}
+}
+
+// Subscribe implements accounts.Backend. The wallet never changes, only the
+// accounts it contains, so no events are sent.
+func (imp *impersonator) Subscribe(sink chan<- accounts.WalletEvent) event.Subscription {
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		<-quit
+		return nil
+	})
+}
+
+func (imp *impersonator) URL() accounts.URL {
+	return accounts.URL{Scheme: "impersonated"}
+}
+
+func (imp *impersonator) Status() (string, error) {
+	imp.lock.RLock()
+	defer imp.lock.RUnlock()
+	return fmt.Sprintf("impersonating %d accounts", len(imp.accounts)), nil
+}
+
+func (imp *impersonator) Open(passphrase string) error { return nil }
+
+func (imp *impersonator) Close() error { return nil }
+
+func (imp *impersonator) Accounts() []accounts.Account {
+	imp.lock.RLock()
+	defer imp.lock.RUnlock()
+
+	accs := make([]accounts.Account, 0, len(imp.accounts))
+	for addr := range imp.accounts {
+		accs = append(accs, accounts.Account{Address: addr, URL: imp.URL()})
+	}
+	slices.SortFunc(accs, func(a, b accounts.Account) int {
+		return a.Address.Cmp(b.Address)
+	})
+	return accs
+}
+
+func (imp *impersonator) Contains(account accounts.Account) bool {
+	imp.lock.RLock()
+	defer imp.lock.RUnlock()
+	_, ok := imp.accounts[account.Address]
+	return ok
+}
+
+func (imp *impersonator) Derive(path accounts.DerivationPath, pin bool) (accounts.Account, error) {
+	return accounts.Account{}, accounts.ErrNotSupported
+}
+
+func (imp *impersonator) SelfDerive(bases []accounts.DerivationPath, chain ethereum.ChainStateReader) {
+}
+
+func (imp *impersonator) SignData(account accounts.Account, mimeType string, data []byte) ([]byte, error) {
+	return nil, accounts.ErrNotSupported
+}
+
+func (imp *impersonator) SignDataWithPassphrase(account accounts.Account, passphrase, mimeType string, data []byte) ([]byte, error) {
+	return nil, accounts.ErrNotSupported
+}
+
+func (imp *impersonator) SignText(account accounts.Account, text []byte) ([]byte, error) {
+	return nil, accounts.ErrNotSupported
+}
+
+func (imp *impersonator) SignTextWithPassphrase(account accounts.Account, passphrase string, hash []byte) ([]byte, error) {
+	return nil, accounts.ErrNotSupported
+}
+
+// SignTx implements accounts.Wallet, attaching the impersonated signature of the
+// account to the transaction and recording its sender.
+func (imp *impersonator) SignTx(account accounts.Account, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	if !imp.Contains(account) {
+		return nil, accounts.ErrUnknownAccount
+	}
+	signed, err := tx.WithSignature(types.LatestSignerForChainID(chainID), impersonatedSignature(account.Address))
+	if err != nil {
+		return nil, err
+	}
+	imp.lock.Lock()
+	imp.sent[signed.Hash()] = account.Address
+	imp.lock.Unlock()
+
+	imp.recordSender(signed)
+	return signed, nil
+}
+
+func (imp *impersonator) SignTxWithPassphrase(account accounts.Account, passphrase string, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return imp.SignTx(account, tx, chainID)
+}
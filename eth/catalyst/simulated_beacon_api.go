@@ -20,6 +20,7 @@ import (
 	"context"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
 )
 
@@ -34,3 +35,51 @@ func (a *api) AddWithdrawal(ctx context.Context, withdrawal *types.Withdrawal) e
 func (a *api) SetFeeRecipient(ctx context.Context, feeRecipient common.Address) {
 	a.simBeacon.setFeeRecipient(feeRecipient)
 }
+
+// Snapshot records the current chain head, returning the id to revert to it.
+func (a *api) Snapshot(ctx context.Context) hexutil.Uint64 {
+	return hexutil.Uint64(a.simBeacon.Snapshot())
+}
+
+// Revert rewinds the chain to the given snapshot, dropping all blocks and
+// transactions since. It returns false if the snapshot doesn't exist.
+func (a *api) Revert(ctx context.Context, id hexutil.Uint64) (bool, error) {
+	return a.simBeacon.Revert(uint64(id))
+}
+
+// IncreaseTime moves the timestamps of future blocks forward by the given number
+// of seconds. It returns the total time offset in seconds.
+func (a *api) IncreaseTime(ctx context.Context, seconds hexutil.Uint64) int64 {
+	return a.simBeacon.IncreaseTime(uint64(seconds))
+}
+
+// Mine seals a new block, optionally at the given timestamp.
+func (a *api) Mine(ctx context.Context, timestamp *hexutil.Uint64) (common.Hash, error) {
+	return a.simBeacon.Mine((*uint64)(timestamp))
+}
+
+// SetBalance sets the balance of an account.
+func (a *api) SetBalance(ctx context.Context, addr common.Address, balance hexutil.Big) error {
+	return a.simBeacon.SetBalance(addr, balance.ToInt())
+}
+
+// SetCode sets the code of an account.
+func (a *api) SetCode(ctx context.Context, addr common.Address, code hexutil.Bytes) error {
+	return a.simBeacon.SetCode(addr, code)
+}
+
+// SetStorageAt sets a storage slot of an account.
+func (a *api) SetStorageAt(ctx context.Context, addr common.Address, slot, value common.Hash) error {
+	return a.simBeacon.SetStorageAt(addr, slot, value)
+}
+
+// ImpersonateAccount allows sending transactions on behalf of the given account
+// via eth_sendTransaction.
+func (a *api) ImpersonateAccount(ctx context.Context, addr common.Address) {
+	a.simBeacon.ImpersonateAccount(addr)
+}
+
+// StopImpersonatingAccount revokes the impersonation of the given account.
+func (a *api) StopImpersonatingAccount(ctx context.Context, addr common.Address) {
+	a.simBeacon.StopImpersonatingAccount(addr)
+}
@@ -17,24 +17,29 @@
 package catalyst
 
 import (
+	"bytes"
 	"context"
 	"math/big"
 	"testing"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/eth"
 	"github.com/ethereum/go-ethereum/eth/downloader"
 	"github.com/ethereum/go-ethereum/eth/ethconfig"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/node"
 	"github.com/ethereum/go-ethereum/p2p"
 	"github.com/ethereum/go-ethereum/params"
 )
 
-func startSimulatedBeaconEthService(t *testing.T, genesis *core.Genesis) (*node.Node, *eth.Ethereum, *SimulatedBeacon) {
+func startSimulatedBeaconEthService(t *testing.T, genesis *core.Genesis, period uint64) (*node.Node, *eth.Ethereum, *SimulatedBeacon) {
 	t.Helper()
 
 	n, err := node.New(&node.Config{
@@ -54,12 +59,13 @@ func startSimulatedBeaconEthService(t *testing.T, genesis *core.Genesis) (*node.
 		t.Fatal("can't create eth service:", err)
 	}
 
-	simBeacon, err := NewSimulatedBeacon(1, ethservice)
+	simBeacon, err := NewSimulatedBeacon(period, ethservice)
 	if err != nil {
 		t.Fatal("can't create simulated beacon:", err)
 	}
 
 	n.RegisterLifecycle(simBeacon)
+	RegisterSimulatedBeaconAPIs(n, simBeacon)
 
 	if err := n.Start(); err != nil {
 		t.Fatal("can't start node:", err)
@@ -86,7 +92,7 @@ func TestSimulatedBeaconSendWithdrawals(t *testing.T) {
 	// short period (1 second) for testing purposes
 	var gasLimit uint64 = 10_000_000
 	genesis := core.DeveloperGenesisBlock(gasLimit, testAddr)
-	node, ethService, mock := startSimulatedBeaconEthService(t, genesis)
+	node, ethService, mock := startSimulatedBeaconEthService(t, genesis, 1)
 	_ = mock
 	defer node.Close()
 
@@ -139,3 +145,266 @@ func TestSimulatedBeaconSendWithdrawals(t *testing.T) {
 		}
 	}
 }
+
+// waitForHeadNumber waits until the chain head reaches the given block number.
+func waitForHeadNumber(t *testing.T, ethService *eth.Ethereum, number uint64) {
+	t.Helper()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for ethService.BlockChain().CurrentBlock().Number.Uint64() < number {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for block %d", number)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSimulatedBeaconStateEdits(t *testing.T) {
+	var (
+		addr    = common.HexToAddress("0x1111111111111111111111111111111111111111")
+		code    = []byte{0x60, 0x01, 0x60, 0x00, 0x55}
+		slot    = common.HexToHash("0x01")
+		value   = common.HexToHash("0xff")
+		balance = big.NewInt(params.Ether)
+	)
+	genesis := core.DeveloperGenesisBlock(10_000_000, common.Address{})
+	node, ethService, _ := startSimulatedBeaconEthService(t, genesis, 0)
+	defer node.Close()
+
+	client := node.Attach()
+	defer client.Close()
+
+	if err := client.Call(nil, "dev_setBalance", addr, (*hexutil.Big)(balance)); err != nil {
+		t.Fatal("setBalance failed:", err)
+	}
+	if err := client.Call(nil, "dev_setCode", addr, hexutil.Bytes(code)); err != nil {
+		t.Fatal("setCode failed:", err)
+	}
+	if err := client.Call(nil, "dev_setStorageAt", addr, slot, value); err != nil {
+		t.Fatal("setStorageAt failed:", err)
+	}
+	if head := ethService.BlockChain().CurrentBlock().Number.Uint64(); head != 3 {
+		t.Fatalf("wrong head block: have %d, want 3", head)
+	}
+	state, err := ethService.BlockChain().State()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have := state.GetBalance(addr); have.Cmp(balance) != 0 {
+		t.Errorf("wrong balance: have %v, want %v", have, balance)
+	}
+	if have := state.GetCode(addr); !bytes.Equal(have, code) {
+		t.Errorf("wrong code: have %x, want %x", have, code)
+	}
+	if have := state.GetState(addr, slot); have != value {
+		t.Errorf("wrong storage: have %x, want %x", have, value)
+	}
+}
+
+func TestSimulatedBeaconSnapshotRevert(t *testing.T) {
+	var (
+		testKey, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		testAddr   = crypto.PubkeyToAddress(testKey.PublicKey)
+		other      = common.HexToAddress("0x2222222222222222222222222222222222222222")
+	)
+	genesis := core.DeveloperGenesisBlock(10_000_000, testAddr)
+	node, ethService, _ := startSimulatedBeaconEthService(t, genesis, 0)
+	defer node.Close()
+
+	client := node.Attach()
+	defer client.Close()
+
+	var first hexutil.Uint64
+	if err := client.Call(&first, "dev_snapshot"); err != nil {
+		t.Fatal("snapshot failed:", err)
+	}
+	head := ethService.BlockChain().CurrentBlock()
+
+	// Change the chain with a transaction, a state edit and a time shift.
+	signer := types.LatestSigner(ethService.BlockChain().Config())
+	tx := types.MustSignNewTx(testKey, signer, &types.DynamicFeeTx{
+		ChainID:   signer.ChainID(),
+		Gas:       params.TxGas,
+		GasFeeCap: big.NewInt(params.InitialBaseFee * 2),
+		GasTipCap: big.NewInt(params.GWei),
+		To:        &other,
+		Value:     big.NewInt(1000),
+	})
+	if err := ethService.APIBackend.SendTx(context.Background(), tx); err != nil {
+		t.Fatal("SendTx failed:", err)
+	}
+	waitForHeadNumber(t, ethService, 1)
+
+	if err := client.Call(nil, "dev_setBalance", other, (*hexutil.Big)(big.NewInt(params.Ether))); err != nil {
+		t.Fatal("setBalance failed:", err)
+	}
+	var second hexutil.Uint64
+	if err := client.Call(&second, "dev_snapshot"); err != nil {
+		t.Fatal("snapshot failed:", err)
+	}
+	if err := client.Call(nil, "dev_increaseTime", hexutil.Uint64(3600)); err != nil {
+		t.Fatal("increaseTime failed:", err)
+	}
+	// Revert to the first snapshot, which also removes the second one.
+	var reverted bool
+	if err := client.Call(&reverted, "dev_revert", first); err != nil || !reverted {
+		t.Fatalf("revert failed: %v %v", reverted, err)
+	}
+	if have := ethService.BlockChain().CurrentBlock().Hash(); have != head.Hash() {
+		t.Fatalf("wrong head after revert: have %x, want %x", have, head.Hash())
+	}
+	state, err := ethService.BlockChain().State()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance := state.GetBalance(other); balance.Sign() != 0 {
+		t.Errorf("state edit not reverted: balance %v", balance)
+	}
+	if nonce := state.GetNonce(testAddr); nonce != 0 {
+		t.Errorf("transaction not reverted: nonce %d", nonce)
+	}
+	if pending, queued := ethService.TxPool().Stats(); pending+queued != 0 {
+		t.Errorf("pool not empty after revert: %d pending, %d queued", pending, queued)
+	}
+	if err := client.Call(&reverted, "dev_revert", second); err != nil || reverted {
+		t.Fatalf("revert to removed snapshot: %v %v", reverted, err)
+	}
+	var offset int64
+	if err := client.Call(&offset, "dev_increaseTime", hexutil.Uint64(0)); err != nil || offset != 0 {
+		t.Fatalf("time offset not reverted: %d %v", offset, err)
+	}
+	// The reverted transaction can be sent again.
+	if err := ethService.APIBackend.SendTx(context.Background(), tx); err != nil {
+		t.Fatal("SendTx failed:", err)
+	}
+	waitForHeadNumber(t, ethService, 1)
+	if block := ethService.BlockChain().CurrentBlock(); block.Hash() == head.Hash() {
+		t.Fatal("transaction not included after revert")
+	}
+}
+
+func TestSimulatedBeaconTime(t *testing.T) {
+	genesis := core.DeveloperGenesisBlock(10_000_000, common.Address{})
+	node, ethService, _ := startSimulatedBeaconEthService(t, genesis, 0)
+	defer node.Close()
+
+	client := node.Attach()
+	defer client.Close()
+
+	var offset int64
+	if err := client.Call(&offset, "dev_increaseTime", hexutil.Uint64(3600)); err != nil || offset != 3600 {
+		t.Fatalf("increaseTime failed: %d %v", offset, err)
+	}
+	now := uint64(time.Now().Unix())
+	if err := client.Call(nil, "dev_mine", nil); err != nil {
+		t.Fatal("mine failed:", err)
+	}
+	if block := ethService.BlockChain().CurrentBlock(); block.Time < now+3600 {
+		t.Fatalf("time offset not applied: block time %d, now %d", block.Time, now)
+	}
+	// Mine at an explicit timestamp.
+	tstamp := ethService.BlockChain().CurrentBlock().Time + 100_000
+	var hash common.Hash
+	if err := client.Call(&hash, "dev_mine", hexutil.Uint64(tstamp)); err != nil {
+		t.Fatal("mine failed:", err)
+	}
+	if block := ethService.BlockChain().CurrentBlock(); block.Hash() != hash || block.Time != tstamp {
+		t.Fatalf("wrong block: have time %d, want %d", block.Time, tstamp)
+	}
+	if err := client.Call(nil, "dev_mine", hexutil.Uint64(tstamp)); err == nil {
+		t.Fatal("mined block with timestamp of parent")
+	}
+	// Later blocks continue from the given timestamp.
+	if err := client.Call(nil, "dev_mine", nil); err != nil {
+		t.Fatal("mine failed:", err)
+	}
+	if block := ethService.BlockChain().CurrentBlock(); block.Time <= tstamp || block.Time > tstamp+60 {
+		t.Fatalf("wrong block time %d after %d", block.Time, tstamp)
+	}
+}
+
+func TestSimulatedBeaconImpersonation(t *testing.T) {
+	var (
+		impersonated = common.HexToAddress("0x3333333333333333333333333333333333333333")
+		recipient    = common.HexToAddress("0x4444444444444444444444444444444444444444")
+	)
+	genesis := core.DeveloperGenesisBlock(10_000_000, common.Address{})
+	node, ethService, _ := startSimulatedBeaconEthService(t, genesis, 0)
+	defer node.Close()
+
+	client := node.Attach()
+	defer client.Close()
+
+	if err := client.Call(nil, "dev_setBalance", impersonated, (*hexutil.Big)(big.NewInt(params.Ether))); err != nil {
+		t.Fatal("setBalance failed:", err)
+	}
+	args := map[string]interface{}{
+		"from":  impersonated,
+		"to":    recipient,
+		"value": (*hexutil.Big)(big.NewInt(1000)),
+	}
+	if err := client.Call(nil, "eth_sendTransaction", args); err == nil {
+		t.Fatal("sent transaction without impersonation")
+	}
+	if err := client.Call(nil, "dev_impersonateAccount", impersonated); err != nil {
+		t.Fatal("impersonateAccount failed:", err)
+	}
+	var hash common.Hash
+	if err := client.Call(&hash, "eth_sendTransaction", args); err != nil {
+		t.Fatal("sendTransaction failed:", err)
+	}
+	waitForHeadNumber(t, ethService, 2)
+
+	// The transaction is included, sent by the impersonated account.
+	if block := ethService.BlockChain().CurrentBlock(); ethService.BlockChain().GetBlockByHash(block.Hash()).Transaction(hash) == nil {
+		t.Fatal("transaction not included")
+	}
+	state, err := ethService.BlockChain().State()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance := state.GetBalance(recipient); balance.Cmp(big.NewInt(1000)) != 0 {
+		t.Errorf("wrong recipient balance %v", balance)
+	}
+	if nonce := state.GetNonce(impersonated); nonce != 1 {
+		t.Errorf("wrong nonce of impersonated account: have %d, want 1", nonce)
+	}
+	// The sender is persisted, so the receipt reports it and the transaction
+	// can be traced.
+	if sender, ok := rawdb.ReadImpersonatedSender(ethService.ChainDb(), hash); !ok || sender != impersonated {
+		t.Errorf("wrong persisted sender: have %v (%t), want %v", sender, ok, impersonated)
+	}
+	var receipt map[string]interface{}
+	if err := client.Call(&receipt, "eth_getTransactionReceipt", hash); err != nil {
+		t.Fatal("getTransactionReceipt failed:", err)
+	}
+	if from := common.HexToAddress(receipt["from"].(string)); from != impersonated {
+		t.Errorf("wrong receipt sender: have %v, want %v", from, impersonated)
+	}
+	if _, err := tracers.NewAPI(ethService.APIBackend).TraceTransaction(context.Background(), hash, nil); err != nil {
+		t.Errorf("failed to trace impersonated transaction: %v", err)
+	}
+	// Transactions carrying the placeholder signature are only accepted if
+	// they were signed by the node.
+	signer := types.LatestSigner(ethService.BlockChain().Config())
+	forged, err := types.NewTx(&types.DynamicFeeTx{
+		ChainID:   signer.ChainID(),
+		Nonce:     1,
+		To:        &recipient,
+		Value:     big.NewInt(1000),
+		Gas:       params.TxGas,
+		GasFeeCap: big.NewInt(params.GWei),
+	}).WithSignature(signer, impersonatedSignature(impersonated))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ethclient.NewClient(client).SendTransaction(context.Background(), forged); err == nil {
+		t.Fatal("forged impersonated transaction accepted")
+	}
+	if err := client.Call(nil, "dev_stopImpersonatingAccount", impersonated); err != nil {
+		t.Fatal("stopImpersonatingAccount failed:", err)
+	}
+	if err := client.Call(nil, "eth_sendTransaction", args); err == nil {
+		t.Fatal("sent transaction after impersonation stopped")
+	}
+}
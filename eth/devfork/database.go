@@ -0,0 +1,176 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package devfork
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// database is a state database which fetches the parts of the forked state
+// missing locally from the remote node.
+type database struct {
+	state.Database
+	fork *Fork
+}
+
+// WrapDatabase returns a state database on top of db, which retrieves missing
+// trie nodes and code of the forked state from the remote node.
+func (f *Fork) WrapDatabase(db state.Database) state.Database {
+	return &database{Database: db, fork: f}
+}
+
+// fetch retrieves the trie nodes on the path to an account and its storage
+// slots, if err reports a missing trie node. It returns whether the failed
+// operation should be retried.
+func (db *database) fetch(err error, addr common.Address, slots ...common.Hash) bool {
+	var missing *trie.MissingNodeError
+	if !errors.As(err, &missing) {
+		return false
+	}
+	disk := db.DiskDB()
+	if rawdb.HasLegacyTrieNode(disk, missing.NodeHash) {
+		return false // already fetched, the node must be unavailable otherwise
+	}
+	if err := db.fork.fetchProof(disk, addr, slots); err != nil {
+		log.Warn("Failed to fetch forked state", "addr", addr, "err", err)
+		return false
+	}
+	if rawdb.HasLegacyTrieNode(disk, missing.NodeHash) {
+		return true
+	}
+	// The missing node is not on the path to the accessed items, retrieve it
+	// directly.
+	if err := db.fork.fetchNode(disk, missing.NodeHash); err != nil {
+		log.Warn("Failed to fetch forked trie node", "hash", missing.NodeHash, "path", common.Bytes2Hex(missing.Path), "err", err)
+		return false
+	}
+	return true
+}
+
+// OpenTrie opens the main account trie.
+func (db *database) OpenTrie(root common.Hash) (state.Trie, error) {
+	tr, err := db.Database.OpenTrie(root)
+	if err != nil {
+		return nil, err
+	}
+	return &forkTrie{Trie: tr, db: db}, nil
+}
+
+// OpenStorageTrie opens the storage trie of an account, fetching its root node
+// if necessary.
+func (db *database) OpenStorageTrie(stateRoot common.Hash, address common.Address, root common.Hash) (state.Trie, error) {
+	tr, err := db.Database.OpenStorageTrie(stateRoot, address, root)
+	for db.fetch(err, address, common.Hash{}) {
+		tr, err = db.Database.OpenStorageTrie(stateRoot, address, root)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &forkTrie{Trie: tr, db: db}, nil
+}
+
+// CopyTrie returns an independent copy of the given trie.
+func (db *database) CopyTrie(t state.Trie) state.Trie {
+	if t, ok := t.(*forkTrie); ok {
+		return &forkTrie{Trie: db.Database.CopyTrie(t.Trie), db: db}
+	}
+	return db.Database.CopyTrie(t)
+}
+
+// ContractCode retrieves a particular contract's code, fetching it from the
+// remote node if necessary.
+func (db *database) ContractCode(addr common.Address, codeHash common.Hash) ([]byte, error) {
+	code, err := db.Database.ContractCode(addr, codeHash)
+	if err == nil || codeHash == types.EmptyCodeHash {
+		return code, err
+	}
+	return db.fork.fetchCode(db.DiskDB(), addr, codeHash)
+}
+
+// ContractCodeSize retrieves a particular contract's code size, fetching the
+// code from the remote node if necessary.
+func (db *database) ContractCodeSize(addr common.Address, codeHash common.Hash) (int, error) {
+	size, err := db.Database.ContractCodeSize(addr, codeHash)
+	if err == nil || codeHash == types.EmptyCodeHash {
+		return size, err
+	}
+	code, err := db.fork.fetchCode(db.DiskDB(), addr, codeHash)
+	return len(code), err
+}
+
+// forkTrie is a trie whose accesses are retried after fetching the missing
+// nodes from the remote node. An access may hit several missing nodes, so it
+// is retried for as long as new nodes are retrieved.
+type forkTrie struct {
+	state.Trie
+	db *database
+}
+
+func (t *forkTrie) GetAccount(address common.Address) (*types.StateAccount, error) {
+	account, err := t.Trie.GetAccount(address)
+	for t.db.fetch(err, address) {
+		account, err = t.Trie.GetAccount(address)
+	}
+	return account, err
+}
+
+func (t *forkTrie) GetStorage(addr common.Address, key []byte) ([]byte, error) {
+	value, err := t.Trie.GetStorage(addr, key)
+	for t.db.fetch(err, addr, common.BytesToHash(key)) {
+		value, err = t.Trie.GetStorage(addr, key)
+	}
+	return value, err
+}
+
+func (t *forkTrie) UpdateAccount(address common.Address, account *types.StateAccount) error {
+	err := t.Trie.UpdateAccount(address, account)
+	for t.db.fetch(err, address) {
+		err = t.Trie.UpdateAccount(address, account)
+	}
+	return err
+}
+
+func (t *forkTrie) UpdateStorage(addr common.Address, key, value []byte) error {
+	err := t.Trie.UpdateStorage(addr, key, value)
+	for t.db.fetch(err, addr, common.BytesToHash(key)) {
+		err = t.Trie.UpdateStorage(addr, key, value)
+	}
+	return err
+}
+
+func (t *forkTrie) DeleteAccount(address common.Address) error {
+	err := t.Trie.DeleteAccount(address)
+	for t.db.fetch(err, address) {
+		err = t.Trie.DeleteAccount(address)
+	}
+	return err
+}
+
+func (t *forkTrie) DeleteStorage(addr common.Address, key []byte) error {
+	err := t.Trie.DeleteStorage(addr, key)
+	for t.db.fetch(err, addr, common.BytesToHash(key)) {
+		err = t.Trie.DeleteStorage(addr, key)
+	}
+	return err
+}
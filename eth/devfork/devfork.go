@@ -0,0 +1,354 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package devfork implements development chains forked off a block of a remote
+// network.
+//
+// The state of the forked block is not downloaded upfront. Accounts, storage and
+// code are fetched from the remote node when first accessed, using eth_getProof and
+// eth_getCode, and the retrieved trie nodes are cached in the local database. As
+// unmodified parts of the state keep their hashes, the local chain can build new
+// state on top of the fetched nodes. This requires the hash state scheme.
+//
+// Deleting from the state may need trie nodes which no proof contains. These are
+// retrieved with debug_dbGet, which only works if the remote node exposes the
+// debug API and stores its state by hash.
+//
+// The remote node must have the state of the forked block available, so unless it
+// is an archive node, only recent blocks can be forked. The forked block serves as
+// the genesis of the local chain, so local blocks continue the numbering of the
+// remote chain. The headers of its recent ancestors are retrieved as well, making
+// their hashes accessible to the EVM.
+package devfork
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	// fetchTimeout is the time allowed for retrieving a single item from the
+	// remote node.
+	fetchTimeout = 30 * time.Second
+
+	// forkAncestors is the number of blocks before the forked one whose headers
+	// are retrieved, covering the block hashes accessible to the EVM.
+	forkAncestors = 255
+)
+
+// Fork is a block of a remote network which a development chain is built on.
+type Fork struct {
+	client  *rpc.Client
+	header  *types.Header
+	hash    common.Hash
+	chainID *big.Int
+	latest  bool // whether the latest block was requested
+}
+
+// Dial connects to the node at the given URL and retrieves the block to fork
+// from. If number is nil, the latest block is forked.
+func Dial(ctx context.Context, rawurl string, number *big.Int) (*Fork, error) {
+	client, err := rpc.DialContext(ctx, rawurl)
+	if err != nil {
+		return nil, err
+	}
+	fork, err := New(ctx, client, number)
+	if err != nil {
+		client.Close()
+		return nil, err
+	}
+	return fork, nil
+}
+
+// New retrieves the block to fork from using the given client. If number is nil,
+// the latest block is forked.
+func New(ctx context.Context, client *rpc.Client, number *big.Int) (*Fork, error) {
+	tag := "latest"
+	if number != nil {
+		tag = hexutil.EncodeBig(number)
+	}
+	header, hash, err := fetchHeader(ctx, client, "eth_getBlockByNumber", tag)
+	if err != nil {
+		return nil, err
+	}
+	if header.BaseFee == nil {
+		return nil, fmt.Errorf("block %v predates London, it can't be forked", header.Number)
+	}
+	var chainID hexutil.Big
+	if err := client.CallContext(ctx, &chainID, "eth_chainId"); err != nil {
+		return nil, err
+	}
+	return &Fork{
+		client:  client,
+		header:  header,
+		hash:    hash,
+		chainID: chainID.ToInt(),
+		latest:  number == nil,
+	}, nil
+}
+
+// fetchHeader retrieves a block header from the remote node. The hash reported
+// by the remote node is returned as well, as the header may contain fields
+// unknown to this version.
+func fetchHeader(ctx context.Context, client *rpc.Client, method string, arg interface{}) (*types.Header, common.Hash, error) {
+	var raw json.RawMessage
+	if err := client.CallContext(ctx, &raw, method, arg, false); err != nil {
+		return nil, common.Hash{}, err
+	}
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, common.Hash{}, fmt.Errorf("block %v not found", arg)
+	}
+	var (
+		header *types.Header
+		block  struct {
+			Hash common.Hash `json:"hash"`
+		}
+	)
+	if err := json.Unmarshal(raw, &header); err != nil {
+		return nil, common.Hash{}, err
+	}
+	if err := json.Unmarshal(raw, &block); err != nil {
+		return nil, common.Hash{}, err
+	}
+	return header, block.Hash, nil
+}
+
+// Close disconnects from the remote node.
+func (f *Fork) Close() {
+	f.client.Close()
+}
+
+// Header returns the header of the forked block.
+func (f *Fork) Header() *types.Header {
+	return types.CopyHeader(f.header)
+}
+
+// Hash returns the hash of the forked block, as reported by the remote node.
+func (f *Fork) Hash() common.Hash {
+	return f.hash
+}
+
+// Config returns the chain config of the forked development chain. It enables the
+// protocol changes of developer chains, using the chain id of the remote network.
+// Cancun is enabled if the forked block is a Cancun block.
+func (f *Fork) Config() *params.ChainConfig {
+	config := *params.AllDevChainProtocolChanges
+	config.ChainID = new(big.Int).Set(f.chainID)
+	if f.header.ExcessBlobGas != nil {
+		config.CancunTime = new(uint64)
+	}
+	return &config
+}
+
+// Genesis returns the genesis block of the forked development chain, which is the
+// forked block itself. Its transactions are not retrieved, so the block has an
+// empty body.
+func (f *Fork) Genesis() *types.Block {
+	block := types.NewBlockWithHeader(f.header)
+	if f.header.WithdrawalsHash != nil {
+		block = block.WithWithdrawals(make([]*types.Withdrawal, 0))
+	}
+	return block
+}
+
+// WriteGenesis writes the genesis block of the forked development chain into the
+// database, along with the root node of the forked state and the headers of the
+// ancestors of the forked block. It's a no-op if the database already contains
+// the forked chain. If the latest block was requested, a chain forked earlier is
+// resumed, switching the fork to its original block.
+func (f *Fork) WriteGenesis(db ethdb.Database) error {
+	block := f.Genesis()
+	if stored := rawdb.ReadCanonicalHash(db, 0); stored != (common.Hash{}) {
+		if stored != block.Hash() && f.latest {
+			if err := f.resume(db); err != nil {
+				return err
+			}
+			block = f.Genesis()
+		}
+		if stored != block.Hash() {
+			return fmt.Errorf("database contains a different chain (genesis %x, fork %x)", stored, block.Hash())
+		}
+		return nil
+	}
+	// Retrieve the root node of the state, the rest is fetched on demand.
+	if err := f.fetchProof(db, common.Address{}, nil); err != nil {
+		return fmt.Errorf("failed to retrieve state root: %v", err)
+	}
+	if err := f.fetchAncestors(db); err != nil {
+		return fmt.Errorf("failed to retrieve ancestor headers: %v", err)
+	}
+	if block.Hash() != f.hash {
+		log.Warn("Forked block has unknown fields, local hashes differ from the remote chain", "hash", block.Hash(), "forkhash", f.hash)
+	}
+	rawdb.WriteTd(db, block.Hash(), block.NumberU64(), block.Difficulty())
+	rawdb.WriteBlock(db, block)
+	rawdb.WriteReceipts(db, block.Hash(), block.NumberU64(), nil)
+	rawdb.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+
+	// The genesis is looked up as canonical block zero, regardless of its number.
+	rawdb.WriteCanonicalHash(db, block.Hash(), 0)
+	rawdb.WriteHeadBlockHash(db, block.Hash())
+	rawdb.WriteHeadFastBlockHash(db, block.Hash())
+	rawdb.WriteHeadHeaderHash(db, block.Hash())
+	rawdb.WriteChainConfig(db, block.Hash(), f.Config())
+
+	log.Info("Wrote genesis of forked chain", "number", block.Number(), "hash", block.Hash())
+	return nil
+}
+
+// resume switches the fork to the block the stored chain was forked off, which
+// is its genesis.
+func (f *Fork) resume(db ethdb.Database) error {
+	stored := rawdb.ReadGenesisHeader(db)
+	if stored == nil {
+		return errors.New("missing genesis header")
+	}
+	f.header, f.hash = stored, stored.Hash()
+	log.Info("Resuming forked chain", "number", stored.Number, "hash", f.hash)
+	return nil
+}
+
+// fetchAncestors retrieves the headers of the blocks before the forked one and
+// writes them into db, so the hashes of recent blocks can be resolved by walking
+// the chain of parent hashes. Headers are only stored as long as they link up,
+// as headers containing fields unknown to this version hash differently.
+func (f *Fork) fetchAncestors(db ethdb.KeyValueWriter) error {
+	number := f.header.Number.Uint64()
+	count := min(number, forkAncestors)
+	if count == 0 {
+		return nil
+	}
+	var (
+		headers = make([]*types.Header, count)
+		batch   = make([]rpc.BatchElem, count)
+	)
+	for i := range batch {
+		batch[i] = rpc.BatchElem{
+			Method: "eth_getBlockByNumber",
+			Args:   []interface{}{hexutil.EncodeUint64(number - uint64(i) - 1), false},
+			Result: &headers[i],
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), fetchTimeout)
+	defer cancel()
+
+	if err := f.client.BatchCallContext(ctx, batch); err != nil {
+		return err
+	}
+	parent := f.header.ParentHash
+	for i, elem := range batch {
+		if elem.Error != nil {
+			return elem.Error
+		}
+		header := headers[i]
+		if header == nil {
+			return fmt.Errorf("block %d not found", number-uint64(i)-1)
+		}
+		if header.Hash() != parent {
+			log.Warn("Ancestor of forked block hashes differently, older block hashes unavailable", "number", header.Number, "hash", parent)
+			break
+		}
+		rawdb.WriteHeader(db, header)
+		parent = header.ParentHash
+	}
+	log.Debug("Fetched forked block ancestors", "count", count)
+	return nil
+}
+
+// proofResult is the part of the eth_getProof response holding the trie nodes.
+type proofResult struct {
+	AccountProof []hexutil.Bytes `json:"accountProof"`
+	StorageProof []struct {
+		Proof []hexutil.Bytes `json:"proof"`
+	} `json:"storageProof"`
+}
+
+// fetchProof retrieves the trie nodes on the paths to an account and the given
+// storage slots at the forked block, and writes them into db. The nodes are
+// stored by their hash, so the remote node can't corrupt the local state.
+func (f *Fork) fetchProof(db ethdb.KeyValueWriter, addr common.Address, slots []common.Hash) error {
+	ctx, cancel := context.WithTimeout(context.Background(), fetchTimeout)
+	defer cancel()
+
+	if slots == nil {
+		slots = []common.Hash{}
+	}
+	var res proofResult
+	if err := f.client.CallContext(ctx, &res, "eth_getProof", addr, slots, hexutil.EncodeBig(f.header.Number)); err != nil {
+		return err
+	}
+	nodes := res.AccountProof
+	for _, proof := range res.StorageProof {
+		nodes = append(nodes, proof.Proof...)
+	}
+	for _, node := range nodes {
+		rawdb.WriteLegacyTrieNode(db, crypto.Keccak256Hash(node), node)
+	}
+	log.Debug("Fetched forked state", "addr", addr, "slots", len(slots), "nodes", len(nodes))
+	return nil
+}
+
+// fetchNode retrieves a single trie node from the database of the remote node,
+// and writes it into db. Unlike proofs, this can retrieve nodes off the path to
+// any key, like the siblings resolved when deleting from a trie, but it requires
+// the debug API and the hash state scheme on the remote node.
+func (f *Fork) fetchNode(db ethdb.KeyValueWriter, hash common.Hash) error {
+	ctx, cancel := context.WithTimeout(context.Background(), fetchTimeout)
+	defer cancel()
+
+	var node hexutil.Bytes
+	if err := f.client.CallContext(ctx, &node, "debug_dbGet", hash.Hex()); err != nil {
+		return err
+	}
+	if crypto.Keccak256Hash(node) != hash {
+		return errors.New("remote trie node hash mismatch")
+	}
+	rawdb.WriteLegacyTrieNode(db, hash, node)
+	log.Debug("Fetched forked trie node", "hash", hash)
+	return nil
+}
+
+// fetchCode retrieves the code of an account at the forked block and writes it
+// into db, if it matches the expected hash.
+func (f *Fork) fetchCode(db ethdb.KeyValueWriter, addr common.Address, codeHash common.Hash) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), fetchTimeout)
+	defer cancel()
+
+	var code hexutil.Bytes
+	if err := f.client.CallContext(ctx, &code, "eth_getCode", addr, hexutil.EncodeBig(f.header.Number)); err != nil {
+		return nil, err
+	}
+	if crypto.Keccak256Hash(code) != codeHash {
+		return nil, errors.New("remote code hash mismatch")
+	}
+	rawdb.WriteCode(db, codeHash, code)
+	log.Debug("Fetched forked code", "addr", addr, "size", len(code))
+	return code, nil
+}
@@ -0,0 +1,388 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package devfork
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/beacon"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/consensus/misc/eip1559"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+var (
+	testAccount  = common.HexToAddress("0x1000000000000000000000000000000000000001")
+	testContract = common.HexToAddress("0x2000000000000000000000000000000000000002")
+	testCode     = []byte{byte(0x60), 0x01, 0x60, 0x00, 0x55}
+	testSlot     = common.HexToHash("0x01")
+	testValue    = common.HexToHash("0xdeadbeef")
+)
+
+// proofList collects the nodes of a merkle proof.
+type proofList []hexutil.Bytes
+
+func (n *proofList) Put(key []byte, value []byte) error {
+	*n = append(*n, value)
+	return nil
+}
+
+func (n *proofList) Delete(key []byte) error {
+	panic("not supported")
+}
+
+// testBackend serves a chain of headers and the state of its last block, standing
+// in for the remote node.
+type testBackend struct {
+	chainID *big.Int
+	headers []*types.Header
+	header  *types.Header
+	diskdb  ethdb.Database
+	state   state.Database
+	codes   map[common.Address][]byte
+	proofs  int
+}
+
+func newTestBackend(t *testing.T) *testBackend {
+	diskdb := rawdb.NewMemoryDatabase()
+	db := state.NewDatabase(diskdb)
+	statedb, _ := state.New(types.EmptyRootHash, db, nil)
+	statedb.SetBalance(testAccount, big.NewInt(params.Ether))
+	statedb.SetNonce(testAccount, 5)
+	statedb.SetCode(testContract, testCode)
+	statedb.SetState(testContract, testSlot, testValue)
+	for i := 0; i < 64; i++ {
+		statedb.SetBalance(common.BigToAddress(big.NewInt(int64(0x100+i))), big.NewInt(int64(i+1)))
+	}
+	root, err := statedb.Commit(0, true)
+	if err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	if err := db.TrieDB().Commit(root, false); err != nil {
+		t.Fatalf("failed to commit trie: %v", err)
+	}
+	headers := make([]*types.Header, 101)
+	for i := range headers {
+		headers[i] = &types.Header{
+			Number:          big.NewInt(int64(i)),
+			Root:            root,
+			Difficulty:      new(big.Int),
+			GasLimit:        30_000_000,
+			Time:            1700000000 + uint64(i)*12,
+			BaseFee:         big.NewInt(params.InitialBaseFee),
+			WithdrawalsHash: &types.EmptyWithdrawalsHash,
+		}
+		if i > 0 {
+			headers[i].ParentHash = headers[i-1].Hash()
+		}
+	}
+	return &testBackend{
+		chainID: big.NewInt(1),
+		headers: headers,
+		header:  headers[len(headers)-1],
+		diskdb:  diskdb,
+		state:   db,
+		codes:   map[common.Address][]byte{testContract: testCode},
+	}
+}
+
+func (b *testBackend) ChainId() *hexutil.Big {
+	return (*hexutil.Big)(b.chainID)
+}
+
+func (b *testBackend) GetBlockByNumber(number string, fullTx bool) *types.Header {
+	if number == "latest" {
+		return b.header
+	}
+	n, err := hexutil.DecodeUint64(number)
+	if err != nil || n >= uint64(len(b.headers)) {
+		return nil
+	}
+	return b.headers[n]
+}
+
+func (b *testBackend) GetBlockByHash(hash common.Hash, fullTx bool) *types.Header {
+	if hash != b.header.Hash() {
+		return nil
+	}
+	return b.header
+}
+
+func (b *testBackend) GetProof(addr common.Address, slots []common.Hash, number string) (*proofResult, error) {
+	b.proofs++
+
+	tr, err := trie.NewStateTrie(trie.StateTrieID(b.header.Root), b.state.TrieDB())
+	if err != nil {
+		return nil, err
+	}
+	res := new(proofResult)
+	if err := tr.Prove(crypto.Keccak256(addr.Bytes()), (*proofList)(&res.AccountProof)); err != nil {
+		return nil, err
+	}
+	account, err := tr.GetAccount(addr)
+	if err != nil {
+		return nil, err
+	}
+	res.StorageProof = make([]struct {
+		Proof []hexutil.Bytes `json:"proof"`
+	}, len(slots))
+	if account == nil || account.Root == types.EmptyRootHash {
+		return res, nil
+	}
+	storage, err := trie.NewStateTrie(trie.StorageTrieID(b.header.Root, crypto.Keccak256Hash(addr.Bytes()), account.Root), b.state.TrieDB())
+	if err != nil {
+		return nil, err
+	}
+	for i, slot := range slots {
+		if err := storage.Prove(crypto.Keccak256(slot.Bytes()), (*proofList)(&res.StorageProof[i].Proof)); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+func (b *testBackend) GetCode(addr common.Address, number string) hexutil.Bytes {
+	return b.codes[addr]
+}
+
+// testDebugBackend serves the raw database of the remote node.
+type testDebugBackend struct {
+	backend *testBackend
+}
+
+func (b *testDebugBackend) DbGet(key string) (hexutil.Bytes, error) {
+	return b.backend.diskdb.Get(common.FromHex(key))
+}
+
+func newTestFork(t *testing.T) (*testBackend, *Fork) {
+	backend := newTestBackend(t)
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", backend); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.RegisterName("debug", &testDebugBackend{backend: backend}); err != nil {
+		t.Fatal(err)
+	}
+	client := rpc.DialInProc(server)
+	t.Cleanup(client.Close)
+
+	fork, err := New(context.Background(), client, backend.header.Number)
+	if err != nil {
+		t.Fatalf("failed to create fork: %v", err)
+	}
+	return backend, fork
+}
+
+func TestWriteGenesis(t *testing.T) {
+	backend, fork := newTestFork(t)
+	if fork.Hash() != backend.header.Hash() {
+		t.Fatalf("wrong fork hash: have %x, want %x", fork.Hash(), backend.header.Hash())
+	}
+	if fork.Config().ChainID.Cmp(backend.chainID) != 0 {
+		t.Fatalf("wrong chain id: have %v, want %v", fork.Config().ChainID, backend.chainID)
+	}
+	db := rawdb.NewMemoryDatabase()
+	if err := fork.WriteGenesis(db); err != nil {
+		t.Fatalf("failed to write genesis: %v", err)
+	}
+	genesis := rawdb.ReadBlock(db, rawdb.ReadCanonicalHash(db, 0), backend.header.Number.Uint64())
+	if genesis == nil {
+		t.Fatal("genesis not written")
+	}
+	if genesis.Hash() != fork.Hash() || genesis.Root() != backend.header.Root {
+		t.Fatalf("wrong genesis: hash %x, root %x", genesis.Hash(), genesis.Root())
+	}
+	if hash := rawdb.ReadCanonicalHash(db, genesis.NumberU64()); hash != genesis.Hash() {
+		t.Fatalf("wrong canonical hash of genesis: have %x, want %x", hash, genesis.Hash())
+	}
+	// The ancestors of the forked block are available by hash.
+	for _, header := range backend.headers[:len(backend.headers)-1] {
+		if !rawdb.HasHeader(db, header.Hash(), header.Number.Uint64()) {
+			t.Fatalf("missing ancestor header %d", header.Number)
+		}
+	}
+	if config := rawdb.ReadChainConfig(db, genesis.Hash()); config == nil || !config.IsDevMode {
+		t.Fatalf("wrong chain config: %v", config)
+	}
+	// Only the root node is fetched upfront.
+	if backend.proofs != 1 {
+		t.Fatalf("wrong number of proof requests: have %d, want 1", backend.proofs)
+	}
+	// Rewriting the same genesis is a no-op, a different one fails.
+	if err := fork.WriteGenesis(db); err != nil {
+		t.Fatalf("failed to rewrite genesis: %v", err)
+	}
+	// Forking off the latest block resumes the existing chain, forking off a
+	// specific block fails.
+	fork.header = &types.Header{Number: big.NewInt(101), Root: backend.header.Root, GasLimit: 30_000_000}
+	fork.hash = fork.header.Hash()
+	fork.latest = true
+	if err := fork.WriteGenesis(db); err != nil {
+		t.Fatalf("failed to resume fork: %v", err)
+	}
+	if fork.Hash() != backend.header.Hash() {
+		t.Fatalf("wrong resumed fork hash: have %x, want %x", fork.Hash(), backend.header.Hash())
+	}
+	fork.header.Time++
+	fork.latest = false
+	if err := fork.WriteGenesis(db); err == nil {
+		t.Fatal("expected error for different genesis")
+	}
+}
+
+func TestForkedChain(t *testing.T) {
+	backend, fork := newTestFork(t)
+	db := rawdb.NewMemoryDatabase()
+	if err := fork.WriteGenesis(db); err != nil {
+		t.Fatalf("failed to write genesis: %v", err)
+	}
+	cacheConfig := &core.CacheConfig{
+		TrieCleanLimit: 16,
+		TrieDirtyLimit: 16,
+		StateScheme:    rawdb.HashScheme,
+		StateWrapper:   fork.WrapDatabase,
+	}
+	chain, err := core.NewBlockChain(db, cacheConfig, nil, nil, beacon.New(ethash.NewFaker()), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	genesis := chain.Genesis()
+	if genesis.Hash() != fork.Hash() || genesis.NumberU64() != backend.header.Number.Uint64() {
+		t.Fatalf("wrong genesis: number %d, hash %x", genesis.NumberU64(), genesis.Hash())
+	}
+	if cutoff := chain.HistoryPruningCutoff(); cutoff != genesis.NumberU64() {
+		t.Fatalf("wrong history cutoff: have %d, want %d", cutoff, genesis.NumberU64())
+	}
+	// Local blocks continue the numbering of the remote chain.
+	header := &types.Header{
+		ParentHash:      genesis.Hash(),
+		UncleHash:       types.EmptyUncleHash,
+		Root:            genesis.Root(),
+		TxHash:          types.EmptyTxsHash,
+		ReceiptHash:     types.EmptyReceiptsHash,
+		Difficulty:      new(big.Int),
+		Number:          new(big.Int).Add(genesis.Number(), common.Big1),
+		GasLimit:        genesis.GasLimit(),
+		Time:            genesis.Time() + 12,
+		BaseFee:         eip1559.CalcBaseFee(chain.Config(), genesis.Header()),
+		WithdrawalsHash: &types.EmptyWithdrawalsHash,
+	}
+	block := types.NewBlockWithWithdrawals(header, nil, nil, nil, []*types.Withdrawal{}, trie.NewStackTrie(nil))
+	if _, err := chain.InsertChain(types.Blocks{block}); err != nil {
+		t.Fatalf("failed to insert block: %v", err)
+	}
+	if head := chain.CurrentBlock(); head.Hash() != block.Hash() || head.Number.Uint64() != 101 {
+		t.Fatalf("wrong head: number %d, hash %x", head.Number, head.Hash())
+	}
+	// The hashes of the remote blocks are accessible to the EVM.
+	getHash := core.GetHashFn(block.Header(), chain)
+	for _, n := range []uint64{100, 99, 50, 0} {
+		if have, want := getHash(n), backend.headers[n].Hash(); have != want {
+			t.Errorf("wrong hash of block %d: have %x, want %x", n, have, want)
+		}
+	}
+	// Rewinding stops at the forked block.
+	if err := chain.SetHead(0); err != nil {
+		t.Fatalf("failed to rewind chain: %v", err)
+	}
+	if head := chain.CurrentBlock(); head.Hash() != genesis.Hash() {
+		t.Fatalf("wrong head after rewind: number %d, hash %x", head.Number, head.Hash())
+	}
+}
+
+func TestLazyState(t *testing.T) {
+	backend, fork := newTestFork(t)
+	db := rawdb.NewMemoryDatabase()
+	if err := fork.WriteGenesis(db); err != nil {
+		t.Fatalf("failed to write genesis: %v", err)
+	}
+	statedb, err := state.New(backend.header.Root, fork.WrapDatabase(state.NewDatabase(db)), nil)
+	if err != nil {
+		t.Fatalf("failed to open forked state: %v", err)
+	}
+	if balance := statedb.GetBalance(testAccount); balance.Cmp(big.NewInt(params.Ether)) != 0 {
+		t.Errorf("wrong balance: have %v, want %v", balance, params.Ether)
+	}
+	if nonce := statedb.GetNonce(testAccount); nonce != 5 {
+		t.Errorf("wrong nonce: have %d, want 5", nonce)
+	}
+	if code := statedb.GetCode(testContract); string(code) != string(testCode) {
+		t.Errorf("wrong code: have %x, want %x", code, testCode)
+	}
+	if value := statedb.GetState(testContract, testSlot); value != testValue {
+		t.Errorf("wrong storage value: have %x, want %x", value, testValue)
+	}
+	if balance := statedb.GetBalance(common.Address{0xff}); balance.Sign() != 0 {
+		t.Errorf("wrong balance of missing account: %v", balance)
+	}
+	if statedb.Error() != nil {
+		t.Fatalf("state error: %v", statedb.Error())
+	}
+	// Fetched parts of the state are served locally afterwards.
+	proofs := backend.proofs
+	statedb, _ = state.New(backend.header.Root, fork.WrapDatabase(state.NewDatabase(db)), nil)
+	statedb.GetBalance(testAccount)
+	statedb.GetState(testContract, testSlot)
+	if backend.proofs != proofs {
+		t.Errorf("state fetched again: %d proof requests, want %d", backend.proofs, proofs)
+	}
+}
+
+func TestModifyState(t *testing.T) {
+	backend, fork := newTestFork(t)
+	db := rawdb.NewMemoryDatabase()
+	if err := fork.WriteGenesis(db); err != nil {
+		t.Fatalf("failed to write genesis: %v", err)
+	}
+	modify := func(statedb *state.StateDB) common.Hash {
+		statedb.SetBalance(testAccount, big.NewInt(1))
+		statedb.SetState(testContract, testSlot, common.Hash{})
+		statedb.SetState(testContract, common.HexToHash("0x02"), testValue)
+		statedb.SetBalance(common.Address{0xff}, big.NewInt(2))
+		statedb.SelfDestruct(common.BigToAddress(big.NewInt(0x100)))
+		root, err := statedb.Commit(1, true)
+		if err != nil {
+			t.Fatalf("failed to commit state: %v", err)
+		}
+		return root
+	}
+	remote, _ := state.New(backend.header.Root, backend.state, nil)
+	want := modify(remote)
+
+	local, err := state.New(backend.header.Root, fork.WrapDatabase(state.NewDatabase(db)), nil)
+	if err != nil {
+		t.Fatalf("failed to open forked state: %v", err)
+	}
+	if have := modify(local); have != want {
+		t.Fatalf("wrong state root: have %x, want %x", have, want)
+	}
+}
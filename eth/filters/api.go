@@ -247,11 +247,19 @@ func (api *FilterAPI) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
 }
 
 // Logs creates a subscription that fires for all new log that match the given filter criteria.
-func (api *FilterAPI) Logs(ctx context.Context, crit FilterCriteria) (*rpc.Subscription, error) {
+//
+// If a cursor is given, the subscription resumes after the log it identifies,
+// replaying all the logs the client missed before streaming new ones. Should the
+// replay fail, an object holding the error is delivered and the subscription
+// stops.
+func (api *FilterAPI) Logs(ctx context.Context, crit FilterCriteria, cursor *LogCursor) (*rpc.Subscription, error) {
 	notifier, supported := rpc.NotifierFromContext(ctx)
 	if !supported {
 		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
 	}
+	if cursor != nil {
+		return api.resumeLogs(ctx, notifier, crit, *cursor)
+	}
 
 	var (
 		rpcSub      = notifier.CreateSubscription()
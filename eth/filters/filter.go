@@ -23,7 +23,9 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/bloombits"
+	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/rpc"
 )
 
@@ -198,6 +200,19 @@ func (f *Filter) rangeLogsAsync(ctx context.Context) (chan *types.Log, chan erro
 			size, sections = f.sys.backend.BloomStatus()
 			err            error
 		)
+		// Use the exact log index if available, the bloombits and unindexed
+		// searches pick up from wherever it ends
+		if logSize, logSections := f.sys.backend.LogIndexStatus(); logSections > 0 && f.hasCriteria() {
+			if indexed := logSections * logSize; indexed > uint64(f.begin) {
+				if indexed > end {
+					indexed = end + 1
+				}
+				if err = f.logIndexLogs(ctx, logSize, indexed-1, logChan); err != nil {
+					errChan <- err
+					return
+				}
+			}
+		}
 		if indexed := sections * size; indexed > uint64(f.begin) {
 			if indexed > end {
 				indexed = end + 1
@@ -265,6 +280,122 @@ func (f *Filter) indexedLogs(ctx context.Context, end uint64, logChan chan *type
 	}
 }
 
+// logIndexLogs returns the logs matching the filter criteria based on the log
+// index available locally.
+func (f *Filter) logIndexLogs(ctx context.Context, size, end uint64, logChan chan *types.Log) error {
+	db := f.sys.backend.ChainDb()
+	for section := uint64(f.begin) / size; section <= end/size; section++ {
+		for _, number := range f.logIndexMatches(db, section) {
+			if number < uint64(f.begin) || number > end {
+				continue
+			}
+			// Retrieve the matching block and pull the logs. Unless the section
+			// was reorged meanwhile, all of them match.
+			header, err := f.sys.backend.HeaderByNumber(ctx, rpc.BlockNumber(number))
+			if header == nil || err != nil {
+				return err
+			}
+			found, err := f.checkMatches(ctx, header)
+			if err != nil {
+				return err
+			}
+			for _, log := range found {
+				select {
+				case logChan <- log:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+		}
+		f.begin = int64(min((section+1)*size-1, end)) + 1
+	}
+	return nil
+}
+
+// hasCriteria reports whether the filter restricts the addresses or topics of
+// the logs. Filters matching all logs can't make use of the log index.
+func (f *Filter) hasCriteria() bool {
+	if len(f.addresses) > 0 {
+		return true
+	}
+	for _, sub := range f.topics {
+		if len(sub) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// logIndexMatches returns the numbers of the blocks within a log index section
+// which contain logs matching all the filter criteria, in ascending order.
+func (f *Filter) logIndexMatches(db ethdb.KeyValueReader, section uint64) []uint64 {
+	// Logs have at most four topics, longer filters never match
+	if len(f.topics) > 4 {
+		return nil
+	}
+	var (
+		matches []uint64
+		first   = true
+	)
+	match := func(position uint8, values []common.Hash) {
+		var blocks []uint64
+		for _, value := range values {
+			blocks = mergeBlocks(blocks, rawdb.ReadLogIndex(db, section, position, value))
+		}
+		if first {
+			matches, first = blocks, false
+		} else {
+			matches = intersectBlocks(matches, blocks)
+		}
+	}
+	if len(f.addresses) > 0 {
+		values := make([]common.Hash, len(f.addresses))
+		for i, address := range f.addresses {
+			values[i] = common.BytesToHash(address.Bytes())
+		}
+		match(rawdb.LogIndexAddress, values)
+	}
+	for i, sub := range f.topics {
+		if len(sub) > 0 {
+			match(uint8(i+1), sub)
+		}
+	}
+	return matches
+}
+
+// mergeBlocks returns the union of two ascending lists of block numbers.
+func mergeBlocks(a, b []uint64) []uint64 {
+	merged := make([]uint64, 0, len(a)+len(b))
+	for len(a) > 0 && len(b) > 0 {
+		switch {
+		case a[0] < b[0]:
+			merged, a = append(merged, a[0]), a[1:]
+		case a[0] > b[0]:
+			merged, b = append(merged, b[0]), b[1:]
+		default:
+			merged, a, b = append(merged, a[0]), a[1:], b[1:]
+		}
+	}
+	merged = append(merged, a...)
+	return append(merged, b...)
+}
+
+// intersectBlocks returns the intersection of two ascending lists of block numbers.
+func intersectBlocks(a, b []uint64) []uint64 {
+	var both []uint64
+	for len(a) > 0 && len(b) > 0 {
+		switch {
+		case a[0] < b[0]:
+			a = a[1:]
+		case a[0] > b[0]:
+			b = b[1:]
+		default:
+			both, a, b = append(both, a[0]), a[1:], b[1:]
+		}
+	}
+	return both
+}
+
 // unindexedLogs returns the logs matching the filter criteria based on raw block
 // iteration and bloom matching.
 func (f *Filter) unindexedLogs(ctx context.Context, end uint64, logChan chan *types.Log) error {
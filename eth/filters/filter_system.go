@@ -42,8 +42,9 @@ import (
 
 // Config represents the configuration of the filter system.
 type Config struct {
-	LogCacheSize int           // maximum number of cached blocks (default: 32)
-	Timeout      time.Duration // how long filters stay active (default: 5min)
+	LogCacheSize   int           // maximum number of cached blocks (default: 32)
+	Timeout        time.Duration // how long filters stay active (default: 5min)
+	LogReplayLimit uint64        // maximum number of blocks a resumed log subscription replays (default: 10000)
 }
 
 func (cfg Config) withDefaults() Config {
@@ -53,6 +54,9 @@ func (cfg Config) withDefaults() Config {
 	if cfg.LogCacheSize == 0 {
 		cfg.LogCacheSize = 32
 	}
+	if cfg.LogReplayLimit == 0 {
+		cfg.LogReplayLimit = 10000
+	}
 	return cfg
 }
 
@@ -74,6 +78,7 @@ type Backend interface {
 	SubscribePendingLogsEvent(ch chan<- []*types.Log) event.Subscription
 
 	BloomStatus() (uint64, uint64)
+	LogIndexStatus() (uint64, uint64)
 	ServiceFilter(ctx context.Context, session *bloombits.MatcherSession)
 }
 
@@ -19,6 +19,7 @@ package filters
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"math/big"
 	"strings"
 	"testing"
@@ -32,6 +33,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/ethereum/go-ethereum/trie"
@@ -369,3 +371,112 @@ func TestFilters(t *testing.T) {
 		}
 	})
 }
+
+// logIndexChain feeds the chain stored in the test backend to a log indexer.
+type logIndexChain struct {
+	*testBackend
+	heads event.Feed
+}
+
+func (c *logIndexChain) SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription {
+	return c.heads.Subscribe(ch)
+}
+
+// Tests that filtering logs via the log index yields the same results as
+// filtering them via the bloom filters of the headers.
+func TestFiltersLogIndex(t *testing.T) {
+	var (
+		db           = rawdb.NewMemoryDatabase()
+		backend, sys = newTestFilterSystem(t, db, Config{})
+		addr1        = common.BytesToAddress([]byte("jeff"))
+		addr2        = common.BytesToAddress([]byte("ethereum"))
+		addr3        = common.BytesToAddress([]byte("random addresses please"))
+		topic1       = common.BytesToHash([]byte("topic1"))
+		topic2       = common.BytesToHash([]byte("topic2"))
+
+		gspec = &core.Genesis{
+			BaseFee: big.NewInt(params.InitialBaseFee),
+			Config:  params.TestChainConfig,
+		}
+	)
+	addLog := func(gen *core.BlockGen, addr common.Address, topics ...common.Hash) {
+		receipt := makeReceipt(addr)
+		receipt.Logs[0].Topics = topics
+		receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
+		gen.AddUncheckedReceipt(receipt)
+		gen.AddUncheckedTx(types.NewTransaction(999, common.HexToAddress("0x999"), big.NewInt(999), 999, gen.BaseFee(), nil))
+	}
+	_, chain, receipts := core.GenerateChainWithGenesis(gspec, ethash.NewFaker(), 1000, func(i int, gen *core.BlockGen) {
+		switch i {
+		case 1:
+			addLog(gen, addr1, topic1)
+		case 2:
+			addLog(gen, addr1, topic2, topic1)
+			addLog(gen, addr2, topic1)
+		case 500:
+			addLog(gen, addr2)
+		case 998:
+			addLog(gen, addr3, topic2)
+		case 999:
+			addLog(gen, addr1, topic1)
+		}
+	})
+	// The test txs are not properly signed, write the chain into the database
+	// directly instead of importing it.
+	gspec.MustCommit(db, trie.NewDatabase(db, trie.HashDefaults))
+	for i, block := range chain {
+		rawdb.WriteBlock(db, block)
+		rawdb.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+		rawdb.WriteHeadBlockHash(db, block.Hash())
+		rawdb.WriteReceipts(db, block.Hash(), block.NumberU64(), receipts[i])
+	}
+	indexer := core.NewLogIndexer(db, 256, 0)
+	indexer.Start(&logIndexChain{testBackend: backend})
+	defer indexer.Close()
+
+	for start := time.Now(); ; time.Sleep(10 * time.Millisecond) {
+		if sections, _, _ := indexer.Sections(); sections == 3 {
+			break
+		}
+		if time.Since(start) > 10*time.Second {
+			t.Fatal("log index not generated in time")
+		}
+	}
+	if blocks := rawdb.ReadLogIndex(db, 0, rawdb.LogIndexAddress, common.BytesToHash(addr1.Bytes())); fmt.Sprint(blocks) != "[2 3]" {
+		t.Fatalf("wrong indexed blocks of address: %v", blocks)
+	}
+	for i, tc := range []struct {
+		begin, end int64
+		addresses  []common.Address
+		topics     [][]common.Hash
+		want       int
+	}{
+		{0, int64(rpc.LatestBlockNumber), []common.Address{addr1}, nil, 3},
+		{0, int64(rpc.LatestBlockNumber), []common.Address{addr1, addr2}, nil, 5},
+		{0, int64(rpc.LatestBlockNumber), nil, [][]common.Hash{{topic1}}, 3},
+		{0, int64(rpc.LatestBlockNumber), nil, [][]common.Hash{{topic2}, {topic1}}, 1},
+		{0, int64(rpc.LatestBlockNumber), []common.Address{addr1}, [][]common.Hash{{topic1, topic2}}, 3},
+		{3, 600, []common.Address{addr1, addr2}, nil, 3},
+		{700, int64(rpc.LatestBlockNumber), nil, [][]common.Hash{{topic2}}, 1},
+		{0, int64(rpc.LatestBlockNumber), []common.Address{{0x01}}, nil, 0},
+	} {
+		backend.logIndexSize, backend.logSections = 0, 0
+		plain, err := sys.NewRangeFilter(tc.begin, tc.end, tc.addresses, tc.topics).Logs(context.Background())
+		if err != nil {
+			t.Fatalf("test %d: failed to filter logs: %v", i, err)
+		}
+		backend.logIndexSize, backend.logSections = 256, 3
+		indexed, err := sys.NewRangeFilter(tc.begin, tc.end, tc.addresses, tc.topics).Logs(context.Background())
+		if err != nil {
+			t.Fatalf("test %d: failed to filter logs via the index: %v", i, err)
+		}
+		if len(plain) != tc.want {
+			t.Errorf("test %d: wrong number of logs: have %d, want %d", i, len(plain), tc.want)
+		}
+		have, _ := json.Marshal(indexed)
+		want, _ := json.Marshal(plain)
+		if string(have) != string(want) {
+			t.Errorf("test %d: indexed logs mismatch:\nhave %s\nwant %s", i, have, want)
+		}
+	}
+}
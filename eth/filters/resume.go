@@ -0,0 +1,309 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package filters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// logReplayBatch is the number of blocks whose logs are replayed at once when
+// resuming a log subscription.
+const logReplayBatch = 2048
+
+var (
+	errUnknownCursor = errors.New("unknown cursor block")
+	errCursorRange   = errors.New("cursor can't be combined with a block range")
+	errCursorTooOld  = errors.New("cursor too far behind the head")
+)
+
+// LogCursor identifies the last log delivered to a subscriber, by the hash of
+// the block containing it and its index within the block. Passing the cursor
+// when subscribing again resumes the subscription right after that log.
+type LogCursor struct {
+	BlockHash common.Hash  `json:"blockHash"`
+	LogIndex  hexutil.Uint `json:"logIndex"`
+}
+
+// logReplay describes the part of the chain a resumed subscription needs to
+// replay to bring the subscriber's view up to date with the canonical chain.
+type logReplay struct {
+	cursor  LogCursor
+	reorged []*types.Header // Blocks of the subscriber's view no longer canonical, newest first
+	from    uint64          // First canonical block to replay logs from
+}
+
+// newLogReplay determines the logs to be replayed for the given cursor by finding
+// the last block of the subscriber's view which is still canonical.
+func (api *FilterAPI) newLogReplay(ctx context.Context, cursor LogCursor) (*logReplay, error) {
+	header, err := api.sys.backend.HeaderByHash(ctx, cursor.BlockHash)
+	if err != nil {
+		return nil, err
+	}
+	if header == nil {
+		return nil, errUnknownCursor
+	}
+	replay := &logReplay{cursor: cursor}
+	for {
+		canon, err := api.sys.backend.HeaderByNumber(ctx, rpc.BlockNumber(header.Number.Int64()))
+		if err != nil {
+			return nil, err
+		}
+		if canon != nil && canon.Hash() == header.Hash() {
+			break
+		}
+		replay.reorged = append(replay.reorged, header)
+
+		if header, err = api.sys.backend.HeaderByHash(ctx, header.ParentHash); err != nil {
+			return nil, err
+		}
+		if header == nil {
+			return nil, errUnknownCursor
+		}
+	}
+	// The rest of the cursor block is replayed if it's still canonical,
+	// otherwise the canonical chain after the common ancestor.
+	replay.from = header.Number.Uint64() + 1
+	if len(replay.reorged) == 0 {
+		replay.from = header.Number.Uint64()
+	}
+	return replay, nil
+}
+
+// blocks returns the number of canonical blocks to be replayed up to the given
+// head.
+func (r *logReplay) blocks(head uint64) uint64 {
+	if head < r.from {
+		return 0
+	}
+	return head - r.from + 1
+}
+
+// canonicalCursor reports whether the cursor block is still canonical.
+func (r *logReplay) canonicalCursor() bool {
+	return len(r.reorged) == 0
+}
+
+// run retrieves the logs to be replayed and delivers them in batches. First, the
+// logs of the reorged blocks the subscriber has seen are delivered as removed,
+// followed by the logs of the canonical chain up to the current head.
+func (r *logReplay) run(ctx context.Context, sys *FilterSystem, crit FilterCriteria, logsCh chan<- []*types.Log) error {
+	deliver := func(logs []*types.Log) error {
+		if len(logs) == 0 {
+			return nil
+		}
+		select {
+		case logsCh <- logs:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	for _, header := range r.reorged {
+		logs, err := sys.NewBlockFilter(header.Hash(), crit.Addresses, crit.Topics).Logs(ctx)
+		if err != nil {
+			return err
+		}
+		var removed []*types.Log
+		for _, log := range logs {
+			if log.BlockHash == r.cursor.BlockHash && log.Index > uint(r.cursor.LogIndex) {
+				continue
+			}
+			logcopy := *log
+			logcopy.Removed = true
+			removed = append(removed, &logcopy)
+		}
+		if err := deliver(removed); err != nil {
+			return err
+		}
+	}
+	from := r.from
+	if r.canonicalCursor() {
+		logs, err := sys.NewBlockFilter(r.cursor.BlockHash, crit.Addresses, crit.Topics).Logs(ctx)
+		if err != nil {
+			return err
+		}
+		var missed []*types.Log
+		for _, log := range logs {
+			if log.Index > uint(r.cursor.LogIndex) {
+				missed = append(missed, log)
+			}
+		}
+		if err := deliver(missed); err != nil {
+			return err
+		}
+		from++
+	}
+	head := sys.backend.CurrentHeader().Number.Uint64()
+	for ; from <= head; from += logReplayBatch {
+		end := min(from+logReplayBatch-1, head)
+		logs, err := sys.NewRangeFilter(int64(from), int64(end), crit.Addresses, crit.Topics).Logs(ctx)
+		if err != nil {
+			return err
+		}
+		if err := deliver(logs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// logReplayError is the notification sent to a resumed subscriber if replaying
+// the missed logs fails. No more logs are delivered after it.
+type logReplayError struct {
+	Error string `json:"error"`
+}
+
+// resumeLogs creates a log subscription continuing after the given cursor. The
+// logs the subscriber missed are replayed from the database, preceded by the
+// removal of the logs which were reorged out of its view, before switching to
+// streaming live logs. Cursors further behind the head than the replay limit
+// are rejected.
+func (api *FilterAPI) resumeLogs(ctx context.Context, notifier *rpc.Notifier, crit FilterCriteria, cursor LogCursor) (*rpc.Subscription, error) {
+	if crit.BlockHash != nil || crit.FromBlock != nil || crit.ToBlock != nil {
+		return nil, errCursorRange
+	}
+	replay, err := api.newLogReplay(ctx, cursor)
+	if err != nil {
+		return nil, err
+	}
+	limit := api.sys.cfg.LogReplayLimit
+	if n := replay.blocks(api.sys.backend.CurrentHeader().Number.Uint64()); n > limit {
+		return nil, fmt.Errorf("%w: %d blocks to replay, limit %d", errCursorTooOld, n, limit)
+	}
+	// Subscribe to live logs before replaying, so nothing is missed in between.
+	// Live logs arriving during the replay are held back until it's done.
+	var (
+		rpcSub      = notifier.CreateSubscription()
+		matchedLogs = make(chan []*types.Log)
+	)
+	logsSub, err := api.events.SubscribeLogs(ethereum.FilterQuery(crit), matchedLogs)
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		var (
+			replayCtx, cancel = context.WithCancel(context.Background())
+			replayed          = make(chan []*types.Log)
+			replayErr         = make(chan error, 1)
+			held              [][]*types.Log
+			view              = newLogView(replay)
+		)
+		defer func() {
+			cancel()
+			logsSub.Unsubscribe()
+		}()
+		go func() {
+			err := replay.run(replayCtx, api.sys, crit, replayed)
+			if err == nil {
+				close(replayed)
+				return
+			}
+			replayErr <- err
+		}()
+		notify := func(logs []*types.Log) {
+			for _, log := range logs {
+				notifier.Notify(rpcSub.ID, log)
+			}
+		}
+		for {
+			select {
+			case logs, ok := <-replayed:
+				if !ok {
+					// Replay done, deliver the held back live logs the subscriber
+					// hasn't seen yet, then stream the new ones directly
+					for _, logs := range held {
+						notify(view.reconcile(logs))
+					}
+					replayed, held, view = nil, nil, nil
+					continue
+				}
+				view.add(logs)
+				notify(logs)
+			case err := <-replayErr:
+				log.Warn("Failed to replay logs", "cursor", cursor.BlockHash, "index", cursor.LogIndex, "err", err)
+				notifier.Notify(rpcSub.ID, &logReplayError{Error: err.Error()})
+				return
+			case logs := <-matchedLogs:
+				if replayed != nil {
+					held = append(held, logs)
+					continue
+				}
+				notify(logs)
+			case <-rpcSub.Err(): // client send an unsubscribe request
+				return
+			case <-notifier.Closed(): // connection dropped
+				return
+			}
+		}
+	}()
+	return rpcSub, nil
+}
+
+// logView tracks the blocks whose logs a resumed subscriber has seen, in order to
+// reconcile the live logs which arrived during the replay with the replayed ones.
+type logView struct {
+	from uint64                   // Blocks below this were all seen before resuming
+	seen map[common.Hash]struct{} // Blocks at or above 'from' with logs seen
+}
+
+func newLogView(replay *logReplay) *logView {
+	view := &logView{
+		from: replay.from,
+		seen: make(map[common.Hash]struct{}),
+	}
+	if replay.canonicalCursor() {
+		view.seen[replay.cursor.BlockHash] = struct{}{}
+	}
+	return view
+}
+
+// add records the delivery of the given logs.
+func (v *logView) add(logs []*types.Log) {
+	for _, log := range logs {
+		if log.Removed {
+			delete(v.seen, log.BlockHash)
+		} else {
+			v.seen[log.BlockHash] = struct{}{}
+		}
+	}
+}
+
+// reconcile filters a batch of live logs, dropping the new logs which were
+// already replayed and the removed logs which the subscriber has never seen.
+func (v *logView) reconcile(logs []*types.Log) []*types.Log {
+	var result []*types.Log
+	for _, log := range logs {
+		_, seen := v.seen[log.BlockHash]
+		switch {
+		case log.Removed && (seen || log.BlockNumber < v.from):
+			result = append(result, log)
+		case !log.Removed && !seen:
+			result = append(result, log)
+		}
+	}
+	v.add(result)
+	return result
+}
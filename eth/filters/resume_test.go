@@ -0,0 +1,200 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package filters
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// logPosition identifies a delivered log for comparing subscription results.
+type logPosition struct {
+	number  uint64
+	hash    common.Hash
+	removed bool
+}
+
+func TestResumeLogs(t *testing.T) {
+	var (
+		db           = rawdb.NewMemoryDatabase()
+		backend, sys = newTestFilterSystem(t, db, Config{})
+		key, _       = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr         = crypto.PubkeyToAddress(key.PublicKey)
+		signer       = types.LatestSigner(params.TestChainConfig)
+		contract     = common.Address{0xfe}
+		gspec        = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc: core.GenesisAlloc{
+				addr:     {Balance: big.NewInt(params.Ether)},
+				contract: {Balance: big.NewInt(0), Code: common.FromHex("0x60006000a000")}, // LOG0(0, 0)
+			},
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+	)
+	logger := func(coinbase common.Address) func(int, *core.BlockGen) {
+		return func(i int, gen *core.BlockGen) {
+			gen.SetCoinbase(coinbase)
+			tx, _ := types.SignTx(types.NewTx(&types.LegacyTx{
+				Nonce:    gen.TxNonce(addr),
+				GasPrice: gen.BaseFee(),
+				Gas:      30000,
+				To:       &contract,
+			}), signer, key)
+			gen.AddTx(tx)
+		}
+	}
+	genDb, chain, receipts := core.GenerateChainWithGenesis(gspec, ethash.NewFaker(), 10, logger(common.Address{}))
+	fork, forkReceipts := core.GenerateChain(gspec.Config, chain[5], ethash.NewFaker(), genDb, 6, logger(common.Address{0x01}))
+
+	gspec.MustCommit(db, trie.NewDatabase(db, trie.HashDefaults))
+	for i, block := range chain {
+		rawdb.WriteBlock(db, block)
+		rawdb.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+		rawdb.WriteReceipts(db, block.Hash(), block.NumberU64(), receipts[i])
+	}
+	rawdb.WriteHeadBlockHash(db, chain[len(chain)-1].Hash())
+	for i, block := range fork {
+		rawdb.WriteBlock(db, block)
+		rawdb.WriteReceipts(db, block.Hash(), block.NumberU64(), forkReceipts[i])
+	}
+	server := rpc.NewServer()
+	defer server.Stop()
+	if err := server.RegisterName("eth", NewFilterAPI(sys, false)); err != nil {
+		t.Fatal(err)
+	}
+	client := rpc.DialInProc(server)
+	defer client.Close()
+
+	// subscribe resumes the log subscription after the cursor, returning the
+	// positions of the first n logs delivered. If live is set, a new log is
+	// posted after receiving them, which is expected to be delivered too.
+	subscribe := func(cursor LogCursor, n int, live bool) []logPosition {
+		t.Helper()
+
+		ch := make(chan *types.Log)
+		crit := map[string]interface{}{"address": contract}
+		sub, err := client.EthSubscribe(context.Background(), ch, "logs", crit, cursor)
+		if err != nil {
+			t.Fatalf("failed to subscribe: %v", err)
+		}
+		defer sub.Unsubscribe()
+
+		var have []logPosition
+		for len(have) < n {
+			select {
+			case log := <-ch:
+				have = append(have, logPosition{log.BlockNumber, log.BlockHash, log.Removed})
+			case err := <-sub.Err():
+				t.Fatalf("subscription failed: %v", err)
+			case <-time.After(5 * time.Second):
+				t.Fatalf("timeout, received %d of %d logs", len(have), n)
+			}
+			if len(have) == n && live {
+				live = false
+				backend.logsFeed.Send([]*types.Log{{Address: contract, Topics: []common.Hash{}, Data: []byte{}, BlockNumber: 11, BlockHash: common.Hash{0x11}}})
+				n++
+			}
+		}
+		return have
+	}
+	check := func(have []logPosition, want []logPosition) {
+		t.Helper()
+		if len(have) != len(want) {
+			t.Fatalf("wrong number of logs: have %d, want %d", len(have), len(want))
+		}
+		for i := range have {
+			if have[i] != want[i] {
+				t.Errorf("log %d: have %+v, want %+v", i, have[i], want[i])
+			}
+		}
+	}
+	position := func(block *types.Block, removed bool) logPosition {
+		return logPosition{block.NumberU64(), block.Hash(), removed}
+	}
+	// Resuming on the canonical chain replays the logs after the cursor, then
+	// streams live logs.
+	var want []logPosition
+	for _, block := range chain[4:] {
+		want = append(want, position(block, false))
+	}
+	want = append(want, logPosition{11, common.Hash{0x11}, false})
+	check(subscribe(LogCursor{BlockHash: chain[3].Hash()}, 6, true), want)
+
+	// Reorg the chain, resuming from the old chain removes the logs the client
+	// has seen, before replaying the new chain.
+	for _, block := range fork {
+		rawdb.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+	}
+	rawdb.WriteHeadBlockHash(db, fork[len(fork)-1].Hash())
+
+	want = []logPosition{position(chain[8], true), position(chain[7], true), position(chain[6], true)}
+	for _, block := range fork {
+		want = append(want, position(block, false))
+	}
+	check(subscribe(LogCursor{BlockHash: chain[8].Hash()}, len(want), false), want)
+
+	// Unknown cursors are rejected.
+	ch := make(chan *types.Log)
+	if _, err := client.EthSubscribe(context.Background(), ch, "logs", map[string]interface{}{}, LogCursor{BlockHash: common.Hash{0xff}}); err == nil {
+		t.Fatal("expected error for unknown cursor")
+	}
+	// Cursors too far behind the head are rejected, instead of replaying an
+	// unbounded range.
+	sys.cfg.LogReplayLimit = 5
+	if _, err := client.EthSubscribe(context.Background(), ch, "logs", map[string]interface{}{}, LogCursor{BlockHash: chain[3].Hash()}); err == nil {
+		t.Fatal("expected error for cursor beyond the replay limit")
+	}
+	check(subscribe(LogCursor{BlockHash: fork[1].Hash()}, 4, false), []logPosition{
+		position(fork[2], false), position(fork[3], false), position(fork[4], false), position(fork[5], false),
+	})
+}
+
+func TestLogViewReconcile(t *testing.T) {
+	var (
+		b4, b5, b6, x7 = common.Hash{4}, common.Hash{5}, common.Hash{6}, common.Hash{7}
+		view           = &logView{from: 5, seen: map[common.Hash]struct{}{b5: {}}}
+	)
+	logs := []*types.Log{
+		{BlockNumber: 5, BlockHash: b5},                // replayed already
+		{BlockNumber: 6, BlockHash: b6},                // new
+		{BlockNumber: 4, BlockHash: b4, Removed: true}, // seen before resuming
+		{BlockNumber: 7, BlockHash: x7, Removed: true}, // never seen
+	}
+	have := view.reconcile(logs)
+	if len(have) != 2 || have[0] != logs[1] || have[1] != logs[2] {
+		t.Fatalf("wrong reconciled logs: %v", have)
+	}
+	have = view.reconcile([]*types.Log{{BlockNumber: 6, BlockHash: b6, Removed: true}})
+	if len(have) != 1 {
+		t.Fatalf("removal of delivered log dropped")
+	}
+	if _, ok := view.seen[b6]; ok {
+		t.Fatalf("removed block still seen")
+	}
+}
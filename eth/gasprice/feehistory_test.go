@@ -22,6 +22,8 @@ import (
 	"math/big"
 	"testing"
 
+	"github.com/ethereum/go-ethereum/consensus/misc/eip4844"
+	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rpc"
 )
 
@@ -58,10 +60,10 @@ func TestFeeHistory(t *testing.T) {
 			MaxHeaderHistory: c.maxHeader,
 			MaxBlockHistory:  c.maxBlock,
 		}
-		backend := newTestBackend(t, big.NewInt(16), c.pending)
+		backend := newTestBackend(t, big.NewInt(16), big.NewInt(28), c.pending)
 		oracle := NewOracle(backend, config)
 
-		first, reward, baseFee, ratio, err := oracle.FeeHistory(context.Background(), c.count, c.last, c.percent)
+		first, reward, baseFee, ratio, blobBaseFee, blobRatio, err := oracle.FeeHistory(context.Background(), c.count, c.last, c.percent)
 		backend.teardown()
 		expReward := c.expCount
 		if len(c.percent) == 0 {
@@ -84,8 +86,61 @@ func TestFeeHistory(t *testing.T) {
 		if len(ratio) != c.expCount {
 			t.Fatalf("Test case %d: gasUsedRatio array length mismatch, want %d, got %d", i, c.expCount, len(ratio))
 		}
+		if len(blobRatio) != c.expCount {
+			t.Fatalf("Test case %d: blobGasUsedRatio array length mismatch, want %d, got %d", i, c.expCount, len(blobRatio))
+		}
+		if len(blobBaseFee) != len(baseFee) {
+			t.Fatalf("Test case %d: blobBaseFee array length mismatch, want %d, got %d", i, len(baseFee), len(blobBaseFee))
+		}
 		if err != c.expErr && !errors.Is(err, c.expErr) {
 			t.Fatalf("Test case %d: error mismatch, want %v, got %v", i, c.expErr, err)
 		}
 	}
 }
+
+func TestFeeHistoryBlobs(t *testing.T) {
+	backend := newTestBackend(t, big.NewInt(16), big.NewInt(28), false)
+	defer backend.teardown()
+	oracle := NewOracle(backend, Config{MaxHeaderHistory: 1000, MaxBlockHistory: 1000})
+
+	first, _, _, _, blobBaseFee, blobRatio, err := oracle.FeeHistory(context.Background(), 8, rpc.LatestBlockNumber, nil)
+	if err != nil {
+		t.Fatalf("failed to retrieve fee history: %v", err)
+	}
+	if first.Uint64() != 25 {
+		t.Fatalf("first block mismatch: want %d, got %d", 25, first)
+	}
+	for i, ratio := range blobRatio {
+		number := first.Uint64() + uint64(i)
+
+		// Blocks before Cancun and the fork block itself carry no blobs, the
+		// following blocks one blob more each, up to the maximum of six.
+		var want float64
+		if number > 28 {
+			blobs := number - 28
+			if blobs > 6 {
+				blobs = 6
+			}
+			want = float64(blobs*params.BlobTxBlobGasPerBlob) / params.MaxBlobGasPerBlock
+		}
+		if ratio != want {
+			t.Errorf("block %d: blobGasUsedRatio mismatch: want %v, got %v", number, want, ratio)
+		}
+		header, _ := backend.HeaderByNumber(context.Background(), rpc.BlockNumber(number))
+		if header.ExcessBlobGas == nil {
+			if blobBaseFee[i].Sign() != 0 {
+				t.Errorf("block %d: non-zero blob base fee before Cancun: %v", number, blobBaseFee[i])
+			}
+			continue
+		}
+		if want := eip4844.CalcBlobFee(*header.ExcessBlobGas); blobBaseFee[i].Cmp(want) != 0 {
+			t.Errorf("block %d: blobBaseFee mismatch: want %v, got %v", number, want, blobBaseFee[i])
+		}
+	}
+	// The blob base fee of the next block is derived from the head
+	head, _ := backend.HeaderByNumber(context.Background(), rpc.LatestBlockNumber)
+	want := eip4844.CalcBlobFee(eip4844.CalcExcessBlobGas(*head.ExcessBlobGas, *head.BlobGasUsed))
+	if have := blobBaseFee[len(blobBaseFee)-1]; have.Cmp(want) != 0 {
+		t.Errorf("next blobBaseFee mismatch: want %v, got %v", want, have)
+	}
+}
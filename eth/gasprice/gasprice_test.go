@@ -18,20 +18,24 @@ package gasprice
 
 import (
 	"context"
+	"crypto/sha256"
 	"math"
 	"math/big"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/beacon"
 	"github.com/ethereum/go-ethereum/consensus/ethash"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/crypto/kzg4844"
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/holiman/uint256"
 )
 
 const testHead = 32
@@ -117,9 +121,22 @@ func (b *testBackend) teardown() {
 	b.chain.Stop()
 }
 
+var (
+	emptyBlob          = kzg4844.Blob{}
+	emptyBlobCommit, _ = kzg4844.BlobToCommitment(emptyBlob)
+	emptyBlobVHash     = blobHash(emptyBlobCommit)
+)
+
+// blobHash computes the versioned hash of a blob commitment.
+func blobHash(commit kzg4844.Commitment) common.Hash {
+	hash := sha256.Sum256(commit[:])
+	hash[0] = params.BlobTxHashVersion
+	return hash
+}
+
 // newTestBackend creates a test backend. OBS: don't forget to invoke tearDown
 // after use, otherwise the blockchain instance will mem-leak via goroutines.
-func newTestBackend(t *testing.T, londonBlock *big.Int, pending bool) *testBackend {
+func newTestBackend(t *testing.T, londonBlock *big.Int, cancunBlock *big.Int, pending bool) *testBackend {
 	var (
 		key, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
 		addr   = crypto.PubkeyToAddress(key.PublicKey)
@@ -133,8 +150,17 @@ func newTestBackend(t *testing.T, londonBlock *big.Int, pending bool) *testBacke
 	config.LondonBlock = londonBlock
 	config.ArrowGlacierBlock = londonBlock
 	config.GrayGlacierBlock = londonBlock
-	config.TerminalTotalDifficulty = common.Big0
-	engine := ethash.NewFaker()
+
+	// Cancun is time-based, activate it at the timestamp of the requested block
+	// using the fixed 10 second block time of the chain generator.
+	if cancunBlock != nil {
+		ts := gspec.Timestamp + cancunBlock.Uint64()*10
+		config.ShanghaiTime = &ts
+		config.CancunTime = &ts
+		signer = types.LatestSigner(gspec.Config)
+	}
+	engine := beacon.New(ethash.NewFaker())
+	td := params.GenesisDifficulty.Uint64()
 
 	// Generate testing blocks
 	_, blocks, _ := core.GenerateChainWithGenesis(gspec, engine, testHead+1, func(i int, b *core.BlockGen) {
@@ -162,8 +188,34 @@ func newTestBackend(t *testing.T, londonBlock *big.Int, pending bool) *testBacke
 			}
 		}
 		b.AddTx(types.MustSignNewTx(key, signer, txdata))
+
+		if cancunBlock != nil && b.Number().Cmp(cancunBlock) >= 0 {
+			b.SetPoS()
+
+			// Put one more blob in each new block, up to the maximum of six
+			blobs := new(big.Int).Sub(b.Number(), cancunBlock).Uint64()
+			for j := uint64(0); j < blobs && j < 6; j++ {
+				blobTx := &types.BlobTx{
+					ChainID:    uint256.MustFromBig(gspec.Config.ChainID),
+					Nonce:      b.TxNonce(addr),
+					To:         common.Address{},
+					Gas:        30000,
+					GasFeeCap:  uint256.NewInt(100 * params.GWei),
+					GasTipCap:  uint256.NewInt(uint64(i+1) * params.GWei),
+					Data:       []byte{},
+					BlobFeeCap: uint256.NewInt(1),
+					BlobHashes: []common.Hash{emptyBlobVHash},
+					Value:      uint256.NewInt(100),
+				}
+				b.AddTx(types.MustSignNewTx(key, signer, blobTx))
+			}
+		}
 	})
-	// Construct testing chain
+	// Construct testing chain, with the merge transition after the last PoW block
+	for _, block := range blocks {
+		td += block.Difficulty().Uint64()
+	}
+	gspec.Config.TerminalTotalDifficulty = new(big.Int).SetUint64(td)
 	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), &core.CacheConfig{TrieCleanNoPrefetch: true}, gspec, nil, engine, vm.Config{}, nil, nil)
 	if err != nil {
 		t.Fatalf("Failed to create local chain, %v", err)
@@ -199,7 +251,7 @@ func TestSuggestTipCap(t *testing.T) {
 		{big.NewInt(33), big.NewInt(params.GWei * int64(30))}, // Fork point in the future
 	}
 	for _, c := range cases {
-		backend := newTestBackend(t, c.fork, false)
+		backend := newTestBackend(t, c.fork, nil, false)
 		oracle := NewOracle(backend, config)
 
 		// The gas price sampled is: 32G, 31G, 30G, 29G, 28G, 27G
@@ -226,14 +226,29 @@ func ServiceGetBlockBodiesQuery(chain *core.BlockChain, query GetBlockBodiesPack
 			lookups >= 2*maxBodiesServe {
 			break
 		}
-		if data := chain.GetBodyRLP(hash); len(data) != 0 {
-			bodies = append(bodies, data)
-			bytes += len(data)
+		data := chain.GetBodyRLP(hash)
+		if len(data) == 0 {
+			// Stop serving at the first pruned body, the remaining ones are
+			// most likely below the history cutoff as well.
+			if isPrunedHistory(chain, hash) {
+				log.Debug("Refusing to serve pruned block bodies", "hash", hash, "cutoff", chain.HistoryPruningCutoff())
+				break
+			}
+			continue
 		}
+		bodies = append(bodies, data)
+		bytes += len(data)
 	}
 	return bodies
 }
 
+// isPrunedHistory reports whether the block with the given hash is known, but
+// its body and receipts have been pruned along with the chain history.
+func isPrunedHistory(chain *core.BlockChain, hash common.Hash) bool {
+	number := chain.HeaderChain().GetBlockNumber(hash)
+	return number != nil && *number < chain.HistoryPruningCutoff()
+}
+
 func handleGetNodeData66(backend Backend, msg Decoder, peer *Peer) error {
 	// Decode the trie node data retrieval message
 	var query GetNodeDataPacket66
@@ -301,7 +316,15 @@ func ServiceGetReceiptsQuery(chain *core.BlockChain, query GetReceiptsPacket) []
 		// Retrieve the requested block's receipts
 		results := chain.GetReceiptsByHash(hash)
 		if results == nil {
-			if header := chain.GetHeaderByHash(hash); header == nil || header.ReceiptHash != types.EmptyRootHash {
+			header := chain.GetHeaderByHash(hash)
+			if header == nil {
+				continue
+			}
+			if header.ReceiptHash != types.EmptyRootHash {
+				if header.Number.Uint64() < chain.HistoryPruningCutoff() {
+					log.Debug("Refusing to serve pruned receipts", "hash", hash, "cutoff", chain.HistoryPruningCutoff())
+					break
+				}
 				continue
 			}
 		}
@@ -180,10 +180,14 @@ func (eth *Ethereum) pathState(block *types.Block) (*state.StateDB, func(), erro
 	if err == nil {
 		return statedb, noopReleaser, nil
 	}
-	// TODO historic state is not supported in path-based scheme.
-	// Fully archive node in pbss will be implemented by relying
-	// on state history, but needs more work on top.
-	return nil, nil, errors.New("historical state not available in path scheme yet")
+	// Otherwise try to reconstruct the historic state from the indexed
+	// state histories. The returned state is read-only, but it's fine
+	// for tracing as nothing is committed.
+	statedb, err = eth.blockchain.HistoricState(block.Root())
+	if err == nil {
+		return statedb, noopReleaser, nil
+	}
+	return nil, nil, fmt.Errorf("historical state not available in path scheme: %w", err)
 }
 
 // stateAtBlock retrieves the state database associated with a certain block.
@@ -31,6 +31,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/beacon"
 	"github.com/ethereum/go-ethereum/consensus/ethash"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/rawdb"
@@ -69,6 +70,9 @@ func newTestBackend(t *testing.T, n int, gspec *core.Genesis, generator func(i i
 		engine:      ethash.NewFaker(),
 		chaindb:     rawdb.NewMemoryDatabase(),
 	}
+	if gspec.Config.TerminalTotalDifficulty != nil {
+		backend.engine = beacon.New(backend.engine)
+	}
 	// Generate blocks for testing
 	_, blocks, _ := core.GenerateChainWithGenesis(gspec, backend.engine, n, generator)
 
@@ -451,6 +455,51 @@ func TestTraceBlock(t *testing.T) {
 	}
 }
 
+func TestIntermediateRoots(t *testing.T) {
+	t.Parallel()
+
+	// Initialize test accounts, and the beacon roots contract of EIP-4788
+	accounts := newAccounts(2)
+	config := *params.TestChainConfig
+	config.TerminalTotalDifficulty = common.Big0
+	config.TerminalTotalDifficultyPassed = true
+	config.ShanghaiTime = new(uint64)
+	config.CancunTime = new(uint64)
+	genesis := &core.Genesis{
+		Config: &config,
+		Alloc: core.GenesisAlloc{
+			accounts[0].addr:                 {Balance: big.NewInt(params.Ether)},
+			params.BeaconRootsStorageAddress: {Balance: common.Big0, Code: common.Hex2Bytes("3373fffffffffffffffffffffffffffffffffffffffe14604457602036146024575f5ffd5b620180005f350680545f35146037575f5ffd5b6201800001545f5260205ff35b6201800042064281555f359062018000015500")},
+		},
+		Difficulty: common.Big0,
+		BaseFee:    big.NewInt(params.InitialBaseFee),
+	}
+	signer := types.LatestSigner(genesis.Config)
+	backend := newTestBackend(t, 1, genesis, func(i int, b *core.BlockGen) {
+		b.SetPoS()
+		for j := 0; j < 2; j++ {
+			tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(accounts[0].addr), accounts[1].addr, big.NewInt(1000), params.TxGas, b.BaseFee(), nil), signer, accounts[0].key)
+			b.AddTx(tx)
+		}
+	})
+	defer backend.chain.Stop()
+	api := NewAPI(backend)
+
+	// The beacon root is stored before the first transaction, so the root
+	// after the last one is the state root of the block.
+	block := backend.chain.GetBlockByNumber(1)
+	roots, err := api.IntermediateRoots(context.Background(), block.Hash(), nil)
+	if err != nil {
+		t.Fatalf("failed to compute intermediate roots: %v", err)
+	}
+	if len(roots) != 2 {
+		t.Fatalf("wrong number of roots: have %d, want 2", len(roots))
+	}
+	if roots[1] != block.Root() {
+		t.Errorf("wrong root after last transaction: have %x, want %x", roots[1], block.Root())
+	}
+}
+
 func TestTracingWithOverrides(t *testing.T) {
 	t.Parallel()
 	// Initialize test accounts
@@ -0,0 +1,389 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	// flatCallTracerName is the name of the native tracer producing the
+	// Parity-style flat call traces served by the trace namespace.
+	flatCallTracerName = "flatCallTracer"
+
+	// stateDiffTracerName is the name of the native tracer producing the
+	// Parity-style state diffs served by trace_replayBlockTransactions.
+	stateDiffTracerName = "stateDiffTracer"
+)
+
+// flatCallTracerConfig is the tracer configuration used for all the trace
+// endpoints, matching the error strings of the Parity trace APIs.
+var flatCallTracerConfig = json.RawMessage(`{"convertParityErrors":true}`)
+
+// TraceAPI is the collection of Parity-style tracing APIs exposed over the
+// trace namespace. It is a thin layer on top of the debug tracing API, running
+// the flat call tracer on the requested transactions.
+type TraceAPI struct {
+	api *API
+}
+
+// NewTraceAPI creates a new API definition for the Parity-style tracing methods
+// of the Ethereum service.
+func NewTraceAPI(backend Backend) *TraceAPI {
+	return &TraceAPI{api: NewAPI(backend)}
+}
+
+// flatTrace is a single frame of a flat call trace, as produced by the flat call
+// tracer. Only the fields needed to filter and inspect the frame are decoded,
+// the frame is returned to the user in its original encoding.
+type flatTrace struct {
+	Action struct {
+		From           *common.Address `json:"from"`
+		To             *common.Address `json:"to"`
+		SelfDestructed *common.Address `json:"address"`
+		RefundAddress  *common.Address `json:"refundAddress"`
+	} `json:"action"`
+	Result *struct {
+		Address *common.Address `json:"address"`
+		Code    hexutil.Bytes   `json:"code"`
+		Output  hexutil.Bytes   `json:"output"`
+	} `json:"result"`
+	TraceAddress []int `json:"traceAddress"`
+
+	raw json.RawMessage
+}
+
+// MarshalJSON implements json.Marshaler, returning the original encoding of
+// the frame.
+func (t *flatTrace) MarshalJSON() ([]byte, error) {
+	return t.raw, nil
+}
+
+// from returns the address initiating the action of the frame.
+func (t *flatTrace) from() *common.Address {
+	if t.Action.From != nil {
+		return t.Action.From
+	}
+	return t.Action.SelfDestructed
+}
+
+// to returns the address receiving the action of the frame. For contract
+// creations this is the address of the new contract, for self-destructs the
+// beneficiary of the destructed balance.
+func (t *flatTrace) to() *common.Address {
+	switch {
+	case t.Action.To != nil:
+		return t.Action.To
+	case t.Action.RefundAddress != nil:
+		return t.Action.RefundAddress
+	case t.Result != nil:
+		return t.Result.Address
+	}
+	return nil
+}
+
+// decodeFlatTraces decodes the result of a flat call tracer run.
+func decodeFlatTraces(result interface{}) ([]*flatTrace, error) {
+	blob, ok := result.(json.RawMessage)
+	if !ok {
+		return nil, fmt.Errorf("unexpected trace result type %T", result)
+	}
+	var frames []json.RawMessage
+	if err := json.Unmarshal(blob, &frames); err != nil {
+		return nil, err
+	}
+	traces := make([]*flatTrace, len(frames))
+	for i, frame := range frames {
+		trace := &flatTrace{raw: frame}
+		if err := json.Unmarshal(frame, trace); err != nil {
+			return nil, err
+		}
+		traces[i] = trace
+	}
+	return traces, nil
+}
+
+// decodeBlockTraces flattens the results of a block trace into the list of all
+// the frames of all the transactions.
+func decodeBlockTraces(results []*txTraceResult) ([]*flatTrace, error) {
+	var traces []*flatTrace
+	for _, res := range results {
+		// Chain tracing leaves the results after a failure empty
+		if res == nil {
+			break
+		}
+		if res.Error != "" {
+			return nil, fmt.Errorf("failed to trace transaction %#x: %s", res.TxHash, res.Error)
+		}
+		frames, err := decodeFlatTraces(res.Result)
+		if err != nil {
+			return nil, err
+		}
+		traces = append(traces, frames...)
+	}
+	return traces, nil
+}
+
+// traceConfig returns the configuration for tracing with the given native
+// tracer and its config.
+func traceConfig(tracer string, config json.RawMessage) *TraceConfig {
+	return &TraceConfig{Tracer: &tracer, TracerConfig: config}
+}
+
+// Block returns the flat call traces of all the transactions in the given block.
+// Note, block and uncle rewards are not reported.
+func (api *TraceAPI) Block(ctx context.Context, number rpc.BlockNumber) ([]*flatTrace, error) {
+	block, err := api.api.blockByNumber(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	results, err := api.api.traceBlock(ctx, block, traceConfig(flatCallTracerName, flatCallTracerConfig))
+	if err != nil {
+		return nil, err
+	}
+	return decodeBlockTraces(results)
+}
+
+// Transaction returns the flat call traces of the given transaction.
+func (api *TraceAPI) Transaction(ctx context.Context, hash common.Hash) ([]*flatTrace, error) {
+	result, err := api.api.TraceTransaction(ctx, hash, traceConfig(flatCallTracerName, flatCallTracerConfig))
+	if err != nil {
+		return nil, err
+	}
+	return decodeFlatTraces(result)
+}
+
+// Get returns the frame of the given transaction at the specified trace address,
+// i.e. the path of call indices leading to the frame from the top level call. The
+// empty trace address identifies the top level call itself.
+func (api *TraceAPI) Get(ctx context.Context, hash common.Hash, indices []hexutil.Uint64) (*flatTrace, error) {
+	traces, err := api.Transaction(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	for _, trace := range traces {
+		if len(trace.TraceAddress) != len(indices) {
+			continue
+		}
+		match := true
+		for i, index := range indices {
+			if uint64(trace.TraceAddress[i]) != uint64(index) {
+				match = false
+				break
+			}
+		}
+		if match {
+			return trace, nil
+		}
+	}
+	return nil, nil
+}
+
+// TraceResults is the result of replaying a transaction with the requested
+// trace types. The fields of the types not requested are left empty.
+type TraceResults struct {
+	Output          hexutil.Bytes   `json:"output"`
+	StateDiff       json.RawMessage `json:"stateDiff"`
+	Trace           []*flatTrace    `json:"trace"`
+	VMTrace         json.RawMessage `json:"vmTrace"`
+	TransactionHash common.Hash     `json:"transactionHash"`
+}
+
+// ReplayBlockTransactions replays all the transactions of the given block and
+// returns the requested trace types for each of them. The supported trace types
+// are "trace" for flat call traces and "stateDiff" for state diffs.
+func (api *TraceAPI) ReplayBlockTransactions(ctx context.Context, number rpc.BlockNumber, traceTypes []string) ([]*TraceResults, error) {
+	var withTrace, withStateDiff bool
+	for _, typ := range traceTypes {
+		switch typ {
+		case "trace":
+			withTrace = true
+		case "stateDiff":
+			withStateDiff = true
+		case "vmTrace":
+			return nil, errors.New("vmTrace is not supported")
+		default:
+			return nil, fmt.Errorf("invalid trace type %q", typ)
+		}
+	}
+	block, err := api.api.blockByNumber(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	// The flat call tracer always runs to retrieve the output of the transaction,
+	// the state diff tracer is only attached when requested.
+	tracers := map[string]json.RawMessage{flatCallTracerName: flatCallTracerConfig}
+	if withStateDiff {
+		tracers[stateDiffTracerName] = nil
+	}
+	config, err := json.Marshal(tracers)
+	if err != nil {
+		return nil, err
+	}
+	results, err := api.api.traceBlock(ctx, block, traceConfig("muxTracer", config))
+	if err != nil {
+		return nil, err
+	}
+	replays := make([]*TraceResults, len(results))
+	for i, res := range results {
+		if res.Error != "" {
+			return nil, fmt.Errorf("failed to trace transaction %#x: %s", res.TxHash, res.Error)
+		}
+		blob, ok := res.Result.(json.RawMessage)
+		if !ok {
+			return nil, fmt.Errorf("unexpected trace result type %T", res.Result)
+		}
+		var outputs map[string]json.RawMessage
+		if err := json.Unmarshal(blob, &outputs); err != nil {
+			return nil, err
+		}
+		traces, err := decodeFlatTraces(outputs[flatCallTracerName])
+		if err != nil {
+			return nil, err
+		}
+		replay := &TraceResults{TransactionHash: res.TxHash}
+		if len(traces) > 0 && traces[0].Result != nil {
+			replay.Output = traces[0].Result.Output
+			if replay.Output == nil {
+				replay.Output = traces[0].Result.Code
+			}
+		}
+		if withTrace {
+			replay.Trace = traces
+		}
+		if withStateDiff {
+			replay.StateDiff = outputs[stateDiffTracerName]
+		}
+		replays[i] = replay
+	}
+	return replays, nil
+}
+
+// TraceFilterArgs are the criteria for filtering the traces of a block range.
+type TraceFilterArgs struct {
+	FromBlock   *rpc.BlockNumber `json:"fromBlock"`   // First block of the range, latest if omitted
+	ToBlock     *rpc.BlockNumber `json:"toBlock"`     // Last block of the range, latest if omitted
+	FromAddress []common.Address `json:"fromAddress"` // Senders to match, any if empty
+	ToAddress   []common.Address `json:"toAddress"`   // Recipients to match, any if empty
+	After       *uint64          `json:"after"`       // Number of matching traces to skip
+	Count       *uint64          `json:"count"`       // Maximum number of traces to return
+}
+
+// matches reports whether the trace satisfies the address criteria of the filter.
+func (args *TraceFilterArgs) matches(trace *flatTrace) bool {
+	if len(args.FromAddress) > 0 {
+		if from := trace.from(); from == nil || !slices.Contains(args.FromAddress, *from) {
+			return false
+		}
+	}
+	if len(args.ToAddress) > 0 {
+		if to := trace.to(); to == nil || !slices.Contains(args.ToAddress, *to) {
+			return false
+		}
+	}
+	return true
+}
+
+// Filter returns the flat call traces in the given block range matching the
+// filter criteria. The blocks are traced concurrently and the matches streamed
+// in chain order, so tracing stops as soon as the requested number of traces
+// has been collected.
+func (api *TraceAPI) Filter(ctx context.Context, args TraceFilterArgs) ([]*flatTrace, error) {
+	var (
+		start = rpc.LatestBlockNumber
+		end   = rpc.LatestBlockNumber
+	)
+	if args.FromBlock != nil {
+		start = *args.FromBlock
+	}
+	if args.ToBlock != nil {
+		end = *args.ToBlock
+	}
+	from, err := api.api.blockByNumber(ctx, start)
+	if err != nil {
+		return nil, err
+	}
+	to, err := api.api.blockByNumber(ctx, end)
+	if err != nil {
+		return nil, err
+	}
+	if from.NumberU64() > to.NumberU64() {
+		return nil, fmt.Errorf("end block (#%d) needs to come after start block (#%d)", to.NumberU64(), from.NumberU64())
+	}
+	if args.Count != nil && *args.Count == 0 {
+		return []*flatTrace{}, nil
+	}
+	// The chain tracer excludes the start block of the range, so start from its
+	// parent. The genesis block has no transactions, it can be skipped anyway.
+	if from.NumberU64() > 0 {
+		if from, err = api.api.blockByNumberAndHash(ctx, rpc.BlockNumber(from.NumberU64()-1), from.ParentHash()); err != nil {
+			return nil, err
+		}
+	}
+	var (
+		closed = make(chan interface{})
+		resCh  = api.api.traceChain(from, to, traceConfig(flatCallTracerName, flatCallTracerConfig), closed)
+	)
+	defer func() {
+		// Abort the chain tracer and drain the results still in flight
+		close(closed)
+		go func() {
+			for range resCh {
+			}
+		}()
+	}()
+	var (
+		skipped uint64
+		matches = []*flatTrace{}
+	)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+
+		case res, ok := <-resCh:
+			if !ok {
+				return matches, nil
+			}
+			traces, err := decodeBlockTraces(res.Traces)
+			if err != nil {
+				return nil, err
+			}
+			for _, trace := range traces {
+				if !args.matches(trace) {
+					continue
+				}
+				if args.After != nil && skipped < *args.After {
+					skipped++
+					continue
+				}
+				matches = append(matches, trace)
+				if args.Count != nil && uint64(len(matches)) >= *args.Count {
+					return matches, nil
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,261 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+func init() {
+	DefaultDirectory.Register(flatCallTracerName, newTestFlatTracer, false)
+}
+
+// testFlatTracer is a minimal stand-in for the native flat call tracer, which
+// can't be imported by this package. It reports the top level call of the
+// transaction in the flat trace format.
+type testFlatTracer struct {
+	ctx   *Context
+	frame map[string]interface{}
+}
+
+func newTestFlatTracer(ctx *Context, _ json.RawMessage) (Tracer, error) {
+	return &testFlatTracer{ctx: ctx}, nil
+}
+
+func (t *testFlatTracer) CaptureTxStart(gasLimit uint64) {}
+
+func (t *testFlatTracer) CaptureTxEnd(restGas uint64) {}
+
+func (t *testFlatTracer) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	t.frame = map[string]interface{}{
+		"action": map[string]interface{}{
+			"callType": "call",
+			"from":     from,
+			"to":       to,
+			"value":    (*hexutil.Big)(value),
+		},
+		"blockNumber":     t.ctx.BlockNumber.Uint64(),
+		"result":          map[string]interface{}{"output": "0x"},
+		"traceAddress":    []int{},
+		"transactionHash": t.ctx.TxHash,
+		"type":            "call",
+	}
+}
+
+func (t *testFlatTracer) CaptureEnd(output []byte, gasUsed uint64, err error) {}
+
+func (t *testFlatTracer) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+}
+
+func (t *testFlatTracer) CaptureExit(output []byte, gasUsed uint64, err error) {}
+
+func (t *testFlatTracer) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+}
+
+func (t *testFlatTracer) CaptureFault(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, depth int, err error) {
+}
+
+func (t *testFlatTracer) GetResult() (json.RawMessage, error) {
+	return json.Marshal([]interface{}{t.frame})
+}
+
+func (t *testFlatTracer) Stop(err error) {}
+
+// newTraceTestBackend creates a chain of 10 blocks, each containing a transfer
+// from the first account to the second, and every even block an additional
+// transfer from the second account to the third.
+func newTraceTestBackend(t *testing.T) (*testBackend, []Account, [][]common.Hash) {
+	accounts := newAccounts(3)
+	genesis := &core.Genesis{
+		Config: params.TestChainConfig,
+		Alloc: core.GenesisAlloc{
+			accounts[0].addr: {Balance: big.NewInt(params.Ether)},
+			accounts[1].addr: {Balance: big.NewInt(params.Ether)},
+			accounts[2].addr: {Balance: big.NewInt(params.Ether)},
+		},
+	}
+	var (
+		signer = types.HomesteadSigner{}
+		hashes = make([][]common.Hash, 11)
+		nonce  uint64
+	)
+	backend := newTestBackend(t, 10, genesis, func(i int, b *core.BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(uint64(i), accounts[1].addr, big.NewInt(1000), params.TxGas, b.BaseFee(), nil), signer, accounts[0].key)
+		b.AddTx(tx)
+		hashes[i+1] = append(hashes[i+1], tx.Hash())
+
+		if (i+1)%2 == 0 {
+			tx, _ := types.SignTx(types.NewTransaction(nonce, accounts[2].addr, big.NewInt(1000), params.TxGas, b.BaseFee(), nil), signer, accounts[1].key)
+			b.AddTx(tx)
+			hashes[i+1] = append(hashes[i+1], tx.Hash())
+			nonce++
+		}
+	})
+	return backend, accounts, hashes
+}
+
+func TestTraceAPIBlock(t *testing.T) {
+	t.Parallel()
+
+	backend, accounts, hashes := newTraceTestBackend(t)
+	defer backend.teardown()
+	api := NewTraceAPI(backend)
+
+	traces, err := api.Block(context.Background(), rpc.BlockNumber(4))
+	if err != nil {
+		t.Fatalf("failed to trace block: %v", err)
+	}
+	if len(traces) != 2 {
+		t.Fatalf("trace count mismatch: have %d, want 2", len(traces))
+	}
+	for i, trace := range traces {
+		var frame struct {
+			TransactionHash common.Hash `json:"transactionHash"`
+		}
+		blob, _ := json.Marshal(trace)
+		if err := json.Unmarshal(blob, &frame); err != nil {
+			t.Fatalf("trace %d: invalid encoding: %v", i, err)
+		}
+		if frame.TransactionHash != hashes[4][i] {
+			t.Errorf("trace %d: transaction mismatch: have %x, want %x", i, frame.TransactionHash, hashes[4][i])
+		}
+	}
+	if to := traces[1].to(); to == nil || *to != accounts[2].addr {
+		t.Errorf("trace 1: recipient mismatch: have %v, want %x", to, accounts[2].addr)
+	}
+	if _, err := api.Block(context.Background(), rpc.BlockNumber(11)); err == nil {
+		t.Errorf("expected error for non-existent block")
+	}
+}
+
+func TestTraceAPIGet(t *testing.T) {
+	t.Parallel()
+
+	backend, accounts, hashes := newTraceTestBackend(t)
+	defer backend.teardown()
+	api := NewTraceAPI(backend)
+
+	trace, err := api.Get(context.Background(), hashes[2][1], nil)
+	if err != nil {
+		t.Fatalf("failed to get trace: %v", err)
+	}
+	if trace == nil {
+		t.Fatal("top level trace not found")
+	}
+	if from := trace.from(); from == nil || *from != accounts[1].addr {
+		t.Errorf("sender mismatch: have %v, want %x", from, accounts[1].addr)
+	}
+	if trace, err := api.Get(context.Background(), hashes[2][1], []hexutil.Uint64{0}); err != nil || trace != nil {
+		t.Errorf("non-existent trace address: have %v, %v, want nil", trace, err)
+	}
+}
+
+func TestTraceAPIFilter(t *testing.T) {
+	t.Parallel()
+
+	backend, accounts, hashes := newTraceTestBackend(t)
+	defer backend.teardown()
+	api := NewTraceAPI(backend)
+
+	number := func(n int64) *rpc.BlockNumber {
+		num := rpc.BlockNumber(n)
+		return &num
+	}
+	count := func(n uint64) *uint64 { return &n }
+
+	var testSuite = []struct {
+		args TraceFilterArgs
+		want []common.Hash
+	}{
+		// The latest block by default
+		{
+			args: TraceFilterArgs{},
+			want: hashes[10],
+		},
+		// Whole chain, genesis included
+		{
+			args: TraceFilterArgs{FromBlock: number(0), ToBlock: number(3)},
+			want: []common.Hash{hashes[1][0], hashes[2][0], hashes[2][1], hashes[3][0]},
+		},
+		// Filter by sender
+		{
+			args: TraceFilterArgs{FromBlock: number(1), ToBlock: number(6), FromAddress: []common.Address{accounts[1].addr}},
+			want: []common.Hash{hashes[2][1], hashes[4][1], hashes[6][1]},
+		},
+		// Filter by recipient
+		{
+			args: TraceFilterArgs{FromBlock: number(5), ToBlock: number(8), ToAddress: []common.Address{accounts[2].addr}},
+			want: []common.Hash{hashes[6][1], hashes[8][1]},
+		},
+		// Filter by sender and recipient, nothing matches
+		{
+			args: TraceFilterArgs{FromBlock: number(1), ToBlock: number(10), FromAddress: []common.Address{accounts[0].addr}, ToAddress: []common.Address{accounts[2].addr}},
+			want: []common.Hash{},
+		},
+		// Pagination
+		{
+			args: TraceFilterArgs{FromBlock: number(1), ToBlock: number(10), FromAddress: []common.Address{accounts[0].addr}, After: count(3), Count: count(2)},
+			want: []common.Hash{hashes[4][0], hashes[5][0]},
+		},
+		{
+			args: TraceFilterArgs{FromBlock: number(1), ToBlock: number(10), Count: count(0)},
+			want: []common.Hash{},
+		},
+	}
+	for i, tc := range testSuite {
+		traces, err := api.Filter(context.Background(), tc.args)
+		if err != nil {
+			t.Errorf("test %d: failed to filter traces: %v", i, err)
+			continue
+		}
+		var have []common.Hash
+		for _, trace := range traces {
+			var frame struct {
+				TransactionHash common.Hash `json:"transactionHash"`
+			}
+			blob, _ := json.Marshal(trace)
+			if err := json.Unmarshal(blob, &frame); err != nil {
+				t.Fatalf("test %d: invalid encoding: %v", i, err)
+			}
+			have = append(have, frame.TransactionHash)
+		}
+		if len(have) != len(tc.want) {
+			t.Errorf("test %d: trace count mismatch: have %d, want %d", i, len(have), len(tc.want))
+			continue
+		}
+		for j := range have {
+			if have[j] != tc.want[j] {
+				t.Errorf("test %d, trace %d: transaction mismatch: have %x, want %x", i, j, have[j], tc.want[j])
+			}
+		}
+	}
+	// Reversed ranges are rejected
+	if _, err := api.Filter(context.Background(), TraceFilterArgs{FromBlock: number(5), ToBlock: number(4)}); err == nil {
+		t.Errorf("expected error for reversed block range")
+	}
+}
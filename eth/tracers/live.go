@@ -0,0 +1,49 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/core"
+)
+
+type liveCtorFn func(json.RawMessage) (core.BlockchainLogger, error)
+
+// LiveDirectory is the collection of tracers which can be used
+// during normal block import operations.
+var LiveDirectory = liveDirectory{elems: make(map[string]liveCtorFn)}
+
+// liveDirectory provides functionality to lookup a live tracer by name
+// and a function to instantiate it.
+type liveDirectory struct {
+	elems map[string]liveCtorFn
+}
+
+// Register registers a tracer constructor by name.
+func (d *liveDirectory) Register(name string, f liveCtorFn) {
+	d.elems[name] = f
+}
+
+// New instantiates a live tracer by name.
+func (d *liveDirectory) New(name string, config json.RawMessage) (core.BlockchainLogger, error) {
+	if f, ok := d.elems[name]; ok {
+		return f(config)
+	}
+	return nil, errors.New("not found")
+}
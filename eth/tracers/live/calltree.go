@@ -0,0 +1,226 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package live contains the tracers which can be attached to the chain during
+// block import.
+package live
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/log"
+
+	// Force-load the native tracers, the call trees are built by callTracer.
+	_ "github.com/ethereum/go-ethereum/eth/tracers/native"
+)
+
+func init() {
+	tracers.LiveDirectory.Register("callTree", newCallTree)
+}
+
+// callTreeConfig are the configuration options of the callTree tracer.
+type callTreeConfig struct {
+	Path    string `json:"path"`    // Directory to write the per-block trace files into
+	WithLog bool   `json:"withLog"` // If true, the call frames will contain the emitted logs
+}
+
+// blockTrace is the content of a single per-block trace file.
+type blockTrace struct {
+	Number       hexutil.Uint64 `json:"number"`
+	Hash         common.Hash    `json:"hash"`
+	Transactions []txTrace      `json:"transactions"`
+}
+
+// txTrace is the call tree of a single transaction within a block.
+type txTrace struct {
+	TxHash common.Hash     `json:"txHash"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// callTree is a live tracer which builds the call tree of every transaction
+// in an imported block, and writes them as a JSON file per block to disk.
+type callTree struct {
+	config callTreeConfig
+	block  *types.Block
+	txs    []txTrace
+
+	tx     *types.Transaction // Transaction currently being executed
+	tracer tracers.Tracer     // Call tracer of the transaction being executed
+}
+
+// newCallTree creates a callTree live tracer.
+func newCallTree(cfg json.RawMessage) (core.BlockchainLogger, error) {
+	var config callTreeConfig
+	if cfg != nil {
+		if err := json.Unmarshal(cfg, &config); err != nil {
+			return nil, err
+		}
+	}
+	if config.Path == "" {
+		return nil, errors.New("callTree tracer output path is required")
+	}
+	if err := os.MkdirAll(config.Path, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create trace directory: %w", err)
+	}
+	return &callTree{config: config}, nil
+}
+
+// OnBlockStart resets the tracer to collect the traces of a new block.
+func (t *callTree) OnBlockStart(block *types.Block, td *big.Int, finalized *types.Header, safe *types.Header) {
+	t.block = block
+	t.txs = make([]txTrace, 0, len(block.Transactions()))
+}
+
+// OnBlockEnd flushes the collected call trees of a successfully processed block.
+func (t *callTree) OnBlockEnd(err error) {
+	defer func() {
+		t.block, t.txs = nil, nil
+	}()
+	if err != nil || t.block == nil {
+		return
+	}
+	blob, err := json.Marshal(&blockTrace{
+		Number:       hexutil.Uint64(t.block.NumberU64()),
+		Hash:         t.block.Hash(),
+		Transactions: t.txs,
+	})
+	if err != nil {
+		log.Warn("Failed to encode block call trees", "number", t.block.Number(), "hash", t.block.Hash(), "err", err)
+		return
+	}
+	name := fmt.Sprintf("%d-%x.json", t.block.NumberU64(), t.block.Hash().Bytes()[:4])
+	if err := os.WriteFile(filepath.Join(t.config.Path, name), blob, 0644); err != nil {
+		log.Warn("Failed to write block call trees", "number", t.block.Number(), "hash", t.block.Hash(), "err", err)
+	}
+}
+
+// OnGenesisBlock is called when the chain is initialised, there are no calls
+// to trace in the genesis block.
+func (t *callTree) OnGenesisBlock(genesis *types.Block, alloc core.GenesisAlloc) {}
+
+// OnTxStart sets up a fresh call tracer for the transaction.
+func (t *callTree) OnTxStart(env *vm.EVM, tx *types.Transaction, from common.Address) {
+	ctx := &tracers.Context{
+		BlockHash:   t.block.Hash(),
+		BlockNumber: t.block.Number(),
+		TxIndex:     len(t.txs),
+		TxHash:      tx.Hash(),
+	}
+	cfg, _ := json.Marshal(map[string]bool{"withLog": t.config.WithLog})
+	tracer, err := tracers.DefaultDirectory.New("callTracer", ctx, cfg)
+	if err != nil {
+		log.Warn("Failed to create call tracer", "tx", tx.Hash(), "err", err)
+		return
+	}
+	t.tx, t.tracer = tx, tracer
+}
+
+// OnTxEnd collects the call tree of the finished transaction.
+func (t *callTree) OnTxEnd(receipt *types.Receipt, err error) {
+	if t.tracer == nil {
+		return
+	}
+	trace := txTrace{TxHash: t.tx.Hash()}
+	if err != nil {
+		trace.Error = err.Error()
+	} else if res, err := t.tracer.GetResult(); err != nil {
+		trace.Error = err.Error()
+	} else {
+		trace.Result = res
+	}
+	t.txs = append(t.txs, trace)
+	t.tx, t.tracer = nil, nil
+}
+
+// CaptureTxStart implements the EVMLogger interface.
+func (t *callTree) CaptureTxStart(gasLimit uint64) {
+	if t.tracer != nil {
+		t.tracer.CaptureTxStart(gasLimit)
+	}
+}
+
+// CaptureTxEnd implements the EVMLogger interface.
+func (t *callTree) CaptureTxEnd(restGas uint64) {
+	if t.tracer != nil {
+		t.tracer.CaptureTxEnd(restGas)
+	}
+}
+
+// CaptureStart implements the EVMLogger interface. Calls made outside of a
+// transaction (e.g. system calls) are not traced.
+func (t *callTree) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	if t.tracer != nil {
+		t.tracer.CaptureStart(env, from, to, create, input, gas, value)
+	}
+}
+
+// CaptureEnd implements the EVMLogger interface.
+func (t *callTree) CaptureEnd(output []byte, gasUsed uint64, err error) {
+	if t.tracer != nil {
+		t.tracer.CaptureEnd(output, gasUsed, err)
+	}
+}
+
+// CaptureEnter implements the EVMLogger interface.
+func (t *callTree) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	if t.tracer != nil {
+		t.tracer.CaptureEnter(typ, from, to, input, gas, value)
+	}
+}
+
+// CaptureExit implements the EVMLogger interface.
+func (t *callTree) CaptureExit(output []byte, gasUsed uint64, err error) {
+	if t.tracer != nil {
+		t.tracer.CaptureExit(output, gasUsed, err)
+	}
+}
+
+// CaptureState implements the EVMLogger interface.
+func (t *callTree) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+	if t.tracer != nil {
+		t.tracer.CaptureState(pc, op, gas, cost, scope, rData, depth, err)
+	}
+}
+
+// CaptureFault implements the EVMLogger interface.
+func (t *callTree) CaptureFault(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, depth int, err error) {
+	if t.tracer != nil {
+		t.tracer.CaptureFault(pc, op, gas, cost, scope, depth, err)
+	}
+}
+
+func (t *callTree) OnBalanceChange(addr common.Address, prev, new *big.Int) {}
+
+func (t *callTree) OnNonceChange(addr common.Address, prev, new uint64) {}
+
+func (t *callTree) OnCodeChange(addr common.Address, prevCodeHash common.Hash, prevCode []byte, codeHash common.Hash, code []byte) {
+}
+
+func (t *callTree) OnStorageChange(addr common.Address, slot common.Hash, prev, new common.Hash) {}
+
+func (t *callTree) OnLog(log *types.Log) {}
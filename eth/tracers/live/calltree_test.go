@@ -0,0 +1,118 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package live
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestCallTree(t *testing.T) {
+	var (
+		key, _ = crypto.GenerateKey()
+		addr   = crypto.PubkeyToAddress(key.PublicKey)
+		dest   = common.HexToAddress("0x000000000000000000000000000000000000aaaa")
+		gspec  = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc: core.GenesisAlloc{
+				addr: {Balance: big.NewInt(params.Ether)},
+				// Calls into the precompile at 0x04 (identity)
+				dest: {Code: []byte{
+					byte(vm.PUSH1), 0, byte(vm.DUP1), byte(vm.DUP1), byte(vm.DUP1), byte(vm.DUP1),
+					byte(vm.PUSH1), 4, byte(vm.GAS), byte(vm.CALL),
+				}},
+			},
+		}
+		signer = types.LatestSigner(gspec.Config)
+		dir    = t.TempDir()
+	)
+	_, blocks, _ := core.GenerateChainWithGenesis(gspec, ethash.NewFaker(), 2, func(i int, b *core.BlockGen) {
+		tx, _ := types.SignNewTx(key, signer, &types.LegacyTx{
+			Nonce:    uint64(i),
+			To:       &dest,
+			Gas:      100000,
+			GasPrice: b.BaseFee(),
+		})
+		b.AddTx(tx)
+	})
+	cfg, _ := json.Marshal(callTreeConfig{Path: dir})
+	tracer, err := tracers.LiveDirectory.New("callTree", cfg)
+	if err != nil {
+		t.Fatalf("failed to create tracer: %v", err)
+	}
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), nil, gspec, nil, ethash.NewFaker(), vm.Config{Tracer: tracer}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("block %d: failed to insert into chain: %v", n, err)
+	}
+	for _, block := range blocks {
+		name := fmt.Sprintf("%d-%x.json", block.NumberU64(), block.Hash().Bytes()[:4])
+		blob, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("block %d: failed to read trace: %v", block.NumberU64(), err)
+		}
+		var trace struct {
+			Hash         common.Hash `json:"hash"`
+			Transactions []struct {
+				TxHash common.Hash `json:"txHash"`
+				Result struct {
+					To    common.Address `json:"to"`
+					Calls []struct {
+						To common.Address `json:"to"`
+					} `json:"calls"`
+				} `json:"result"`
+			} `json:"transactions"`
+		}
+		if err := json.Unmarshal(blob, &trace); err != nil {
+			t.Fatalf("block %d: failed to decode trace: %v", block.NumberU64(), err)
+		}
+		if trace.Hash != block.Hash() {
+			t.Errorf("block %d: hash mismatch: have %x, want %x", block.NumberU64(), trace.Hash, block.Hash())
+		}
+		if len(trace.Transactions) != 1 {
+			t.Fatalf("block %d: transaction count mismatch: have %d, want 1", block.NumberU64(), len(trace.Transactions))
+		}
+		tx := trace.Transactions[0]
+		if tx.TxHash != block.Transactions()[0].Hash() {
+			t.Errorf("block %d: tx hash mismatch: have %x, want %x", block.NumberU64(), tx.TxHash, block.Transactions()[0].Hash())
+		}
+		if tx.Result.To != dest {
+			t.Errorf("block %d: call target mismatch: have %x, want %x", block.NumberU64(), tx.Result.To, dest)
+		}
+		if len(tx.Result.Calls) != 1 || tx.Result.Calls[0].To != common.BytesToAddress([]byte{4}) {
+			t.Errorf("block %d: inner calls mismatch: %+v", block.NumberU64(), tx.Result.Calls)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package native
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+)
+
+func init() {
+	tracers.DefaultDirectory.Register("stateDiffTracer", newStateDiffTracer, false)
+}
+
+// The markers used by the Parity-style state diff format.
+const (
+	diffSame    = "="
+	diffBorn    = "+"
+	diffDied    = "-"
+	diffChanged = "*"
+)
+
+// stateDiff is the state diff of a single transaction, keyed by the modified
+// accounts.
+type stateDiff = map[common.Address]*accountDiff
+
+// accountDiff describes the changes of the individual fields of an account.
+type accountDiff struct {
+	Balance *fieldDiff                 `json:"balance"`
+	Code    *fieldDiff                 `json:"code"`
+	Nonce   *fieldDiff                 `json:"nonce"`
+	Storage map[common.Hash]*fieldDiff `json:"storage"`
+}
+
+// fieldDiff is the change of a single value. Depending on the kind of the change
+// it is encoded either as "=" (unchanged), {"+": to} (created), {"-": from}
+// (deleted) or {"*": {"from": from, "to": to}} (modified).
+type fieldDiff struct {
+	kind string
+	from interface{}
+	to   interface{}
+}
+
+// MarshalJSON implements json.Marshaler.
+func (d *fieldDiff) MarshalJSON() ([]byte, error) {
+	switch d.kind {
+	case diffBorn:
+		return json.Marshal(map[string]interface{}{diffBorn: d.to})
+	case diffDied:
+		return json.Marshal(map[string]interface{}{diffDied: d.from})
+	case diffChanged:
+		return json.Marshal(map[string]interface{}{diffChanged: struct {
+			From interface{} `json:"from"`
+			To   interface{} `json:"to"`
+		}{d.from, d.to}})
+	default:
+		return json.Marshal(diffSame)
+	}
+}
+
+// newFieldDiff returns the diff of a field present both before and after the
+// transaction, depending on whether its value was changed.
+func newFieldDiff(from, to interface{}, equal bool) *fieldDiff {
+	if equal {
+		return &fieldDiff{kind: diffSame}
+	}
+	return &fieldDiff{kind: diffChanged, from: from, to: to}
+}
+
+// stateDiffTracer is a native tracer which reports the changes a transaction
+// made to the state, in the format of Parity's stateDiff trace. It reuses the
+// prestate tracer to collect the accounts and storage slots touched during the
+// execution, and compares them to the state at the end of the transaction.
+type stateDiffTracer struct {
+	*prestateTracer
+	diff stateDiff
+}
+
+func newStateDiffTracer(ctx *tracers.Context, _ json.RawMessage) (tracers.Tracer, error) {
+	return &stateDiffTracer{
+		prestateTracer: &prestateTracer{
+			pre:     state{},
+			post:    state{},
+			created: make(map[common.Address]bool),
+			deleted: make(map[common.Address]bool),
+		},
+		diff: stateDiff{},
+	}, nil
+}
+
+// CaptureStart implements the EVMLogger interface to initialize the tracing operation.
+func (t *stateDiffTracer) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	t.prestateTracer.CaptureStart(env, from, to, create, input, gas, value)
+
+	// The contract account is created before the tracer is invoked, so the
+	// nonce set by EIP-158 must be reverted to get the pre-tx state.
+	if create {
+		t.pre[to].Nonce = 0
+		t.created[to] = true
+	}
+}
+
+// CaptureEnd is called after the call finishes to finalize the tracing.
+func (t *stateDiffTracer) CaptureEnd(output []byte, gasUsed uint64, err error) {
+}
+
+// CaptureTxEnd compares the collected prestate with the state at the end of the
+// transaction and assembles the diff of all modified accounts.
+func (t *stateDiffTracer) CaptureTxEnd(restGas uint64) {
+	for addr, pre := range t.pre {
+		var (
+			db        = t.env.StateDB
+			preExists = pre.Nonce > 0 || len(pre.Code) > 0 || pre.Balance.Sign() != 0
+			postAlive = db.Exist(addr) && !db.HasSelfDestructed(addr) && !db.Empty(addr)
+		)
+		switch {
+		case !preExists && !postAlive:
+			// The account was only touched or created and destroyed within
+			// the transaction, nothing to report. Note, storage is not checked
+			// for existence as the tracer only collects the accessed slots.
+			continue
+
+		case !preExists:
+			diff := &accountDiff{
+				Balance: &fieldDiff{kind: diffBorn, to: (*hexutil.Big)(db.GetBalance(addr))},
+				Code:    &fieldDiff{kind: diffBorn, to: hexutil.Bytes(db.GetCode(addr))},
+				Nonce:   &fieldDiff{kind: diffBorn, to: hexutil.Uint64(db.GetNonce(addr))},
+				Storage: make(map[common.Hash]*fieldDiff),
+			}
+			for key := range pre.Storage {
+				if val := db.GetState(addr, key); val != (common.Hash{}) {
+					diff.Storage[key] = &fieldDiff{kind: diffBorn, to: val}
+				}
+			}
+			t.diff[addr] = diff
+
+		case !postAlive:
+			diff := &accountDiff{
+				Balance: &fieldDiff{kind: diffDied, from: (*hexutil.Big)(pre.Balance)},
+				Code:    &fieldDiff{kind: diffDied, from: hexutil.Bytes(pre.Code)},
+				Nonce:   &fieldDiff{kind: diffDied, from: hexutil.Uint64(pre.Nonce)},
+				Storage: make(map[common.Hash]*fieldDiff),
+			}
+			for key, val := range pre.Storage {
+				if val != (common.Hash{}) {
+					diff.Storage[key] = &fieldDiff{kind: diffDied, from: val}
+				}
+			}
+			t.diff[addr] = diff
+
+		default:
+			var (
+				balance = db.GetBalance(addr)
+				code    = db.GetCode(addr)
+				nonce   = db.GetNonce(addr)
+			)
+			diff := &accountDiff{
+				Balance: newFieldDiff((*hexutil.Big)(pre.Balance), (*hexutil.Big)(balance), pre.Balance.Cmp(balance) == 0),
+				Code:    newFieldDiff(hexutil.Bytes(pre.Code), hexutil.Bytes(code), bytes.Equal(pre.Code, code)),
+				Nonce:   newFieldDiff(hexutil.Uint64(pre.Nonce), hexutil.Uint64(nonce), pre.Nonce == nonce),
+				Storage: make(map[common.Hash]*fieldDiff),
+			}
+			modified := diff.Balance.kind != diffSame || diff.Code.kind != diffSame || diff.Nonce.kind != diffSame
+			for key, val := range pre.Storage {
+				// Slots of a surviving account are always reported as modified,
+				// cleared and fresh slots included.
+				if newVal := db.GetState(addr, key); val != newVal {
+					diff.Storage[key] = &fieldDiff{kind: diffChanged, from: val, to: newVal}
+					modified = true
+				}
+			}
+			if modified {
+				t.diff[addr] = diff
+			}
+		}
+	}
+}
+
+// GetResult returns the json-encoded state diff, and any error arising from the
+// encoding or forceful termination (via `Stop`).
+func (t *stateDiffTracer) GetResult() (json.RawMessage, error) {
+	res, err := json.Marshal(t.diff)
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(res), t.reason
+}
@@ -0,0 +1,336 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package native
+
+import (
+	"encoding/json"
+	"math/big"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+func init() {
+	tracers.DefaultDirectory.Register("tokenTransferTracer", newTokenTransferTracer, false)
+}
+
+// The event signatures of the token transfer logs. ERC-20 and ERC-721 share the
+// Transfer event, they differ in whether the value is indexed.
+var (
+	transferEventID       = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+	transferSingleEventID = crypto.Keccak256Hash([]byte("TransferSingle(address,address,address,uint256,uint256)"))
+	transferBatchEventID  = crypto.Keccak256Hash([]byte("TransferBatch(address,address,address,uint256[],uint256[])"))
+)
+
+// The kinds of transfers reported by the tracer.
+const (
+	transferNative  = "native"
+	transferERC20   = "erc20"
+	transferERC721  = "erc721"
+	transferERC1155 = "erc1155"
+)
+
+// tokenTransfer is a single transfer of ether or tokens.
+type tokenTransfer struct {
+	Type     string          `json:"type"`
+	CallType string          `json:"callType,omitempty"` // Opcode moving the ether, for native transfers
+	Token    *common.Address `json:"token,omitempty"`    // Token contract, for token transfers
+	Operator *common.Address `json:"operator,omitempty"` // Operator of the transfer, for ERC-1155 transfers
+	From     common.Address  `json:"from"`
+	To       common.Address  `json:"to"`
+	TokenID  *hexutil.Big    `json:"tokenId,omitempty"` // Transferred token, for ERC-721 and ERC-1155 transfers
+	Value    *hexutil.Big    `json:"value,omitempty"`   // Transferred amount, except for ERC-721 transfers
+}
+
+// tokenTransferTracer is a native tracer which extracts the transfers of ether
+// and ERC-20, ERC-721 and ERC-1155 tokens made by a transaction, in execution
+// order. Tokens are detected by their standard transfer events, ether transfers
+// by the value moved by the transaction and its internal calls, creations and
+// self-destructs. Transfers made within reverted calls are not reported, and
+// neither are gas fees.
+//
+// Example:
+//
+//	> debug.traceTransaction("0x...", {tracer: "tokenTransferTracer"})
+//	[
+//	  {type: "native", callType: "CALL", from: "0x...", to: "0x...", value: "0xde0b6b3a7640000"},
+//	  {type: "erc20", token: "0x...", from: "0x...", to: "0x...", value: "0x2a"}
+//	]
+type tokenTransferTracer struct {
+	noopTracer
+	callstack [][]*tokenTransfer // Transfers of the calls in progress, dropped if the call fails
+	interrupt atomic.Bool        // Atomic flag to signal execution interruption
+	reason    error              // Textual reason for the interruption
+}
+
+// newTokenTransferTracer returns a native go tracer which extracts the ether
+// and token transfers of a transaction, and implements vm.EVMLogger.
+func newTokenTransferTracer(ctx *tracers.Context, _ json.RawMessage) (tracers.Tracer, error) {
+	return &tokenTransferTracer{callstack: make([][]*tokenTransfer, 1)}, nil
+}
+
+// addNative records an ether transfer in the current call, if it moves any value.
+func (t *tokenTransferTracer) addNative(typ vm.OpCode, from, to common.Address, value *big.Int) {
+	if value == nil || value.Sign() == 0 {
+		return
+	}
+	t.add(&tokenTransfer{
+		Type:     transferNative,
+		CallType: typ.String(),
+		From:     from,
+		To:       to,
+		Value:    (*hexutil.Big)(new(big.Int).Set(value)),
+	})
+}
+
+// add records a transfer in the current call.
+func (t *tokenTransferTracer) add(transfer *tokenTransfer) {
+	last := len(t.callstack) - 1
+	t.callstack[last] = append(t.callstack[last], transfer)
+}
+
+// CaptureStart implements the EVMLogger interface to initialize the tracing operation.
+func (t *tokenTransferTracer) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	typ := vm.CALL
+	if create {
+		typ = vm.CREATE
+	}
+	t.addNative(typ, from, to, value)
+}
+
+// CaptureEnd is called after the call finishes to finalize the tracing.
+func (t *tokenTransferTracer) CaptureEnd(output []byte, gasUsed uint64, err error) {
+	if err != nil {
+		t.callstack[0] = nil
+	}
+}
+
+// CaptureState implements the EVMLogger interface to trace a single step of VM execution.
+func (t *tokenTransferTracer) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+	// All the transfer events have three or four topics
+	if err != nil || (op != vm.LOG3 && op != vm.LOG4) {
+		return
+	}
+	// Skip if tracing was interrupted
+	if t.interrupt.Load() {
+		return
+	}
+	var (
+		stackData = scope.Stack.Data()
+		mStart    = stackData[len(stackData)-1]
+		mSize     = stackData[len(stackData)-2]
+		topics    = make([]common.Hash, int(op-vm.LOG0))
+	)
+	for i := range topics {
+		topics[i] = stackData[len(stackData)-3-i].Bytes32()
+	}
+	if topics[0] != transferEventID && topics[0] != transferSingleEventID && topics[0] != transferBatchEventID {
+		return
+	}
+	data, err := tracers.GetMemoryCopyPadded(scope.Memory, int64(mStart.Uint64()), int64(mSize.Uint64()))
+	if err != nil {
+		// mSize was unrealistically large
+		log.Warn("failed to copy log data", "err", err, "tracer", "tokenTransferTracer", "offset", mStart, "size", mSize)
+		return
+	}
+	for _, transfer := range decodeTokenTransfers(scope.Contract.Address(), topics, data) {
+		t.add(transfer)
+	}
+}
+
+// CaptureEnter is called when EVM enters a new scope (via call, create or selfdestruct).
+func (t *tokenTransferTracer) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	t.callstack = append(t.callstack, nil)
+
+	// Ether is only moved by calls, creations and self-destructs. Delegate calls
+	// report the value of their parent, and call codes send it to the caller.
+	switch typ {
+	case vm.CALL, vm.CREATE, vm.CREATE2, vm.SELFDESTRUCT:
+		t.addNative(typ, from, to, value)
+	}
+}
+
+// CaptureExit is called when EVM exits a scope, even if the scope didn't
+// execute any code.
+func (t *tokenTransferTracer) CaptureExit(output []byte, gasUsed uint64, err error) {
+	size := len(t.callstack)
+	if size <= 1 {
+		return
+	}
+	transfers := t.callstack[size-1]
+	t.callstack = t.callstack[:size-1]
+
+	// Transfers of failed calls are reverted
+	if err == nil {
+		t.callstack[size-2] = append(t.callstack[size-2], transfers...)
+	}
+}
+
+// GetResult returns the json-encoded list of transfers, and any error arising
+// from the encoding or forceful termination (via `Stop`).
+func (t *tokenTransferTracer) GetResult() (json.RawMessage, error) {
+	transfers := t.callstack[0]
+	if transfers == nil {
+		transfers = []*tokenTransfer{}
+	}
+	res, err := json.Marshal(transfers)
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(res), t.reason
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (t *tokenTransferTracer) Stop(err error) {
+	t.reason = err
+	t.interrupt.Store(true)
+}
+
+// decodeTokenTransfers decodes the transfers announced by a log of the given
+// token contract. Logs not matching any of the standard transfer events are
+// ignored.
+func decodeTokenTransfers(token common.Address, topics []common.Hash, data []byte) []*tokenTransfer {
+	switch {
+	case topics[0] == transferEventID && len(topics) == 3 && len(data) == 32:
+		from, to, ok := topicAddresses(topics[1:])
+		if !ok {
+			return nil
+		}
+		return []*tokenTransfer{{
+			Type:  transferERC20,
+			Token: &token,
+			From:  from,
+			To:    to,
+			Value: (*hexutil.Big)(new(big.Int).SetBytes(data)),
+		}}
+
+	case topics[0] == transferEventID && len(topics) == 4 && len(data) == 0:
+		from, to, ok := topicAddresses(topics[1:3])
+		if !ok {
+			return nil
+		}
+		return []*tokenTransfer{{
+			Type:    transferERC721,
+			Token:   &token,
+			From:    from,
+			To:      to,
+			TokenID: (*hexutil.Big)(topics[3].Big()),
+		}}
+
+	case topics[0] == transferSingleEventID && len(topics) == 4 && len(data) == 64:
+		operator, ok := topicAddress(topics[1])
+		if !ok {
+			return nil
+		}
+		from, to, ok := topicAddresses(topics[2:])
+		if !ok {
+			return nil
+		}
+		return []*tokenTransfer{{
+			Type:     transferERC1155,
+			Token:    &token,
+			Operator: &operator,
+			From:     from,
+			To:       to,
+			TokenID:  (*hexutil.Big)(new(big.Int).SetBytes(data[:32])),
+			Value:    (*hexutil.Big)(new(big.Int).SetBytes(data[32:])),
+		}}
+
+	case topics[0] == transferBatchEventID && len(topics) == 4:
+		operator, ok := topicAddress(topics[1])
+		if !ok {
+			return nil
+		}
+		from, to, ok := topicAddresses(topics[2:])
+		if !ok {
+			return nil
+		}
+		ids, ok := decodeUint256Array(data, 0)
+		if !ok {
+			return nil
+		}
+		values, ok := decodeUint256Array(data, 1)
+		if !ok || len(ids) != len(values) {
+			return nil
+		}
+		transfers := make([]*tokenTransfer, len(ids))
+		for i := range ids {
+			transfers[i] = &tokenTransfer{
+				Type:     transferERC1155,
+				Token:    &token,
+				Operator: &operator,
+				From:     from,
+				To:       to,
+				TokenID:  (*hexutil.Big)(ids[i]),
+				Value:    (*hexutil.Big)(values[i]),
+			}
+		}
+		return transfers
+	}
+	return nil
+}
+
+// topicAddress decodes an address from an indexed event parameter, which must be
+// left padded with zeroes.
+func topicAddress(topic common.Hash) (common.Address, bool) {
+	for _, b := range topic[:common.HashLength-common.AddressLength] {
+		if b != 0 {
+			return common.Address{}, false
+		}
+	}
+	return common.BytesToAddress(topic[:]), true
+}
+
+// topicAddresses decodes the sender and recipient of a transfer.
+func topicAddresses(topics []common.Hash) (from, to common.Address, ok bool) {
+	if from, ok = topicAddress(topics[0]); !ok {
+		return
+	}
+	to, ok = topicAddress(topics[1])
+	return
+}
+
+// decodeUint256Array decodes the dynamic uint256 array referenced by the given
+// head slot of ABI encoded event data.
+func decodeUint256Array(data []byte, slot int) ([]*big.Int, bool) {
+	word := func(offset uint64) (*big.Int, bool) {
+		if offset > uint64(len(data)) || uint64(len(data))-offset < 32 {
+			return nil, false
+		}
+		return new(big.Int).SetBytes(data[offset : offset+32]), true
+	}
+	offset, ok := word(uint64(slot) * 32)
+	if !ok || !offset.IsUint64() {
+		return nil, false
+	}
+	length, ok := word(offset.Uint64())
+	if !ok || !length.IsUint64() || length.Uint64() > uint64(len(data))/32 {
+		return nil, false
+	}
+	values := make([]*big.Int, length.Uint64())
+	for i := range values {
+		if values[i], ok = word(offset.Uint64() + 32*uint64(i+1)); !ok {
+			return nil, false
+		}
+	}
+	return values, true
+}
@@ -519,7 +519,14 @@ func testStatusFunctions(t *testing.T, client *rpc.Client) {
 			big.NewInt(765625000),
 			big.NewInt(671627818),
 		},
-		GasUsedRatio: []float64{0.008912678667376286},
+		GasUsedRatio:     []float64{0.008912678667376286},
+		BlobBaseFee:      []*big.Int{big.NewInt(0), big.NewInt(0)},
+		BlobGasUsedRatio: []float64{0},
+	}
+	// Zero big integers decoded from JSON differ from big.NewInt(0) in their
+	// internal representation, normalize them before comparing.
+	for i, fee := range history.BlobBaseFee {
+		history.BlobBaseFee[i] = new(big.Int).Set(fee)
 	}
 	if !reflect.DeepEqual(history, want) {
 		t.Fatalf("FeeHistory result doesn't match expected: (got: %v, want: %v)", history, want)
@@ -0,0 +1,189 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package simulated provides a simulated blockchain for testing contract bindings
+// and other clients, backed by a real in-memory node.
+package simulated
+
+import (
+	"errors"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/eth"
+	"github.com/ethereum/go-ethereum/eth/catalyst"
+	"github.com/ethereum/go-ethereum/eth/downloader"
+	"github.com/ethereum/go-ethereum/eth/ethconfig"
+	"github.com/ethereum/go-ethereum/eth/filters"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Client exposes the methods provided by the Ethereum RPC client.
+type Client interface {
+	ethereum.BlockNumberReader
+	ethereum.ChainReader
+	ethereum.ChainStateReader
+	ethereum.ContractCaller
+	ethereum.GasEstimator
+	ethereum.GasPricer
+	ethereum.GasPricer1559
+	ethereum.FeeHistoryReader
+	ethereum.LogFilterer
+	ethereum.PendingStateReader
+	ethereum.PendingContractCaller
+	ethereum.TransactionReader
+	ethereum.TransactionSender
+	ethereum.ChainIDReader
+}
+
+// simClient wraps ethclient. This exists to prevent extracting ethclient.Client
+// from the Client interface returned by Backend.
+type simClient struct {
+	*ethclient.Client
+}
+
+// Backend is a simulated blockchain. You can use it to test your contracts or
+// other code that interacts with the Ethereum chain.
+//
+// The chain is run by a real node: the Ethereum service of an in-memory node is
+// driven by a simulated beacon client, which seals a block whenever Commit is
+// called. The client accesses the node through the in-process RPC server, so the
+// code under test exercises the same code paths as against a live node.
+type Backend struct {
+	node   *node.Node
+	beacon *catalyst.SimulatedBeacon
+	client simClient
+}
+
+// NewBackend creates a new simulated blockchain that can be used as a backend
+// for contract bindings in unit tests.
+//
+// A simulated backend always uses chainID 1337. All protocol changes up to and
+// including Cancun are active from genesis.
+func NewBackend(alloc core.GenesisAlloc, options ...func(nodeConf *node.Config, ethConf *ethconfig.Config)) *Backend {
+	// Create the default configurations for the outer node shell and the Ethereum
+	// service to mutate with the options afterwards
+	nodeConf := node.DefaultConfig
+	nodeConf.DataDir = ""
+	nodeConf.P2P = p2p.Config{NoDiscovery: true}
+
+	config := *params.AllDevChainProtocolChanges
+	config.CancunTime = new(uint64)
+
+	ethConf := ethconfig.Defaults
+	ethConf.Genesis = &core.Genesis{
+		Config:   &config,
+		GasLimit: ethconfig.Defaults.Miner.GasCeil,
+		Alloc:    alloc,
+	}
+	ethConf.SyncMode = downloader.FullSync
+
+	for _, option := range options {
+		option(&nodeConf, &ethConf)
+	}
+	// Assemble the Ethereum stack to run the chain with
+	stack, err := node.New(&nodeConf)
+	if err != nil {
+		panic(err) // this should never happen
+	}
+	sim, err := newWithNode(stack, &ethConf, 0)
+	if err != nil {
+		panic(err) // this should never happen
+	}
+	return sim
+}
+
+// newWithNode sets up a simulated backend on an existing node. The provided node
+// must not be started and will be started by this method.
+func newWithNode(stack *node.Node, conf *eth.Config, blockPeriod uint64) (*Backend, error) {
+	backend, err := eth.New(stack, conf)
+	if err != nil {
+		return nil, err
+	}
+	// Register the filter system
+	filterSystem := filters.NewFilterSystem(backend.APIBackend, filters.Config{})
+	stack.RegisterAPIs([]rpc.API{{
+		Namespace: "eth",
+		Service:   filters.NewFilterAPI(filterSystem, false),
+	}})
+	// Start the node
+	if err := stack.Start(); err != nil {
+		return nil, err
+	}
+	// Set up the simulated beacon
+	beacon, err := catalyst.NewSimulatedBeacon(blockPeriod, backend)
+	if err != nil {
+		stack.Close()
+		return nil, err
+	}
+	return &Backend{
+		node:   stack,
+		beacon: beacon,
+		client: simClient{ethclient.NewClient(stack.Attach())},
+	}, nil
+}
+
+// Close shuts down the simulated backend. It can't be used afterwards.
+func (n *Backend) Close() error {
+	if n.client.Client != nil {
+		n.client.Close()
+		n.client = simClient{}
+	}
+	var err error
+	if n.beacon != nil {
+		err = n.beacon.Stop()
+		n.beacon = nil
+	}
+	if n.node != nil {
+		err = errors.Join(err, n.node.Close())
+		n.node = nil
+	}
+	return err
+}
+
+// Commit seals a block containing the pending transactions and returns its hash.
+func (n *Backend) Commit() common.Hash {
+	return n.beacon.Commit()
+}
+
+// Rollback removes all pending transactions, reverting to the last committed state.
+func (n *Backend) Rollback() {
+	n.beacon.Rollback()
+}
+
+// Fork rewinds the chain to the given ancestor block, which can be used to
+// simulate reorgs. Transactions (old and new) can then be applied on top and
+// committed. Forking is only possible if there are no pending transactions.
+func (n *Backend) Fork(parentHash common.Hash) error {
+	return n.beacon.Fork(parentHash)
+}
+
+// AdjustTime changes the block timestamp and creates a new block.
+// It can only be called on empty blocks.
+func (n *Backend) AdjustTime(adjustment time.Duration) error {
+	return n.beacon.AdjustTime(adjustment)
+}
+
+// Client returns a client that accesses the simulated chain.
+func (n *Backend) Client() Client {
+	return n.client
+}
@@ -0,0 +1,298 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package simulated
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/crypto/kzg4844"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+)
+
+var (
+	testKey, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	testAddr   = crypto.PubkeyToAddress(testKey.PublicKey)
+)
+
+func simTestBackend(testAddr common.Address) *Backend {
+	return NewBackend(
+		core.GenesisAlloc{
+			testAddr: {Balance: big.NewInt(10000000000000000)},
+		},
+	)
+}
+
+func newTx(sim *Backend, key *ecdsa.PrivateKey) (*types.Transaction, error) {
+	client := sim.Client()
+
+	// create a signed transaction to send
+	head, _ := client.HeaderByNumber(context.Background(), nil) // Should be child's, good enough
+	gasPrice := new(big.Int).Add(head.BaseFee, big.NewInt(params.GWei))
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+	chainid, _ := client.ChainID(context.Background())
+	nonce, err := client.PendingNonceAt(context.Background(), addr)
+	if err != nil {
+		return nil, err
+	}
+	tx := types.NewTx(&types.DynamicFeeTx{
+		ChainID:   chainid,
+		Nonce:     nonce,
+		GasTipCap: big.NewInt(params.GWei),
+		GasFeeCap: gasPrice,
+		Gas:       21000,
+		To:        &addr,
+	})
+	return types.SignTx(tx, types.LatestSignerForChainID(chainid), key)
+}
+
+func TestNewBackend(t *testing.T) {
+	sim := NewBackend(core.GenesisAlloc{})
+	defer sim.Close()
+
+	client := sim.Client()
+	num, err := client.BlockNumber(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if num != 0 {
+		t.Fatalf("expected 0 got %v", num)
+	}
+	// Create a block
+	sim.Commit()
+	num, err = client.BlockNumber(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if num != 1 {
+		t.Fatalf("expected 1 got %v", num)
+	}
+}
+
+func TestSendTransaction(t *testing.T) {
+	sim := simTestBackend(testAddr)
+	defer sim.Close()
+
+	client := sim.Client()
+	ctx := context.Background()
+
+	signedTx, err := newTx(sim, testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.SendTransaction(ctx, signedTx); err != nil {
+		t.Fatalf("could not add tx to pending block: %v", err)
+	}
+	block := sim.Commit()
+	receipt, err := client.TransactionReceipt(ctx, signedTx.Hash())
+	if err != nil {
+		t.Fatalf("transaction not included: %v", err)
+	}
+	if receipt.BlockHash != block || receipt.Status != types.ReceiptStatusSuccessful {
+		t.Fatalf("wrong receipt: block %x status %d", receipt.BlockHash, receipt.Status)
+	}
+}
+
+func TestAdjustTime(t *testing.T) {
+	sim := NewBackend(core.GenesisAlloc{})
+	defer sim.Close()
+
+	client := sim.Client()
+	block1, _ := client.BlockByNumber(context.Background(), nil)
+
+	// Create a block
+	if err := sim.AdjustTime(time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	block2, _ := client.BlockByNumber(context.Background(), nil)
+	prevTime := block1.Time()
+	newTime := block2.Time()
+	if newTime-prevTime != uint64(time.Minute.Seconds()) {
+		t.Errorf("adjusted time not equal to 60 seconds. prev: %v, new: %v", prevTime, newTime)
+	}
+}
+
+func TestRollback(t *testing.T) {
+	sim := simTestBackend(testAddr)
+	defer sim.Close()
+	client := sim.Client()
+
+	for i := 0; i < 3; i++ {
+		tx, err := newTx(sim, testKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := client.SendTransaction(context.Background(), tx); err != nil {
+			t.Fatalf("failed to send transaction: %v", err)
+		}
+	}
+	if nonce, _ := client.PendingNonceAt(context.Background(), testAddr); nonce != 3 {
+		t.Fatalf("wrong pending nonce %d before rollback", nonce)
+	}
+	sim.Rollback()
+
+	if nonce, _ := client.PendingNonceAt(context.Background(), testAddr); nonce != 0 {
+		t.Fatalf("wrong pending nonce %d after rollback", nonce)
+	}
+	head := sim.Commit()
+	block, err := client.BlockByHash(context.Background(), head)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(block.Transactions()) != 0 {
+		t.Fatalf("rolled back transactions included: %d", len(block.Transactions()))
+	}
+	// The pool accepts the same nonces again.
+	tx, _ := newTx(sim, testKey)
+	if err := client.SendTransaction(context.Background(), tx); err != nil {
+		t.Fatalf("failed to send transaction after rollback: %v", err)
+	}
+}
+
+func TestFork(t *testing.T) {
+	sim := simTestBackend(testAddr)
+	defer sim.Close()
+	client := sim.Client()
+
+	parent := sim.Commit()
+	tx, _ := newTx(sim, testKey)
+	if err := client.SendTransaction(context.Background(), tx); err != nil {
+		t.Fatal(err)
+	}
+	sim.Commit()
+	if _, err := client.TransactionReceipt(context.Background(), tx.Hash()); err != nil {
+		t.Fatalf("transaction not included: %v", err)
+	}
+	// Forking is refused while transactions are pending.
+	pending, _ := newTx(sim, testKey)
+	if err := client.SendTransaction(context.Background(), pending); err != nil {
+		t.Fatal(err)
+	}
+	if err := sim.Fork(parent); err == nil {
+		t.Fatal("forked with pending transactions")
+	}
+	sim.Rollback()
+
+	// Rewind and build a longer chain without the transaction.
+	if err := sim.Fork(parent); err != nil {
+		t.Fatalf("failed to fork: %v", err)
+	}
+	if num, _ := client.BlockNumber(context.Background()); num != 1 {
+		t.Fatalf("wrong head %d after fork", num)
+	}
+	sim.Commit()
+	sim.Commit()
+	if num, _ := client.BlockNumber(context.Background()); num != 3 {
+		t.Fatalf("wrong head %d after building side chain", num)
+	}
+	if _, err := client.TransactionReceipt(context.Background(), tx.Hash()); err == nil {
+		t.Fatal("transaction of the old chain still included")
+	}
+	if nonce, _ := client.NonceAt(context.Background(), testAddr, nil); nonce != 0 {
+		t.Fatalf("wrong nonce %d on side chain", nonce)
+	}
+}
+
+func TestBlobTransaction(t *testing.T) {
+	sim := simTestBackend(testAddr)
+	defer sim.Close()
+	client := sim.Client()
+
+	var (
+		blob          kzg4844.Blob
+		commitment, _ = kzg4844.BlobToCommitment(blob)
+		proof, _      = kzg4844.ComputeBlobProof(blob, commitment)
+		sidecar       = &types.BlobTxSidecar{
+			Blobs:       []kzg4844.Blob{blob},
+			Commitments: []kzg4844.Commitment{commitment},
+			Proofs:      []kzg4844.Proof{proof},
+		}
+	)
+	head, _ := client.HeaderByNumber(context.Background(), nil)
+	tx := types.MustSignNewTx(testKey, types.LatestSignerForChainID(params.AllDevChainProtocolChanges.ChainID), &types.BlobTx{
+		ChainID:    uint256.MustFromBig(params.AllDevChainProtocolChanges.ChainID),
+		GasTipCap:  uint256.NewInt(params.GWei),
+		GasFeeCap:  uint256.MustFromBig(new(big.Int).Add(head.BaseFee, big.NewInt(params.GWei))),
+		Gas:        params.TxGas,
+		To:         testAddr,
+		BlobFeeCap: uint256.NewInt(params.GWei),
+		BlobHashes: sidecar.BlobHashes(),
+		Sidecar:    sidecar,
+	})
+	if err := client.SendTransaction(context.Background(), tx); err != nil {
+		t.Fatalf("failed to send blob transaction: %v", err)
+	}
+	sim.Commit()
+
+	receipt, err := client.TransactionReceipt(context.Background(), tx.Hash())
+	if err != nil {
+		t.Fatalf("blob transaction not included: %v", err)
+	}
+	if receipt.BlobGasUsed != params.BlobTxBlobGasPerBlob {
+		t.Fatalf("wrong blob gas used %d", receipt.BlobGasUsed)
+	}
+	block, _ := client.HeaderByNumber(context.Background(), nil)
+	if block.BlobGasUsed == nil || *block.BlobGasUsed != params.BlobTxBlobGasPerBlob || block.ParentBeaconRoot == nil {
+		t.Fatalf("wrong cancun header fields: %+v", block)
+	}
+}
+
+// answerABI describes a contract returning 42 for any call.
+const answerABI = `[{"type":"function","name":"answer","inputs":[],"outputs":[{"type":"uint256"}],"stateMutability":"view"}]`
+
+var answerCode = common.FromHex("0x600a600c600039600a6000f3602a60005260206000f3")
+
+func TestContractBinding(t *testing.T) {
+	sim := simTestBackend(testAddr)
+	defer sim.Close()
+	client := sim.Client()
+
+	parsed, err := abi.JSON(strings.NewReader(answerABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts, err := bind.NewKeyedTransactorWithChainID(testKey, params.AllDevChainProtocolChanges.ChainID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr, tx, contract, err := bind.DeployContract(opts, parsed, answerCode, client)
+	if err != nil {
+		t.Fatalf("failed to deploy contract: %v", err)
+	}
+	sim.Commit()
+	if _, err := bind.WaitDeployed(context.Background(), client, tx); err != nil {
+		t.Fatalf("contract not deployed: %v", err)
+	}
+	var out []interface{}
+	if err := contract.Call(nil, &out, "answer"); err != nil {
+		t.Fatalf("failed to call contract at %x: %v", addr, err)
+	}
+	if len(out) != 1 || out[0].(*big.Int).Int64() != 42 {
+		t.Fatalf("wrong result %v", out)
+	}
+}
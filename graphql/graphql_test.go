@@ -28,6 +28,7 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/consensus"
 	"github.com/ethereum/go-ethereum/consensus/beacon"
 	"github.com/ethereum/go-ethereum/consensus/ethash"
@@ -40,8 +41,10 @@ import (
 	"github.com/ethereum/go-ethereum/eth/filters"
 	"github.com/ethereum/go-ethereum/node"
 	"github.com/ethereum/go-ethereum/params"
-
+	"github.com/gorilla/websocket"
+	"github.com/graph-gophers/graphql-go"
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/exp/slices"
 )
 
 func TestBuildSchema(t *testing.T) {
@@ -58,6 +61,9 @@ func TestBuildSchema(t *testing.T) {
 	if _, err := newHandler(stack, nil, nil, []string{}, []string{}); err != nil {
 		t.Errorf("Could not construct GraphQL handler: %v", err)
 	}
+	if _, err := graphql.ParseSchema(schema+subscriptionSchema, new(SubscriptionResolver)); err != nil {
+		t.Errorf("Could not construct GraphQL subscription schema: %v", err)
+	}
 }
 
 // Tests that a graphQL request is successfully handled when graphql is enabled on the specified endpoint
@@ -437,6 +443,18 @@ func createNode(t *testing.T) *node.Node {
 }
 
 func newGQLService(t *testing.T, stack *node.Node, shanghai bool, gspec *core.Genesis, genBlocks int, genfunc func(i int, gen *core.BlockGen)) (*handler, []*types.Block) {
+	ethBackend, chain := newGQLBackend(t, stack, shanghai, gspec, genBlocks, genfunc)
+
+	// Set up handler
+	filterSystem := filters.NewFilterSystem(ethBackend.APIBackend, filters.Config{})
+	handler, err := newHandler(stack, ethBackend.APIBackend, filterSystem, []string{}, []string{})
+	if err != nil {
+		t.Fatalf("could not create graphql service: %v", err)
+	}
+	return handler, chain
+}
+
+func newGQLBackend(t *testing.T, stack *node.Node, shanghai bool, gspec *core.Genesis, genBlocks int, genfunc func(i int, gen *core.BlockGen)) (*eth.Ethereum, []*types.Block) {
 	ethConf := &ethconfig.Config{
 		Genesis:        gspec,
 		NetworkId:      1337,
@@ -461,17 +479,291 @@ func newGQLService(t *testing.T, stack *node.Node, shanghai bool, gspec *core.Ge
 		t.Fatalf("could not create eth backend: %v", err)
 	}
 	// Create some blocks and import them
-	chain, _ := core.GenerateChain(params.AllEthashProtocolChanges, ethBackend.BlockChain().Genesis(),
+	chain, _ := core.GenerateChain(gspec.Config, ethBackend.BlockChain().Genesis(),
 		engine, ethBackend.ChainDb(), genBlocks, genfunc)
 	_, err = ethBackend.BlockChain().InsertChain(chain)
 	if err != nil {
 		t.Fatalf("could not create import blocks: %v", err)
 	}
-	// Set up handler
+	return ethBackend, chain
+}
+
+func TestGraphQLSubscriptions(t *testing.T) {
+	var (
+		key, _  = crypto.GenerateKey()
+		addr    = crypto.PubkeyToAddress(key.PublicKey)
+		dad     = common.HexToAddress("0x0000000000000000000000000000000000000dad")
+		config  = *params.AllEthashProtocolChanges
+		genesis = &core.Genesis{
+			Config:     &config,
+			GasLimit:   11500000,
+			Difficulty: big.NewInt(1048576),
+			Alloc: core.GenesisAlloc{
+				addr: {Balance: big.NewInt(params.Ether)},
+				dad: {
+					// LOG0(0, 0), RETURN(0, 0)
+					Code:    common.Hex2Bytes("60006000a060006000f3"),
+					Balance: big.NewInt(0),
+				},
+			},
+		}
+		signer = types.LatestSigner(genesis.Config)
+		stack  = createNode(t)
+	)
+	defer stack.Close()
+
+	config.TerminalTotalDifficulty = common.Big0
+	config.TerminalTotalDifficultyPassed = true
+	ethBackend, _ := newGQLBackend(t, stack, false, genesis, 0, nil)
 	filterSystem := filters.NewFilterSystem(ethBackend.APIBackend, filters.Config{})
-	handler, err := newHandler(stack, ethBackend.APIBackend, filterSystem, []string{}, []string{})
-	if err != nil {
+	if _, err := newHandler(stack, ethBackend.APIBackend, filterSystem, []string{}, []string{}); err != nil {
 		t.Fatalf("could not create graphql service: %v", err)
 	}
-	return handler, chain
+	if err := stack.Start(); err != nil {
+		t.Fatalf("could not start node: %v", err)
+	}
+	dialer := websocket.Dialer{Subprotocols: []string{wsProtocol}}
+	conn, _, err := dialer.Dial("ws"+strings.TrimPrefix(stack.HTTPEndpoint(), "http")+"/graphql", nil)
+	if err != nil {
+		t.Fatalf("could not dial graphql websocket: %v", err)
+	}
+	defer conn.Close()
+
+	send := func(msg wsMessage) {
+		t.Helper()
+		if err := conn.WriteJSON(msg); err != nil {
+			t.Fatalf("failed to send message: %v", err)
+		}
+	}
+	subscribe := func(id, query string) {
+		t.Helper()
+		payload, _ := json.Marshal(wsSubscribePayload{Query: query})
+		send(wsMessage{ID: id, Type: wsSubscribe, Payload: payload})
+	}
+	// read returns the next message, skipping the ones of the given ids.
+	read := func(skip ...string) wsMessage {
+		t.Helper()
+		for {
+			var msg wsMessage
+			conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+			if err := conn.ReadJSON(&msg); err != nil {
+				t.Fatalf("failed to read message: %v", err)
+			}
+			if !slices.Contains(skip, msg.ID) {
+				return msg
+			}
+		}
+	}
+	// Initialize the connection and subscribe to all the feeds.
+	send(wsMessage{Type: wsConnectionInit})
+	if msg := read(); msg.Type != wsConnectionAck {
+		t.Fatalf("expected connection ack, got %+v", msg)
+	}
+	send(wsMessage{Type: wsPing})
+	if msg := read(); msg.Type != wsPong {
+		t.Fatalf("expected pong, got %+v", msg)
+	}
+	subscribe("blocks", "subscription { newBlock { number hash transactionCount } }")
+	subscribe("logs", fmt.Sprintf(`subscription { logs(filter: {addresses: ["%s"]}) { index account { address } transaction { hash } } }`, dad))
+	subscribe("pending", "subscription { pendingTransactions { hash nonce } }")
+
+	// Invalid subscriptions are rejected with an error.
+	subscribe("invalid", "subscription { newBlock { unknownField } }")
+	if msg := read(); msg.ID != "invalid" || msg.Type != wsError {
+		t.Fatalf("expected error for invalid subscription, got %+v", msg)
+	}
+	// Subscriptions are set up asynchronously, wait until they are registered.
+	time.Sleep(100 * time.Millisecond)
+
+	// Import a block with a log, check the new block and log notifications.
+	tx, _ := types.SignNewTx(key, signer, &types.LegacyTx{To: &dad, Gas: 100000, GasPrice: big.NewInt(params.InitialBaseFee)})
+	chain, _ := core.GenerateChain(genesis.Config, ethBackend.BlockChain().Genesis(), beacon.NewFaker(), ethBackend.ChainDb(), 1, func(i int, gen *core.BlockGen) {
+		gen.AddTx(tx)
+	})
+	if _, err := ethBackend.BlockChain().InsertChain(chain); err != nil {
+		t.Fatalf("could not import block: %v", err)
+	}
+	wants := map[string]string{
+		"blocks": fmt.Sprintf(`{"data":{"newBlock":{"number":"0x1","hash":"%s","transactionCount":"0x1"}}}`, chain[0].Hash()),
+		"logs":   fmt.Sprintf(`{"data":{"logs":{"index":"0x0","account":{"address":"%s"},"transaction":{"hash":"%s"}}}}`, strings.ToLower(dad.Hex()), tx.Hash()),
+	}
+	for len(wants) > 0 {
+		msg := read("pending")
+		want, ok := wants[msg.ID]
+		if !ok || msg.Type != wsNext {
+			t.Fatalf("unexpected message %+v", msg)
+		}
+		if string(msg.Payload) != want {
+			t.Fatalf("wrong %s notification:\nhave: %s\nwant: %s", msg.ID, msg.Payload, want)
+		}
+		delete(wants, msg.ID)
+	}
+	// Send a transaction through a mutation, check the pending notification.
+	tx, _ = types.SignNewTx(key, signer, &types.LegacyTx{To: &dad, Nonce: 1, Gas: 100000, GasPrice: big.NewInt(2 * params.InitialBaseFee)})
+	raw, _ := tx.MarshalBinary()
+	subscribe("send", fmt.Sprintf(`mutation { sendRawTransaction(data: "%s") }`, hexutil.Encode(raw)))
+
+	var sent, completed, notified bool
+	for !sent || !completed || !notified {
+		msg := read("blocks", "logs")
+		switch {
+		case msg.ID == "send" && msg.Type == wsNext:
+			if want := fmt.Sprintf(`{"data":{"sendRawTransaction":"%s"}}`, tx.Hash()); string(msg.Payload) != want {
+				t.Fatalf("wrong mutation result:\nhave: %s\nwant: %s", msg.Payload, want)
+			}
+			sent = true
+		case msg.ID == "send" && msg.Type == wsComplete:
+			completed = true
+		case msg.ID == "pending" && msg.Type == wsNext:
+			if want := fmt.Sprintf(`{"data":{"pendingTransactions":{"hash":"%s","nonce":"0x1"}}}`, tx.Hash()); string(msg.Payload) != want {
+				t.Fatalf("wrong pending notification:\nhave: %s\nwant: %s", msg.Payload, want)
+			}
+			notified = true
+		default:
+			t.Fatalf("unexpected message %+v", msg)
+		}
+	}
+	// Subscribing with an id in use terminates the connection.
+	subscribe("blocks", "subscription { newBlock { number } }")
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			if !websocket.IsCloseError(err, wsSubscriberExists) {
+				t.Fatalf("expected close with code %d, got %v", wsSubscriberExists, err)
+			}
+			break
+		}
+	}
+}
+
+func TestOperationType(t *testing.T) {
+	tests := []struct {
+		doc, name, want string
+	}{
+		{`{ block { number } }`, "", "query"},
+		{`query { block { number } }`, "", "query"},
+		{`mutation Send($d: Bytes!) { sendRawTransaction(data: $d) }`, "", "mutation"},
+		{`subscription { newBlock { number } }`, "", "subscription"},
+		{"# subscription\nquery Q { logs(filter: {topics: []}) { data } }", "", "query"},
+		{`fragment F on Block { number } subscription S { newBlock { ...F } }`, "", "subscription"},
+		{`query Q { block { number } } subscription S { newBlock { number } }`, "S", "subscription"},
+		{`subscription S($a: String = "{") { newBlock { number } } query Q { block { number } }`, "Q", "query"},
+	}
+	for i, test := range tests {
+		if have := operationType(test.doc, test.name); have != test.want {
+			t.Errorf("test %d: wrong operation type %q, want %q", i, have, test.want)
+		}
+	}
+}
+
+func TestGraphQLTracing(t *testing.T) {
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr    = crypto.PubkeyToAddress(key.PublicKey)
+		dad     = common.HexToAddress("0x0000000000000000000000000000000000000dad")
+		beef    = common.HexToAddress("0x000000000000000000000000000000000000beef")
+		config  = *params.AllEthashProtocolChanges
+		genesis = &core.Genesis{
+			Config:     &config,
+			GasLimit:   11500000,
+			Difficulty: big.NewInt(1048576),
+			Alloc: core.GenesisAlloc{
+				addr: {Balance: big.NewInt(params.Ether)},
+				// CALL(GAS, 0xbeef, 0, 0, 0, 0, 0), SSTORE(0, 1)
+				dad: {Code: common.Hex2Bytes("600060006000600060006200beef5af1506001600055")},
+				// REVERT(0, 0)
+				beef: {Code: common.Hex2Bytes("60006000fd")},
+			},
+		}
+		signer = types.LatestSigner(genesis.Config)
+		stack  = createNode(t)
+	)
+	defer stack.Close()
+
+	var txs []*types.Transaction
+	newGQLService(t, stack, true, genesis, 1, func(i int, gen *core.BlockGen) {
+		gen.SetCoinbase(common.Address{1})
+		for nonce := uint64(0); nonce < 2; nonce++ {
+			tx, _ := types.SignNewTx(key, signer, &types.LegacyTx{Nonce: nonce, To: &dad, Value: big.NewInt(100), Gas: 100000, GasPrice: big.NewInt(params.InitialBaseFee)})
+			gen.AddTx(tx)
+			txs = append(txs, tx)
+		}
+	})
+	if err := stack.Start(); err != nil {
+		t.Fatalf("could not start node: %v", err)
+	}
+	var (
+		sender = strings.ToLower(addr.Hex())
+		trace  = fmt.Sprintf(`{"type":"CALL","from":"%s","to":"0x0000000000000000000000000000000000000dad","value":"0x64","error":null,"calls":[{"type":"CALL","from":"0x0000000000000000000000000000000000000dad","to":"0x000000000000000000000000000000000000beef","value":"0x0","error":"execution reverted","calls":[]}]}`, sender)
+	)
+	for i, tt := range []struct {
+		body string
+		want string
+	}{
+		{
+			body: fmt.Sprintf(`{"query": "{ transaction(hash: \"%s\") { trace { type from to value error calls { type from to value error calls { type } } } } }"}`, txs[0].Hash()),
+			want: fmt.Sprintf(`{"data":{"transaction":{"trace":%s}}}`, trace),
+		},
+		{
+			body: `{"query": "{ block { transactions { trace { type from to value error calls { type from to value error calls { type } } } } } }"}`,
+			want: fmt.Sprintf(`{"data":{"block":{"transactions":[{"trace":%s},{"trace":%s}]}}}`, trace, trace),
+		},
+		{
+			body: `{"query": "{ block { stateDiff { address created destroyed nonceBefore nonceAfter codeBefore storage { slot before after } } } }"}`,
+			want: fmt.Sprintf(`{"data":{"block":{"stateDiff":[`+
+				`{"address":"0x0000000000000000000000000000000000000dad","created":false,"destroyed":false,"nonceBefore":null,"nonceAfter":null,"codeBefore":null,"storage":[{"slot":"0x0000000000000000000000000000000000000000000000000000000000000000","before":"0x0000000000000000000000000000000000000000000000000000000000000000","after":"0x0000000000000000000000000000000000000000000000000000000000000001"}]},`+
+				`{"address":"0x0100000000000000000000000000000000000000","created":true,"destroyed":false,"nonceBefore":null,"nonceAfter":"0x0","codeBefore":null,"storage":[]},`+
+				`{"address":"%s","created":false,"destroyed":false,"nonceBefore":"0x0","nonceAfter":"0x2","codeBefore":null,"storage":[]}]}}}`, sender),
+		},
+		{
+			body: `{"query": "{ block(number: 0) { stateDiff { address } } }"}`,
+			want: `{"data":{"block":{"stateDiff":[]}}}`,
+		},
+	} {
+		resp, err := http.Post(fmt.Sprintf("%s/graphql", stack.HTTPEndpoint()), "application/json", strings.NewReader(tt.body))
+		if err != nil {
+			t.Fatalf("could not post: %v", err)
+		}
+		bodyBytes, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatalf("could not read from response body: %v", err)
+		}
+		if have := string(bodyBytes); have != tt.want {
+			t.Errorf("testcase %d %s,\nhave:\n%v\nwant:\n%v", i, tt.body, have, tt.want)
+		}
+	}
+}
+
+func TestTraceBudget(t *testing.T) {
+	if err := chargeTrace(context.Background(), 1); err != errTracingUnavailable {
+		t.Fatalf("expected error for missing budget, got %v", err)
+	}
+	ctx := withTraceBudget(context.Background())
+	if err := chargeTrace(ctx, maxTraceGas-1); err != nil {
+		t.Fatalf("failed to charge budget: %v", err)
+	}
+	if err := chargeTrace(ctx, 2); err != errTraceLimit {
+		t.Fatalf("expected limit error, got %v", err)
+	}
+	if err := chargeTrace(ctx, 1); err != nil {
+		t.Fatalf("failed to charge remaining budget: %v", err)
+	}
+	// Every operation gets its own budget.
+	if err := chargeTrace(withTraceBudget(ctx), maxTraceGas); err != nil {
+		t.Fatalf("failed to charge fresh budget: %v", err)
+	}
+	// So does every event delivered by a subscription, regardless of the
+	// budget of the operation.
+	sr := &SubscriptionResolver{Resolver: new(Resolver)}
+	first, second := sr.eventResolver(), sr.eventResolver()
+	if err := first.chargeTrace(ctx, maxTraceGas); err != nil {
+		t.Fatalf("failed to charge event budget: %v", err)
+	}
+	if err := first.chargeTrace(ctx, 1); err != errTraceLimit {
+		t.Fatalf("expected limit error, got %v", err)
+	}
+	if err := second.chargeTrace(ctx, maxTraceGas); err != nil {
+		t.Fatalf("failed to charge fresh event budget: %v", err)
+	}
 }
@@ -33,11 +33,6 @@ const schema string = `
     # 0x-prefixed hexadecimal.
     scalar Long
 
-    schema {
-        query: Query
-        mutation: Mutation
-    }
-
     # Account is an Ethereum account at a particular block.
     type Account {
         # Address is the address owning the account.
@@ -69,6 +64,9 @@ const schema string = `
         data: Bytes!
         # Transaction is the transaction that generated this log entry.
         transaction: Transaction!
+        # Removed is true if the log was reverted due to a chain reorganisation.
+        # It is only ever set for logs delivered by the logs subscription.
+        removed: Boolean!
     }
 
     # EIP-2718
@@ -170,6 +168,73 @@ const schema string = `
         rawReceipt: Bytes!
         # BlobVersionedHashes is a set of hash outputs from the blobs in the transaction.
         blobVersionedHashes: [Bytes32!]
+        # Trace is the tree of calls made by this transaction, as recorded by the
+        # call tracer. If the transaction has not yet been mined, this field will
+        # be null. Tracing counts against the execution limit of the query.
+        trace: CallFrame
+    }
+
+    # CallFrame is a call made during the execution of a transaction.
+    type CallFrame {
+        # Type is the kind of the call, e.g. CALL, DELEGATECALL or CREATE.
+        type: String!
+        # From is the address making the call.
+        from: Address!
+        # To is the address the call is sent to. For contract creations, it's
+        # the address of the new contract.
+        to: Address
+        # Value is the value, in wei, sent along with the call. This is null for
+        # calls that can't transfer value.
+        value: BigInt
+        # Gas is the amount of gas made available to the call.
+        gas: Long!
+        # GasUsed is the amount of gas used by the call, including its subcalls.
+        gasUsed: Long!
+        # Input is the data sent to the callee, or the init code of a creation.
+        input: Bytes!
+        # Output is the data returned by the call.
+        output: Bytes
+        # Error is the error the call failed with, null if it succeeded.
+        error: String
+        # RevertReason is the decoded reason of a reverted call, if available.
+        revertReason: String
+        # Calls is the list of subcalls made by this call.
+        calls: [CallFrame!]!
+    }
+
+    # AccountDiff is the change of an account caused by the transactions of a
+    # block. Fields which were not modified are null.
+    type AccountDiff {
+        # Address is the address of the account.
+        address: Address!
+        # Created is true if the account didn't exist before the block.
+        created: Boolean!
+        # Destroyed is true if the account doesn't exist after the block.
+        destroyed: Boolean!
+        # BalanceBefore and BalanceAfter are the balance of the account, in wei,
+        # before and after the block.
+        balanceBefore: BigInt
+        balanceAfter: BigInt
+        # NonceBefore and NonceAfter are the nonce of the account before and
+        # after the block.
+        nonceBefore: Long
+        nonceAfter: Long
+        # CodeBefore and CodeAfter are the code of the account before and after
+        # the block.
+        codeBefore: Bytes
+        codeAfter: Bytes
+        # Storage is the list of modified storage slots, ordered by slot.
+        storage: [StorageDiff!]!
+    }
+
+    # StorageDiff is the change of a storage slot caused by the transactions of
+    # a block.
+    type StorageDiff {
+        # Slot is the 32 byte slot identifier.
+        slot: Bytes32!
+        # Before and After are the values of the slot before and after the block.
+        before: Bytes32!
+        after: Bytes32!
     }
 
     # BlockFilterCriteria encapsulates log filter criteria for a filter applied
@@ -279,6 +344,10 @@ const schema string = `
         blobGasUsed: Long
         # ExcessBlobGas is a running total of blob gas consumed in excess of the target, prior to the block.
         excessBlobGas: Long
+        # StateDiff is the list of accounts modified by the transactions of this
+        # block, ordered by address. Block rewards and withdrawals are not included.
+        # Tracing counts against the execution limit of the query.
+        stateDiff: [AccountDiff!]!
     }
 
     # CallData represents the data associated with a local contract call.
@@ -392,3 +461,31 @@ const schema string = `
         sendRawTransaction(data: Bytes!): Bytes32!
     }
 `
+
+// subscriptionSchema defines the subscriptions on top of the types of the schema.
+// It is parsed into a separate schema, as graphql-go resolves all the root types
+// with the same resolver, where the logs subscription would clash with the logs
+// query. Queries and mutations are always executed by the main schema, the query
+// root type is only defined here because GraphQL mandates one.
+const subscriptionSchema string = `
+    schema {
+        query: SubscriptionQuery
+        subscription: Subscription
+    }
+
+    type SubscriptionQuery {
+        # ChainID returns the current chain ID for transaction replay protection.
+        chainID: BigInt!
+    }
+
+    type Subscription {
+        # NewBlock fires for every block added to the canonical chain.
+        newBlock: Block!
+        # Logs fires for every log matching the filter in the blocks added to the
+        # canonical chain. Logs of blocks reorged out are sent again, with the
+        # removed flag set.
+        logs(filter: BlockFilterCriteria!): Log!
+        # PendingTransactions fires for every transaction added to the pending state.
+        pendingTransactions: Transaction!
+    }
+`
@@ -25,18 +25,27 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/eth/filters"
+	"github.com/ethereum/go-ethereum/eth/tracers"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/node"
 	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/gorilla/websocket"
 	"github.com/graph-gophers/graphql-go"
 	gqlErrors "github.com/graph-gophers/graphql-go/errors"
 )
 
 type handler struct {
-	Schema *graphql.Schema
+	Schema        *graphql.Schema
+	Subscriptions *graphql.Schema // nil if subscriptions are not supported
+
+	upgrader websocket.Upgrader
 }
 
 func (h handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if websocket.IsWebSocketUpgrade(r) {
+		h.serveWebsocket(w, r)
+		return
+	}
 	var params struct {
 		Query         string                 `json:"query"`
 		OperationName string                 `json:"operationName"`
@@ -87,7 +96,7 @@ func (h handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		})
 	}
 
-	response := h.Schema.Exec(ctx, params.Query, params.OperationName, params.Variables)
+	response := h.Schema.Exec(withTraceBudget(ctx), params.Query, params.OperationName, params.Variables)
 	if timer != nil {
 		timer.Stop()
 	}
@@ -114,13 +123,24 @@ func New(stack *node.Node, backend ethapi.Backend, filterSystem *filters.FilterS
 // newHandler returns a new `http.Handler` that will answer GraphQL queries.
 // It additionally exports an interactive query browser on the / endpoint.
 func newHandler(stack *node.Node, backend ethapi.Backend, filterSystem *filters.FilterSystem, cors, vhosts []string) (*handler, error) {
-	q := Resolver{backend, filterSystem}
+	q := Resolver{backend: backend, filterSystem: filterSystem}
+	if tracerBackend, ok := backend.(tracers.Backend); ok {
+		q.tracer = tracers.NewAPI(tracerBackend)
+	}
 
 	s, err := graphql.ParseSchema(schema, &q)
 	if err != nil {
 		return nil, err
 	}
-	h := handler{Schema: s}
+	h := handler{Schema: s, upgrader: newUpgrader(cors)}
+
+	// Subscriptions are served over websockets, backed by the filter system.
+	if filterSystem != nil {
+		sr := &SubscriptionResolver{Resolver: &q, events: filters.NewEventSystem(filterSystem, false)}
+		if h.Subscriptions, err = graphql.ParseSchema(schema+subscriptionSchema, sr); err != nil {
+			return nil, err
+		}
+	}
 	handler := node.NewHTTPHandlerStack(h, cors, vhosts, nil)
 
 	stack.RegisterHandler("GraphQL UI", "/graphql/ui", GraphiQL{})
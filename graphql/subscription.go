@@ -0,0 +1,150 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package graphql
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth/filters"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// SubscriptionResolver is the top-level object of the subscription schema. The
+// objects it delivers are resolved the same way as the results of queries.
+type SubscriptionResolver struct {
+	*Resolver
+	events *filters.EventSystem
+}
+
+// eventResolver returns the resolver of a single delivered event, which has its
+// own tracing budget instead of sharing one across the whole subscription.
+func (r *SubscriptionResolver) eventResolver() *Resolver {
+	er := *r.Resolver
+	er.budget = newTraceBudget()
+	return &er
+}
+
+// NewBlock delivers the blocks added to the canonical chain.
+func (r *SubscriptionResolver) NewBlock(ctx context.Context) (<-chan *Block, error) {
+	var (
+		headers = make(chan *types.Header)
+		sub     = r.events.SubscribeNewHeads(headers)
+		blocks  = make(chan *Block)
+	)
+	go func() {
+		defer close(blocks)
+		defer sub.Unsubscribe()
+
+		for {
+			select {
+			case header := <-headers:
+				hash := header.Hash()
+				numberOrHash := rpc.BlockNumberOrHashWithHash(hash, false)
+				block := &Block{
+					r:            r.eventResolver(),
+					numberOrHash: &numberOrHash,
+					hash:         hash,
+					header:       header,
+				}
+				select {
+				case blocks <- block:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return blocks, nil
+}
+
+// Logs delivers the logs matching the filter criteria, as the blocks containing
+// them are added to or removed from the canonical chain.
+func (r *SubscriptionResolver) Logs(ctx context.Context, args struct{ Filter BlockFilterCriteria }) (<-chan *Log, error) {
+	var crit ethereum.FilterQuery
+	if args.Filter.Addresses != nil {
+		crit.Addresses = *args.Filter.Addresses
+	}
+	if args.Filter.Topics != nil {
+		crit.Topics = *args.Filter.Topics
+	}
+	matched := make(chan []*types.Log)
+	sub, err := r.events.SubscribeLogs(crit, matched)
+	if err != nil {
+		return nil, err
+	}
+	logs := make(chan *Log)
+	go func() {
+		defer close(logs)
+		defer sub.Unsubscribe()
+
+		for {
+			select {
+			case matches := <-matched:
+				for _, log := range matches {
+					er := r.eventResolver()
+					l := &Log{
+						r:           er,
+						transaction: &Transaction{r: er, hash: log.TxHash},
+						log:         log,
+					}
+					select {
+					case logs <- l:
+					case <-ctx.Done():
+						return
+					}
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return logs, nil
+}
+
+// PendingTransactions delivers the transactions added to the pending state.
+func (r *SubscriptionResolver) PendingTransactions(ctx context.Context) (<-chan *Transaction, error) {
+	var (
+		pending = make(chan []*types.Transaction)
+		sub     = r.events.SubscribePendingTxs(pending)
+		txs     = make(chan *Transaction)
+	)
+	go func() {
+		defer close(txs)
+		defer sub.Unsubscribe()
+
+		for {
+			select {
+			case batch := <-pending:
+				for _, tx := range batch {
+					t := &Transaction{r: r.eventResolver(), hash: tx.Hash(), tx: tx}
+					select {
+					case txs <- t:
+					case <-ctx.Done():
+						return
+					}
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return txs, nil
+}
@@ -0,0 +1,449 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	_ "github.com/ethereum/go-ethereum/eth/tracers/native" // register the tracers used below
+)
+
+const (
+	// maxTraceGas is the total amount of gas the transactions traced by a single
+	// query may have used, limiting the execution a query can trigger.
+	maxTraceGas = 300_000_000
+
+	// traceTimeout is the maximum time tracing a single transaction may take.
+	traceTimeout = "5s"
+
+	// traceReexec is the number of blocks which may be re-executed to regenerate
+	// the state a transaction is traced on. Re-execution isn't charged against
+	// the tracing budget, so only blocks with available state can be traced.
+	traceReexec = uint64(0)
+)
+
+var (
+	errTracingUnavailable = errors.New("tracing is not supported by the backend")
+	errTraceLimit         = fmt.Errorf("query exceeds the tracing limit of %d gas", maxTraceGas)
+)
+
+type traceBudgetKey struct{}
+
+// traceBudget is the amount of gas left for tracing in a query.
+type traceBudget struct {
+	gas atomic.Uint64
+}
+
+// newTraceBudget creates a full tracing budget.
+func newTraceBudget() *traceBudget {
+	budget := new(traceBudget)
+	budget.gas.Store(maxTraceGas)
+	return budget
+}
+
+// charge deducts gas from the budget, failing if it is exhausted.
+func (b *traceBudget) charge(gas uint64) error {
+	for {
+		left := b.gas.Load()
+		if gas > left {
+			return errTraceLimit
+		}
+		if b.gas.CompareAndSwap(left, left-gas) {
+			return nil
+		}
+	}
+}
+
+// withTraceBudget returns a copy of the context carrying a fresh tracing budget.
+// It must be called once for every executed operation.
+func withTraceBudget(ctx context.Context) context.Context {
+	return context.WithValue(ctx, traceBudgetKey{}, newTraceBudget())
+}
+
+// chargeTrace deducts the gas used by traced transactions from the budget of
+// the query, failing if the budget is exhausted.
+func chargeTrace(ctx context.Context, gas uint64) error {
+	budget, ok := ctx.Value(traceBudgetKey{}).(*traceBudget)
+	if !ok {
+		return errTracingUnavailable
+	}
+	return budget.charge(gas)
+}
+
+// chargeTrace deducts the gas used by traced transactions from the budget of
+// the subscription event being resolved, or of the query if not resolving an
+// event.
+func (r *Resolver) chargeTrace(ctx context.Context, gas uint64) error {
+	if r.budget != nil {
+		return r.budget.charge(gas)
+	}
+	return chargeTrace(ctx, gas)
+}
+
+// traceConfig returns the configuration for tracing with the given tracer.
+func traceConfig(tracer string) *tracers.TraceConfig {
+	var (
+		timeout = traceTimeout
+		reexec  = traceReexec
+	)
+	return &tracers.TraceConfig{Tracer: &tracer, Timeout: &timeout, Reexec: &reexec}
+}
+
+// callFrame is the encoding of a call frame produced by the call tracer.
+type callFrame struct {
+	Type         string          `json:"type"`
+	From         common.Address  `json:"from"`
+	To           *common.Address `json:"to"`
+	Value        *hexutil.Big    `json:"value"`
+	Gas          hexutil.Uint64  `json:"gas"`
+	GasUsed      hexutil.Uint64  `json:"gasUsed"`
+	Input        hexutil.Bytes   `json:"input"`
+	Output       hexutil.Bytes   `json:"output"`
+	Error        string          `json:"error"`
+	RevertReason string          `json:"revertReason"`
+	Calls        []*callFrame    `json:"calls"`
+}
+
+// CallFrame is a call made during the execution of a transaction.
+type CallFrame struct {
+	frame *callFrame
+}
+
+func (c *CallFrame) Type(ctx context.Context) string {
+	return c.frame.Type
+}
+
+func (c *CallFrame) From(ctx context.Context) common.Address {
+	return c.frame.From
+}
+
+func (c *CallFrame) To(ctx context.Context) *common.Address {
+	return c.frame.To
+}
+
+func (c *CallFrame) Value(ctx context.Context) *hexutil.Big {
+	return c.frame.Value
+}
+
+func (c *CallFrame) Gas(ctx context.Context) hexutil.Uint64 {
+	return c.frame.Gas
+}
+
+func (c *CallFrame) GasUsed(ctx context.Context) hexutil.Uint64 {
+	return c.frame.GasUsed
+}
+
+func (c *CallFrame) Input(ctx context.Context) hexutil.Bytes {
+	if c.frame.Input == nil {
+		return hexutil.Bytes{}
+	}
+	return c.frame.Input
+}
+
+func (c *CallFrame) Output(ctx context.Context) *hexutil.Bytes {
+	if c.frame.Output == nil {
+		return nil
+	}
+	return &c.frame.Output
+}
+
+func (c *CallFrame) Error(ctx context.Context) *string {
+	if c.frame.Error == "" {
+		return nil
+	}
+	return &c.frame.Error
+}
+
+func (c *CallFrame) RevertReason(ctx context.Context) *string {
+	if c.frame.RevertReason == "" {
+		return nil
+	}
+	return &c.frame.RevertReason
+}
+
+func (c *CallFrame) Calls(ctx context.Context) []*CallFrame {
+	calls := make([]*CallFrame, len(c.frame.Calls))
+	for i, frame := range c.frame.Calls {
+		calls[i] = &CallFrame{frame: frame}
+	}
+	return calls
+}
+
+// Trace returns the call tree of the transaction, or nil if the transaction
+// is not mined yet.
+func (t *Transaction) Trace(ctx context.Context) (*CallFrame, error) {
+	if t.r.tracer == nil {
+		return nil, errTracingUnavailable
+	}
+	receipt, err := t.getReceipt(ctx)
+	if err != nil || receipt == nil {
+		return nil, err
+	}
+	// Tracing a transaction re-executes the ones before it in the block.
+	if err := t.r.chargeTrace(ctx, receipt.CumulativeGasUsed); err != nil {
+		return nil, err
+	}
+	res, err := t.r.tracer.TraceTransaction(ctx, t.hash, traceConfig("callTracer"))
+	if err != nil {
+		return nil, err
+	}
+	blob, ok := res.(json.RawMessage)
+	if !ok {
+		return nil, fmt.Errorf("unexpected trace result type %T", res)
+	}
+	frame := new(callFrame)
+	if err := json.Unmarshal(blob, frame); err != nil {
+		return nil, err
+	}
+	return &CallFrame{frame: frame}, nil
+}
+
+// tracedDiff is a field of a Parity-style state diff produced by the state diff
+// tracer. It's encoded as "=" if the field is unchanged, {"+": to} if created,
+// {"-": from} if deleted and {"*": {"from": from, "to": to}} if modified.
+type tracedDiff[T any] struct {
+	changed  bool
+	from, to *T
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *tracedDiff[T]) UnmarshalJSON(input []byte) error {
+	if string(input) == `"="` {
+		return nil
+	}
+	var enc map[string]json.RawMessage
+	if err := json.Unmarshal(input, &enc); err != nil {
+		return err
+	}
+	if len(enc) != 1 {
+		return fmt.Errorf("invalid state diff field %s", input)
+	}
+	d.changed = true
+	if value, ok := enc["+"]; ok {
+		d.to = new(T)
+		return json.Unmarshal(value, d.to)
+	}
+	if value, ok := enc["-"]; ok {
+		d.from = new(T)
+		return json.Unmarshal(value, d.from)
+	}
+	if value, ok := enc["*"]; ok {
+		var change struct {
+			From *T `json:"from"`
+			To   *T `json:"to"`
+		}
+		if err := json.Unmarshal(value, &change); err != nil {
+			return err
+		}
+		d.from, d.to = change.From, change.To
+		return nil
+	}
+	return fmt.Errorf("invalid state diff field %s", input)
+}
+
+// tracedAccountDiff is the state diff of an account in a single transaction.
+type tracedAccountDiff struct {
+	Balance tracedDiff[hexutil.Big]                  `json:"balance"`
+	Nonce   tracedDiff[hexutil.Uint64]               `json:"nonce"`
+	Code    tracedDiff[hexutil.Bytes]                `json:"code"`
+	Storage map[common.Hash]*tracedDiff[common.Hash] `json:"storage"`
+}
+
+// fieldChange accumulates the changes of a field made by the transactions of
+// a block: the value before the first and after the last modification.
+type fieldChange[T any] struct {
+	changed       bool
+	before, after *T
+}
+
+// apply records the change of the field made by the next transaction.
+func (c *fieldChange[T]) apply(diff *tracedDiff[T]) {
+	if !diff.changed {
+		return
+	}
+	if !c.changed {
+		c.changed, c.before = true, diff.from
+	}
+	c.after = diff.to
+}
+
+// AccountDiff is the change of an account caused by the transactions of a block.
+type AccountDiff struct {
+	address common.Address
+	balance fieldChange[hexutil.Big]
+	nonce   fieldChange[hexutil.Uint64]
+	code    fieldChange[hexutil.Bytes]
+	storage map[common.Hash]*fieldChange[common.Hash]
+}
+
+// apply records the changes of the account made by the next transaction.
+func (a *AccountDiff) apply(diff *tracedAccountDiff) {
+	a.balance.apply(&diff.Balance)
+	a.nonce.apply(&diff.Nonce)
+	a.code.apply(&diff.Code)
+	for slot, d := range diff.Storage {
+		change := a.storage[slot]
+		if change == nil {
+			change = new(fieldChange[common.Hash])
+			a.storage[slot] = change
+		}
+		change.apply(d)
+	}
+}
+
+func (a *AccountDiff) Address(ctx context.Context) common.Address {
+	return a.address
+}
+
+// Created reports whether the account didn't exist before the block. Accounts
+// are created and deleted with all their fields, which the balance tracks.
+func (a *AccountDiff) Created(ctx context.Context) bool {
+	return a.balance.changed && a.balance.before == nil && a.balance.after != nil
+}
+
+func (a *AccountDiff) Destroyed(ctx context.Context) bool {
+	return a.balance.changed && a.balance.before != nil && a.balance.after == nil
+}
+
+func (a *AccountDiff) BalanceBefore(ctx context.Context) *hexutil.Big {
+	return a.balance.before
+}
+
+func (a *AccountDiff) BalanceAfter(ctx context.Context) *hexutil.Big {
+	return a.balance.after
+}
+
+func (a *AccountDiff) NonceBefore(ctx context.Context) *hexutil.Uint64 {
+	return a.nonce.before
+}
+
+func (a *AccountDiff) NonceAfter(ctx context.Context) *hexutil.Uint64 {
+	return a.nonce.after
+}
+
+func (a *AccountDiff) CodeBefore(ctx context.Context) *hexutil.Bytes {
+	return a.code.before
+}
+
+func (a *AccountDiff) CodeAfter(ctx context.Context) *hexutil.Bytes {
+	return a.code.after
+}
+
+func (a *AccountDiff) Storage(ctx context.Context) []*StorageDiff {
+	diffs := make([]*StorageDiff, 0, len(a.storage))
+	for slot, change := range a.storage {
+		diff := &StorageDiff{slot: slot}
+		if change.before != nil {
+			diff.before = *change.before
+		}
+		if change.after != nil {
+			diff.after = *change.after
+		}
+		if diff.before != diff.after {
+			diffs = append(diffs, diff)
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool {
+		return diffs[i].slot.Cmp(diffs[j].slot) < 0
+	})
+	return diffs
+}
+
+// StorageDiff is the change of a storage slot caused by the transactions of a
+// block.
+type StorageDiff struct {
+	slot, before, after common.Hash
+}
+
+func (s *StorageDiff) Slot(ctx context.Context) common.Hash {
+	return s.slot
+}
+
+func (s *StorageDiff) Before(ctx context.Context) common.Hash {
+	return s.before
+}
+
+func (s *StorageDiff) After(ctx context.Context) common.Hash {
+	return s.after
+}
+
+// StateDiff returns the accounts modified by the transactions of the block, by
+// merging the state diffs of the individual transactions.
+func (b *Block) StateDiff(ctx context.Context) ([]*AccountDiff, error) {
+	if b.r.tracer == nil {
+		return nil, errTracingUnavailable
+	}
+	header, err := b.resolveHeader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// The genesis state is not the result of transactions, and blocks
+	// without transactions don't need tracing.
+	if header.Number.Sign() == 0 || header.TxHash == types.EmptyTxsHash {
+		return []*AccountDiff{}, nil
+	}
+	if err := b.r.chargeTrace(ctx, header.GasUsed); err != nil {
+		return nil, err
+	}
+	results, err := b.r.tracer.TraceBlockByHash(ctx, b.hash, traceConfig("stateDiffTracer"))
+	if err != nil {
+		return nil, err
+	}
+	accounts := make(map[common.Address]*AccountDiff)
+	for _, res := range results {
+		if res.Error != "" {
+			return nil, fmt.Errorf("failed to trace transaction %#x: %s", res.TxHash, res.Error)
+		}
+		blob, ok := res.Result.(json.RawMessage)
+		if !ok {
+			return nil, fmt.Errorf("unexpected trace result type %T", res.Result)
+		}
+		var diffs map[common.Address]*tracedAccountDiff
+		if err := json.Unmarshal(blob, &diffs); err != nil {
+			return nil, err
+		}
+		for addr, diff := range diffs {
+			account := accounts[addr]
+			if account == nil {
+				account = &AccountDiff{address: addr, storage: make(map[common.Hash]*fieldChange[common.Hash])}
+				accounts[addr] = account
+			}
+			account.apply(diff)
+		}
+	}
+	diffs := make([]*AccountDiff, 0, len(accounts))
+	for _, account := range accounts {
+		// Skip the accounts both created and destroyed within the block.
+		if account.balance.changed && account.balance.before == nil && account.balance.after == nil {
+			continue
+		}
+		diffs = append(diffs, account)
+	}
+	sort.Slice(diffs, func(i, j int) bool {
+		return diffs[i].address.Cmp(diffs[j].address) < 0
+	})
+	return diffs, nil
+}
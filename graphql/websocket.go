@@ -0,0 +1,381 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/gorilla/websocket"
+	"github.com/graph-gophers/graphql-go"
+	gqlErrors "github.com/graph-gophers/graphql-go/errors"
+)
+
+// The GraphQL over WebSocket protocol, as implemented by the graphql-ws library.
+// See https://github.com/enisdenjo/graphql-ws/blob/master/PROTOCOL.md
+const (
+	wsProtocol = "graphql-transport-ws"
+
+	wsConnectionInit = "connection_init"
+	wsConnectionAck  = "connection_ack"
+	wsPing           = "ping"
+	wsPong           = "pong"
+	wsSubscribe      = "subscribe"
+	wsNext           = "next"
+	wsError          = "error"
+	wsComplete       = "complete"
+
+	// Close codes of the protocol.
+	wsBadRequest          = 4400
+	wsUnauthorized        = 4401
+	wsInitTimeout         = 4408
+	wsSubscriberExists    = 4409
+	wsTooManyInitRequests = 4429
+)
+
+const (
+	wsConnectionInitTimeout = 10 * time.Second
+	wsWriteTimeout          = 10 * time.Second
+	wsReadLimit             = 1024 * 1024
+)
+
+// wsMessage is a message of the graphql-ws protocol.
+type wsMessage struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// wsSubscribePayload is the payload of a subscribe message.
+type wsSubscribePayload struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// newUpgrader creates the websocket upgrader for GraphQL connections, accepting
+// browser connections only from the allowed origins.
+func newUpgrader(origins []string) websocket.Upgrader {
+	return websocket.Upgrader{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 1024,
+		Subprotocols:    []string{wsProtocol},
+		CheckOrigin: func(r *http.Request) bool {
+			origin := r.Header.Get("Origin")
+			if origin == "" {
+				return true
+			}
+			for _, allowed := range origins {
+				if allowed == "*" || strings.EqualFold(allowed, origin) {
+					return true
+				}
+			}
+			log.Warn("Rejected GraphQL websocket connection", "origin", origin)
+			return false
+		},
+	}
+}
+
+// wsConn is a GraphQL websocket connection.
+type wsConn struct {
+	h    *handler
+	conn *websocket.Conn
+
+	writeMu sync.Mutex // serializes the writes to the connection
+
+	mu   sync.Mutex
+	subs map[string]context.CancelFunc // active operations by id
+	wg   sync.WaitGroup
+}
+
+// serveWebsocket upgrades the request to a websocket connection and serves the
+// GraphQL operations sent over it until the connection is closed.
+func (h handler) serveWebsocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Debug("GraphQL websocket upgrade failed", "err", err)
+		return
+	}
+	defer conn.Close()
+
+	c := &wsConn{h: &h, conn: conn, subs: make(map[string]context.CancelFunc)}
+	if conn.Subprotocol() != wsProtocol {
+		c.close(websocket.CloseProtocolError, "Subprotocol not acceptable")
+		return
+	}
+	conn.SetReadLimit(wsReadLimit)
+	c.serve(r.Context())
+}
+
+// serve reads and handles the messages of the client.
+func (c *wsConn) serve(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer func() {
+		cancel()
+		c.wg.Wait()
+	}()
+	// The client must initialize the connection in time.
+	initialized := make(chan struct{})
+	go func() {
+		select {
+		case <-initialized:
+		case <-ctx.Done():
+		case <-time.After(wsConnectionInitTimeout):
+			c.close(wsInitTimeout, "Connection initialisation timeout")
+		}
+	}()
+	var acked bool
+	for {
+		var msg wsMessage
+		if err := c.conn.ReadJSON(&msg); err != nil {
+			if _, ok := err.(*json.SyntaxError); ok {
+				c.close(wsBadRequest, "Invalid message received")
+			}
+			return
+		}
+		switch msg.Type {
+		case wsConnectionInit:
+			if acked {
+				c.close(wsTooManyInitRequests, "Too many initialisation requests")
+				return
+			}
+			acked = true
+			close(initialized)
+			c.send(wsMessage{Type: wsConnectionAck})
+
+		case wsPing:
+			c.send(wsMessage{Type: wsPong, Payload: msg.Payload})
+
+		case wsPong:
+
+		case wsSubscribe:
+			if !acked {
+				c.close(wsUnauthorized, "Unauthorized")
+				return
+			}
+			var payload wsSubscribePayload
+			if msg.ID == "" || json.Unmarshal(msg.Payload, &payload) != nil {
+				c.close(wsBadRequest, "Invalid message received")
+				return
+			}
+			if !c.start(ctx, msg.ID, payload) {
+				c.close(wsSubscriberExists, fmt.Sprintf("Subscriber for %s already exists", msg.ID))
+				return
+			}
+
+		case wsComplete:
+			c.stop(msg.ID)
+
+		default:
+			c.close(wsBadRequest, "Invalid message received")
+			return
+		}
+	}
+}
+
+// start executes an operation, sending its results to the client. It returns
+// false if an operation with the same id is already running.
+func (c *wsConn) start(ctx context.Context, id string, payload wsSubscribePayload) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.subs[id]; ok {
+		return false
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	c.subs[id] = cancel
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+
+		c.run(ctx, id, payload)
+		// Report completion, unless the client stopped the operation.
+		c.mu.Lock()
+		_, running := c.subs[id]
+		delete(c.subs, id)
+		c.mu.Unlock()
+		cancel()
+
+		if running {
+			c.send(wsMessage{ID: id, Type: wsComplete})
+		}
+	}()
+	return true
+}
+
+// stop terminates the operation with the given id.
+func (c *wsConn) stop(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if cancel, ok := c.subs[id]; ok {
+		cancel()
+		delete(c.subs, id)
+	}
+}
+
+// run executes an operation, sending its results until it's done.
+func (c *wsConn) run(ctx context.Context, id string, payload wsSubscribePayload) {
+	// The events of subscriptions carry their own tracing budgets, this one
+	// only limits queries.
+	ctx = withTraceBudget(ctx)
+
+	var responses <-chan interface{}
+	if operationType(payload.Query, payload.OperationName) == "subscription" && c.h.Subscriptions != nil {
+		var err error
+		responses, err = c.h.Subscriptions.Subscribe(ctx, payload.Query, payload.OperationName, payload.Variables)
+		if err != nil {
+			c.sendErrors(id, &graphql.Response{Errors: []*gqlErrors.QueryError{{Message: err.Error()}}})
+			return
+		}
+	} else {
+		result := make(chan interface{}, 1)
+		result <- c.h.Schema.Exec(ctx, payload.Query, payload.OperationName, payload.Variables)
+		close(result)
+		responses = result
+	}
+	first := true
+	for {
+		select {
+		case resp, ok := <-responses:
+			if !ok {
+				return
+			}
+			r := resp.(*graphql.Response)
+			// Errors preventing the execution of the operation are reported
+			// in an error message, terminating it.
+			if first && r.Data == nil && len(r.Errors) > 0 {
+				c.sendErrors(id, r)
+				return
+			}
+			first = false
+
+			data, err := json.Marshal(r)
+			if err != nil {
+				log.Warn("Failed to encode GraphQL response", "err", err)
+				return
+			}
+			c.send(wsMessage{ID: id, Type: wsNext, Payload: data})
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// sendErrors sends an error message terminating the operation.
+func (c *wsConn) sendErrors(id string, r *graphql.Response) {
+	c.mu.Lock()
+	delete(c.subs, id)
+	c.mu.Unlock()
+
+	data, _ := json.Marshal(r.Errors)
+	c.send(wsMessage{ID: id, Type: wsError, Payload: data})
+}
+
+// send writes a message to the client.
+func (c *wsConn) send(msg wsMessage) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	if err := c.conn.WriteJSON(msg); err != nil {
+		log.Debug("Failed to send GraphQL websocket message", "err", err)
+		c.conn.Close()
+	}
+}
+
+// close terminates the connection with the given close code.
+func (c *wsConn) close(code int, reason string) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	deadline := time.Now().Add(wsWriteTimeout)
+	c.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), deadline)
+	c.conn.Close()
+}
+
+// operationType returns the type of the operation selected from a GraphQL
+// document: "query", "mutation" or "subscription". Only the top level of the
+// document is scanned, it's validated when the operation is executed.
+func operationType(doc, name string) string {
+	var (
+		depth   int
+		pending string // type of the operation whose name is expected next
+		first   string // type of the first operation in the document
+	)
+	for i := 0; i < len(doc); i++ {
+		switch ch := doc[i]; {
+		case ch == '#':
+			for i < len(doc) && doc[i] != '\n' {
+				i++
+			}
+		case ch == '"':
+			// Skip string literals, which may contain braces
+			if strings.HasPrefix(doc[i:], `"""`) {
+				end := strings.Index(doc[i+3:], `"""`)
+				if end < 0 {
+					return first
+				}
+				i += end + 5
+				continue
+			}
+			for i++; i < len(doc) && doc[i] != '"'; i++ {
+				if doc[i] == '\\' {
+					i++
+				}
+			}
+		case ch == '{' || ch == '(':
+			if depth == 0 && ch == '{' && pending == "" && first == "" {
+				first = "query" // shorthand query
+			}
+			if depth == 0 && pending != "" && pending != "fragment" && name == "" {
+				return pending
+			}
+			depth++
+		case ch == '}' || ch == ')':
+			depth--
+			if depth == 0 {
+				pending = ""
+			}
+		case depth == 0 && (ch == '_' || ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z'):
+			end := i
+			for end < len(doc) && (doc[end] == '_' || doc[end] >= 'a' && doc[end] <= 'z' || doc[end] >= 'A' && doc[end] <= 'Z' || doc[end] >= '0' && doc[end] <= '9') {
+				end++
+			}
+			word := doc[i:end]
+			i = end - 1
+
+			switch {
+			case pending == "" && (word == "query" || word == "mutation" || word == "subscription" || word == "fragment"):
+				pending = word
+				if first == "" && word != "fragment" {
+					first = word
+				}
+			case pending != "" && pending != "fragment" && word == name:
+				return pending
+			}
+		}
+	}
+	return first
+}
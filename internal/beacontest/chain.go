@@ -0,0 +1,300 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package beacontest provides a fake beacon chain and a fake beacon node REST
+// API for testing the beacon light client.
+package beacontest
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/beacon/merkle"
+	"github.com/ethereum/go-ethereum/beacon/params"
+	"github.com/ethereum/go-ethereum/beacon/types"
+	"github.com/ethereum/go-ethereum/common"
+	ctypes "github.com/ethereum/go-ethereum/core/types"
+	bls "github.com/protolambda/bls12-381-util"
+)
+
+// blsOrder is the order of the BLS12-381 scalar field.
+var blsOrder, _ = new(big.Int).SetString("73eda753299d7d483339d80809a1d80553bda402fffe5bfeffffffff00000001", 16)
+
+// Committee is a sync committee with known secret keys.
+type Committee struct {
+	keys       [params.SyncCommitteeSize]*big.Int
+	serialized types.SerializedSyncCommittee
+}
+
+// NewCommittee deterministically generates a sync committee from the seed.
+func NewCommittee(seed uint64) *Committee {
+	var (
+		c    = new(Committee)
+		pubs = make([]*bls.Pubkey, params.SyncCommitteeSize)
+		buf  [16]byte
+	)
+	binary.BigEndian.PutUint64(buf[:8], seed)
+	for i := range c.keys {
+		binary.BigEndian.PutUint64(buf[8:], uint64(i))
+		h := sha256.Sum256(buf[:])
+		c.keys[i] = new(big.Int).Mod(new(big.Int).SetBytes(h[:]), blsOrder)
+
+		pub, err := bls.SkToPk(secretKey(c.keys[i]))
+		if err != nil {
+			panic(err)
+		}
+		pubs[i] = pub
+		enc := pub.Serialize()
+		copy(c.serialized[i*params.BLSPubkeySize:], enc[:])
+	}
+	aggregate, err := bls.AggregatePubkeys(pubs)
+	if err != nil {
+		panic(err)
+	}
+	enc := aggregate.Serialize()
+	copy(c.serialized[params.SyncCommitteeSize*params.BLSPubkeySize:], enc[:])
+	return c
+}
+
+// secretKey converts a scalar into a BLS secret key.
+func secretKey(k *big.Int) *bls.SecretKey {
+	var (
+		sk  bls.SecretKey
+		enc [32]byte
+	)
+	k.FillBytes(enc[:])
+	if err := sk.Deserialize(&enc); err != nil {
+		panic(err)
+	}
+	return &sk
+}
+
+// Serialized returns the serialized form of the committee.
+func (c *Committee) Serialized() *types.SerializedSyncCommittee {
+	s := c.serialized
+	return &s
+}
+
+// Sign creates a sync aggregate of the given signing root, signed by the first
+// signers members of the committee.
+func (c *Committee) Sign(root common.Hash, signers int) types.SyncAggregate {
+	var (
+		agg types.SyncAggregate
+		sum = new(big.Int)
+	)
+	for i := 0; i < signers; i++ {
+		agg.Signers[i/8] |= 1 << (i % 8)
+		sum.Add(sum, c.keys[i])
+	}
+	// The aggregate of signatures over the same message equals the signature
+	// made with the sum of the secret keys.
+	sig := bls.Sign(secretKey(sum.Mod(sum, blsOrder)), root[:])
+	agg.Signature = sig.Serialize()
+	return agg
+}
+
+// Tree is a sparse binary merkle tree, mapping generalized indices to leaves.
+// Nodes without leaves below them are treated as zero values.
+type Tree map[uint64]merkle.Value
+
+// Root returns the root hash of the tree.
+func (t Tree) Root() common.Hash {
+	return common.Hash(t.node(1))
+}
+
+// Branch returns the merkle proof of the leaf at the given generalized index.
+func (t Tree) Branch(index uint64) merkle.Values {
+	var branch merkle.Values
+	for ; index > 1; index >>= 1 {
+		branch = append(branch, t.node(index^1))
+	}
+	return branch
+}
+
+// node computes the value of the node at the given generalized index.
+func (t Tree) node(index uint64) merkle.Value {
+	if v, ok := t[index]; ok {
+		return v
+	}
+	for leaf := range t {
+		for leaf > index {
+			leaf >>= 1
+		}
+		if leaf == index {
+			var (
+				left   = t.node(index * 2)
+				right  = t.node(index*2 + 1)
+				hasher = sha256.New()
+				res    merkle.Value
+			)
+			hasher.Write(left[:])
+			hasher.Write(right[:])
+			hasher.Sum(res[:0])
+			return res
+		}
+	}
+	return merkle.Value{}
+}
+
+// Header is a fake beacon header along with its beacon state tree.
+type Header struct {
+	types.HeaderWithExecProof
+	State Tree
+}
+
+// Chain generates fake beacon headers and light client data signed by
+// deterministic sync committees.
+type Chain struct {
+	Config     *types.ChainConfig
+	committees map[uint64]*Committee
+}
+
+// NewChain creates a fake beacon chain with a single fork.
+func NewChain() *Chain {
+	config := (&types.ChainConfig{GenesisValidatorsRoot: common.Hash{1}}).AddFork("GENESIS", 0, []byte{0, 0, 0, 1})
+	return &Chain{
+		Config:     config,
+		committees: make(map[uint64]*Committee),
+	}
+}
+
+// Committee returns the sync committee of the given period.
+func (c *Chain) Committee(period uint64) *Committee {
+	if c.committees[period] == nil {
+		c.committees[period] = NewCommittee(period)
+	}
+	return c.committees[period]
+}
+
+// NewHeader creates a beacon header at the given slot. The execution payload
+// header and the finalized header referenced by the beacon state are optional.
+func (c *Chain) NewHeader(slot uint64, parent common.Hash, exec *types.ExecutionHeader, finalized *types.Header) *Header {
+	period := types.SyncPeriod(slot)
+	state := Tree{
+		params.StateIndexSyncCommittee:     merkle.Value(c.Committee(period).Serialized().Root()),
+		params.StateIndexNextSyncCommittee: merkle.Value(c.Committee(period + 1).Serialized().Root()),
+	}
+	if finalized != nil {
+		state[params.StateIndexFinalBlock] = merkle.Value(finalized.Hash())
+	}
+	body := make(Tree)
+	if exec != nil {
+		body[params.BodyIndexExecPayload] = merkle.Value(exec.Root())
+	}
+	h := &Header{
+		HeaderWithExecProof: types.HeaderWithExecProof{
+			Header: types.Header{
+				Slot:       slot,
+				ParentRoot: parent,
+				StateRoot:  state.Root(),
+				BodyRoot:   body.Root(),
+			},
+			PayloadHeader: exec,
+		},
+		State: state,
+	}
+	if exec != nil {
+		h.PayloadBranch = body.Branch(params.BodyIndexExecPayload)
+	}
+	return h
+}
+
+// Bootstrap returns the bootstrap data of the header.
+func (c *Chain) Bootstrap(h *Header) *types.BootstrapData {
+	return &types.BootstrapData{
+		Header:          h.HeaderWithExecProof,
+		Committee:       c.Committee(h.Header.SyncPeriod()).Serialized(),
+		CommitteeBranch: h.State.Branch(params.StateIndexSyncCommittee),
+	}
+}
+
+// sign signs the header in the slot after it by the committee of that slot.
+func (c *Chain) sign(h *Header, signers int) (types.SyncAggregate, uint64) {
+	slot := h.Header.Slot + 1
+	root, err := c.Config.Forks.SigningRoot(h.Header)
+	if err != nil {
+		panic(err)
+	}
+	return c.Committee(types.SyncPeriod(slot)).Sign(root, signers), slot
+}
+
+// Update returns a light client update attested by the header, proving the
+// next sync committee. The header must not be at the last slot of a period.
+func (c *Chain) Update(h *Header, signers int) (*types.LightClientUpdate, *types.SerializedSyncCommittee) {
+	sig, slot := c.sign(h, signers)
+	next := c.Committee(h.Header.SyncPeriod() + 1).Serialized()
+	return &types.LightClientUpdate{
+		AttestedHeader: types.SignedHeader{
+			Header:        h.Header,
+			Signature:     sig,
+			SignatureSlot: slot,
+		},
+		NextSyncCommitteeRoot:   next.Root(),
+		NextSyncCommitteeBranch: h.State.Branch(params.StateIndexNextSyncCommittee),
+	}, next
+}
+
+// Optimistic returns an optimistic update of the header.
+func (c *Chain) Optimistic(h *Header, signers int) *types.OptimisticUpdate {
+	sig, slot := c.sign(h, signers)
+	return &types.OptimisticUpdate{
+		Attested:      h.HeaderWithExecProof,
+		Signature:     sig,
+		SignatureSlot: common.Decimal(slot),
+	}
+}
+
+// Finality returns a finality update of the attested header, which must have
+// been created with the finalized header.
+func (c *Chain) Finality(attested, finalized *Header, signers int) *types.FinalityUpdate {
+	sig, slot := c.sign(attested, signers)
+	return &types.FinalityUpdate{
+		Attested:       attested.HeaderWithExecProof,
+		Finalized:      finalized.HeaderWithExecProof,
+		FinalityBranch: attested.State.Branch(params.StateIndexFinalBlock),
+		Signature:      sig,
+		SignatureSlot:  common.Decimal(slot),
+	}
+}
+
+// ExecutionHeader creates the execution payload header of an execution block.
+// The transaction and withdrawal roots are not the SSZ roots of the block, they
+// are not needed for tests.
+func ExecutionHeader(block *ctypes.Block) *types.ExecutionHeader {
+	h := &types.ExecutionHeader{
+		ParentHash:       block.ParentHash(),
+		FeeRecipient:     block.Coinbase(),
+		StateRoot:        block.Root(),
+		ReceiptsRoot:     block.ReceiptHash(),
+		LogsBloom:        block.Bloom(),
+		PrevRandao:       block.MixDigest(),
+		BlockNumber:      block.NumberU64(),
+		GasLimit:         block.GasLimit(),
+		GasUsed:          block.GasUsed(),
+		Timestamp:        block.Time(),
+		ExtraData:        block.Extra(),
+		BaseFeePerGas:    block.BaseFee(),
+		BlockHash:        block.Hash(),
+		TransactionsRoot: block.TxHash(),
+		BlobGasUsed:      block.BlobGasUsed(),
+		ExcessBlobGas:    block.ExcessBlobGas(),
+	}
+	if root := block.Header().WithdrawalsHash; root != nil {
+		h.WithdrawalsRoot = *root
+	}
+	return h
+}
@@ -0,0 +1,193 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package beacontest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/beacon/light/api"
+	"github.com/ethereum/go-ethereum/beacon/merkle"
+	"github.com/ethereum/go-ethereum/beacon/types"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// jsonUpdate is the beacon REST API representation of a light client update.
+type jsonUpdate struct {
+	AttestedHeader          types.HeaderWithExecProof      `json:"attested_header"`
+	NextSyncCommittee       *types.SerializedSyncCommittee `json:"next_sync_committee"`
+	NextSyncCommitteeBranch merkle.Values                  `json:"next_sync_committee_branch"`
+	FinalizedHeader         types.HeaderWithExecProof      `json:"finalized_header"`
+	FinalityBranch          merkle.Values                  `json:"finality_branch"`
+	SyncAggregate           types.SyncAggregate            `json:"sync_aggregate"`
+	SignatureSlot           common.Decimal                 `json:"signature_slot"`
+}
+
+// Server is a fake beacon node serving the light client REST API endpoints
+// from the data set by the test.
+type Server struct {
+	*httptest.Server
+
+	lock       sync.Mutex
+	bootstraps map[common.Hash]*types.BootstrapData
+	updates    map[uint64]*jsonUpdate
+	optimistic *types.OptimisticUpdate
+	finality   *types.FinalityUpdate
+	blocks     map[common.Hash]*api.ExecutionBlock
+	requests   map[string]int
+}
+
+// NewServer starts a new fake beacon API server.
+func NewServer() *Server {
+	s := &Server{
+		bootstraps: make(map[common.Hash]*types.BootstrapData),
+		updates:    make(map[uint64]*jsonUpdate),
+		blocks:     make(map[common.Hash]*api.ExecutionBlock),
+		requests:   make(map[string]int),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// AddBootstrap makes the bootstrap data available for its header's block root.
+func (s *Server) AddBootstrap(bootstrap *types.BootstrapData) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.bootstraps[bootstrap.Header.Header.Hash()] = bootstrap
+}
+
+// AddUpdate makes the light client update available for its period.
+func (s *Server) AddUpdate(update *types.LightClientUpdate, committee *types.SerializedSyncCommittee) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	enc := &jsonUpdate{
+		AttestedHeader:          types.HeaderWithExecProof{Header: update.AttestedHeader.Header},
+		NextSyncCommittee:       committee,
+		NextSyncCommitteeBranch: update.NextSyncCommitteeBranch,
+		FinalityBranch:          update.FinalityBranch,
+		SyncAggregate:           update.AttestedHeader.Signature,
+		SignatureSlot:           common.Decimal(update.AttestedHeader.SignatureSlot),
+	}
+	if update.FinalizedHeader != nil {
+		enc.FinalizedHeader.Header = *update.FinalizedHeader
+	}
+	s.updates[update.AttestedHeader.Header.SyncPeriod()] = enc
+}
+
+// SetOptimistic sets the latest optimistic update.
+func (s *Server) SetOptimistic(update *types.OptimisticUpdate) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.optimistic = update
+}
+
+// SetFinality sets the latest finality update.
+func (s *Server) SetFinality(update *types.FinalityUpdate) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.finality = update
+}
+
+// AddBlock makes the execution block available for the given beacon block root.
+func (s *Server) AddBlock(root common.Hash, block *api.ExecutionBlock) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.blocks[root] = block
+}
+
+// Requests returns the number of requests served for the given endpoint path
+// prefix.
+func (s *Server) Requests(prefix string) int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	var count int
+	for path, n := range s.requests {
+		if strings.HasPrefix(path, prefix) {
+			count += n
+		}
+	}
+	return count
+}
+
+// serve handles a single API request.
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.requests[r.URL.Path]++
+
+	var (
+		path = r.URL.Path
+		data interface{}
+	)
+	switch {
+	case strings.HasPrefix(path, "/eth/v1/beacon/light_client/bootstrap/"):
+		if b := s.bootstraps[common.HexToHash(strings.TrimPrefix(path, "/eth/v1/beacon/light_client/bootstrap/"))]; b != nil {
+			data = b
+		}
+	case path == "/eth/v1/beacon/light_client/updates":
+		start, err1 := strconv.ParseUint(r.URL.Query().Get("start_period"), 10, 64)
+		count, err2 := strconv.ParseUint(r.URL.Query().Get("count"), 10, 64)
+		if err1 != nil || err2 != nil {
+			http.Error(w, "invalid query", http.StatusBadRequest)
+			return
+		}
+		type versioned struct {
+			Version string      `json:"version"`
+			Data    *jsonUpdate `json:"data"`
+		}
+		updates := []versioned{}
+		for period := start; period < start+count && s.updates[period] != nil; period++ {
+			updates = append(updates, versioned{Version: "deneb", Data: s.updates[period]})
+		}
+		writeJSON(w, updates)
+		return
+	case path == "/eth/v1/beacon/light_client/optimistic_update":
+		if s.optimistic != nil {
+			data = s.optimistic
+		}
+	case path == "/eth/v1/beacon/light_client/finality_update":
+		if s.finality != nil {
+			data = s.finality
+		}
+	case strings.HasPrefix(path, "/eth/v2/beacon/blocks/"):
+		if b := s.blocks[common.HexToHash(strings.TrimPrefix(path, "/eth/v2/beacon/blocks/"))]; b != nil {
+			data = map[string]interface{}{"message": b}
+		}
+	}
+	if data == nil {
+		http.NotFound(w, r)
+		return
+	}
+	writeJSON(w, map[string]interface{}{"version": "deneb", "data": data})
+}
+
+// writeJSON writes the JSON encoded response.
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,119 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package era
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// accumulatorDepth is the depth of the merkle tree over the header records,
+// large enough to hold MaxEra1Size leaves.
+const accumulatorDepth = 13
+
+// ComputeAccumulator calculates the SSZ hash tree root of the Era1
+// accumulator of header records.
+//
+// The accumulator is defined as List[HeaderRecord, 8192], where each header
+// record is the container {block_hash: Bytes32, total_difficulty: Uint256}.
+func ComputeAccumulator(hashes []common.Hash, tds []*big.Int) (common.Hash, error) {
+	if len(hashes) != len(tds) {
+		return common.Hash{}, fmt.Errorf("must have equal number hashes as td values")
+	}
+	if len(hashes) > MaxEra1Size {
+		return common.Hash{}, fmt.Errorf("too many records: have %d, max %d", len(hashes), MaxEra1Size)
+	}
+	layer := make([][32]byte, len(hashes))
+	for i := range hashes {
+		rec := headerRecord{Hash: hashes[i], TotalDifficulty: tds[i]}
+		root, err := rec.hashTreeRoot()
+		if err != nil {
+			return common.Hash{}, err
+		}
+		layer[i] = root
+	}
+	// Merkleize the records, padding the tree with zero subtrees up to the
+	// list limit.
+	var zero [32]byte
+	for depth := 0; depth < accumulatorDepth; depth++ {
+		if len(layer)%2 == 1 {
+			layer = append(layer, zero)
+		}
+		next := make([][32]byte, len(layer)/2)
+		for i := range next {
+			next[i] = hashPair(layer[2*i], layer[2*i+1])
+		}
+		layer = next
+		zero = hashPair(zero, zero)
+	}
+	root := zero
+	if len(layer) > 0 {
+		root = layer[0]
+	}
+	// Mix in the length of the list.
+	var length [32]byte
+	binary.LittleEndian.PutUint64(length[:], uint64(len(hashes)))
+	return hashPair(root, length), nil
+}
+
+// headerRecord is an individual record for a historical header.
+//
+// See https://github.com/ethereum/portal-network-specs/blob/master/history-network.md#the-header-accumulator
+// for more information.
+type headerRecord struct {
+	Hash            common.Hash
+	TotalDifficulty *big.Int
+}
+
+// hashTreeRoot computes the SSZ hash tree root of the header record, the hash
+// of the block hash and the little-endian encoded total difficulty.
+func (h *headerRecord) hashTreeRoot() ([32]byte, error) {
+	if h.TotalDifficulty.Sign() < 0 || h.TotalDifficulty.BitLen() > 256 {
+		return [32]byte{}, fmt.Errorf("invalid total difficulty: %v", h.TotalDifficulty)
+	}
+	return hashPair(h.Hash, bigToBytes32(h.TotalDifficulty)), nil
+}
+
+// hashPair returns the sha256 hash of the concatenation of a and b.
+func hashPair(a, b [32]byte) [32]byte {
+	hasher := sha256.New()
+	hasher.Write(a[:])
+	hasher.Write(b[:])
+
+	var h [32]byte
+	hasher.Sum(h[:0])
+	return h
+}
+
+// bigToBytes32 converts a big.Int into a little-endian 32-byte array.
+func bigToBytes32(n *big.Int) (b [32]byte) {
+	n.FillBytes(b[:])
+	reverseOrder(b[:])
+	return
+}
+
+// reverseOrder reverses the byte order of b in place and returns it.
+func reverseOrder(b []byte) []byte {
+	for i := 0; i < len(b)/2; i++ {
+		b[i], b[len(b)-1-i] = b[len(b)-1-i], b[i]
+	}
+	return b
+}
@@ -0,0 +1,248 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package era
+
+import (
+	"bytes"
+	"io"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+type testchain struct {
+	headers  [][]byte
+	bodies   [][]byte
+	receipts [][]byte
+	tds      []*big.Int
+}
+
+func TestEra1Builder(t *testing.T) {
+	// Get temp directory.
+	f, err := os.CreateTemp("", "era1-test")
+	if err != nil {
+		t.Fatalf("error creating temp file: %v", err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	var (
+		builder = NewBuilder(f)
+		chain   = testchain{}
+	)
+	for i := 0; i < 128; i++ {
+		chain.headers = append(chain.headers, []byte{byte('h'), byte(i)})
+		chain.bodies = append(chain.bodies, []byte{byte('b'), byte(i)})
+		chain.receipts = append(chain.receipts, []byte{byte('r'), byte(i)})
+		chain.tds = append(chain.tds, big.NewInt(int64(i)))
+	}
+
+	// Write blocks to Era1.
+	for i := 0; i < len(chain.headers); i++ {
+		var (
+			header   = chain.headers[i]
+			body     = chain.bodies[i]
+			receipts = chain.receipts[i]
+			hash     = common.Hash{byte(i)}
+			td       = chain.tds[i]
+		)
+		if err = builder.AddRLP(header, body, receipts, uint64(i), hash, td, big.NewInt(1)); err != nil {
+			t.Fatalf("error adding entry: %v", err)
+		}
+	}
+
+	// Finalize Era1.
+	if _, err := builder.Finalize(); err != nil {
+		t.Fatalf("error finalizing era1: %v", err)
+	}
+
+	// Verify Era1 contents.
+	e, err := Open(f.Name())
+	if err != nil {
+		t.Fatalf("failed to open era: %v", err)
+	}
+	defer e.Close()
+	it, err := NewRawIterator(e)
+	if err != nil {
+		t.Fatalf("failed to make iterator: %s", err)
+	}
+	for i := uint64(0); i < uint64(len(chain.headers)); i++ {
+		if !it.Next() {
+			t.Fatalf("expected more entries")
+		}
+		if it.Error() != nil {
+			t.Fatalf("unexpected error %v", it.Error())
+		}
+		// Check headers.
+		header, err := io.ReadAll(it.Header)
+		if err != nil {
+			t.Fatalf("error reading header: %v", err)
+		}
+		if !bytes.Equal(header, chain.headers[i]) {
+			t.Fatalf("mismatched header: want %s, got %s", chain.headers[i], header)
+		}
+		// Check bodies.
+		body, err := io.ReadAll(it.Body)
+		if err != nil {
+			t.Fatalf("error reading body: %v", err)
+		}
+		if !bytes.Equal(body, chain.bodies[i]) {
+			t.Fatalf("mismatched body: want %s, got %s", chain.bodies[i], body)
+		}
+		// Check receipts.
+		receipts, err := io.ReadAll(it.Receipts)
+		if err != nil {
+			t.Fatalf("error reading receipts: %v", err)
+		}
+		if !bytes.Equal(receipts, chain.receipts[i]) {
+			t.Fatalf("mismatched receipts: want %s, got %s", chain.receipts[i], receipts)
+		}
+		// Check total difficulty.
+		rawTd, err := io.ReadAll(it.TotalDifficulty)
+		if err != nil {
+			t.Fatalf("error reading td: %v", err)
+		}
+		td := new(big.Int).SetBytes(reverseOrder(rawTd))
+		if td.Cmp(chain.tds[i]) != 0 {
+			t.Fatalf("mismatched tds: want %s, got %s", chain.tds[i], td)
+		}
+	}
+	if it.Next() {
+		t.Fatalf("unexpected trailing entry")
+	}
+}
+
+func TestEra1BlockRoundtrip(t *testing.T) {
+	var (
+		buf     = new(seekableBuffer)
+		builder = NewBuilder(buf)
+		blocks  []*types.Block
+		hashes  []common.Hash
+		tds     []*big.Int
+		td      = new(big.Int)
+	)
+	for i := 0; i < 16; i++ {
+		header := &types.Header{
+			Number:     big.NewInt(int64(100 + i)),
+			Difficulty: big.NewInt(int64(1000 + i)),
+			Extra:      []byte{byte(i)},
+		}
+		block := types.NewBlockWithHeader(header)
+		td = new(big.Int).Add(td, header.Difficulty)
+		receipts := types.Receipts{{Status: types.ReceiptStatusSuccessful, CumulativeGasUsed: uint64(i), Logs: []*types.Log{}}}
+		if err := builder.Add(block, receipts, td); err != nil {
+			t.Fatalf("error adding block: %v", err)
+		}
+		blocks = append(blocks, block)
+		hashes = append(hashes, block.Hash())
+		tds = append(tds, td)
+	}
+	root, err := builder.Finalize()
+	if err != nil {
+		t.Fatalf("error finalizing era1: %v", err)
+	}
+	e, err := From(buf)
+	if err != nil {
+		t.Fatalf("failed to open era: %v", err)
+	}
+	if e.Start() != 100 || e.Count() != 16 {
+		t.Fatalf("unexpected range: start %d, count %d", e.Start(), e.Count())
+	}
+	if have, err := e.Accumulator(); err != nil || have != root {
+		t.Fatalf("accumulator mismatch: have %x, want %x, err %v", have, root, err)
+	}
+	if want, _ := ComputeAccumulator(hashes, tds); want != root {
+		t.Fatalf("recomputed accumulator mismatch: have %x, want %x", root, want)
+	}
+	if itd, err := e.InitialTD(); err != nil || itd.Sign() != 0 {
+		t.Fatalf("unexpected initial td: %v, err %v", itd, err)
+	}
+	block, err := e.GetBlockByNumber(107)
+	if err != nil {
+		t.Fatalf("failed to read block: %v", err)
+	}
+	if block.Hash() != blocks[7].Hash() {
+		t.Fatalf("block hash mismatch: have %x, want %x", block.Hash(), blocks[7].Hash())
+	}
+	if _, err := e.GetBlockByNumber(116); err == nil {
+		t.Fatal("expected error for out-of-bounds block")
+	}
+	it, err := NewIterator(e)
+	if err != nil {
+		t.Fatalf("failed to make iterator: %v", err)
+	}
+	for i := 0; it.Next(); i++ {
+		block, receipts, err := it.BlockAndReceipts()
+		if err != nil {
+			t.Fatalf("error reading block %d: %v", i, err)
+		}
+		if block.Hash() != hashes[i] {
+			t.Fatalf("block %d hash mismatch", i)
+		}
+		if len(receipts) != 1 || receipts[0].CumulativeGasUsed != uint64(i) {
+			t.Fatalf("block %d receipts mismatch", i)
+		}
+		if td, err := it.TotalDifficulty(); err != nil || td.Cmp(tds[i]) != 0 {
+			t.Fatalf("block %d td mismatch: have %v, want %v", i, td, tds[i])
+		}
+	}
+	if err := it.Error(); err != nil {
+		t.Fatalf("iterator error: %v", err)
+	}
+}
+
+func TestComputeAccumulator(t *testing.T) {
+	// The accumulator of an empty list is the zero-subtree root of the
+	// list limit, mixed in with a zero length.
+	root, err := ComputeAccumulator(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var zero [32]byte
+	for i := 0; i < accumulatorDepth; i++ {
+		zero = hashPair(zero, zero)
+	}
+	if want := common.Hash(hashPair(zero, [32]byte{})); root != want {
+		t.Fatalf("empty accumulator mismatch: have %x, want %x", root, want)
+	}
+	if _, err := ComputeAccumulator([]common.Hash{{}}, nil); err == nil {
+		t.Fatal("expected error for mismatched lengths")
+	}
+	a, _ := ComputeAccumulator([]common.Hash{{1}}, []*big.Int{big.NewInt(1)})
+	b, _ := ComputeAccumulator([]common.Hash{{1}}, []*big.Int{big.NewInt(2)})
+	if a == b {
+		t.Fatal("accumulator does not commit to total difficulty")
+	}
+}
+
+// seekableBuffer is an in-memory ReadAtSeekCloser.
+type seekableBuffer struct {
+	bytes.Buffer
+}
+
+func (b *seekableBuffer) ReadAt(p []byte, off int64) (int, error) {
+	return bytes.NewReader(b.Bytes()).ReadAt(p, off)
+}
+
+func (b *seekableBuffer) Seek(offset int64, whence int) (int64, error) {
+	return bytes.NewReader(b.Bytes()).Seek(offset, whence)
+}
+
+func (b *seekableBuffer) Close() error { return nil }
@@ -442,8 +442,11 @@ func (b testBackend) SyncProgress() ethereum.SyncProgress { return ethereum.Sync
 func (b testBackend) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
 	return big.NewInt(0), nil
 }
-func (b testBackend) FeeHistory(ctx context.Context, blockCount uint64, lastBlock rpc.BlockNumber, rewardPercentiles []float64) (*big.Int, [][]*big.Int, []*big.Int, []float64, error) {
-	return nil, nil, nil, nil, nil
+func (b testBackend) FeeHistory(ctx context.Context, blockCount uint64, lastBlock rpc.BlockNumber, rewardPercentiles []float64) (*big.Int, [][]*big.Int, []*big.Int, []float64, []*big.Int, []float64, error) {
+	return nil, nil, nil, nil, nil, nil, nil
+}
+func (b testBackend) BlobBaseFee(ctx context.Context) *big.Int {
+	return new(big.Int)
 }
 func (b testBackend) ChainDb() ethdb.Database           { return b.db }
 func (b testBackend) AccountManager() *accounts.Manager { return nil }
@@ -593,7 +596,8 @@ func (b testBackend) SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscripti
 func (b testBackend) SubscribePendingLogsEvent(ch chan<- []*types.Log) event.Subscription {
 	panic("implement me")
 }
-func (b testBackend) BloomStatus() (uint64, uint64) { panic("implement me") }
+func (b testBackend) BloomStatus() (uint64, uint64)    { panic("implement me") }
+func (b testBackend) LogIndexStatus() (uint64, uint64) { panic("implement me") }
 func (b testBackend) ServiceFilter(ctx context.Context, session *bloombits.MatcherSession) {
 	panic("implement me")
 }
@@ -870,6 +874,94 @@ func TestCall(t *testing.T) {
 	}
 }
 
+func TestSimulateV1(t *testing.T) {
+	t.Parallel()
+	// Initialize test accounts
+	var (
+		accounts = newAccounts(2)
+		reverter = common.HexToAddress("0x000000000000000000000000000000000000aaaa")
+		genesis  = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc: core.GenesisAlloc{
+				accounts[0].addr: {Balance: big.NewInt(params.Ether)},
+				// Contract reverting with empty data
+				reverter: {Balance: big.NewInt(0), Code: []byte{byte(vm.PUSH1), 0, byte(vm.DUP1), byte(vm.REVERT)}},
+			},
+		}
+		genBlocks = 10
+	)
+	api := NewBlockChainAPI(newTestBackend(t, genBlocks, genesis, ethash.NewFaker(), func(i int, b *core.BlockGen) {}))
+
+	var (
+		value     = (*hexutil.Big)(big.NewInt(1000))
+		timestamp = hexutil.Uint64(genesis.Timestamp + 1000)
+		number    = (*hexutil.Big)(big.NewInt(int64(genBlocks + 3)))
+		latest    = rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+	)
+	results, err := api.SimulateV1(context.Background(), simOpts{
+		BlockStateCalls: []simBlock{
+			{
+				Calls: []TransactionArgs{
+					{From: &accounts[0].addr, To: &accounts[1].addr, Value: value},
+					{From: &accounts[1].addr, To: &reverter},
+				},
+			},
+			{
+				BlockOverrides: &BlockOverrides{Number: number, Time: &timestamp},
+				Calls: []TransactionArgs{
+					// Spends the funds received in the previous block
+					{From: &accounts[1].addr, To: &accounts[0].addr, Value: value},
+				},
+			},
+		},
+		TraceTransfers: true,
+	}, &latest)
+	if err != nil {
+		t.Fatalf("simulation failed: %v", err)
+	}
+	// The gap between the two blocks is filled with an empty one
+	if len(results) != 3 {
+		t.Fatalf("block count mismatch: have %d, want 3", len(results))
+	}
+	for i, res := range results {
+		if have, want := res["number"].(*hexutil.Big).ToInt().Uint64(), uint64(genBlocks+1+i); have != want {
+			t.Errorf("block %d: number mismatch: have %d, want %d", i, have, want)
+		}
+		if i > 0 && res["parentHash"] != results[i-1]["hash"] {
+			t.Errorf("block %d: parent hash mismatch", i)
+		}
+	}
+	if have := results[2]["timestamp"].(hexutil.Uint64); have != timestamp {
+		t.Errorf("timestamp mismatch: have %d, want %d", have, timestamp)
+	}
+	calls := results[0]["calls"].([]simCallResult)
+	if len(calls) != 2 {
+		t.Fatalf("call count mismatch: have %d, want 2", len(calls))
+	}
+	if calls[0].Status != hexutil.Uint64(types.ReceiptStatusSuccessful) || calls[0].GasUsed != hexutil.Uint64(params.TxGas) {
+		t.Errorf("transfer result mismatch: %+v", calls[0])
+	}
+	if len(calls[0].Logs) != 1 || calls[0].Logs[0].Address != transferAddress || calls[0].Logs[0].Topics[2] != common.BytesToHash(accounts[1].addr.Bytes()) {
+		t.Errorf("transfer log mismatch: %+v", calls[0].Logs)
+	}
+	if calls[1].Status != hexutil.Uint64(types.ReceiptStatusFailed) || calls[1].Error == nil || calls[1].Error.Code != 3 {
+		t.Errorf("revert result mismatch: %+v", calls[1])
+	}
+	if calls := results[2]["calls"].([]simCallResult); len(calls) != 1 || calls[0].Status != hexutil.Uint64(types.ReceiptStatusSuccessful) {
+		t.Errorf("carried over state not used: %+v", calls)
+	}
+	// Blocks must be in order
+	_, err = api.SimulateV1(context.Background(), simOpts{
+		BlockStateCalls: []simBlock{
+			{BlockOverrides: &BlockOverrides{Number: number}},
+			{BlockOverrides: &BlockOverrides{Number: number}},
+		},
+	}, &latest)
+	if _, ok := err.(*invalidBlockNumberError); !ok {
+		t.Errorf("expected invalid block number error, got %v", err)
+	}
+}
+
 type Account struct {
 	key  *ecdsa.PrivateKey
 	addr common.Address
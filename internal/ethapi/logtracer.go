@@ -0,0 +1,192 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+var (
+	// keccak256("Transfer(address,address,uint256)")
+	transferTopic = common.HexToHash("ddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef")
+	// ERC-7528
+	transferAddress = common.HexToAddress("0xEeeeeEeeeEeEeeEeEeEeeEEEeeeeEeeeeeeeEEeE")
+)
+
+// tracer is a simple tracer that records all logs and
+// ether transfers. Transfers are recorded as if they
+// were logs. Transfer events include:
+// - tx value
+// - call value
+// - self destructs
+//
+// The log format for a transfer is:
+// - address: 0xEeeeeEeeeEeEeeEeEeEeeEEEeeeeEeeeeeeeEEeE
+// - data: Value
+// - topics:
+//   - Transfer(address,address,uint256)
+//   - Sender address
+//   - Recipient address
+type tracer struct {
+	// logs keeps logs for all open call frames.
+	// This lets us clear logs for failed calls.
+	logs           [][]*types.Log
+	count          int
+	traceTransfers bool
+	blockNumber    uint64
+	blockHash      common.Hash
+	txHash         common.Hash
+	txIdx          uint
+}
+
+func newTracer(traceTransfers bool, blockNumber uint64, blockHash, txHash common.Hash, txIndex uint) *tracer {
+	return &tracer{
+		traceTransfers: traceTransfers,
+		blockNumber:    blockNumber,
+		blockHash:      blockHash,
+		txHash:         txHash,
+		txIdx:          txIndex,
+	}
+}
+
+// CaptureTxStart implements the EVMLogger interface.
+func (t *tracer) CaptureTxStart(gasLimit uint64) {}
+
+// CaptureTxEnd implements the EVMLogger interface.
+func (t *tracer) CaptureTxEnd(restGas uint64) {}
+
+// CaptureStart implements the EVMLogger interface to initialize the tracing operation.
+func (t *tracer) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	t.onEnter(vm.CALL, from, to, value)
+}
+
+// CaptureEnd is called after the call finishes to finalize the tracing.
+func (t *tracer) CaptureEnd(output []byte, gasUsed uint64, err error) {
+	t.onExit(err)
+}
+
+// CaptureEnter is called when EVM enters a new scope (via call, create or selfdestruct).
+func (t *tracer) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	t.onEnter(typ, from, to, value)
+}
+
+// CaptureExit is called when EVM exits a scope, even if the scope didn't
+// execute any code.
+func (t *tracer) CaptureExit(output []byte, gasUsed uint64, err error) {
+	t.onExit(err)
+}
+
+// CaptureState implements the EVMLogger interface to record the logs emitted
+// by the executed code.
+func (t *tracer) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+	// Skip if tracing was interrupted or the opcode failed
+	if err != nil || op < vm.LOG0 || op > vm.LOG4 {
+		return
+	}
+	var (
+		stackData = scope.Stack.Data()
+		stackLen  = len(stackData)
+		size      = int(op - vm.LOG0)
+	)
+	if stackLen < size+2 {
+		return
+	}
+	mStart := stackData[stackLen-1]
+	mSize := stackData[stackLen-2]
+	topics := make([]common.Hash, size)
+	for i := 0; i < size; i++ {
+		topic := stackData[stackLen-2-(i+1)]
+		topics[i] = common.Hash(topic.Bytes32())
+	}
+	data := scope.Memory.GetCopy(int64(mStart.Uint64()), int64(mSize.Uint64()))
+	t.captureLog(scope.Contract.Address(), topics, data)
+}
+
+// CaptureFault implements the EVMLogger interface to trace an execution fault.
+func (t *tracer) CaptureFault(pc uint64, op vm.OpCode, gas, cost uint64, _ *vm.ScopeContext, depth int, err error) {
+}
+
+func (t *tracer) onEnter(typ vm.OpCode, from common.Address, to common.Address, value *big.Int) {
+	t.logs = append(t.logs, make([]*types.Log, 0))
+	if typ == vm.DELEGATECALL || typ == vm.CALLCODE {
+		return
+	}
+	if t.traceTransfers && value != nil && value.Sign() > 0 {
+		t.captureTransfer(from, to, value)
+	}
+}
+
+func (t *tracer) onExit(err error) {
+	if len(t.logs) == 0 {
+		return
+	}
+	size := len(t.logs)
+	if err != nil {
+		// Discard all logs emitted by the failed frame and its children.
+		t.count -= len(t.logs[size-1])
+		t.logs = t.logs[:size-1]
+		return
+	}
+	if size > 1 {
+		// Move the logs of the frame into its parent
+		t.logs[size-2] = append(t.logs[size-2], t.logs[size-1]...)
+		t.logs = t.logs[:size-1]
+	}
+}
+
+func (t *tracer) captureLog(address common.Address, topics []common.Hash, data []byte) {
+	t.logs[len(t.logs)-1] = append(t.logs[len(t.logs)-1], &types.Log{
+		Address:     address,
+		Topics:      topics,
+		Data:        data,
+		BlockNumber: t.blockNumber,
+		BlockHash:   t.blockHash,
+		TxHash:      t.txHash,
+		TxIndex:     t.txIdx,
+		Index:       uint(t.count),
+	})
+	t.count++
+}
+
+func (t *tracer) captureTransfer(from, to common.Address, value *big.Int) {
+	topics := []common.Hash{
+		transferTopic,
+		common.BytesToHash(from.Bytes()),
+		common.BytesToHash(to.Bytes()),
+	}
+	t.captureLog(transferAddress, topics, common.BigToHash(value).Bytes())
+}
+
+// reset prepares the tracer for the next transaction.
+func (t *tracer) reset(txHash common.Hash, txIdx uint) {
+	t.logs = nil
+	t.txHash = txHash
+	t.txIdx = txIdx
+}
+
+// Logs returns the logs emitted by the last transaction, including the
+// synthetic transfer logs if enabled.
+func (t *tracer) Logs() []*types.Log {
+	if len(t.logs) == 0 {
+		return []*types.Log{}
+	}
+	return t.logs[0]
+}
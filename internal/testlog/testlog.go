@@ -18,6 +18,9 @@
 package testlog
 
 import (
+	"bytes"
+	"context"
+	"log/slog"
 	"sync"
 	"testing"
 
@@ -25,18 +28,18 @@ import (
 )
 
 // Handler returns a log handler which logs to the unit test log of t.
-func Handler(t *testing.T, level log.Lvl) log.Handler {
-	return log.LvlFilterHandler(level, &handler{t, log.TerminalFormat(false)})
+func Handler(t *testing.T, level slog.Level) slog.Handler {
+	return log.NewTerminalHandlerWithLevel(&writer{t}, level, false)
 }
 
-type handler struct {
-	t   *testing.T
-	fmt log.Format
+// writer emits every write as a separate entry into the unit test log.
+type writer struct {
+	t *testing.T
 }
 
-func (h *handler) Log(r *log.Record) error {
-	h.t.Logf("%s", h.fmt.Format(r))
-	return nil
+func (w *writer) Write(p []byte) (int, error) {
+	w.t.Logf("%s", p)
+	return len(p), nil
 }
 
 // logger implements log.Logger such that all output goes to the unit test log via
@@ -44,32 +47,45 @@ func (h *handler) Log(r *log.Record) error {
 // helpers, so the file and line number in unit test output correspond to the call site
 // which emitted the log message.
 type logger struct {
-	t  *testing.T
-	l  log.Logger
-	mu *sync.Mutex
-	h  *bufHandler
+	t   *testing.T
+	l   log.Logger
+	mu  *sync.Mutex
+	buf *bytes.Buffer
 }
 
-type bufHandler struct {
-	buf []*log.Record
-	fmt log.Format
+// Logger returns a logger which logs to the unit test log of t.
+func Logger(t *testing.T, level slog.Level) log.Logger {
+	buf := new(bytes.Buffer)
+	return &logger{
+		t:   t,
+		l:   log.NewLogger(log.NewTerminalHandlerWithLevel(buf, level, false)),
+		mu:  new(sync.Mutex),
+		buf: buf,
+	}
 }
 
-func (h *bufHandler) Log(r *log.Record) error {
-	h.buf = append(h.buf, r)
-	return nil
+func (l *logger) Handler() slog.Handler {
+	return l.l.Handler()
 }
 
-// Logger returns a logger which logs to the unit test log of t.
-func Logger(t *testing.T, level log.Lvl) log.Logger {
-	l := &logger{
-		t:  t,
-		l:  log.New(),
-		mu: new(sync.Mutex),
-		h:  &bufHandler{fmt: log.TerminalFormat(false)},
-	}
-	l.l.SetHandler(log.LvlFilterHandler(level, l.h))
-	return l
+func (l *logger) Write(level slog.Level, msg string, ctx ...interface{}) {
+	l.t.Helper()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.l.Write(level, msg, ctx...)
+	l.flush()
+}
+
+func (l *logger) Log(level slog.Level, msg string, ctx ...interface{}) {
+	l.t.Helper()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.l.Log(level, msg, ctx...)
+	l.flush()
+}
+
+func (l *logger) Enabled(ctx context.Context, level slog.Level) bool {
+	return l.l.Enabled(ctx, level)
 }
 
 func (l *logger) Trace(msg string, ctx ...interface{}) {
@@ -120,23 +136,19 @@ func (l *logger) Crit(msg string, ctx ...interface{}) {
 	l.flush()
 }
 
-func (l *logger) New(ctx ...interface{}) log.Logger {
-	return &logger{l.t, l.l.New(ctx...), l.mu, l.h}
+func (l *logger) With(ctx ...interface{}) log.Logger {
+	return &logger{l.t, l.l.With(ctx...), l.mu, l.buf}
 }
 
-func (l *logger) GetHandler() log.Handler {
-	return l.l.GetHandler()
-}
-
-func (l *logger) SetHandler(h log.Handler) {
-	l.l.SetHandler(h)
+func (l *logger) New(ctx ...interface{}) log.Logger {
+	return l.With(ctx...)
 }
 
 // flush writes all buffered messages and clears the buffer.
 func (l *logger) flush() {
 	l.t.Helper()
-	for _, r := range l.h.buf {
-		l.t.Logf("%s", l.h.fmt.Format(r))
+	if l.buf.Len() > 0 {
+		l.t.Logf("%s", l.buf.String())
+		l.buf.Reset()
 	}
-	l.h.buf = nil
 }
@@ -0,0 +1,162 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracing
+
+import (
+	"context"
+	"encoding/binary"
+	"math"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+const (
+	queueSize      = 4096             // Maximum number of finished spans waiting for export
+	exportBatch    = 512              // Maximum number of spans exported at once
+	exportInterval = 5 * time.Second  // Interval after which queued spans are exported
+	exportTimeout  = 10 * time.Second // Timeout of a single export
+)
+
+var (
+	exportedSpansMeter = metrics.NewRegisteredMeter("tracing/spans/exported", nil)
+	droppedSpansMeter  = metrics.NewRegisteredMeter("tracing/spans/dropped", nil)
+	exportFailureMeter = metrics.NewRegisteredMeter("tracing/export/failures", nil)
+)
+
+// Exporter sends finished spans to a tracing backend.
+type Exporter interface {
+	Export(ctx context.Context, spans []*Span) error
+}
+
+// tracer batches finished spans and hands them to the exporter.
+type tracer struct {
+	exporter  Exporter
+	threshold uint64 // traces with an id below this are sampled
+	always    bool   // sample all traces, regardless of the threshold
+
+	queue chan *Span
+	quit  chan struct{}
+	done  chan struct{}
+}
+
+// Setup enables tracing, reporting the finished spans to the given exporter. The
+// fraction sampleRatio of the traces started locally is recorded, while traces
+// continued from remote callers follow the sampling decision of the caller. The
+// returned function disables tracing again, after exporting the pending spans.
+func Setup(exporter Exporter, sampleRatio float64) (stop func()) {
+	t := &tracer{
+		exporter: exporter,
+		always:   sampleRatio >= 1,
+		queue:    make(chan *Span, queueSize),
+		quit:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	if sampleRatio > 0 && sampleRatio < 1 {
+		t.threshold = uint64(sampleRatio * math.MaxUint64)
+	}
+	go t.loop()
+
+	if prev := active.Swap(t); prev != nil {
+		prev.stop()
+	}
+	return func() {
+		active.CompareAndSwap(t, nil)
+		t.stop()
+	}
+}
+
+// sample decides whether a trace started locally is recorded.
+func (t *tracer) sample(id TraceID) bool {
+	return t.always || binary.BigEndian.Uint64(id[8:]) < t.threshold
+}
+
+// enqueue adds a finished span to the export queue, dropping it if the queue
+// is full.
+func (t *tracer) enqueue(s *Span) {
+	select {
+	case t.queue <- s:
+	default:
+		droppedSpansMeter.Mark(1)
+	}
+}
+
+// stop terminates the export loop after flushing the queue.
+func (t *tracer) stop() {
+	select {
+	case <-t.quit:
+	default:
+		close(t.quit)
+	}
+	<-t.done
+}
+
+// loop exports the queued spans whenever a full batch is available, or the
+// export interval elapsed.
+func (t *tracer) loop() {
+	defer close(t.done)
+
+	ticker := time.NewTicker(exportInterval)
+	defer ticker.Stop()
+
+	batch := make([]*Span, 0, exportBatch)
+	for {
+		select {
+		case s := <-t.queue:
+			batch = append(batch, s)
+			if len(batch) == exportBatch {
+				t.export(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			t.export(batch)
+			batch = batch[:0]
+		case <-t.quit:
+			for {
+				select {
+				case s := <-t.queue:
+					batch = append(batch, s)
+				default:
+					for len(batch) > 0 {
+						n := min(len(batch), exportBatch)
+						t.export(batch[:n])
+						batch = batch[n:]
+					}
+					return
+				}
+			}
+		}
+	}
+}
+
+// export sends a batch of spans to the exporter.
+func (t *tracer) export(spans []*Span) {
+	if len(spans) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+	defer cancel()
+
+	if err := t.exporter.Export(ctx, spans); err != nil {
+		exportFailureMeter.Mark(1)
+		droppedSpansMeter.Mark(int64(len(spans)))
+		log.Warn("Failed to export trace spans", "spans", len(spans), "err", err)
+		return
+	}
+	exportedSpansMeter.Mark(int64(len(spans)))
+}
@@ -0,0 +1,269 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package tracing implements lightweight distributed tracing following the
+// OpenTelemetry data model.
+//
+// Spans are created with Start and form a tree through the context passed to it.
+// Tracing is disabled until an exporter is installed with Setup. Until then, Start
+// returns a nil span whose methods are all no-ops, so instrumented code doesn't
+// need to check whether tracing is enabled.
+package tracing
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"math"
+	"math/rand"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// TraceID identifies a trace, i.e. a tree of spans.
+type TraceID [16]byte
+
+// String returns the hex encoding of the trace id.
+func (id TraceID) String() string { return hex.EncodeToString(id[:]) }
+
+// SpanID identifies a single span within a trace.
+type SpanID [8]byte
+
+// String returns the hex encoding of the span id.
+func (id SpanID) String() string { return hex.EncodeToString(id[:]) }
+
+// SpanContext is the part of a span which is propagated to its children, both
+// within the process and to remote services.
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool
+}
+
+// IsValid reports whether both the trace and span ids are set.
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != (TraceID{}) && sc.SpanID != (SpanID{})
+}
+
+// Traceparent returns the W3C trace context header value of the span context.
+func (sc SpanContext) Traceparent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return "00-" + sc.TraceID.String() + "-" + sc.SpanID.String() + "-" + flags
+}
+
+var errInvalidTraceparent = errors.New("invalid traceparent")
+
+// ParseTraceparent parses a W3C trace context header value, as sent in the
+// 'traceparent' HTTP header.
+func ParseTraceparent(header string) (SpanContext, error) {
+	var sc SpanContext
+
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return sc, errInvalidTraceparent
+	}
+	// Version 00 has exactly four fields, future versions may append more.
+	if parts[0] == "00" && len(parts) != 4 {
+		return sc, errInvalidTraceparent
+	}
+	if len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return sc, errInvalidTraceparent
+	}
+	if _, err := hex.Decode(sc.TraceID[:], []byte(parts[1])); err != nil {
+		return sc, errInvalidTraceparent
+	}
+	if _, err := hex.Decode(sc.SpanID[:], []byte(parts[2])); err != nil {
+		return sc, errInvalidTraceparent
+	}
+	var flags [1]byte
+	if _, err := hex.Decode(flags[:], []byte(parts[3])); err != nil {
+		return sc, errInvalidTraceparent
+	}
+	if !sc.IsValid() {
+		return sc, errInvalidTraceparent
+	}
+	sc.Sampled = flags[0]&0x01 != 0
+	return sc, nil
+}
+
+type spanContextKey struct{}
+
+// ContextWithSpanContext returns a copy of the context carrying the given span
+// context. Spans started from the returned context become its children. This is
+// used to continue traces started by remote callers.
+func ContextWithSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, spanContextKey{}, sc)
+}
+
+// SpanContextFromContext returns the span context of the active span in ctx.
+func SpanContextFromContext(ctx context.Context) (SpanContext, bool) {
+	sc, ok := ctx.Value(spanContextKey{}).(SpanContext)
+	return sc, ok
+}
+
+// SpanKind describes the relationship of a span to its remote parent and children.
+// The values match the OpenTelemetry protocol.
+type SpanKind int
+
+const (
+	SpanKindInternal SpanKind = 1
+	SpanKindServer   SpanKind = 2
+	SpanKindClient   SpanKind = 3
+)
+
+// Attribute is a key-value pair describing a span.
+type Attribute struct {
+	Key   string
+	Value interface{} // string, int64, float64 or bool
+}
+
+// String creates a string attribute.
+func String(key, value string) Attribute { return Attribute{key, value} }
+
+// Int creates an integer attribute.
+func Int(key string, value int) Attribute { return Attribute{key, int64(value)} }
+
+// Int64 creates an integer attribute.
+func Int64(key string, value int64) Attribute { return Attribute{key, value} }
+
+// Uint64 creates an integer attribute. Values exceeding the int64 range are capped.
+func Uint64(key string, value uint64) Attribute {
+	if value > math.MaxInt64 {
+		value = math.MaxInt64
+	}
+	return Attribute{key, int64(value)}
+}
+
+// Bool creates a boolean attribute.
+func Bool(key string, value bool) Attribute { return Attribute{key, value} }
+
+// Duration creates an attribute holding a duration in milliseconds.
+func Duration(key string, value time.Duration) Attribute {
+	return Attribute{key, float64(value) / float64(time.Millisecond)}
+}
+
+// Span is a timed operation within a trace. A nil span is valid and ignores all
+// calls, which is what Start returns if tracing is disabled.
+//
+// Spans are not safe for concurrent use.
+type Span struct {
+	name   string
+	kind   SpanKind
+	sc     SpanContext
+	parent SpanID
+	start  time.Time
+	end    time.Time
+	attrs  []Attribute
+	err    error
+
+	tracer *tracer
+}
+
+// Start creates a new span with the given name. If ctx carries a span context,
+// the span becomes its child, otherwise it starts a new trace. The returned
+// context carries the new span and should be passed on to create child spans.
+// The span must be finished by calling End.
+func Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, *Span) {
+	return start(ctx, name, SpanKindInternal, attrs)
+}
+
+// StartServer is like Start, but marks the span as handling a request of a remote
+// client.
+func StartServer(ctx context.Context, name string, attrs ...Attribute) (context.Context, *Span) {
+	return start(ctx, name, SpanKindServer, attrs)
+}
+
+func start(ctx context.Context, name string, kind SpanKind, attrs []Attribute) (context.Context, *Span) {
+	t := active.Load()
+	if t == nil {
+		return ctx, nil
+	}
+	span := &Span{
+		name:   name,
+		kind:   kind,
+		start:  time.Now(),
+		attrs:  attrs,
+		tracer: t,
+	}
+	binary.BigEndian.PutUint64(span.sc.SpanID[:], nonZeroRandom())
+	if parent, ok := SpanContextFromContext(ctx); ok && parent.IsValid() {
+		span.sc.TraceID = parent.TraceID
+		span.sc.Sampled = parent.Sampled
+		span.parent = parent.SpanID
+	} else {
+		binary.BigEndian.PutUint64(span.sc.TraceID[:8], rand.Uint64())
+		binary.BigEndian.PutUint64(span.sc.TraceID[8:], nonZeroRandom())
+		span.sc.Sampled = t.sample(span.sc.TraceID)
+	}
+	return ContextWithSpanContext(ctx, span.sc), span
+}
+
+// nonZeroRandom returns a random number which is never zero, as ids consisting
+// of zeroes are invalid.
+func nonZeroRandom() uint64 {
+	for {
+		if n := rand.Uint64(); n != 0 {
+			return n
+		}
+	}
+}
+
+// SpanContext returns the span context of the span.
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.sc
+}
+
+// IsRecording reports whether the span will be exported when finished. This can
+// be used to avoid computing expensive attributes.
+func (s *Span) IsRecording() bool {
+	return s != nil && s.sc.Sampled
+}
+
+// SetAttributes adds attributes to the span.
+func (s *Span) SetAttributes(attrs ...Attribute) {
+	if !s.IsRecording() {
+		return
+	}
+	s.attrs = append(s.attrs, attrs...)
+}
+
+// SetError marks the span as failed with the given error. Nil errors are ignored.
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.err = err
+}
+
+// End finishes the span and queues it for export.
+func (s *Span) End() {
+	if !s.IsRecording() || !s.end.IsZero() {
+		return
+	}
+	s.end = time.Now()
+	s.tracer.enqueue(s)
+}
+
+// active is the tracer spans are reported to, nil if tracing is disabled.
+var active atomic.Pointer[tracer]
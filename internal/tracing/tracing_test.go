@@ -0,0 +1,198 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// testExporter collects the exported spans.
+type testExporter struct {
+	mu    sync.Mutex
+	spans []*Span
+}
+
+func (e *testExporter) Export(ctx context.Context, spans []*Span) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, spans...)
+	return nil
+}
+
+func TestTraceparent(t *testing.T) {
+	const header = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	sc, err := ParseTraceparent(header)
+	if err != nil {
+		t.Fatalf("failed to parse traceparent: %v", err)
+	}
+	if sc.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" || sc.SpanID.String() != "00f067aa0ba902b7" || !sc.Sampled {
+		t.Fatalf("wrong span context: %+v", sc)
+	}
+	if have := sc.Traceparent(); have != header {
+		t.Errorf("wrong encoding: have %s, want %s", have, header)
+	}
+	// Future versions may carry additional fields.
+	if _, err := ParseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-ext"); err != nil {
+		t.Errorf("failed to parse future version: %v", err)
+	}
+	for _, invalid := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-ext",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e473x-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-1",
+	} {
+		if _, err := ParseTraceparent(invalid); err == nil {
+			t.Errorf("%q: expected error", invalid)
+		}
+	}
+}
+
+func TestDisabled(t *testing.T) {
+	ctx, span := Start(context.Background(), "disabled")
+	if span != nil {
+		t.Fatal("span created with tracing disabled")
+	}
+	if _, ok := SpanContextFromContext(ctx); ok {
+		t.Fatal("span context set with tracing disabled")
+	}
+	// Methods of nil spans must not panic.
+	span.SetAttributes(String("key", "value"))
+	span.SetError(errors.New("failed"))
+	span.End()
+}
+
+func TestSpanTree(t *testing.T) {
+	exporter := new(testExporter)
+	stop := Setup(exporter, 1)
+
+	ctx, root := StartServer(context.Background(), "root", String("key", "value"))
+	_, child := Start(ctx, "child")
+	child.SetError(errors.New("failed"))
+	child.End()
+	root.End()
+	root.End() // ending twice has no effect
+
+	// Traces continued from remote callers follow their sampling decision.
+	remote, _ := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	_, sampled := Start(ContextWithSpanContext(context.Background(), remote), "sampled")
+	sampled.End()
+	remote.Sampled = false
+	_, unsampled := Start(ContextWithSpanContext(context.Background(), remote), "unsampled")
+	unsampled.End()
+
+	stop()
+	if _, span := Start(context.Background(), "stopped"); span != nil {
+		t.Fatal("span created after stopping")
+	}
+	if len(exporter.spans) != 3 {
+		t.Fatalf("wrong number of exported spans: have %d, want 3", len(exporter.spans))
+	}
+	have, want := exporter.spans[0], exporter.spans[1]
+	if have.name != "child" || have.sc.TraceID != want.sc.TraceID || have.parent != want.sc.SpanID || have.err == nil {
+		t.Errorf("wrong child span: %+v", have)
+	}
+	if want.name != "root" || want.kind != SpanKindServer || want.parent != (SpanID{}) || len(want.attrs) != 1 {
+		t.Errorf("wrong root span: %+v", want)
+	}
+	if span := exporter.spans[2]; span.name != "sampled" || span.sc.TraceID != remote.TraceID || span.parent != remote.SpanID {
+		t.Errorf("wrong remote child span: %+v", span)
+	}
+}
+
+func TestSampling(t *testing.T) {
+	exporter := new(testExporter)
+	stop := Setup(exporter, 0)
+	for i := 0; i < 10; i++ {
+		ctx, span := Start(context.Background(), "root")
+		if span.IsRecording() {
+			t.Fatal("span recorded with zero sample ratio")
+		}
+		// Children inherit the trace and the sampling decision.
+		_, child := Start(ctx, "child")
+		if child.SpanContext().TraceID != span.SpanContext().TraceID || child.IsRecording() {
+			t.Fatal("child span doesn't follow its parent")
+		}
+		child.End()
+		span.End()
+	}
+	stop()
+	if len(exporter.spans) != 0 {
+		t.Fatalf("unsampled spans exported: %d", len(exporter.spans))
+	}
+}
+
+func TestOTLPExporter(t *testing.T) {
+	var (
+		path string
+		req  otlpRequest
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	exporter, err := NewOTLPExporter(srv.URL, String("service.name", "geth"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stop := Setup(exporter, 1)
+	ctx, root := Start(context.Background(), "root", Int("number", 42), Bool("ok", true))
+	_, child := Start(ctx, "child")
+	child.SetError(errors.New("failed"))
+	child.End()
+	root.End()
+	stop()
+
+	if path != "/v1/traces" {
+		t.Errorf("wrong request path %q", path)
+	}
+	if len(req.ResourceSpans) != 1 || len(req.ResourceSpans[0].ScopeSpans) != 1 {
+		t.Fatalf("wrong request structure: %+v", req)
+	}
+	if attrs := req.ResourceSpans[0].Resource.Attributes; len(attrs) != 1 || *attrs[0].Value.StringValue != "geth" {
+		t.Errorf("wrong resource attributes: %+v", attrs)
+	}
+	spans := req.ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != 2 {
+		t.Fatalf("wrong number of spans: %d", len(spans))
+	}
+	if spans[0].Name != "child" || spans[0].ParentSpanID != spans[1].SpanID || spans[0].Status.Code != otlpStatusError {
+		t.Errorf("wrong child span: %+v", spans[0])
+	}
+	if spans[1].Name != "root" || spans[1].ParentSpanID != "" || len(spans[1].TraceID) != 32 {
+		t.Errorf("wrong root span: %+v", spans[1])
+	}
+	if attrs := spans[1].Attributes; len(attrs) != 2 || *attrs[0].Value.IntValue != "42" || !*attrs[1].Value.BoolValue {
+		t.Errorf("wrong span attributes: %+v", attrs)
+	}
+}
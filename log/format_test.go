@@ -76,7 +76,7 @@ func TestPrettyBigInt(t *testing.T) {
 
 	for _, tt := range tests {
 		v, _ := new(big.Int).SetString(tt.int, 10)
-		if have := formatLogfmtBigInt(v); have != tt.s {
+		if have := string(appendBigInt(nil, v)); have != tt.s {
 			t.Errorf("invalid output %s, want %s", have, tt.s)
 		}
 	}
@@ -94,7 +94,7 @@ func TestPrettyUint256(t *testing.T) {
 	for _, tt := range tests {
 		v := new(uint256.Int)
 		v.SetFromDecimal(tt.int)
-		if have := formatLogfmtUint256(v); have != tt.s {
+		if have := string(appendU256(nil, v)); have != tt.s {
 			t.Errorf("invalid output %s, want %s", have, tt.s)
 		}
 	}
@@ -149,10 +149,9 @@ func TestSanitation(t *testing.T) {
 		},
 	} {
 		var (
-			logger = New()
 			out    = new(strings.Builder)
+			logger = NewLogger(NewTerminalHandlerWithLevel(out, LevelInfo, false))
 		)
-		logger.SetHandler(LvlFilterHandler(LvlInfo, StreamHandler(out, TerminalFormat(false))))
 		logger.Info(tt.msg, tt.msg, tt.msg)
 		if have := out.String()[24:]; tt.want != have {
 			t.Fatalf("test %d: want / have: \n%v\n%v", i, tt.want, have)
@@ -17,8 +17,10 @@
 package log
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"log/slog"
 	"regexp"
 	"runtime"
 	"strconv"
@@ -37,48 +39,51 @@ var errTraceSyntax = errors.New("expect file.go:234")
 // glog logger: setting global log levels; overriding with callsite pattern
 // matches; and requesting backtraces at certain positions.
 type GlogHandler struct {
-	origin Handler // The origin handler this wraps
+	origin slog.Handler // The origin handler this wraps
+	filter *glogFilter  // The filter rules, shared with the handlers derived via WithAttrs
+}
 
-	level     atomic.Uint32 // Current log level, atomically accessible
-	override  atomic.Bool   // Flag whether overrides are used, atomically accessible
-	backtrace atomic.Bool   // Flag whether backtrace location is set
+// glogFilter contains the filtering configuration of a GlogHandler. It is shared
+// between a handler and all its derived handlers, so changing the verbosity also
+// affects the loggers created earlier.
+type glogFilter struct {
+	level     atomic.Int64 // Current log level, atomically accessible
+	override  atomic.Bool  // Flag whether overrides are used, atomically accessible
+	backtrace atomic.Bool  // Flag whether backtrace location is set
 
-	patterns  []pattern       // Current list of patterns to override with
-	siteCache map[uintptr]Lvl // Cache of callsite pattern evaluations
-	location  string          // file:line location where to do a stackdump at
-	lock      sync.RWMutex    // Lock protecting the override pattern list
+	patterns  []pattern              // Current list of patterns to override with
+	siteCache map[uintptr]slog.Level // Cache of callsite pattern evaluations
+	location  string                 // file:line location where to do a stackdump at
+	lock      sync.RWMutex           // Lock protecting the override pattern list
 }
 
 // NewGlogHandler creates a new log handler with filtering functionality similar
 // to Google's glog logger. The returned handler implements Handler.
-func NewGlogHandler(h Handler) *GlogHandler {
+func NewGlogHandler(h slog.Handler) *GlogHandler {
 	return &GlogHandler{
 		origin: h,
+		filter: new(glogFilter),
 	}
 }
 
-// SetHandler updates the handler to write records to the specified sub-handler.
-func (h *GlogHandler) SetHandler(nh Handler) {
-	h.origin = nh
-}
-
 // pattern contains a filter for the Vmodule option, holding a verbosity level
 // and a file pattern to match.
 type pattern struct {
 	pattern *regexp.Regexp
-	level   Lvl
+	level   slog.Level
 }
 
 // Verbosity sets the glog verbosity ceiling. The verbosity of individual packages
 // and source files can be raised using Vmodule.
-func (h *GlogHandler) Verbosity(level Lvl) {
-	h.level.Store(uint32(level))
+func (h *GlogHandler) Verbosity(level slog.Level) {
+	h.filter.level.Store(int64(level))
 }
 
 // Vmodule sets the glog verbosity pattern.
 //
 // The syntax of the argument is a comma-separated list of pattern=N, where the
-// pattern is a literal file name or "glob" pattern matching and N is a V level.
+// pattern is a literal file name or "glob" pattern matching and N is a V level
+// in the legacy verbosity range (0=crit .. 5=trace).
 //
 // For instance:
 //
@@ -108,11 +113,13 @@ func (h *GlogHandler) Vmodule(ruleset string) error {
 			return errVmoduleSyntax
 		}
 		// Parse the level and if correct, assemble the filter rule
-		level, err := strconv.Atoi(parts[1])
+		l, err := strconv.Atoi(parts[1])
 		if err != nil {
 			return errVmoduleSyntax
 		}
-		if level <= 0 {
+		level := FromLegacyLevel(l)
+
+		if level == LevelCrit {
 			continue // Ignore. It's harmless but no point in paying the overhead.
 		}
 		// Compile the rule pattern into a regular expression
@@ -130,19 +137,15 @@ func (h *GlogHandler) Vmodule(ruleset string) error {
 		matcher = matcher + "$"
 
 		re, _ := regexp.Compile(matcher)
-		filter = append(filter, pattern{re, Lvl(level)})
+		filter = append(filter, pattern{re, level})
 	}
 	// Swap out the vmodule pattern for the new filter system
-	h.lock.Lock()
-	defer h.lock.Unlock()
-
-	h.patterns = filter
-	h.siteCache = make(map[uintptr]Lvl)
-	h.override.Store(len(filter) != 0)
-	// Enable location storage (globally)
-	if len(h.patterns) > 0 {
-		stackEnabled.Store(true)
-	}
+	h.filter.lock.Lock()
+	defer h.filter.lock.Unlock()
+
+	h.filter.patterns = filter
+	h.filter.siteCache = make(map[uintptr]slog.Level)
+	h.filter.override.Store(len(filter) != 0)
 	return nil
 }
 
@@ -170,67 +173,99 @@ func (h *GlogHandler) BacktraceAt(location string) error {
 		return errTraceSyntax
 	}
 	// All seems valid
-	h.lock.Lock()
-	defer h.lock.Unlock()
+	h.filter.lock.Lock()
+	defer h.filter.lock.Unlock()
 
-	h.location = location
-	h.backtrace.Store(len(location) > 0)
-	// Enable location storage (globally)
-	stackEnabled.Store(true)
+	h.filter.location = location
+	h.filter.backtrace.Store(len(location) > 0)
 	return nil
 }
 
-// Log implements Handler.Log, filtering a log record through the global, local
-// and backtrace filters, finally emitting it if either allow it through.
-func (h *GlogHandler) Log(r *Record) error {
+// Enabled implements slog.Handler, reporting whether the handler handles records
+// at the given level. If vmodule overrides or a backtrace location are set, the
+// decision can only be made based on the call site, so all records are passed.
+func (h *GlogHandler) Enabled(ctx context.Context, lvl slog.Level) bool {
+	return h.filter.override.Load() || h.filter.backtrace.Load() || slog.Level(h.filter.level.Load()) <= lvl
+}
+
+// WithAttrs implements slog.Handler, returning a handler which adds the attributes
+// to all records, filtered with the same (shared) rules as the parent handler.
+func (h *GlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &GlogHandler{
+		origin: h.origin.WithAttrs(attrs),
+		filter: h.filter,
+	}
+}
+
+// WithGroup implements slog.Handler, returning a handler which qualifies all
+// subsequent attributes with the group name, filtered with the same (shared)
+// rules as the parent handler.
+func (h *GlogHandler) WithGroup(name string) slog.Handler {
+	return &GlogHandler{
+		origin: h.origin.WithGroup(name),
+		filter: h.filter,
+	}
+}
+
+// Handle implements slog.Handler, filtering a log record through the global,
+// local and backtrace filters, finally emitting it if either allow it through.
+func (h *GlogHandler) Handle(ctx context.Context, r slog.Record) error {
+	f := h.filter
+
 	// If backtracing is requested, check whether this is the callsite
-	if h.backtrace.Load() {
+	if f.backtrace.Load() && r.PC != 0 {
 		// Everything below here is slow. Although we could cache the call sites the
 		// same way as for vmodule, backtracing is so rare it's not worth the extra
 		// complexity.
-		h.lock.RLock()
-		match := h.location == r.Call.String()
-		h.lock.RUnlock()
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		site := fmt.Sprintf("%s:%d", frame.File[strings.LastIndexByte(frame.File, '/')+1:], frame.Line)
+
+		f.lock.RLock()
+		match := f.location == site
+		f.lock.RUnlock()
 
 		if match {
 			// Callsite matched, raise the log level to info and gather the stacks
-			r.Lvl = LvlInfo
+			r.Level = slog.LevelInfo
 
 			buf := make([]byte, 1024*1024)
 			buf = buf[:runtime.Stack(buf, true)]
-			r.Msg += "\n\n" + string(buf)
+			r.Message += "\n\n" + string(buf)
 		}
 	}
 	// If the global log level allows, fast track logging
-	if h.level.Load() >= uint32(r.Lvl) {
-		return h.origin.Log(r)
+	if slog.Level(f.level.Load()) <= r.Level {
+		return h.origin.Handle(ctx, r)
 	}
 	// If no local overrides are present, fast track skipping
-	if !h.override.Load() {
+	if !f.override.Load() || r.PC == 0 {
 		return nil
 	}
 	// Check callsite cache for previously calculated log levels
-	h.lock.RLock()
-	lvl, ok := h.siteCache[r.Call.Frame().PC]
-	h.lock.RUnlock()
+	f.lock.RLock()
+	lvl, ok := f.siteCache[r.PC]
+	f.lock.RUnlock()
 
 	// If we didn't cache the callsite yet, calculate it
 	if !ok {
-		h.lock.Lock()
-		for _, rule := range h.patterns {
-			if rule.pattern.MatchString(fmt.Sprintf("%+s", r.Call)) {
-				h.siteCache[r.Call.Frame().PC], lvl, ok = rule.level, rule.level, true
+		f.lock.Lock()
+
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		for _, rule := range f.patterns {
+			if rule.pattern.MatchString(frame.File) {
+				f.siteCache[r.PC], lvl, ok = rule.level, rule.level, true
 				break
 			}
 		}
 		// If no rule matched, remember to drop log the next time
 		if !ok {
-			h.siteCache[r.Call.Frame().PC] = 0
+			lvl = LevelCrit + 1
+			f.siteCache[r.PC] = lvl
 		}
-		h.lock.Unlock()
+		f.lock.Unlock()
 	}
-	if lvl >= r.Lvl {
-		return h.origin.Log(r)
+	if lvl <= r.Level {
+		return h.origin.Handle(ctx, r)
 	}
 	return nil
 }
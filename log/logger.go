@@ -1,122 +1,126 @@
 package log
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
+	"math"
 	"os"
+	"reflect"
+	"runtime"
 	"time"
-
-	"github.com/go-stack/stack"
 )
 
-const timeKey = "t"
-const lvlKey = "lvl"
-const msgKey = "msg"
-const ctxKey = "ctx"
-const errorKey = "LOG15_ERROR"
-const skipLevel = 2
+const errorKey = "LOG_ERROR"
 
-type Lvl int
+// The legacy verbosity levels, as used on the command line (--verbosity) and in
+// the vmodule patterns.
+const (
+	legacyLevelCrit = iota
+	legacyLevelError
+	legacyLevelWarn
+	legacyLevelInfo
+	legacyLevelDebug
+	legacyLevelTrace
+)
 
+// The log levels of the logger, mapped onto the slog level space. Trace and Crit
+// extend the standard slog levels on both ends.
 const (
-	LvlCrit Lvl = iota
-	LvlError
-	LvlWarn
-	LvlInfo
-	LvlDebug
-	LvlTrace
+	levelMaxVerbosity slog.Level = math.MinInt
+	LevelTrace        slog.Level = -8
+	LevelDebug                   = slog.LevelDebug
+	LevelInfo                    = slog.LevelInfo
+	LevelWarn                    = slog.LevelWarn
+	LevelError                   = slog.LevelError
+	LevelCrit         slog.Level = 12
+
+	// for backward-compatibility
+	LvlTrace = LevelTrace
+	LvlDebug = LevelDebug
+	LvlInfo  = LevelInfo
+	LvlWarn  = LevelWarn
+	LvlError = LevelError
+	LvlCrit  = LevelCrit
 )
 
-// AlignedString returns a 5-character string containing the name of a Lvl.
-func (l Lvl) AlignedString() string {
+// FromLegacyLevel converts from the old Geth verbosity level constants (0 for
+// crit up to 5 for trace) to the levels defined by slog. Values outside of the
+// legacy range are clamped to the closest level.
+func FromLegacyLevel(lvl int) slog.Level {
+	switch lvl {
+	case legacyLevelCrit:
+		return LevelCrit
+	case legacyLevelError:
+		return slog.LevelError
+	case legacyLevelWarn:
+		return slog.LevelWarn
+	case legacyLevelInfo:
+		return slog.LevelInfo
+	case legacyLevelDebug:
+		return slog.LevelDebug
+	case legacyLevelTrace:
+		return LevelTrace
+	}
+	if lvl > legacyLevelTrace {
+		return LevelTrace
+	}
+	return LevelCrit
+}
+
+// LevelAlignedString returns a 5-character string containing the name of a Lvl.
+func LevelAlignedString(l slog.Level) string {
 	switch l {
-	case LvlTrace:
+	case LevelTrace:
 		return "TRACE"
-	case LvlDebug:
+	case slog.LevelDebug:
 		return "DEBUG"
-	case LvlInfo:
+	case slog.LevelInfo:
 		return "INFO "
-	case LvlWarn:
+	case slog.LevelWarn:
 		return "WARN "
-	case LvlError:
+	case slog.LevelError:
 		return "ERROR"
-	case LvlCrit:
+	case LevelCrit:
 		return "CRIT "
 	default:
-		panic("bad level")
+		return "unknown level"
 	}
 }
 
-// String returns the name of a Lvl.
-func (l Lvl) String() string {
+// LevelString returns a string containing the name of a Lvl.
+func LevelString(l slog.Level) string {
 	switch l {
-	case LvlTrace:
-		return "trce"
-	case LvlDebug:
-		return "dbug"
-	case LvlInfo:
+	case LevelTrace:
+		return "trace"
+	case slog.LevelDebug:
+		return "debug"
+	case slog.LevelInfo:
 		return "info"
-	case LvlWarn:
+	case slog.LevelWarn:
 		return "warn"
-	case LvlError:
-		return "eror"
-	case LvlCrit:
+	case slog.LevelError:
+		return "error"
+	case LevelCrit:
 		return "crit"
 	default:
-		panic("bad level")
+		return "unknown"
 	}
 }
 
-// LvlFromString returns the appropriate Lvl from a string name.
-// Useful for parsing command line args and configuration files.
-func LvlFromString(lvlString string) (Lvl, error) {
-	switch lvlString {
-	case "trace", "trce":
-		return LvlTrace, nil
-	case "debug", "dbug":
-		return LvlDebug, nil
-	case "info":
-		return LvlInfo, nil
-	case "warn":
-		return LvlWarn, nil
-	case "error", "eror":
-		return LvlError, nil
-	case "crit":
-		return LvlCrit, nil
-	default:
-		return LvlDebug, fmt.Errorf("unknown level: %v", lvlString)
-	}
-}
-
-// A Record is what a Logger asks its handler to write
-type Record struct {
-	Time     time.Time
-	Lvl      Lvl
-	Msg      string
-	Ctx      []interface{}
-	Call     stack.Call
-	KeyNames RecordKeyNames
-}
-
-// RecordKeyNames gets stored in a Record when the write function is executed.
-type RecordKeyNames struct {
-	Time string
-	Msg  string
-	Lvl  string
-	Ctx  string
-}
-
 // A Logger writes key/value pairs to a Handler
 type Logger interface {
-	// New returns a new Logger that has this logger's context plus the given context
-	New(ctx ...interface{}) Logger
+	// With returns a new Logger that has this logger's attributes plus the given attributes
+	With(ctx ...interface{}) Logger
 
-	// GetHandler gets the handler associated with the logger.
-	GetHandler() Handler
+	// New returns a new Logger that has this logger's attributes plus the given attributes.
+	// Identical to 'With'.
+	New(ctx ...interface{}) Logger
 
-	// SetHandler updates the logger to write records to the specified handler.
-	SetHandler(h Handler)
+	// Log logs a message at the specified level with context key/value pairs
+	Log(level slog.Level, msg string, ctx ...interface{})
 
-	// Log a message at the trace level with context key/value pairs
+	// Trace log a message at the trace level with context key/value pairs
 	//
 	// # Usage
 	//
@@ -125,7 +129,7 @@ type Logger interface {
 	//	log.Trace("msg", "key1", val1, "key2", val2)
 	Trace(msg string, ctx ...interface{})
 
-	// Log a message at the debug level with context key/value pairs
+	// Debug logs a message at the debug level with context key/value pairs
 	//
 	// # Usage Examples
 	//
@@ -134,7 +138,7 @@ type Logger interface {
 	//	log.Debug("msg", "key1", val1, "key2", val2)
 	Debug(msg string, ctx ...interface{})
 
-	// Log a message at the info level with context key/value pairs
+	// Info logs a message at the info level with context key/value pairs
 	//
 	// # Usage Examples
 	//
@@ -143,7 +147,7 @@ type Logger interface {
 	//	log.Info("msg", "key1", val1, "key2", val2)
 	Info(msg string, ctx ...interface{})
 
-	// Log a message at the warn level with context key/value pairs
+	// Warn logs a message at the warn level with context key/value pairs
 	//
 	// # Usage Examples
 	//
@@ -152,7 +156,7 @@ type Logger interface {
 	//	log.Warn("msg", "key1", val1, "key2", val2)
 	Warn(msg string, ctx ...interface{})
 
-	// Log a message at the error level with context key/value pairs
+	// Error logs a message at the error level with context key/value pairs
 	//
 	// # Usage Examples
 	//
@@ -161,7 +165,7 @@ type Logger interface {
 	//	log.Error("msg", "key1", val1, "key2", val2)
 	Error(msg string, ctx ...interface{})
 
-	// Log a message at the crit level with context key/value pairs, and then exit.
+	// Crit logs a message at the crit level with context key/value pairs, and exits
 	//
 	// # Usage Examples
 	//
@@ -169,105 +173,113 @@ type Logger interface {
 	//	log.Crit("msg", "key1", val1)
 	//	log.Crit("msg", "key1", val1, "key2", val2)
 	Crit(msg string, ctx ...interface{})
+
+	// Write logs a message at the specified level
+	Write(level slog.Level, msg string, attrs ...any)
+
+	// Enabled reports whether l emits log records at the given context and level.
+	Enabled(ctx context.Context, level slog.Level) bool
+
+	// Handler returns the underlying handler of the inner logger.
+	Handler() slog.Handler
 }
 
 type logger struct {
-	ctx []interface{}
-	h   *swapHandler
+	inner *slog.Logger
+}
+
+// NewLogger returns a logger with the specified handler set
+func NewLogger(h slog.Handler) Logger {
+	return &logger{
+		slog.New(h),
+	}
+}
+
+func (l *logger) Handler() slog.Handler {
+	return l.inner.Handler()
 }
 
-func (l *logger) write(msg string, lvl Lvl, ctx []interface{}, skip int) {
-	record := &Record{
-		Time: time.Now(),
-		Lvl:  lvl,
-		Msg:  msg,
-		Ctx:  newContext(l.ctx, ctx),
-		KeyNames: RecordKeyNames{
-			Time: timeKey,
-			Msg:  msgKey,
-			Lvl:  lvlKey,
-			Ctx:  ctxKey,
-		},
+// Write logs a message at the specified level.
+func (l *logger) Write(level slog.Level, msg string, attrs ...any) {
+	l.write(level, msg, attrs, 3)
+}
+
+// write assembles the log record and passes it to the handler. The skip argument
+// is the number of stack frames to ascend to find the call site of the logger,
+// with 0 identifying the caller of runtime.Callers.
+func (l *logger) write(level slog.Level, msg string, attrs []any, skip int) {
+	if !l.inner.Enabled(context.Background(), level) {
+		return
 	}
-	if stackEnabled.Load() {
-		record.Call = stack.Caller(skip)
+	var pcs [1]uintptr
+	runtime.Callers(skip, pcs[:])
+
+	if !balanced(attrs) {
+		attrs = append(attrs, nil, errorKey, "Normalized odd number of arguments by adding nil")
 	}
-	l.h.Log(record)
+	r := slog.NewRecord(time.Now(), level, msg, pcs[0])
+	r.Add(attrs...)
+	l.inner.Handler().Handle(context.Background(), r)
+}
+
+// balanced reports whether the context arguments consist of complete key/value
+// pairs. Arguments of type slog.Attr stand on their own, without a key.
+func balanced(ctx []any) bool {
+	for i := 0; i < len(ctx); i++ {
+		if _, ok := ctx[i].(slog.Attr); !ok {
+			i++
+			if i == len(ctx) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (l *logger) Log(level slog.Level, msg string, attrs ...any) {
+	l.write(level, msg, attrs, 3)
+}
+
+func (l *logger) With(ctx ...interface{}) Logger {
+	return &logger{l.inner.With(ctx...)}
 }
 
 func (l *logger) New(ctx ...interface{}) Logger {
-	child := &logger{newContext(l.ctx, ctx), new(swapHandler)}
-	child.SetHandler(l.h)
-	return child
+	return l.With(ctx...)
 }
 
-func newContext(prefix []interface{}, suffix []interface{}) []interface{} {
-	normalizedSuffix := normalize(suffix)
-	newCtx := make([]interface{}, len(prefix)+len(normalizedSuffix))
-	n := copy(newCtx, prefix)
-	copy(newCtx[n:], normalizedSuffix)
-	return newCtx
+// Enabled reports whether l emits log records at the given context and level.
+func (l *logger) Enabled(ctx context.Context, level slog.Level) bool {
+	return l.inner.Enabled(ctx, level)
 }
 
 func (l *logger) Trace(msg string, ctx ...interface{}) {
-	l.write(msg, LvlTrace, ctx, skipLevel)
+	l.write(LevelTrace, msg, ctx, 3)
 }
 
 func (l *logger) Debug(msg string, ctx ...interface{}) {
-	l.write(msg, LvlDebug, ctx, skipLevel)
+	l.write(slog.LevelDebug, msg, ctx, 3)
 }
 
 func (l *logger) Info(msg string, ctx ...interface{}) {
-	l.write(msg, LvlInfo, ctx, skipLevel)
+	l.write(slog.LevelInfo, msg, ctx, 3)
 }
 
 func (l *logger) Warn(msg string, ctx ...interface{}) {
-	l.write(msg, LvlWarn, ctx, skipLevel)
+	l.write(slog.LevelWarn, msg, ctx, 3)
 }
 
 func (l *logger) Error(msg string, ctx ...interface{}) {
-	l.write(msg, LvlError, ctx, skipLevel)
+	l.write(slog.LevelError, msg, ctx, 3)
 }
 
 func (l *logger) Crit(msg string, ctx ...interface{}) {
-	l.write(msg, LvlCrit, ctx, skipLevel)
+	l.write(LevelCrit, msg, ctx, 3)
 	os.Exit(1)
 }
 
-func (l *logger) GetHandler() Handler {
-	return l.h.Get()
-}
-
-func (l *logger) SetHandler(h Handler) {
-	l.h.Swap(h)
-}
-
-func normalize(ctx []interface{}) []interface{} {
-	// if the caller passed a Ctx object, then expand it
-	if len(ctx) == 1 {
-		if ctxMap, ok := ctx[0].(Ctx); ok {
-			ctx = ctxMap.toArray()
-		}
-	}
-
-	// ctx needs to be even because it's a series of key/value pairs
-	// no one wants to check for errors on logging functions,
-	// so instead of erroring on bad input, we'll just make sure
-	// that things are the right length and users can fix bugs
-	// when they see the output looks wrong
-	if len(ctx)%2 != 0 {
-		ctx = append(ctx, nil, errorKey, "Normalized odd number of arguments by adding nil")
-	}
-
-	return ctx
-}
-
 // Lazy allows you to defer calculation of a logged value that is expensive
-// to compute until it is certain that it must be evaluated with the given filters.
-//
-// Lazy may also be used in conjunction with a Logger's New() function
-// to generate a child logger which always reports the current value of changing
-// state.
+// to compute until it is certain that it must be evaluated by the handler.
 //
 // You may wrap any function which takes no arguments to Lazy. It may return any
 // number of values of any type.
@@ -275,20 +287,26 @@ type Lazy struct {
 	Fn interface{}
 }
 
-// Ctx is a map of key/value pairs to pass as context to a log function
-// Use this only if you really need greater safety around the arguments you pass
-// to the logging functions.
-type Ctx map[string]interface{}
-
-func (c Ctx) toArray() []interface{} {
-	arr := make([]interface{}, len(c)*2)
-
-	i := 0
-	for k, v := range c {
-		arr[i] = k
-		arr[i+1] = v
-		i += 2
+// LogValue implements slog.LogValuer, evaluating the wrapped function when the
+// value is resolved by a handler.
+func (lz Lazy) LogValue() slog.Value {
+	t := reflect.TypeOf(lz.Fn)
+	if t == nil || t.Kind() != reflect.Func {
+		return slog.StringValue(fmt.Sprintf("INVALID_LAZY, not func: %+v", lz.Fn))
 	}
-
-	return arr
+	if t.NumIn() > 0 {
+		return slog.StringValue(fmt.Sprintf("INVALID_LAZY, func takes args: %+v", lz.Fn))
+	}
+	if t.NumOut() == 0 {
+		return slog.StringValue(fmt.Sprintf("INVALID_LAZY, no func return val: %+v", lz.Fn))
+	}
+	results := reflect.ValueOf(lz.Fn).Call(nil)
+	if len(results) == 1 {
+		return slog.AnyValue(results[0].Interface())
+	}
+	values := make([]interface{}, len(results))
+	for i, v := range results {
+		values[i] = v.Interface()
+	}
+	return slog.AnyValue(values)
 }
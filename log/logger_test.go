@@ -2,25 +2,28 @@ package log
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"math/big"
 	"os"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/holiman/uint256"
 )
 
 // TestLoggingWithTrace checks that if BackTraceAt is set, then the
 // gloghandler is capable of spitting out a stacktrace
 func TestLoggingWithTrace(t *testing.T) {
-	defer stackEnabled.Store(stackEnabled.Load())
 	out := new(bytes.Buffer)
-	logger := New()
-	{
-		glog := NewGlogHandler(StreamHandler(out, TerminalFormat(false)))
-		glog.Verbosity(LvlTrace)
-		if err := glog.BacktraceAt("logger_test.go:24"); err != nil {
-			t.Fatal(err)
-		}
-		logger.SetHandler(glog)
+	glog := NewGlogHandler(NewTerminalHandler(out, false))
+	glog.Verbosity(LevelTrace)
+	if err := glog.BacktraceAt("logger_test.go:27"); err != nil {
+		t.Fatal(err)
 	}
+	logger := NewLogger(glog)
 	logger.Trace("a message", "foo", "bar") // Will be bumped to INFO
 	have := out.String()
 	if !strings.HasPrefix(have, "INFO") {
@@ -37,16 +40,12 @@ func TestLoggingWithTrace(t *testing.T) {
 
 // TestLoggingWithVmodule checks that vmodule works.
 func TestLoggingWithVmodule(t *testing.T) {
-	defer stackEnabled.Store(stackEnabled.Load())
 	out := new(bytes.Buffer)
-	logger := New()
-	{
-		glog := NewGlogHandler(StreamHandler(out, TerminalFormat(false)))
-		glog.Verbosity(LvlCrit)
-		logger.SetHandler(glog)
-		logger.Warn("This should not be seen", "ignored", "true")
-		glog.Vmodule("logger_test.go=5")
-	}
+	glog := NewGlogHandler(NewTerminalHandler(out, false))
+	glog.Verbosity(LevelCrit)
+	logger := NewLogger(glog)
+	logger.Warn("This should not be seen", "ignored", "true")
+	glog.Vmodule("logger_test.go=5")
 	logger.Trace("a message", "foo", "bar")
 	have := out.String()
 	// The timestamp is locale-dependent, so we want to trim that off
@@ -58,8 +57,110 @@ func TestLoggingWithVmodule(t *testing.T) {
 	}
 }
 
+// Tests that the verbosity changes of a glog handler also apply to the loggers
+// derived from it earlier.
+func TestGlogDerivedLoggers(t *testing.T) {
+	out := new(bytes.Buffer)
+	glog := NewGlogHandler(NewTerminalHandler(out, false))
+	glog.Verbosity(LevelInfo)
+
+	logger := NewLogger(glog).New("peer", "abc")
+	logger.Debug("hidden")
+	if out.Len() != 0 {
+		t.Fatalf("debug message logged at info verbosity: %s", out.String())
+	}
+	glog.Verbosity(LevelDebug)
+	logger.Debug("shown")
+	if have := out.String(); !strings.Contains(have, "shown") || !strings.Contains(have, "peer=abc") {
+		t.Fatalf("debug message not logged after verbosity change: %q", have)
+	}
+}
+
+// Tests that the package level logging functions report the call site of the
+// caller, not of the logging package.
+func TestRootCallSite(t *testing.T) {
+	defer SetDefault(Root())
+
+	out := new(bytes.Buffer)
+	glog := NewGlogHandler(NewTerminalHandler(out, false))
+	glog.Verbosity(LevelCrit)
+	glog.Vmodule("logger_test.go=5")
+	SetDefault(NewLogger(glog))
+
+	Trace("root message")
+	if !strings.Contains(out.String(), "root message") {
+		t.Fatalf("vmodule not applied to package level logging: %q", out.String())
+	}
+}
+
+func TestTerminalHandlerGroups(t *testing.T) {
+	out := new(bytes.Buffer)
+	logger := NewLogger(NewTerminalHandler(out, false).WithGroup("rpc"))
+	logger.With("conn", 1).Info("Served", slog.Group("req", "method", "eth_call"), "ok", true)
+
+	have := strings.SplitN(out.String(), "] ", 2)[1]
+	want := "Served                                   rpc.conn=1 rpc.req.method=eth_call rpc.ok=true\n"
+	if have != want {
+		t.Errorf("\nhave: %q\nwant: %q\n", have, want)
+	}
+}
+
+func TestJSONHandler(t *testing.T) {
+	out := new(bytes.Buffer)
+	logger := NewLogger(JSONHandler(out))
+	logger.Info("Imported", "number", uint64(12345678), "ok", true, "err", errors.New("boom"),
+		"td", big.NewInt(1000), "fee", uint256.NewInt(7), "elapsed", time.Second, slog.Group("peer", "id", "abc"))
+
+	var have map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &have); err != nil {
+		t.Fatalf("invalid JSON output %q: %v", out.String(), err)
+	}
+	want := map[string]interface{}{
+		"lvl":     "info",
+		"msg":     "Imported",
+		"number":  float64(12345678),
+		"ok":      true,
+		"err":     "boom",
+		"td":      "1000",
+		"fee":     "7",
+		"elapsed": "1s",
+		"peer":    map[string]interface{}{"id": "abc"},
+	}
+	if _, ok := have["t"]; !ok {
+		t.Errorf("missing timestamp: %v", have)
+	}
+	for key, val := range want {
+		if have, _ := json.Marshal(have[key]); string(have) != string(must(json.Marshal(val))) {
+			t.Errorf("field %q mismatch: have %s, want %v", key, have, val)
+		}
+	}
+}
+
+func TestLogfmtHandler(t *testing.T) {
+	out := new(bytes.Buffer)
+	logger := NewLogger(LogfmtHandlerWithLevel(out, LevelInfo))
+	logger.Debug("hidden")
+	logger.With("module", "rpc").Warn("Slow request", "n", 3, "lazy", Lazy{func() string { return "evaluated" }})
+
+	have := out.String()
+	if !strings.HasPrefix(have, "t=") {
+		t.Fatalf("wrong prefix: %q", have)
+	}
+	want := " lvl=warn msg=\"Slow request\" module=rpc n=3 lazy=evaluated\n"
+	if !strings.HasSuffix(have, want) {
+		t.Errorf("\nhave: %q\nwant suffix: %q\n", have, want)
+	}
+}
+
+func must(b []byte, err error) []byte {
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
 func BenchmarkTraceLogging(b *testing.B) {
-	Root().SetHandler(LvlFilterHandler(LvlInfo, StreamHandler(os.Stderr, TerminalFormat(true))))
+	SetDefault(NewLogger(NewTerminalHandlerWithLevel(os.Stderr, LevelInfo, true)))
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		Trace("a message", "v", i)
@@ -1,33 +1,34 @@
 package log
 
 import (
+	"log/slog"
 	"os"
+	"sync/atomic"
 )
 
-var (
-	root          = &logger{[]interface{}{}, new(swapHandler)}
-	StdoutHandler = StreamHandler(os.Stdout, LogfmtFormat())
-	StderrHandler = StreamHandler(os.Stderr, LogfmtFormat())
-)
+var root atomic.Value
 
 func init() {
-	root.SetHandler(DiscardHandler())
+	root.Store(&logger{slog.New(DiscardHandler())})
 }
 
-// New returns a new logger with the given context.
-// New is a convenient alias for Root().New
-func New(ctx ...interface{}) Logger {
-	return root.New(ctx...)
+// SetDefault sets the default global logger. If the logger is backed by this
+// package, it is also installed as the default logger of the slog package.
+func SetDefault(l Logger) {
+	root.Store(l)
+	if lg, ok := l.(*logger); ok {
+		slog.SetDefault(lg.inner)
+	}
 }
 
 // Root returns the root logger
 func Root() Logger {
-	return root
+	return root.Load().(Logger)
 }
 
 // The following functions bypass the exported logger methods (logger.Debug,
 // etc.) to keep the call depth the same for all paths to logger.write so
-// runtime.Caller(2) always refers to the call site in client code.
+// runtime.Callers always refers to the call site in client code.
 
 // Trace is a convenient alias for Root().Trace
 //
@@ -39,7 +40,7 @@ func Root() Logger {
 //	log.Trace("msg", "key1", val1)
 //	log.Trace("msg", "key1", val1, "key2", val2)
 func Trace(msg string, ctx ...interface{}) {
-	root.write(msg, LvlTrace, ctx, skipLevel)
+	write(LevelTrace, msg, ctx)
 }
 
 // Debug is a convenient alias for Root().Debug
@@ -52,7 +53,7 @@ func Trace(msg string, ctx ...interface{}) {
 //	log.Debug("msg", "key1", val1)
 //	log.Debug("msg", "key1", val1, "key2", val2)
 func Debug(msg string, ctx ...interface{}) {
-	root.write(msg, LvlDebug, ctx, skipLevel)
+	write(slog.LevelDebug, msg, ctx)
 }
 
 // Info is a convenient alias for Root().Info
@@ -65,7 +66,7 @@ func Debug(msg string, ctx ...interface{}) {
 //	log.Info("msg", "key1", val1)
 //	log.Info("msg", "key1", val1, "key2", val2)
 func Info(msg string, ctx ...interface{}) {
-	root.write(msg, LvlInfo, ctx, skipLevel)
+	write(slog.LevelInfo, msg, ctx)
 }
 
 // Warn is a convenient alias for Root().Warn
@@ -78,7 +79,7 @@ func Info(msg string, ctx ...interface{}) {
 //	log.Warn("msg", "key1", val1)
 //	log.Warn("msg", "key1", val1, "key2", val2)
 func Warn(msg string, ctx ...interface{}) {
-	root.write(msg, LvlWarn, ctx, skipLevel)
+	write(slog.LevelWarn, msg, ctx)
 }
 
 // Error is a convenient alias for Root().Error
@@ -91,7 +92,7 @@ func Warn(msg string, ctx ...interface{}) {
 //	log.Error("msg", "key1", val1)
 //	log.Error("msg", "key1", val1, "key2", val2)
 func Error(msg string, ctx ...interface{}) {
-	root.write(msg, LvlError, ctx, skipLevel)
+	write(slog.LevelError, msg, ctx)
 }
 
 // Crit is a convenient alias for Root().Crit
@@ -104,15 +105,22 @@ func Error(msg string, ctx ...interface{}) {
 //	log.Crit("msg", "key1", val1)
 //	log.Crit("msg", "key1", val1, "key2", val2)
 func Crit(msg string, ctx ...interface{}) {
-	root.write(msg, LvlCrit, ctx, skipLevel)
+	write(LevelCrit, msg, ctx)
 	os.Exit(1)
 }
 
-// Output is a convenient alias for write, allowing for the modification of
-// the calldepth (number of stack frames to skip).
-// calldepth influences the reported line number of the log message.
-// A calldepth of zero reports the immediate caller of Output.
-// Non-zero calldepth skips as many stack frames.
-func Output(msg string, lvl Lvl, calldepth int, ctx ...interface{}) {
-	root.write(msg, lvl, ctx, calldepth+skipLevel)
+// New returns a new logger with the given context.
+// New is a convenient alias for Root().New
+func New(ctx ...interface{}) Logger {
+	return Root().With(ctx...)
+}
+
+// write forwards a log record from the package level functions to the root
+// logger, skipping the extra stack frames of the indirection.
+func write(level slog.Level, msg string, ctx []interface{}) {
+	if l, ok := Root().(*logger); ok {
+		l.write(level, msg, ctx, 4)
+		return
+	}
+	Root().Write(level, msg, ctx...)
 }
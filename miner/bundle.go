@@ -0,0 +1,152 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// maxBundlesPerBlock is the maximum number of bundles simulated for a single
+// block. The bundle pool returns the best paying bundles first.
+const maxBundlesPerBlock = 64
+
+// errBundleReverted is returned if a transaction of a bundle failed, which was
+// not marked as allowed to revert.
+var errBundleReverted = errors.New("bundle transaction reverted")
+
+// simulatedBundle is a bundle along with the profit it yields when included at
+// the top of the block.
+type simulatedBundle struct {
+	bundle *txpool.Bundle
+	profit *big.Int // Increase of the coinbase balance
+	price  *big.Int // Profit per gas used
+}
+
+// commitBundles includes as many of the given bundles into the sealing block as
+// possible, most profitable per gas first. Bundles are simulated on top of the
+// current state, and are skipped if they fail or don't pay the fee recipient.
+// Only the first maxBundlesPerBlock bundles are considered. An error is returned
+// if the block building was interrupted.
+func (w *worker) commitBundles(interrupt *atomic.Int32, env *environment, bundles []*txpool.Bundle) error {
+	if env.gasPool == nil {
+		env.gasPool = new(core.GasPool).AddGas(env.header.GasLimit)
+	}
+	if len(bundles) > maxBundlesPerBlock {
+		bundles = bundles[:maxBundlesPerBlock]
+	}
+	simulated := make([]*simulatedBundle, 0, len(bundles))
+	for _, bundle := range bundles {
+		if interrupt != nil {
+			if signal := interrupt.Load(); signal != commitInterruptNone {
+				return signalToErr(signal)
+			}
+		}
+		sim, err := w.simulateBundle(env, bundle)
+		if err != nil {
+			log.Debug("Skipping invalid bundle", "hash", bundle.Hash(), "err", err)
+			continue
+		}
+		if sim.profit.Sign() <= 0 {
+			log.Debug("Skipping unprofitable bundle", "hash", bundle.Hash())
+			continue
+		}
+		simulated = append(simulated, sim)
+	}
+	sort.SliceStable(simulated, func(i, j int) bool {
+		return simulated[i].price.Cmp(simulated[j].price) > 0
+	})
+	// Bundles may conflict with each other, so each one is re-executed on top
+	// of the previously included ones.
+	for _, sim := range simulated {
+		if interrupt != nil {
+			if signal := interrupt.Load(); signal != commitInterruptNone {
+				return signalToErr(signal)
+			}
+		}
+		work, err := w.commitBundle(env, sim.bundle)
+		if err != nil {
+			log.Debug("Skipping conflicting bundle", "hash", sim.bundle.Hash(), "err", err)
+			continue
+		}
+		env.discard()
+		*env = *work
+	}
+	return nil
+}
+
+// simulateBundle executes a bundle on top of the sealing block, measuring how
+// much it pays to the fee recipient.
+func (w *worker) simulateBundle(env *environment, bundle *txpool.Bundle) (*simulatedBundle, error) {
+	work, err := w.commitBundle(env, bundle)
+	if err != nil {
+		return nil, err
+	}
+	defer work.discard()
+
+	var (
+		profit = new(big.Int).Sub(work.state.GetBalance(work.coinbase), env.state.GetBalance(env.coinbase))
+		gas    = work.header.GasUsed - env.header.GasUsed
+	)
+	return &simulatedBundle{
+		bundle: bundle,
+		profit: profit,
+		price:  new(big.Int).Div(profit, new(big.Int).SetUint64(gas)),
+	}, nil
+}
+
+// commitBundle executes all transactions of a bundle on a copy of the sealing
+// environment, which is returned if the bundle could be included atomically.
+func (w *worker) commitBundle(env *environment, bundle *txpool.Bundle) (*environment, error) {
+	work := env.copy()
+	for i, tx := range bundle.Txs {
+		if tx.Protected() && !w.chainConfig.IsEIP155(work.header.Number) {
+			work.discard()
+			return nil, fmt.Errorf("transaction %d (%x): replay protection not yet active", i, tx.Hash())
+		}
+		if w.config.Policy != nil {
+			from, err := types.Sender(work.signer, tx)
+			if err == nil {
+				err = w.config.Policy.filter(tx, from)
+			}
+			if err != nil {
+				work.discard()
+				return nil, fmt.Errorf("transaction %d (%x): %w", i, tx.Hash(), err)
+			}
+		}
+		work.state.SetTxContext(tx.Hash(), work.tcount)
+		if _, err := w.commitTransaction(work, tx); err != nil {
+			work.discard()
+			return nil, fmt.Errorf("transaction %d (%x): %w", i, tx.Hash(), err)
+		}
+		work.tcount++
+
+		if receipt := work.receipts[len(work.receipts)-1]; receipt.Status == types.ReceiptStatusFailed && !bundle.AllowsRevert(tx.Hash()) {
+			work.discard()
+			return nil, fmt.Errorf("%w: transaction %d (%x)", errBundleReverted, i, tx.Hash())
+		}
+	}
+	return work, nil
+}
@@ -0,0 +1,105 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func bundleTx(nonce uint64, tip int64) *types.Transaction {
+	return types.MustSignNewTx(testBankKey, types.LatestSigner(params.TestChainConfig), &types.DynamicFeeTx{
+		ChainID:   params.TestChainConfig.ChainID,
+		Nonce:     nonce,
+		GasTipCap: big.NewInt(tip),
+		GasFeeCap: big.NewInt(2*params.InitialBaseFee + tip),
+		Gas:       params.TxGas,
+		To:        &testUserAddress,
+		Value:     big.NewInt(1000),
+	})
+}
+
+func TestBundleInclusion(t *testing.T) {
+	engine := ethash.NewFaker()
+	defer engine.Close()
+
+	w, b := newTestWorker(t, ethashChainConfig, engine, rawdb.NewMemoryDatabase(), 0)
+	defer w.close()
+
+	// Wait for the pending transactions to be promoted.
+	if err := b.txPool.Sync(); err != nil {
+		t.Fatalf("failed to sync pool: %v", err)
+	}
+
+	generate := func() *types.Block {
+		t.Helper()
+
+		r := w.getSealingBlock(&generateParams{
+			parentHash: b.chain.CurrentBlock().Hash(),
+			timestamp:  uint64(time.Now().Unix()),
+			coinbase:   common.HexToAddress("0xdeadbeef"),
+			forceTime:  true,
+		})
+		if r.err != nil {
+			t.Fatalf("failed to generate block: %v", r.err)
+		}
+		return r.block
+	}
+	// Bundles failing partially are not included at all.
+	var (
+		pending = pendingTxs[0]
+		broken  = &txpool.Bundle{Txs: types.Transactions{bundleTx(0, params.GWei), bundleTx(2, params.GWei)}, BlockNumber: 1}
+	)
+	if err := b.txPool.AddBundle(broken); err != nil {
+		t.Fatalf("failed to add bundle: %v", err)
+	}
+	if txs := generate().Transactions(); len(txs) != 1 || txs[0].Hash() != pending.Hash() {
+		t.Fatalf("wrong transactions included with broken bundle: %d", len(txs))
+	}
+	// Bundles paying less than the pending transactions they displace are not
+	// included either.
+	cheap := &txpool.Bundle{Txs: types.Transactions{bundleTx(0, 1)}, BlockNumber: 1}
+	if err := b.txPool.AddBundle(cheap); err != nil {
+		t.Fatalf("failed to add bundle: %v", err)
+	}
+	if txs := generate().Transactions(); len(txs) != 1 || txs[0].Hash() != pending.Hash() {
+		t.Fatalf("wrong transactions included with unprofitable bundle: %d", len(txs))
+	}
+	// Profitable bundles are placed at the top of the block, in order.
+	bundle := &txpool.Bundle{Txs: types.Transactions{bundleTx(0, params.GWei), bundleTx(1, params.GWei)}, BlockNumber: 1}
+	if err := b.txPool.AddBundle(bundle); err != nil {
+		t.Fatalf("failed to add bundle: %v", err)
+	}
+	txs := generate().Transactions()
+	if len(txs) != 2 || txs[0].Hash() != bundle.Txs[0].Hash() || txs[1].Hash() != bundle.Txs[1].Hash() {
+		t.Fatalf("bundle not included at the top of the block: %d transactions", len(txs))
+	}
+	// Bundled transactions are never exposed to the network.
+	for _, tx := range bundle.Txs {
+		if b.txPool.Has(tx.Hash()) || b.txPool.Get(tx.Hash()) != nil {
+			t.Fatalf("bundled transaction %x visible in pool", tx.Hash())
+		}
+	}
+}
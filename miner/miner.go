@@ -18,11 +18,13 @@
 package miner
 
 import (
+	"errors"
 	"fmt"
 	"math/big"
 	"sync"
 	"time"
 
+	"github.com/ethereum/go-ethereum/beacon/engine"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/consensus"
@@ -53,6 +55,10 @@ type Config struct {
 	Recommit  time.Duration  // The time interval for miner to re-create mining work.
 
 	NewPayloadTimeout time.Duration // The maximum time allowance for creating a new payload
+	Reports           bool          // Record why pending transactions are left out of payloads
+
+	Policy   *Policy      `toml:"-"` // Transaction selection policy, optional, Go API only
+	Ordering OrderingFunc `toml:"-"` // Ordering of the pending transactions, by price and nonce if nil
 }
 
 // DefaultConfig contains default settings for miner.
@@ -240,7 +246,31 @@ func (miner *Miner) SubscribePendingLogs(ch chan<- []*types.Log) event.Subscript
 	return miner.worker.pendingLogsFeed.Subscribe(ch)
 }
 
+// SubscribeNewPayloads starts delivering the payloads whose building started
+// to the given channel.
+func (miner *Miner) SubscribeNewPayloads(ch chan<- NewPayloadEvent) event.Subscription {
+	return miner.worker.payloadFeed.Subscribe(ch)
+}
+
 // BuildPayload builds the payload according to the provided parameters.
 func (miner *Miner) BuildPayload(args *BuildPayloadArgs) (*Payload, error) {
 	return miner.worker.buildPayload(args)
 }
+
+// PayloadReport explains which pending transactions were left out of the most
+// profitable block of a recently built payload, and why. Payloads are only recorded
+// if a transaction selection policy is set or reports are enabled.
+func (miner *Miner) PayloadReport(id engine.PayloadID) (*PayloadReport, error) {
+	if !miner.worker.reporting() {
+		return nil, errors.New("payload reports are disabled")
+	}
+	payload, ok := miner.worker.payloads.Get(id)
+	if !ok {
+		return nil, errors.New("unknown payload")
+	}
+	record := payload.buildRecord()
+	if record == nil {
+		return nil, errors.New("no block with transactions built yet")
+	}
+	return record.report(id), nil
+}
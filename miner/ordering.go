@@ -80,6 +80,32 @@ func (s *txByPriceAndTime) Pop() interface{} {
 	return x
 }
 
+// TransactionOrdering is a set of pending transactions, returned in the order in
+// which the miner should try to include them.
+type TransactionOrdering interface {
+	// Peek returns the next transaction to include, or nil if none is left.
+	Peek() *txpool.LazyTransaction
+
+	// Shift replaces the current transaction with the next one from the same
+	// account.
+	Shift()
+
+	// Pop removes the current transaction along with all subsequent ones from
+	// the same account.
+	Pop()
+}
+
+// OrderingFunc creates the ordering of the pending transactions of a block with
+// the given base fee. The transactions of each account are sorted by nonce, and
+// the map is owned by the ordering afterwards.
+type OrderingFunc func(signer types.Signer, txs map[common.Address][]*txpool.LazyTransaction, baseFee *big.Int) TransactionOrdering
+
+// PriceAndNonceOrdering is the default ordering of the miner, returning the
+// transactions with the highest effective tip first while honouring nonces.
+func PriceAndNonceOrdering(signer types.Signer, txs map[common.Address][]*txpool.LazyTransaction, baseFee *big.Int) TransactionOrdering {
+	return newTransactionsByPriceAndNonce(signer, txs, baseFee)
+}
+
 // transactionsByPriceAndNonce represents a set of transactions that can return
 // transactions in a profit-maximizing sorted order, while supporting removing
 // entire batches of transactions for non-executable accounts.
@@ -41,6 +41,7 @@ type BuildPayloadArgs struct {
 	Random       common.Hash       // The provided randomness value
 	Withdrawals  types.Withdrawals // The provided withdrawals
 	BeaconRoot   *common.Hash      // The provided beaconRoot (Cancun)
+	GasLimit     uint64            // Optional gas limit target, overriding the configured gas ceiling
 }
 
 // Id computes an 8-byte identifier by hashing the components of the payload arguments.
@@ -55,11 +56,20 @@ func (args *BuildPayloadArgs) Id() engine.PayloadID {
 	if args.BeaconRoot != nil {
 		hasher.Write(args.BeaconRoot[:])
 	}
+	if args.GasLimit != 0 {
+		binary.Write(hasher, binary.BigEndian, args.GasLimit)
+	}
 	var out engine.PayloadID
 	copy(out[:], hasher.Sum(nil)[:8])
 	return out
 }
 
+// NewPayloadEvent is posted when the building of a payload started.
+type NewPayloadEvent struct {
+	Args    *BuildPayloadArgs
+	Payload *Payload
+}
+
 // Payload wraps the built payload(block waiting for sealing). According to the
 // engine-api specification, EL should build the initial version of the payload
 // which has an empty transaction set and then keep update it in order to maximize
@@ -71,6 +81,7 @@ type Payload struct {
 	full     *types.Block
 	sidecars []*types.BlobTxSidecar
 	fullFees *big.Int
+	record   *buildRecord // transaction selection of the full block
 	stop     chan struct{}
 	lock     sync.Mutex
 	cond     *sync.Cond
@@ -105,6 +116,7 @@ func (payload *Payload) update(r *newPayloadResult, elapsed time.Duration) {
 		payload.full = r.block
 		payload.fullFees = r.fees
 		payload.sidecars = r.sidecars
+		payload.record = r.record
 
 		feesInEther := new(big.Float).Quo(new(big.Float).SetInt(r.fees), big.NewFloat(params.Ether))
 		log.Info("Updated payload",
@@ -139,6 +151,38 @@ func (payload *Payload) Resolve() *engine.ExecutionPayloadEnvelope {
 	return engine.BlockToExecutableData(payload.empty, big.NewInt(0), nil)
 }
 
+// Best returns the most profitable full payload built so far, without terminating
+// the background updates. It returns nil if no full payload is available yet.
+func (payload *Payload) Best() *engine.ExecutionPayloadEnvelope {
+	payload.lock.Lock()
+	defer payload.lock.Unlock()
+
+	if payload.full == nil {
+		return nil
+	}
+	return engine.BlockToExecutableData(payload.full, payload.fullFees, payload.sidecars)
+}
+
+// buildRecord returns the record of the transaction selection of the full block,
+// or nil if no full block was built yet.
+func (payload *Payload) buildRecord() *buildRecord {
+	payload.lock.Lock()
+	defer payload.lock.Unlock()
+
+	return payload.record
+}
+
+// GasLimit returns the gas limit of the payload, which is the same for all its
+// versions.
+func (payload *Payload) GasLimit() uint64 {
+	return payload.empty.GasLimit()
+}
+
+// Done returns a channel which is closed when the payload is resolved.
+func (payload *Payload) Done() <-chan struct{} {
+	return payload.stop
+}
+
 // ResolveEmpty is basically identical to Resolve, but it expects empty block only.
 // It's only used in tests.
 func (payload *Payload) ResolveEmpty() *engine.ExecutionPayloadEnvelope {
@@ -187,6 +231,7 @@ func (w *worker) buildPayload(args *BuildPayloadArgs) (*Payload, error) {
 		random:      args.Random,
 		withdrawals: args.Withdrawals,
 		beaconRoot:  args.BeaconRoot,
+		gasLimit:    args.GasLimit,
 		noTxs:       true,
 	}
 	empty := w.getSealingBlock(emptyParams)
@@ -218,6 +263,7 @@ func (w *worker) buildPayload(args *BuildPayloadArgs) (*Payload, error) {
 			random:      args.Random,
 			withdrawals: args.Withdrawals,
 			beaconRoot:  args.BeaconRoot,
+			gasLimit:    args.GasLimit,
 			noTxs:       false,
 		}
 
@@ -239,5 +285,9 @@ func (w *worker) buildPayload(args *BuildPayloadArgs) (*Payload, error) {
 			}
 		}
 	}()
+	if w.reporting() {
+		w.payloads.Add(payload.id, payload)
+	}
+	w.payloadFeed.Send(NewPayloadEvent{Args: args, Payload: payload})
 	return payload, nil
 }
@@ -0,0 +1,149 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// TxFilter screens the transactions before their inclusion into a block, e.g.
+// for compliance.
+type TxFilter interface {
+	// FilterTx returns an error if the transaction sent by from must not be
+	// included.
+	FilterTx(tx *types.Transaction, from common.Address) error
+}
+
+// InclusionList provides transactions which must be included into the blocks
+// built, if they are valid.
+type InclusionList interface {
+	// Transactions returns the transactions to include at the top of the block
+	// with the given header.
+	Transactions(header *types.Header) []*types.Transaction
+}
+
+// StaticInclusionList is an inclusion list of a fixed set of transactions. Once
+// included into the chain, the transactions are skipped.
+type StaticInclusionList []*types.Transaction
+
+// Transactions implements InclusionList.
+func (l StaticInclusionList) Transactions(header *types.Header) []*types.Transaction {
+	return l
+}
+
+// AddressFilter rejects the transactions sent by or to any of its addresses.
+type AddressFilter map[common.Address]struct{}
+
+// NewAddressFilter creates a filter rejecting the given addresses.
+func NewAddressFilter(addrs ...common.Address) AddressFilter {
+	f := make(AddressFilter, len(addrs))
+	for _, addr := range addrs {
+		f[addr] = struct{}{}
+	}
+	return f
+}
+
+// FilterTx implements TxFilter.
+func (f AddressFilter) FilterTx(tx *types.Transaction, from common.Address) error {
+	if _, ok := f[from]; ok {
+		return fmt.Errorf("sender %x is blocked", from)
+	}
+	if to := tx.To(); to != nil {
+		if _, ok := f[*to]; ok {
+			return fmt.Errorf("recipient %x is blocked", *to)
+		}
+	}
+	return nil
+}
+
+// Policy controls the selection of transactions for the blocks built. The
+// filters apply to all transactions, including the ones of the inclusion list
+// and of bundles. Policies are code, so they can only be set through the Go API
+// by programs embedding the node, not by configuration files or flags.
+type Policy struct {
+	Inclusions InclusionList // Transactions to include if valid, optional
+	Filters    []TxFilter    // Filters all included transactions have to pass
+}
+
+// filter checks a transaction against all filters of the policy.
+func (p *Policy) filter(tx *types.Transaction, from common.Address) error {
+	if p == nil {
+		return nil
+	}
+	for _, f := range p.Filters {
+		if err := f.FilterTx(tx, from); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// inclusions returns the transactions which must be included into the block
+// with the given header.
+func (p *Policy) inclusions(header *types.Header) []*types.Transaction {
+	if p == nil || p.Inclusions == nil {
+		return nil
+	}
+	return p.Inclusions.Transactions(header)
+}
+
+// commitInclusionList includes the transactions of the inclusion list at the top
+// of the sealing block, skipping the invalid ones.
+func (w *worker) commitInclusionList(env *environment) {
+	txs := w.config.Policy.inclusions(env.header)
+	if len(txs) == 0 {
+		return
+	}
+	if env.gasPool == nil {
+		env.gasPool = new(core.GasPool).AddGas(env.header.GasLimit)
+	}
+	for _, tx := range txs {
+		from, err := types.Sender(env.signer, tx)
+		if err == nil && env.state.GetNonce(from) > tx.Nonce() {
+			continue // included into the chain already
+		}
+		env.inclusions = append(env.inclusions, tx)
+
+		if err == nil && tx.Protected() && !w.chainConfig.IsEIP155(env.header.Number) {
+			err = errReplayProtection
+		}
+		if err == nil && tx.Type() == types.BlobTxType && tx.BlobTxSidecar() == nil {
+			err = errors.New("missing blob sidecar")
+		}
+		if err != nil {
+			env.exclude(tx.Hash(), ExcludedInvalid, err)
+			continue
+		}
+		if err := w.config.Policy.filter(tx, from); err != nil {
+			env.exclude(tx.Hash(), ExcludedFilter, err)
+			continue
+		}
+		env.state.SetTxContext(tx.Hash(), env.tcount)
+		if _, err := w.commitTransaction(env, tx); err != nil {
+			log.Debug("Skipping transaction of inclusion list", "hash", tx.Hash(), "err", err)
+			env.exclude(tx.Hash(), exclusionReason(err), err)
+			continue
+		}
+		env.tcount++
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestPolicyAndReport(t *testing.T) {
+	var (
+		engine  = ethash.NewFaker()
+		blocked = common.HexToAddress("0xbad")
+		signer  = types.LatestSigner(params.TestChainConfig)
+		send    = func(nonce uint64, to common.Address, feeCap int64) *types.Transaction {
+			return types.MustSignNewTx(testBankKey, signer, &types.DynamicFeeTx{
+				ChainID:   params.TestChainConfig.ChainID,
+				Nonce:     nonce,
+				GasTipCap: big.NewInt(1),
+				GasFeeCap: big.NewInt(feeCap),
+				Gas:       params.TxGas,
+				To:        &to,
+				Value:     big.NewInt(1000),
+			})
+		}
+		cheap    = send(0, testUserAddress, params.InitialBaseFee/2)
+		filtered = send(1, blocked, params.InitialBaseFee)
+		gapped   = send(2, testUserAddress, params.InitialBaseFee)
+	)
+	defer engine.Close()
+
+	b := newTestWorkerBackend(t, params.TestChainConfig, engine, rawdb.NewMemoryDatabase(), 0)
+	config := *testConfig
+	config.Policy = &Policy{
+		Inclusions: StaticInclusionList{cheap, pendingTxs[0]},
+		Filters:    []TxFilter{NewAddressFilter(blocked)},
+	}
+	w := newWorker(&config, params.TestChainConfig, engine, b, new(event.TypeMux), nil, false)
+	defer w.close()
+
+	if errs := b.txPool.Add(append([]*types.Transaction{pendingTxs[0]}, filtered, gapped), true, false); errs[0] != nil || errs[1] != nil || errs[2] != nil {
+		t.Fatalf("failed to add transactions: %v", errs)
+	}
+	b.txPool.Sync()
+	payload, err := w.buildPayload(&BuildPayloadArgs{
+		Parent:       b.chain.CurrentBlock().Hash(),
+		Timestamp:    uint64(time.Now().Unix()),
+		FeeRecipient: common.HexToAddress("0xdeadbeef"),
+	})
+	if err != nil {
+		t.Fatalf("failed to build payload: %v", err)
+	}
+	full := payload.ResolveFull().ExecutionPayload
+	if len(full.Transactions) != 1 {
+		t.Fatalf("wrong number of transactions included: %d", len(full.Transactions))
+	}
+	// The valid transaction of the inclusion list is placed at the top.
+	var tx types.Transaction
+	if err := tx.UnmarshalBinary(full.Transactions[0]); err != nil || tx.Hash() != pendingTxs[0].Hash() {
+		t.Fatalf("inclusion list not included: %x", tx.Hash())
+	}
+	if _, ok := w.payloads.Get(payload.id); !ok {
+		t.Fatal("payload not retained for reporting")
+	}
+	report := payload.buildRecord().report(payload.id)
+	if report.ID != payload.id || report.BlockHash != full.BlockHash || len(report.Included) != 1 {
+		t.Fatalf("wrong report: %+v", report)
+	}
+	type exclusion struct {
+		hash   common.Hash
+		reason string
+	}
+	check := func(report *PayloadReport, want []exclusion) {
+		t.Helper()
+		if len(report.Excluded) != len(want) {
+			t.Fatalf("wrong number of excluded transactions: have %d, want %d", len(report.Excluded), len(want))
+		}
+		for i, ex := range report.Excluded {
+			if ex.Hash != want[i].hash || ex.Reason != want[i].reason || ex.From != testBankAddress {
+				t.Errorf("excluded transaction %d: have %x (%s), want %x (%s)", i, ex.Hash, ex.Reason, want[i].hash, want[i].reason)
+			}
+		}
+	}
+	check(report, []exclusion{
+		{cheap.Hash(), ExcludedFeeTooLow},
+		{filtered.Hash(), ExcludedFilter},
+		{gapped.Hash(), ExcludedNonceGap},
+	})
+	// The report is recorded while building, it doesn't change with the pool.
+	if _, err := b.chain.InsertChain(types.Blocks{payload.full}); err != nil {
+		t.Fatalf("failed to insert block: %v", err)
+	}
+	b.txPool.Sync()
+	check(payload.buildRecord().report(payload.id), []exclusion{
+		{cheap.Hash(), ExcludedFeeTooLow},
+		{filtered.Hash(), ExcludedFilter},
+		{gapped.Hash(), ExcludedNonceGap},
+	})
+	// Transactions of the inclusion list which were included into the chain
+	// already are skipped.
+	payload, err = w.buildPayload(&BuildPayloadArgs{
+		Parent:       b.chain.CurrentBlock().Hash(),
+		Timestamp:    uint64(time.Now().Unix()) + 1,
+		FeeRecipient: common.HexToAddress("0xdeadbeef"),
+	})
+	if err != nil {
+		t.Fatalf("failed to build payload: %v", err)
+	}
+	if full := payload.ResolveFull().ExecutionPayload; len(full.Transactions) != 0 {
+		t.Fatalf("wrong number of transactions included: %d", len(full.Transactions))
+	}
+	check(payload.buildRecord().report(payload.id), []exclusion{
+		{filtered.Hash(), ExcludedFilter},
+		{gapped.Hash(), ExcludedNonceGap},
+	})
+}
+
+// Tests that payloads are only recorded for reporting if enabled.
+func TestReportDisabled(t *testing.T) {
+	engine := ethash.NewFaker()
+	defer engine.Close()
+
+	b := newTestWorkerBackend(t, params.TestChainConfig, engine, rawdb.NewMemoryDatabase(), 0)
+	w := newWorker(testConfig, params.TestChainConfig, engine, b, new(event.TypeMux), nil, false)
+	defer w.close()
+
+	b.txPool.Add(pendingTxs, true, true)
+	payload, err := w.buildPayload(&BuildPayloadArgs{
+		Parent:       b.chain.CurrentBlock().Hash(),
+		Timestamp:    uint64(time.Now().Unix()),
+		FeeRecipient: common.HexToAddress("0xdeadbeef"),
+	})
+	if err != nil {
+		t.Fatalf("failed to build payload: %v", err)
+	}
+	if full := payload.ResolveFull().ExecutionPayload; len(full.Transactions) == 0 {
+		t.Fatal("no transactions included")
+	}
+	if record := payload.buildRecord(); record != nil {
+		t.Fatal("payload recorded with reports disabled")
+	}
+	if _, ok := w.payloads.Get(payload.id); ok {
+		t.Fatal("payload retained with reports disabled")
+	}
+}
@@ -0,0 +1,216 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/beacon/engine"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/lru"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Reasons for leaving transactions out of a payload.
+const (
+	ExcludedFeeTooLow   = "fee too low" // below the base fee or the minimum tip
+	ExcludedNonceGap    = "nonce gap"   // a preceding transaction of the sender was left out
+	ExcludedGasLimit    = "gas limit"   // not enough gas or blob space left in the block
+	ExcludedFilter      = "filter"      // rejected by a filter of the policy
+	ExcludedInvalid     = "invalid"     // failed to execute
+	ExcludedInterrupted = "interrupted" // building stopped before reaching it
+	ExcludedUnknown     = "unknown"     // not considered for other reasons
+)
+
+// exclusion is the reason a transaction was left out while building a block.
+type exclusion struct {
+	reason string
+	err    error
+}
+
+// exclusionReason classifies an error of including a transaction.
+func exclusionReason(err error) string {
+	switch {
+	case errors.Is(err, core.ErrGasLimitReached), errors.Is(err, errMaxBlobsReached):
+		return ExcludedGasLimit
+	case errors.Is(err, core.ErrFeeCapTooLow):
+		return ExcludedFeeTooLow
+	case errors.Is(err, core.ErrNonceTooHigh):
+		return ExcludedNonceGap
+	default:
+		return ExcludedInvalid
+	}
+}
+
+// exclude records why a transaction was left out of the sealing block.
+func (env *environment) exclude(hash common.Hash, reason string, err error) {
+	if env.excluded == nil {
+		env.excluded = make(map[common.Hash]*exclusion)
+	}
+	env.excluded[hash] = &exclusion{reason: reason, err: err}
+}
+
+// excludeRemaining records the transactions left in the ordering as excluded for
+// the given reason. Only the next transaction of each sender is recorded, as the
+// subsequent ones are left out due to the nonce gap.
+func (env *environment) excludeRemaining(txs TransactionOrdering, reason string, err error) {
+	for ltx := txs.Peek(); ltx != nil; ltx = txs.Peek() {
+		env.exclude(ltx.Hash, reason, err)
+		txs.Pop()
+	}
+}
+
+// buildRecord explains the contents of a built block.
+type buildRecord struct {
+	block    *types.Block
+	excluded []*ExcludedTx
+}
+
+// ExcludedTx is a transaction left out of a payload.
+type ExcludedTx struct {
+	Hash   common.Hash    `json:"hash"`
+	From   common.Address `json:"from"`
+	Nonce  hexutil.Uint64 `json:"nonce"`
+	Reason string         `json:"reason"`
+	Error  string         `json:"error,omitempty"`
+}
+
+// PayloadReport explains which pending transactions were left out of the most
+// profitable block of a payload, and why.
+type PayloadReport struct {
+	ID        engine.PayloadID `json:"id"`
+	Number    hexutil.Uint64   `json:"number"`
+	BlockHash common.Hash      `json:"blockHash"`
+	Included  []common.Hash    `json:"included"`
+	Excluded  []*ExcludedTx    `json:"excluded"`
+}
+
+// newBuildRecord records why transactions were left out of a block, right after
+// building it. The pending transactions are the ones available when the building
+// started, the transactions of the inclusion list which were not included are
+// recorded as well.
+func newBuildRecord(env *environment, block *types.Block, pending map[common.Address][]*txpool.LazyTransaction, interrupted bool) *buildRecord {
+	var (
+		record   = &buildRecord{block: block, excluded: []*ExcludedTx{}}
+		included = make(map[common.Hash]bool)
+		reported = make(map[common.Hash]bool)
+	)
+	for _, tx := range block.Transactions() {
+		included[tx.Hash()] = true
+	}
+	exclude := func(hash common.Hash, from common.Address, nonce uint64, reason string, err error) {
+		ex := &ExcludedTx{Hash: hash, From: from, Nonce: hexutil.Uint64(nonce), Reason: reason}
+		if err != nil {
+			ex.Error = err.Error()
+		}
+		record.excluded = append(record.excluded, ex)
+		reported[hash] = true
+	}
+	for _, tx := range env.inclusions {
+		if included[tx.Hash()] || reported[tx.Hash()] {
+			continue
+		}
+		from, _ := types.Sender(env.signer, tx)
+		if ex := env.excluded[tx.Hash()]; ex != nil {
+			exclude(tx.Hash(), from, tx.Nonce(), ex.reason, ex.err)
+		} else {
+			exclude(tx.Hash(), from, tx.Nonce(), ExcludedUnknown, nil)
+		}
+	}
+	// Transactions below the minimum tip are only returned without enforcing
+	// the tips, they were never considered.
+	eligible := make(map[common.Hash]bool)
+	for _, txs := range env.pending {
+		for _, ltx := range txs {
+			eligible[ltx.Hash] = true
+		}
+	}
+	senders := make([]common.Address, 0, len(pending))
+	for from := range pending {
+		senders = append(senders, from)
+	}
+	sort.Slice(senders, func(i, j int) bool { return senders[i].Cmp(senders[j]) < 0 })
+
+	for _, from := range senders {
+		// The pending transactions of a sender have consecutive nonces, so only
+		// the first one needs to be resolved.
+		txs := pending[from]
+		first := txs[0].Resolve()
+		if first == nil {
+			continue
+		}
+		gap := false
+		for i, ltx := range txs {
+			if included[ltx.Hash] {
+				continue
+			}
+			if reported[ltx.Hash] {
+				gap = true
+				continue
+			}
+			reason, err := env.exclusion(ltx, eligible[ltx.Hash], gap, interrupted)
+			exclude(ltx.Hash, from, first.Nonce()+uint64(i), reason, err)
+			gap = true
+		}
+	}
+	return record
+}
+
+// exclusion determines why a pending transaction was left out of the block.
+func (env *environment) exclusion(ltx *txpool.LazyTransaction, eligible bool, gap bool, interrupted bool) (string, error) {
+	if gap {
+		return ExcludedNonceGap, nil
+	}
+	if ex := env.excluded[ltx.Hash]; ex != nil {
+		return ex.reason, ex.err
+	}
+	if baseFee := env.header.BaseFee; !eligible || (baseFee != nil && ltx.GasFeeCap.Cmp(baseFee) < 0) {
+		return ExcludedFeeTooLow, nil
+	}
+	if interrupted {
+		return ExcludedInterrupted, nil
+	}
+	return ExcludedUnknown, nil
+}
+
+// report creates the report of the recorded block.
+func (r *buildRecord) report(id engine.PayloadID) *PayloadReport {
+	report := &PayloadReport{
+		ID:        id,
+		Number:    hexutil.Uint64(r.block.NumberU64()),
+		BlockHash: r.block.Hash(),
+		Included:  make([]common.Hash, 0, len(r.block.Transactions())),
+		Excluded:  r.excluded,
+	}
+	for _, tx := range r.block.Transactions() {
+		report.Included = append(report.Included, tx.Hash())
+	}
+	return report
+}
+
+// reportedPayloads is the number of recently built payloads which can be
+// reported on.
+const reportedPayloads = 32
+
+// newPayloadCache creates the cache of the payloads which can be reported on.
+func newPayloadCache() *lru.Cache[engine.PayloadID, *Payload] {
+	return lru.NewCache[engine.PayloadID, *Payload](reportedPayloads)
+}
@@ -24,7 +24,9 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/ethereum/go-ethereum/beacon/engine"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/lru"
 	"github.com/ethereum/go-ethereum/consensus"
 	"github.com/ethereum/go-ethereum/consensus/misc/eip1559"
 	"github.com/ethereum/go-ethereum/consensus/misc/eip4844"
@@ -77,6 +79,8 @@ var (
 	errBlockInterruptedByNewHead  = errors.New("new head arrived while building block")
 	errBlockInterruptedByRecommit = errors.New("recommit interrupt while building block")
 	errBlockInterruptedByTimeout  = errors.New("timeout while building block")
+	errMaxBlobsReached            = errors.New("max data blobs reached")
+	errReplayProtection           = errors.New("replay protection not yet active")
 )
 
 // environment is the worker's current environment and holds all
@@ -93,6 +97,10 @@ type environment struct {
 	receipts []*types.Receipt
 	sidecars []*types.BlobTxSidecar
 	blobs    int
+
+	inclusions []*types.Transaction                         // transactions of the inclusion list
+	excluded   map[common.Hash]*exclusion                   // transactions left out, with the reason
+	pending    map[common.Address][]*txpool.LazyTransaction // pending transactions considered, above the minimum tip
 }
 
 // copy creates a deep copy of environment.
@@ -104,6 +112,7 @@ func (env *environment) copy() *environment {
 		coinbase: env.coinbase,
 		header:   types.CopyHeader(env.header),
 		receipts: copyReceipts(env.receipts),
+		blobs:    env.blobs,
 	}
 	if env.gasPool != nil {
 		gasPool := *env.gasPool
@@ -115,6 +124,12 @@ func (env *environment) copy() *environment {
 	cpy.sidecars = make([]*types.BlobTxSidecar, len(env.sidecars))
 	copy(cpy.sidecars, env.sidecars)
 
+	cpy.inclusions = append([]*types.Transaction(nil), env.inclusions...)
+	for hash, ex := range env.excluded {
+		cpy.exclude(hash, ex.reason, ex.err)
+	}
+	cpy.pending = env.pending
+
 	return cpy
 }
 
@@ -155,6 +170,7 @@ type newPayloadResult struct {
 	block    *types.Block
 	fees     *big.Int               // total block fees
 	sidecars []*types.BlobTxSidecar // collected blobs of blob transactions
+	record   *buildRecord           // record of the transaction selection, nil for empty blocks
 }
 
 // getWorkReq represents a request for getting a new sealing work with provided parameters.
@@ -180,6 +196,10 @@ type worker struct {
 
 	// Feeds
 	pendingLogsFeed event.Feed
+	payloadFeed     event.Feed // Feed of payloads whose building started
+
+	// Recently built payloads, kept for reporting
+	payloads *lru.Cache[engine.PayloadID, *Payload]
 
 	// Subscriptions
 	mux          *event.TypeMux
@@ -262,6 +282,7 @@ func newWorker(config *Config, chainConfig *params.ChainConfig, engine consensus
 		exitCh:             make(chan struct{}),
 		resubmitIntervalCh: make(chan time.Duration),
 		resubmitAdjustCh:   make(chan *intervalAdjust, resubmitAdjustChanSize),
+		payloads:           newPayloadCache(),
 	}
 	// Subscribe NewTxsEvent for tx pool
 	worker.txsSub = eth.TxPool().SubscribeNewTxsEvent(worker.txsCh)
@@ -549,7 +570,7 @@ func (w *worker) mainLoop() {
 						GasTipCap: tx.GasTipCap(),
 					})
 				}
-				txset := newTransactionsByPriceAndNonce(w.current.signer, txs, w.current.header.BaseFee)
+				txset := w.orderTransactions(w.current, txs)
 				tcount := w.current.tcount
 				w.commitTransactions(w.current, txset, nil)
 
@@ -762,7 +783,7 @@ func (w *worker) commitBlobTransaction(env *environment, tx *types.Transaction)
 	// and not during execution. This means core.ApplyTransaction will not return an error if the
 	// tx has too many blobs. So we have to explicitly check it here.
 	if (env.blobs+len(sc.Blobs))*params.BlobTxBlobGasPerBlob > params.MaxBlobGasPerBlock {
-		return nil, errors.New("max data blobs reached")
+		return nil, errMaxBlobsReached
 	}
 
 	receipt, err := w.applyTransaction(env, tx)
@@ -791,7 +812,16 @@ func (w *worker) applyTransaction(env *environment, tx *types.Transaction) (*typ
 	return receipt, err
 }
 
-func (w *worker) commitTransactions(env *environment, txs *transactionsByPriceAndNonce, interrupt *atomic.Int32) error {
+// orderTransactions returns the pending transactions in the order the sealing
+// block should be filled with, as defined by the configured ordering.
+func (w *worker) orderTransactions(env *environment, txs map[common.Address][]*txpool.LazyTransaction) TransactionOrdering {
+	if w.config.Ordering != nil {
+		return w.config.Ordering(env.signer, txs, env.header.BaseFee)
+	}
+	return newTransactionsByPriceAndNonce(env.signer, txs, env.header.BaseFee)
+}
+
+func (w *worker) commitTransactions(env *environment, txs TransactionOrdering, interrupt *atomic.Int32) error {
 	gasLimit := env.header.GasLimit
 	if env.gasPool == nil {
 		env.gasPool = new(core.GasPool).AddGas(gasLimit)
@@ -808,6 +838,7 @@ func (w *worker) commitTransactions(env *environment, txs *transactionsByPriceAn
 		// If we don't have enough gas for any further transactions then we're done.
 		if env.gasPool.Gas() < params.TxGas {
 			log.Trace("Not enough gas for further transactions", "have", env.gasPool, "want", params.TxGas)
+			env.excludeRemaining(txs, ExcludedGasLimit, core.ErrGasLimitReached)
 			break
 		}
 		// Retrieve the next transaction and abort if all done.
@@ -830,10 +861,31 @@ func (w *worker) commitTransactions(env *environment, txs *transactionsByPriceAn
 		// phase, start ignoring the sender until we do.
 		if tx.Protected() && !w.chainConfig.IsEIP155(env.header.Number) {
 			log.Trace("Ignoring replay protected transaction", "hash", tx.Hash(), "eip155", w.chainConfig.EIP155Block)
+			env.exclude(tx.Hash(), ExcludedInvalid, errReplayProtection)
+			txs.Pop()
+			continue
+		}
+		// If we don't have enough space for the transaction, skip the account.
+		if env.gasPool.Gas() < tx.Gas() {
+			log.Trace("Not enough gas left for transaction", "hash", tx.Hash(), "left", env.gasPool.Gas(), "needed", tx.Gas())
+			env.exclude(tx.Hash(), ExcludedGasLimit, core.ErrGasLimitReached)
+			txs.Pop()
+			continue
+		}
+		if blobs := len(tx.BlobHashes()); blobs > 0 && (env.blobs+blobs)*params.BlobTxBlobGasPerBlob > params.MaxBlobGasPerBlock {
+			log.Trace("Not enough blob space left for transaction", "hash", tx.Hash(), "left", params.MaxBlobGasPerBlock/params.BlobTxBlobGasPerBlob-env.blobs, "needed", blobs)
+			env.exclude(tx.Hash(), ExcludedGasLimit, errMaxBlobsReached)
 			txs.Pop()
 			continue
 		}
 
+		// Skip the sender if the transaction is rejected by the policy.
+		if err := w.config.Policy.filter(tx, from); err != nil {
+			log.Trace("Skipping filtered transaction", "hash", tx.Hash(), "sender", from, "err", err)
+			env.exclude(tx.Hash(), ExcludedFilter, err)
+			txs.Pop()
+			continue
+		}
 		// Start executing the transaction
 		env.state.SetTxContext(tx.Hash(), env.tcount)
 
@@ -854,6 +906,7 @@ func (w *worker) commitTransactions(env *environment, txs *transactionsByPriceAn
 			// Transaction is regarded as invalid, drop all consecutive transactions from
 			// the same sender because of `nonce-too-high` clause.
 			log.Debug("Transaction failed, account skipped", "hash", tx.Hash(), "err", err)
+			env.exclude(tx.Hash(), exclusionReason(err), err)
 			txs.Pop()
 		}
 	}
@@ -884,6 +937,7 @@ type generateParams struct {
 	random      common.Hash       // The randomness generated by beacon chain, empty before the merge
 	withdrawals types.Withdrawals // List of withdrawals to include in block.
 	beaconRoot  *common.Hash      // The beacon root (cancun field).
+	gasLimit    uint64            // Gas limit target overriding the gas ceiling, if non-zero
 	noTxs       bool              // Flag whether an empty block without any transaction is expected
 }
 
@@ -913,10 +967,14 @@ func (w *worker) prepareWork(genParams *generateParams) (*environment, error) {
 		timestamp = parent.Time + 1
 	}
 	// Construct the sealing block header.
+	gasCeil := w.config.GasCeil
+	if genParams.gasLimit != 0 {
+		gasCeil = genParams.gasLimit
+	}
 	header := &types.Header{
 		ParentHash: parent.Hash(),
 		Number:     new(big.Int).Add(parent.Number, common.Big1),
-		GasLimit:   core.CalcGasLimit(parent.GasLimit, w.config.GasCeil),
+		GasLimit:   core.CalcGasLimit(parent.GasLimit, gasCeil),
 		Time:       timestamp,
 		Coinbase:   genParams.coinbase,
 	}
@@ -933,7 +991,7 @@ func (w *worker) prepareWork(genParams *generateParams) (*environment, error) {
 		header.BaseFee = eip1559.CalcBaseFee(w.chainConfig, parent)
 		if !w.chainConfig.IsLondon(parent.Number) {
 			parentGasLimit := parent.GasLimit * w.chainConfig.ElasticityMultiplier()
-			header.GasLimit = core.CalcGasLimit(parentGasLimit, w.config.GasCeil)
+			header.GasLimit = core.CalcGasLimit(parentGasLimit, gasCeil)
 		}
 	}
 	// Apply EIP-4844, EIP-4788.
@@ -970,12 +1028,59 @@ func (w *worker) prepareWork(genParams *generateParams) (*environment, error) {
 	return env, nil
 }
 
-// fillTransactions retrieves the pending transactions from the txpool and fills them
-// into the given sealing block. The transaction selection and ordering strategy can
-// be customized with the plugin in the future.
+// fillTransactions retrieves the pending transactions and bundles from the txpool
+// and fills them into the given sealing block. Bundles are placed at the top of
+// the block, if that is more profitable than including the pending transactions
+// only. The transactions of the inclusion list precede both.
 func (w *worker) fillTransactions(interrupt *atomic.Int32, env *environment) error {
+	w.commitInclusionList(env)
+
+	bundles := w.eth.TxPool().Bundles(env.header.Number.Uint64(), env.header.Time)
+	if len(bundles) == 0 {
+		return w.fillPendingTransactions(interrupt, env)
+	}
+	// Bundles are available, build the block both with and without them at the
+	// top and keep the more profitable one. The blocks are built concurrently, so
+	// both get the whole time allowance.
+	var (
+		plain    = env.copy()
+		plainErr error
+		wg       sync.WaitGroup
+	)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		plainErr = w.fillPendingTransactions(interrupt, plain)
+	}()
+	err := w.commitBundles(interrupt, env, bundles)
+	if err == nil {
+		err = w.fillPendingTransactions(interrupt, env)
+	}
+	wg.Wait()
+	if err == nil {
+		err = plainErr
+	}
+	if env.state.GetBalance(env.coinbase).Cmp(plain.state.GetBalance(plain.coinbase)) < 0 {
+		log.Debug("Dropped less profitable bundles", "number", env.header.Number)
+		env.discard()
+		*env = *plain
+	} else {
+		plain.discard()
+	}
+	return err
+}
+
+// fillPendingTransactions retrieves the pending transactions from the txpool and
+// fills them into the given sealing block, locals first.
+func (w *worker) fillPendingTransactions(interrupt *atomic.Int32, env *environment) error {
 	pending := w.eth.TxPool().Pending(true)
 
+	// Keep track of the transactions considered, for reporting on the block.
+	env.pending = make(map[common.Address][]*txpool.LazyTransaction, len(pending))
+	for from, txs := range pending {
+		env.pending[from] = txs
+	}
+
 	// Split the pending transactions into locals and remotes.
 	localTxs, remoteTxs := make(map[common.Address][]*txpool.LazyTransaction), pending
 	for _, account := range w.eth.TxPool().Locals() {
@@ -987,13 +1092,13 @@ func (w *worker) fillTransactions(interrupt *atomic.Int32, env *environment) err
 
 	// Fill the block with all available pending transactions.
 	if len(localTxs) > 0 {
-		txs := newTransactionsByPriceAndNonce(env.signer, localTxs, env.header.BaseFee)
+		txs := w.orderTransactions(env, localTxs)
 		if err := w.commitTransactions(env, txs, interrupt); err != nil {
 			return err
 		}
 	}
 	if len(remoteTxs) > 0 {
-		txs := newTransactionsByPriceAndNonce(env.signer, remoteTxs, env.header.BaseFee)
+		txs := w.orderTransactions(env, remoteTxs)
 		if err := w.commitTransactions(env, txs, interrupt); err != nil {
 			return err
 		}
@@ -1009,7 +1114,18 @@ func (w *worker) generateWork(params *generateParams) *newPayloadResult {
 	}
 	defer work.discard()
 
+	var (
+		record      = !params.noTxs && w.reporting()
+		pending     map[common.Address][]*txpool.LazyTransaction
+		interrupted bool
+	)
 	if !params.noTxs {
+		// Take the transactions available to the block before filling it, so
+		// the ones arriving meanwhile aren't reported as left out.
+		if record {
+			pending = w.eth.TxPool().Pending(false)
+		}
+
 		interrupt := new(atomic.Int32)
 		timer := time.AfterFunc(w.newpayloadTimeout, func() {
 			interrupt.Store(commitInterruptTimeout)
@@ -1020,16 +1136,27 @@ func (w *worker) generateWork(params *generateParams) *newPayloadResult {
 		if errors.Is(err, errBlockInterruptedByTimeout) {
 			log.Warn("Block building is interrupted", "allowance", common.PrettyDuration(w.newpayloadTimeout))
 		}
+		interrupted = err != nil
 	}
 	block, err := w.engine.FinalizeAndAssemble(w.chain, work.header, work.state, work.txs, nil, work.receipts, params.withdrawals)
 	if err != nil {
 		return &newPayloadResult{err: err}
 	}
-	return &newPayloadResult{
+	result := &newPayloadResult{
 		block:    block,
 		fees:     totalFees(block, work.receipts),
 		sidecars: work.sidecars,
 	}
+	if record {
+		result.record = newBuildRecord(work, block, pending, interrupted)
+	}
+	return result
+}
+
+// reporting reports whether the contents of built payloads are recorded, which
+// is only done if a transaction selection policy is set or reports are enabled.
+func (w *worker) reporting() bool {
+	return w.config.Policy != nil || w.config.Reports
 }
 
 // commitWork generates several new sealing tasks based on the parent block
@@ -204,6 +204,18 @@ type Config struct {
 	// BatchResponseMaxSize is the maximum number of bytes returned from a batched rpc call.
 	BatchResponseMaxSize int `toml:",omitempty"`
 
+	// RPCRateLimit is the number of cost units each client may spend per second on
+	// the HTTP, WebSocket and IPC endpoints, and on the authenticated endpoints
+	// except for the engine API. Zero disables rate limiting.
+	RPCRateLimit float64 `toml:",omitempty"`
+
+	// RPCRateLimitBurst is the maximum number of cost units a client may spend at once.
+	RPCRateLimitBurst int `toml:",omitempty"`
+
+	// RPCMethodCosts are the rate limiting cost weights of RPC methods. Keys ending in
+	// '*' match all methods with the given prefix, other methods cost one unit.
+	RPCMethodCosts map[string]int `toml:",omitempty"`
+
 	// JWTSecret is the path to the hex-encoded jwt secret.
 	JWTSecret string `toml:",omitempty"`
 
@@ -478,3 +490,25 @@ func (c *Config) GetKeyStoreDir() (string, bool, error) {
 
 	return keydir, isEphemeral, nil
 }
+
+// rateLimitConfig returns the rate limits of the public RPC endpoints.
+func (c *Config) rateLimitConfig() rpc.RateLimitConfig {
+	return rpc.RateLimitConfig{
+		Rate:  c.RPCRateLimit,
+		Burst: c.RPCRateLimitBurst,
+		Costs: c.RPCMethodCosts,
+	}
+}
+
+// authRateLimitConfig returns the rate limits of the authenticated RPC endpoints,
+// whose clients are told apart by the subject of their JWT token. The engine API
+// is exempt, so the consensus client is never throttled.
+func (c *Config) authRateLimitConfig() rpc.RateLimitConfig {
+	config := c.rateLimitConfig()
+	config.Costs = make(map[string]int, len(c.RPCMethodCosts)+1)
+	for method, cost := range c.RPCMethodCosts {
+		config.Costs[method] = cost
+	}
+	config.Costs["engine_*"] = 0
+	return config
+}
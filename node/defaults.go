@@ -51,6 +51,23 @@ var (
 	DefaultAuthModules = []string{"eth", "engine"}
 )
 
+// DefaultRPCMethodCosts are the default rate limiting cost weights of RPC methods.
+var DefaultRPCMethodCosts = map[string]int{
+	"debug_trace*":                 100,
+	"debug_standardTrace*":         100,
+	"trace_*":                      100,
+	"eth_getLogs":                  20,
+	"eth_call":                     10,
+	"eth_estimateGas":              10,
+	"eth_createAccessList":         10,
+	"eth_getProof":                 10,
+	"eth_getFilterLogs":            20,
+	"eth_getBlockReceipts":         10,
+	"debug_getModifiedAccountsBy*": 50,
+	"debug_storageRangeAt":         20,
+	"debug_accountRange":           20,
+}
+
 // DefaultConfig contains reasonable default settings.
 var DefaultConfig = Config{
 	DataDir:              DefaultDataDir(),
@@ -65,6 +82,8 @@ var DefaultConfig = Config{
 	WSModules:            []string{"net", "web3"},
 	BatchRequestLimit:    1000,
 	BatchResponseMaxSize: 25 * 1000 * 1000,
+	RPCRateLimitBurst:    1000,
+	RPCMethodCosts:       DefaultRPCMethodCosts,
 	GraphQLVirtualHosts:  []string{"localhost"},
 	P2P: p2p.Config{
 		ListenAddr: ":30303",
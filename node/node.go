@@ -103,6 +103,7 @@ func New(conf *Config) (*Node, error) {
 	}
 	server := rpc.NewServer()
 	server.SetBatchLimits(conf.BatchRequestLimit, conf.BatchResponseMaxSize)
+	server.SetRateLimits(conf.rateLimitConfig())
 	node := &Node{
 		config:        conf,
 		inprocHandler: server,
@@ -407,6 +408,7 @@ func (n *Node) startRPC() error {
 	rpcConfig := rpcEndpointConfig{
 		batchItemLimit:         n.config.BatchRequestLimit,
 		batchResponseSizeLimit: n.config.BatchResponseMaxSize,
+		rateLimit:              n.config.rateLimitConfig(),
 	}
 
 	initHttp := func(server *httpServer, port int) error {
@@ -453,6 +455,7 @@ func (n *Node) startRPC() error {
 			jwtSecret:              secret,
 			batchItemLimit:         engineAPIBatchItemLimit,
 			batchResponseSizeLimit: engineAPIBatchResponseSizeLimit,
+			rateLimit:              n.config.authRateLimitConfig(),
 		}
 		if err := server.enableRPC(allAPIs, httpConfig{
 			CorsAllowedOrigins: DefaultAuthCors,
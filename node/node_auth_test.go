@@ -197,6 +197,80 @@ func TestAuthEndpoints(t *testing.T) {
 	}
 }
 
+// Tests that the authenticated endpoints rate limit their clients by the subject
+// of their JWT token, leaving the engine API unlimited.
+func TestAuthRateLimit(t *testing.T) {
+	var secret [32]byte
+	if _, err := crand.Read(secret[:]); err != nil {
+		t.Fatalf("failed to create jwt secret: %v", err)
+	}
+	jwtPath := path.Join(t.TempDir(), "jwt_secret")
+	if err := os.WriteFile(jwtPath, []byte(hexutil.Encode(secret[:])), 0600); err != nil {
+		t.Fatalf("failed to prepare jwt secret file: %v", err)
+	}
+	conf := &Config{
+		AuthAddr:          "127.0.0.1",
+		AuthPort:          0,
+		JWTSecret:         jwtPath,
+		RPCRateLimit:      0.001,
+		RPCRateLimitBurst: 1,
+	}
+	node, err := New(conf)
+	if err != nil {
+		t.Fatalf("could not create a new node: %v", err)
+	}
+	node.RegisterAPIs([]rpc.API{
+		{Namespace: "engine", Service: helloRPC("hello engine"), Authenticated: true},
+		{Namespace: "eth", Service: helloRPC("hello eth"), Authenticated: true},
+	})
+	if err := node.Start(); err != nil {
+		t.Fatalf("failed to start test node: %v", err)
+	}
+	defer node.Close()
+
+	dial := func(subject string) *rpc.Client {
+		cl, err := rpc.DialOptions(context.Background(), node.HTTPAuthEndpoint(), rpc.WithHTTPAuth(subjectAuth(secret, subject)))
+		if err != nil {
+			t.Fatalf("failed to dial rpc endpoint: %v", err)
+		}
+		return cl
+	}
+	alice, bob := dial("alice"), dial("bob")
+	defer alice.Close()
+	defer bob.Close()
+
+	var x string
+	if err := alice.Call(&x, "eth_helloWorld"); err != nil {
+		t.Fatalf("first call of alice failed: %v", err)
+	}
+	if err := alice.Call(&x, "eth_helloWorld"); err == nil {
+		t.Fatal("second call of alice not rate limited")
+	}
+	if err := bob.Call(&x, "eth_helloWorld"); err != nil {
+		t.Fatalf("first call of bob failed: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := alice.Call(&x, "engine_helloWorld"); err != nil {
+			t.Fatalf("engine call %d rate limited: %v", i, err)
+		}
+	}
+}
+
+func subjectAuth(secret [32]byte, subject string) rpc.HTTPAuth {
+	return func(header http.Header) error {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"iat": &jwt.NumericDate{Time: time.Now()},
+			"sub": subject,
+		})
+		s, err := token.SignedString(secret[:])
+		if err != nil {
+			return fmt.Errorf("failed to create JWT token: %w", err)
+		}
+		header.Set("Authorization", "Bearer "+s)
+		return nil
+	}
+}
+
 func noneAuth(secret [32]byte) rpc.HTTPAuth {
 	return func(header http.Header) error {
 		token := jwt.NewWithClaims(jwt.SigningMethodNone, jwt.MapClaims{
@@ -44,7 +44,7 @@ func TestMain(m *testing.M) {
 
 	flag.Parse()
 	log.PrintOrigins(true)
-	log.Root().SetHandler(log.LvlFilterHandler(log.Lvl(*loglevel), log.StreamHandler(colorable.NewColorableStderr(), log.TerminalFormat(true))))
+	log.SetDefault(log.NewLogger(log.NewTerminalHandlerWithLevel(colorable.NewColorableStderr(), log.FromLegacyLevel(*loglevel), true)))
 	os.Exit(m.Run())
 }
 
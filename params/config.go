@@ -333,6 +333,19 @@ type ChainConfig struct {
 	Ethash    *EthashConfig `json:"ethash,omitempty"`
 	Clique    *CliqueConfig `json:"clique,omitempty"`
 	IsDevMode bool          `json:"isDev,omitempty"`
+
+	// Impersonation resolves the senders of transactions sent on behalf of
+	// impersonated accounts. It's only set for development chains.
+	Impersonation ImpersonationOracle `json:"-"`
+}
+
+// ImpersonationOracle resolves the senders of the transactions a development
+// chain sent on behalf of impersonated accounts, since they carry a placeholder
+// signature the sender can't be recovered from.
+type ImpersonationOracle interface {
+	// ImpersonatedSender returns the account the transaction with the given
+	// hash was sent on behalf of, if it was sent by impersonation.
+	ImpersonatedSender(hash common.Hash) (common.Address, bool)
 }
 
 // EthashConfig is the consensus engine configs for proof-of-work based sealing.
@@ -40,6 +40,7 @@ type clientConfig struct {
 	idgen              func() ID
 	batchItemLimit     int
 	batchResponseLimit int
+	rateLimiter        *rateLimiter
 }
 
 func (cfg *clientConfig) initHeaders() {
@@ -25,6 +25,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/ethereum/go-ethereum/internal/tracing"
 	"github.com/ethereum/go-ethereum/log"
 )
 
@@ -62,6 +63,7 @@ type handler struct {
 	allowSubscribe       bool
 	batchRequestLimit    int
 	batchResponseMaxSize int
+	rateLimiter          *rateLimiter // nil if calls are not rate limited
 
 	subLock    sync.Mutex
 	serverSubs map[ID]*Subscription
@@ -491,6 +493,11 @@ func (h *handler) handleCallMsg(ctx *callProc, msg *jsonrpcMessage) *jsonrpcMess
 
 // handleCall processes method calls.
 func (h *handler) handleCall(cp *callProc, msg *jsonrpcMessage) *jsonrpcMessage {
+	if h.rateLimiter != nil {
+		if err := h.rateLimiter.take(cp.ctx, h.rateLimitMethod(msg)); err != nil {
+			return msg.errorResponse(err)
+		}
+	}
 	if msg.isSubscribe() {
 		return h.handleSubscribe(cp, msg)
 	}
@@ -504,12 +511,24 @@ func (h *handler) handleCall(cp *callProc, msg *jsonrpcMessage) *jsonrpcMessage
 		return msg.errorResponse(&methodNotFoundError{method: msg.Method})
 	}
 
+	ctx, span := tracing.StartServer(cp.ctx, msg.Method,
+		tracing.String("rpc.system", "jsonrpc"),
+		tracing.String("rpc.method", msg.Method),
+		tracing.String("rpc.jsonrpc.request_id", string(msg.ID)),
+	)
+	defer span.End()
+
 	args, err := parsePositionalArguments(msg.Params, callb.argTypes)
 	if err != nil {
+		span.SetError(err)
 		return msg.errorResponse(&invalidParamsError{err.Error()})
 	}
 	start := time.Now()
-	answer := h.runMethod(cp.ctx, msg, callb, args)
+	answer := h.runMethod(ctx, msg, callb, args)
+	if answer.Error != nil {
+		span.SetAttributes(tracing.Int("rpc.jsonrpc.error_code", answer.Error.Code))
+		span.SetError(answer.Error)
+	}
 
 	// Collect the statistics for RPC calls if metrics is enabled.
 	// We only care about pure rpc call. Filter out subscription.
@@ -527,6 +546,21 @@ func (h *handler) handleCall(cp *callProc, msg *jsonrpcMessage) *jsonrpcMessage
 	return answer
 }
 
+// rateLimitMethod returns the name a call is rate limited and counted under. Calls
+// of unknown methods share a single name, so clients can't create arbitrary
+// metrics.
+func (h *handler) rateLimitMethod(msg *jsonrpcMessage) string {
+	switch {
+	case msg.isSubscribe() || msg.isUnsubscribe():
+		if h.reg.hasService(msg.namespace()) {
+			return msg.Method
+		}
+	case h.reg.callback(msg.Method) != nil:
+		return msg.Method
+	}
+	return unknownMethod
+}
+
 // handleSubscribe processes *_subscribe method calls.
 func (h *handler) handleSubscribe(cp *callProc, msg *jsonrpcMessage) *jsonrpcMessage {
 	if !h.allowSubscribe {
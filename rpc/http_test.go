@@ -23,6 +23,8 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+
+	"github.com/ethereum/go-ethereum/internal/tracing"
 )
 
 func confirmStatusCode(t *testing.T, got, want int) {
@@ -241,3 +243,57 @@ func TestNewContextWithHeaders(t *testing.T) {
 		t.Error("call failed:", err)
 	}
 }
+
+type traceTestService struct{}
+
+func (traceTestService) SpanContext(ctx context.Context) string {
+	sc, _ := tracing.SpanContextFromContext(ctx)
+	return sc.Traceparent()
+}
+
+// Tests that the trace context sent by HTTP clients is continued by the server.
+func TestHTTPTraceparent(t *testing.T) {
+	s := newTestServer()
+	defer s.Stop()
+	if err := s.RegisterName("trace", traceTestService{}); err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	c, err := Dial(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	c.SetHeader("traceparent", traceparent)
+
+	// Without tracing, the trace context of the caller is passed through.
+	var have string
+	if err := c.Call(&have, "trace_spanContext"); err != nil {
+		t.Fatal(err)
+	}
+	if have != traceparent {
+		t.Errorf("wrong span context: have %s, want %s", have, traceparent)
+	}
+	// With tracing enabled, the method runs in a child span of the caller.
+	stop := tracing.Setup(nopExporter{}, 1)
+	defer stop()
+
+	if err := c.Call(&have, "trace_spanContext"); err != nil {
+		t.Fatal(err)
+	}
+	sc, err := tracing.ParseTraceparent(have)
+	if err != nil {
+		t.Fatalf("invalid span context %q: %v", have, err)
+	}
+	if sc.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" || sc.SpanID.String() == "00f067aa0ba902b7" || !sc.Sampled {
+		t.Errorf("wrong span context: %s", have)
+	}
+}
+
+type nopExporter struct{}
+
+func (nopExporter) Export(ctx context.Context, spans []*tracing.Span) error { return nil }
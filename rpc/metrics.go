@@ -32,6 +32,12 @@ var (
 	serveTimeHistName = "rpc/duration"
 
 	rpcServingTimer = metrics.NewRegisteredTimer("rpc/duration/all", nil)
+
+	// rateLimitHitMeter counts the calls rejected by the rate limiter.
+	rateLimitHitMeter = metrics.NewRegisteredMeter("rpc/ratelimit/hits", nil)
+
+	// rateLimitCounterName is the prefix of the per-method rate limit hit counters.
+	rateLimitCounterName = "rpc/ratelimit"
 )
 
 // updateServeTimeHistogram tracks the serving time of a remote RPC call.
@@ -48,3 +54,9 @@ func updateServeTimeHistogram(method string, success bool, elapsed time.Duration
 	}
 	metrics.GetOrRegisterHistogramLazy(h, nil, sampler).Update(elapsed.Microseconds())
 }
+
+// updateRateLimitCounter tracks a call of the given method rejected by the rate
+// limiter.
+func updateRateLimitCounter(method string) {
+	metrics.GetOrRegisterCounter(fmt.Sprintf("%s/%s", rateLimitCounterName, method), nil).Inc(1)
+}
@@ -0,0 +1,201 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/lru"
+	"github.com/ethereum/go-ethereum/common/mclock"
+)
+
+const (
+	// defaultMethodCost is the cost of methods not listed in RateLimitConfig.Costs.
+	defaultMethodCost = 1
+
+	// unknownMethod is the name calls of unregistered methods are rate limited
+	// and counted under.
+	unknownMethod = "unknown"
+
+	// rateLimitBuckets is the maximum number of clients tracked by the rate limiter.
+	// The buckets of the least recently seen clients are dropped beyond this.
+	rateLimitBuckets = 16384
+)
+
+// RateLimitConfig configures the per-client rate limiting of a server.
+//
+// Every client is given a token bucket which is refilled at Rate units per second,
+// up to Burst units. Each method call consumes the cost of the method from the bucket
+// of the client, and is rejected if not enough units are available. Clients
+// authenticated by a JWT token are identified by the subject of the token, all others
+// by their remote IP address.
+type RateLimitConfig struct {
+	// Rate is the number of cost units refilled into the bucket of a client per
+	// second. Zero disables rate limiting.
+	Rate float64
+
+	// Burst is the capacity of the bucket of a client. If zero, it defaults to
+	// the rate.
+	Burst int
+
+	// Costs are the cost weights of methods, keyed by the method name. A key ending
+	// in '*' matches all methods with the given prefix, e.g. "debug_trace*". The
+	// longest matching key is used, and methods not matching any key cost one unit.
+	Costs map[string]int
+}
+
+// rateLimiter tracks the token buckets of the clients of a server.
+type rateLimiter struct {
+	rate     float64
+	burst    float64
+	costs    map[string]float64
+	prefixes []string // wildcard cost keys, longest first
+	clock    mclock.Clock
+
+	mu      sync.Mutex
+	buckets lru.BasicLRU[string, *tokenBucket]
+}
+
+// tokenBucket is the state of a single client.
+type tokenBucket struct {
+	tokens float64
+	last   mclock.AbsTime
+}
+
+func newRateLimiter(config RateLimitConfig, clock mclock.Clock) *rateLimiter {
+	l := &rateLimiter{
+		rate:    config.Rate,
+		burst:   float64(config.Burst),
+		costs:   make(map[string]float64, len(config.Costs)),
+		clock:   clock,
+		buckets: lru.NewBasicLRU[string, *tokenBucket](rateLimitBuckets),
+	}
+	if l.burst <= 0 {
+		l.burst = math.Max(l.rate, defaultMethodCost)
+	}
+	for key, cost := range config.Costs {
+		l.costs[key] = float64(cost)
+		if strings.HasSuffix(key, "*") {
+			l.prefixes = append(l.prefixes, key)
+		}
+	}
+	sort.Slice(l.prefixes, func(i, j int) bool {
+		return len(l.prefixes[i]) > len(l.prefixes[j])
+	})
+	return l
+}
+
+// cost returns the number of units consumed by a call of the given method. Costs
+// exceeding the burst are capped, so expensive methods stay callable with a full
+// bucket.
+func (l *rateLimiter) cost(method string) float64 {
+	cost, ok := l.costs[method]
+	if !ok {
+		cost = defaultMethodCost
+		for _, prefix := range l.prefixes {
+			if strings.HasPrefix(method, strings.TrimSuffix(prefix, "*")) {
+				cost = l.costs[prefix]
+				break
+			}
+		}
+	}
+	return math.Min(cost, l.burst)
+}
+
+// take consumes the cost of the given method from the bucket of the client the
+// context belongs to. It returns a *rateLimitError if the bucket has insufficient
+// units.
+func (l *rateLimiter) take(ctx context.Context, method string) error {
+	var (
+		cost = l.cost(method)
+		key  = rateLimitKey(PeerInfoFromContext(ctx))
+	)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock.Now()
+	bucket, ok := l.buckets.Get(key)
+	if !ok {
+		bucket = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets.Add(key, bucket)
+	}
+	bucket.tokens = math.Min(l.burst, bucket.tokens+time.Duration(now-bucket.last).Seconds()*l.rate)
+	bucket.last = now
+
+	if bucket.tokens < cost {
+		rateLimitHitMeter.Mark(1)
+		updateRateLimitCounter(method)
+		wait := time.Duration((cost - bucket.tokens) / l.rate * float64(time.Second))
+		return &rateLimitError{retryAfter: wait}
+	}
+	bucket.tokens -= cost
+	return nil
+}
+
+// rateLimitKey returns the identifier of the bucket used for a client.
+func rateLimitKey(info PeerInfo) string {
+	if info.JWTSubject != "" {
+		return "jwt:" + info.JWTSubject
+	}
+	host, _, err := net.SplitHostPort(info.RemoteAddr)
+	if err != nil {
+		host = info.RemoteAddr
+	}
+	if host == "" {
+		// IPC connections don't carry an address, all of them share a bucket.
+		return info.Transport
+	}
+	return "ip:" + host
+}
+
+// rateLimitError is returned when a client exceeds its rate limit. The error data
+// contains the number of seconds after which the call can be retried.
+type rateLimitError struct {
+	retryAfter time.Duration
+}
+
+func (e *rateLimitError) ErrorCode() int { return errcodeLimitExceeded }
+
+func (e *rateLimitError) Error() string {
+	return fmt.Sprintf("%s, retry after %v", errMsgLimitExceeded, e.retryAfter.Round(time.Millisecond))
+}
+
+func (e *rateLimitError) ErrorData() interface{} {
+	return map[string]interface{}{"retryAfter": e.retryAfter.Seconds()}
+}
+
+type jwtSubjectContextKey struct{}
+
+// WithJWTSubject returns a copy of the context which carries the subject of the JWT
+// token a HTTP request was authenticated with. The server picks it up into the
+// PeerInfo of the request and uses it to identify the client for rate limiting.
+func WithJWTSubject(ctx context.Context, subject string) context.Context {
+	return context.WithValue(ctx, jwtSubjectContextKey{}, subject)
+}
+
+// jwtSubjectFromContext returns the JWT subject set by WithJWTSubject.
+func jwtSubjectFromContext(ctx context.Context) string {
+	subject, _ := ctx.Value(jwtSubjectContextKey{}).(string)
+	return subject
+}
@@ -0,0 +1,268 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/mclock"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+func peerContext(info PeerInfo) context.Context {
+	return context.WithValue(context.Background(), peerInfoContextKey{}, info)
+}
+
+func TestRateLimiterCosts(t *testing.T) {
+	l := newRateLimiter(RateLimitConfig{
+		Rate:  10,
+		Burst: 100,
+		Costs: map[string]int{
+			"debug_*":           50,
+			"debug_trace*":      100,
+			"debug_traceCall":   200,
+			"eth_getLogs":       20,
+			"eth_blockNumber":   1,
+			"trace_replayBlock": 5,
+		},
+	}, new(mclock.Simulated))
+
+	tests := []struct {
+		method string
+		cost   float64
+	}{
+		{"eth_blockNumber", 1},
+		{"eth_getLogs", 20},
+		{"eth_call", defaultMethodCost},
+		{"debug_getRawBlock", 50},
+		{"debug_traceTransaction", 100},
+		{"debug_traceCall", 100}, // capped at burst
+		{"trace_replayBlock", 5},
+		{"trace_block", defaultMethodCost},
+	}
+	for _, test := range tests {
+		if cost := l.cost(test.method); cost != test.cost {
+			t.Errorf("%s: wrong cost %v, want %v", test.method, cost, test.cost)
+		}
+	}
+}
+
+func TestRateLimiterBuckets(t *testing.T) {
+	var (
+		clock = new(mclock.Simulated)
+		l     = newRateLimiter(RateLimitConfig{Rate: 2, Burst: 10, Costs: map[string]int{"debug_*": 8}}, clock)
+		ctxA  = peerContext(PeerInfo{Transport: "http", RemoteAddr: "10.0.0.1:1234"})
+		ctxA2 = peerContext(PeerInfo{Transport: "ws", RemoteAddr: "10.0.0.1:5678"})
+		ctxB  = peerContext(PeerInfo{Transport: "http", RemoteAddr: "10.0.0.2:1234"})
+		ctxC  = peerContext(PeerInfo{Transport: "http", RemoteAddr: "10.0.0.1:1234", JWTSubject: "alice"})
+	)
+	if err := l.take(ctxA, "debug_traceBlock"); err != nil {
+		t.Fatalf("first call rejected: %v", err)
+	}
+	// The same IP shares the bucket across connections and transports.
+	err := l.take(ctxA2, "debug_traceBlock")
+	var limitErr *rateLimitError
+	if !errors.As(err, &limitErr) {
+		t.Fatalf("expected rate limit error, got %v", err)
+	}
+	if limitErr.retryAfter != 3*time.Second {
+		t.Errorf("wrong retry hint %v, want %v", limitErr.retryAfter, 3*time.Second)
+	}
+	// Cheap calls still pass with the remaining units.
+	if err := l.take(ctxA, "eth_blockNumber"); err != nil {
+		t.Fatalf("cheap call rejected: %v", err)
+	}
+	// Other clients are not affected.
+	if err := l.take(ctxB, "debug_traceBlock"); err != nil {
+		t.Fatalf("call of other IP rejected: %v", err)
+	}
+	if err := l.take(ctxC, "debug_traceBlock"); err != nil {
+		t.Fatalf("call of JWT subject rejected: %v", err)
+	}
+	// After the hinted time the bucket is refilled.
+	clock.Run(3500 * time.Millisecond)
+	if err := l.take(ctxA, "debug_traceBlock"); err != nil {
+		t.Fatalf("call rejected after refill: %v", err)
+	}
+}
+
+func TestRateLimitKey(t *testing.T) {
+	tests := []struct {
+		info PeerInfo
+		key  string
+	}{
+		{PeerInfo{Transport: "http", RemoteAddr: "10.0.0.1:1234"}, "ip:10.0.0.1"},
+		{PeerInfo{Transport: "ws", RemoteAddr: "[::1]:1234"}, "ip:::1"},
+		{PeerInfo{Transport: "http", RemoteAddr: "10.0.0.1:1234", JWTSubject: "cl"}, "jwt:cl"},
+		{PeerInfo{Transport: "ipc"}, "ipc"},
+	}
+	for _, test := range tests {
+		if key := rateLimitKey(test.info); key != test.key {
+			t.Errorf("%+v: wrong key %q, want %q", test.info, key, test.key)
+		}
+	}
+}
+
+// checkRateLimited calls a method until the rate limit is hit, and checks the
+// error returned for the rejected call.
+func checkRateLimited(t *testing.T, c *Client, allowed int) {
+	t.Helper()
+
+	for i := 0; i < allowed; i++ {
+		if err := c.Call(nil, "test_noArgsRets"); err != nil {
+			t.Fatalf("call %d rejected: %v", i, err)
+		}
+	}
+	err := c.Call(nil, "test_noArgsRets")
+	if err == nil {
+		t.Fatal("expected rate limit error")
+	}
+	var rpcErr Error
+	if !errors.As(err, &rpcErr) || rpcErr.ErrorCode() != errcodeLimitExceeded {
+		t.Fatalf("wrong error %v", err)
+	}
+	var dataErr DataError
+	if !errors.As(err, &dataErr) {
+		t.Fatalf("error has no data: %v", err)
+	}
+	data, ok := dataErr.ErrorData().(map[string]interface{})
+	if !ok || data["retryAfter"] == nil {
+		t.Fatalf("wrong error data %v", dataErr.ErrorData())
+	}
+}
+
+func newRateLimitedServer() *Server {
+	server := newTestServer()
+	server.SetRateLimits(RateLimitConfig{Rate: 0.001, Burst: 2})
+	return server
+}
+
+func TestRateLimitHTTP(t *testing.T) {
+	server := newRateLimitedServer()
+	defer server.Stop()
+	httpsrv := httptest.NewServer(server)
+	defer httpsrv.Close()
+
+	c, err := DialHTTP(httpsrv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	checkRateLimited(t, c, 2)
+}
+
+func TestRateLimitWebsocket(t *testing.T) {
+	server := newRateLimitedServer()
+	defer server.Stop()
+	httpsrv := httptest.NewServer(server.WebsocketHandler([]string{"*"}))
+	defer httpsrv.Close()
+
+	c, err := DialWebsocket(context.Background(), "ws:"+strings.TrimPrefix(httpsrv.URL, "http:"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	checkRateLimited(t, c, 2)
+}
+
+func TestRateLimitIPC(t *testing.T) {
+	server := newRateLimitedServer()
+	defer server.Stop()
+
+	p1, p2 := net.Pipe()
+	go server.ServeCodec(NewCodec(p1), 0)
+	c, err := newClient(context.Background(), new(clientConfig), func(context.Context) (ServerCodec, error) {
+		return NewCodec(p2), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	checkRateLimited(t, c, 2)
+
+	// In-process clients are exempt.
+	inproc := DialInProc(server)
+	defer inproc.Close()
+	for i := 0; i < 5; i++ {
+		if err := inproc.Call(nil, "test_noArgsRets"); err != nil {
+			t.Fatalf("in-process call %d rejected: %v", i, err)
+		}
+	}
+}
+
+// Tests that calls of unknown methods are counted under a single name, so clients
+// can't register arbitrary metrics.
+func TestRateLimitUnknownMethods(t *testing.T) {
+	server := newRateLimitedServer()
+	defer server.Stop()
+	httpsrv := httptest.NewServer(server)
+	defer httpsrv.Close()
+
+	c, err := DialHTTP(httpsrv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	for i := 0; i < 3; i++ {
+		c.Call(nil, fmt.Sprintf("junk_method%d", i))
+	}
+	if metrics.DefaultRegistry.Get(rateLimitCounterName+"/junk_method2") != nil {
+		t.Fatal("counter registered for unknown method")
+	}
+	if metrics.DefaultRegistry.Get(rateLimitCounterName+"/"+unknownMethod) == nil {
+		t.Fatal("unknown method calls not counted")
+	}
+}
+
+func TestRateLimitJWTSubject(t *testing.T) {
+	server := newRateLimitedServer()
+	defer server.Stop()
+	httpsrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		subject := r.Header.Get("X-Test-Subject")
+		server.ServeHTTP(w, r.WithContext(WithJWTSubject(r.Context(), subject)))
+	}))
+	defer httpsrv.Close()
+
+	dial := func(subject string) *Client {
+		c, err := DialOptions(context.Background(), httpsrv.URL, WithHeader("X-Test-Subject", subject))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+	alice, bob := dial("alice"), dial("bob")
+	defer alice.Close()
+	defer bob.Close()
+
+	var info PeerInfo
+	if err := alice.Call(&info, "test_peerInfo"); err != nil {
+		t.Fatal(err)
+	}
+	if info.JWTSubject != "alice" {
+		t.Fatalf("wrong JWTSubject %q", info.JWTSubject)
+	}
+	checkRateLimited(t, alice, 1)
+	checkRateLimited(t, bob, 2)
+}
@@ -22,6 +22,7 @@ import (
 	"sync"
 	"sync/atomic"
 
+	"github.com/ethereum/go-ethereum/common/mclock"
 	"github.com/ethereum/go-ethereum/log"
 )
 
@@ -51,6 +52,7 @@ type Server struct {
 	run                atomic.Bool
 	batchItemLimit     int
 	batchResponseLimit int
+	rateLimiter        *rateLimiter
 }
 
 // NewServer creates a new server instance with no registered handlers.
@@ -78,6 +80,20 @@ func (s *Server) SetBatchLimits(itemLimit, maxResponseSize int) {
 	s.batchResponseLimit = maxResponseSize
 }
 
+// SetRateLimits enables per-client rate limiting of method calls. Calls exceeding the
+// limit of a client are answered with an error carrying a retry-after hint. A zero
+// rate disables rate limiting. In-process clients are never rate limited.
+//
+// This method should be called before processing any requests via ServeCodec, ServeHTTP,
+// ServeListener etc.
+func (s *Server) SetRateLimits(config RateLimitConfig) {
+	if config.Rate <= 0 {
+		s.rateLimiter = nil
+		return
+	}
+	s.rateLimiter = newRateLimiter(config, mclock.System{})
+}
+
 // RegisterName creates a service for the given receiver type under the given name. When no
 // methods on the given receiver match the criteria to be either a RPC method or a
 // subscription an error is returned. Otherwise a new service is created and added to the
@@ -92,6 +108,12 @@ func (s *Server) RegisterName(name string, receiver interface{}) error {
 //
 // Note that codec options are no longer supported.
 func (s *Server) ServeCodec(codec ServerCodec, options CodecOption) {
+	s.serveCodec(codec, s.rateLimiter)
+}
+
+// serveCodec serves the requests of a codec, applying the given rate limiter. The
+// limiter may be nil to serve the codec without rate limiting.
+func (s *Server) serveCodec(codec ServerCodec, limiter *rateLimiter) {
 	defer codec.close()
 
 	if !s.trackCodec(codec) {
@@ -103,6 +125,7 @@ func (s *Server) ServeCodec(codec ServerCodec, options CodecOption) {
 		idgen:              s.idgen,
 		batchItemLimit:     s.batchItemLimit,
 		batchResponseLimit: s.batchResponseLimit,
+		rateLimiter:        limiter,
 	}
 	c := initClient(codec, &s.services, cfg)
 	<-codec.closed()
@@ -138,6 +161,7 @@ func (s *Server) serveSingleRequest(ctx context.Context, codec ServerCodec) {
 
 	h := newHandler(ctx, codec, s.idgen, &s.services, s.batchItemLimit, s.batchResponseLimit)
 	h.allowSubscribe = false
+	h.rateLimiter = s.rateLimiter
 	defer h.close(io.EOF, nil)
 
 	reqs, batch, err := codec.readBatch()
@@ -201,6 +225,10 @@ type PeerInfo struct {
 	// Address of client. This will usually contain the IP address and port.
 	RemoteAddr string
 
+	// Subject of the JWT token the client authenticated with. This is only set for
+	// HTTP and WebSocket connections carrying a subject set by WithJWTSubject.
+	JWTSubject string
+
 	// Additional information for HTTP and WebSocket connections.
 	HTTP struct {
 		// Protocol version, i.e. "HTTP/1.1". This is not set for WebSocket.
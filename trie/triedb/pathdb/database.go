@@ -86,10 +86,11 @@ type layer interface {
 
 // Config contains the settings for database.
 type Config struct {
-	StateHistory   uint64 // Number of recent blocks to maintain state history for
-	CleanCacheSize int    // Maximum memory allowance (in bytes) for caching clean nodes
-	DirtyCacheSize int    // Maximum memory allowance (in bytes) for caching dirty nodes
-	ReadOnly       bool   // Flag whether the database is opened in read only mode.
+	StateHistory      uint64 // Number of recent blocks to maintain state history for
+	StateHistoryIndex bool   // Flag whether the state histories are indexed for historical state reads
+	CleanCacheSize    int    // Maximum memory allowance (in bytes) for caching clean nodes
+	DirtyCacheSize    int    // Maximum memory allowance (in bytes) for caching dirty nodes
+	ReadOnly          bool   // Flag whether the database is opened in read only mode.
 }
 
 // sanitize checks the provided user configurations and changes anything that's
@@ -134,6 +135,7 @@ type Database struct {
 	diskdb     ethdb.Database           // Persistent storage for matured trie nodes
 	tree       *layerTree               // The group for all known layers
 	freezer    *rawdb.ResettableFreezer // Freezer for storing trie histories, nil possible in tests
+	indexer    *historyIndexer          // Background indexer of state histories, nil if not enabled
 	lock       sync.RWMutex             // Lock to prevent mutations from happening at the same time
 }
 
@@ -178,6 +180,17 @@ func New(diskdb ethdb.Database, config *Config) *Database {
 		if pruned != 0 {
 			log.Warn("Truncated extra state histories", "number", pruned)
 		}
+		// Start indexing the state histories if historical state reads are
+		// requested, otherwise drop the stale index left by a previous run,
+		// as it's not maintained anymore.
+		if config.StateHistoryIndex {
+			db.indexer = newHistoryIndexer(db)
+		} else if rawdb.ReadStateHistoryIndexHead(diskdb) != nil {
+			log.Info("Deleting state history index")
+			if err := rawdb.DeleteStateHistoryIndex(diskdb); err != nil {
+				log.Crit("Failed to delete state history index", "err", err)
+			}
+		}
 	}
 	log.Warn("Path-based state scheme is an experimental feature")
 	return db
@@ -279,6 +292,9 @@ func (db *Database) Reset(root common.Hash) error {
 		if err := db.freezer.Reset(); err != nil {
 			return err
 		}
+		if err := rawdb.DeleteStateHistoryIndex(db.diskdb); err != nil {
+			return err
+		}
 	}
 	// Re-construct a new disk layer backed by persistent state
 	// with **empty clean cache and node buffer**.
@@ -364,6 +380,11 @@ func (db *Database) Recoverable(root common.Hash) bool {
 
 // Close closes the trie database and the held freezer.
 func (db *Database) Close() error {
+	// Terminate the history indexer before holding the lock, it might be
+	// waiting for it.
+	if db.indexer != nil {
+		db.indexer.close()
+	}
 	db.lock.Lock()
 	defer db.lock.Unlock()
 
@@ -23,6 +23,7 @@ import (
 	"math/big"
 	"math/rand"
 	"testing"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/rawdb"
@@ -97,9 +98,13 @@ type tester struct {
 }
 
 func newTester(t *testing.T) *tester {
+	return newTesterWithConfig(t, &Config{CleanCacheSize: 256 * 1024, DirtyCacheSize: 256 * 1024})
+}
+
+func newTesterWithConfig(t *testing.T, config *Config) *tester {
 	var (
 		disk, _ = rawdb.NewDatabaseWithFreezer(rawdb.NewMemoryDatabase(), t.TempDir(), "", false)
-		db      = New(disk, &Config{CleanCacheSize: 256 * 1024, DirtyCacheSize: 256 * 1024})
+		db      = New(disk, config)
 		obj     = &tester{
 			db:           db,
 			preimages:    make(map[common.Hash]common.Address),
@@ -553,6 +558,94 @@ func TestCorruptedJournal(t *testing.T) {
 }
 
 // copyAccounts returns a deep-copied account set of the provided one.
+func TestHistoricalStateReads(t *testing.T) {
+	tester := newTesterWithConfig(t, &Config{StateHistoryIndex: true, CleanCacheSize: 256 * 1024, DirtyCacheSize: 256 * 1024})
+	defer tester.release()
+
+	var (
+		bottom = tester.bottomIndex()
+		disk   = tester.roots[bottom]
+		loader = newHashLoader(tester.snapAccounts[disk], tester.snapStorages[disk])
+	)
+	// Pick a fixed set of accounts to check, both existent and non-existent
+	// ones at the various historical states.
+	var addrs []common.Address
+	for _, addr := range tester.preimages {
+		addrs = append(addrs, addr)
+		if len(addrs) == 32 {
+			break
+		}
+	}
+	verify := func(root common.Hash) error {
+		reader, err := tester.db.HistoricReader(root, loader)
+		if err != nil {
+			return err
+		}
+		for _, addr := range addrs {
+			addrHash := crypto.Keccak256Hash(addr.Bytes())
+			blob, err := reader.Account(addr)
+			if err != nil {
+				return err
+			}
+			if want := tester.snapAccounts[root][addrHash]; !bytes.Equal(blob, want) {
+				return fmt.Errorf("account %x mismatch: have %x, want %x", addr, blob, want)
+			}
+			for slot, want := range tester.snapStorages[root][addrHash] {
+				blob, err := reader.Storage(addr, slot)
+				if err != nil {
+					return err
+				}
+				if !bytes.Equal(blob, want) {
+					return fmt.Errorf("slot %x:%x mismatch: have %x, want %x", addr, slot, blob, want)
+				}
+			}
+		}
+		return nil
+	}
+	// Serve the reads before the histories are indexed, partially at least
+	for i := 0; i < bottom; i += 32 {
+		if err := verify(tester.roots[i]); err != nil {
+			t.Fatalf("state %d: failed to read unindexed state: %v", i, err)
+		}
+	}
+	// Wait until all histories are indexed and serve the reads again
+	for start := time.Now(); readIndexHead(tester.db.diskdb) != tester.db.tree.bottom().stateID(); {
+		if time.Since(start) > 10*time.Second {
+			t.Fatal("state histories are not indexed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	for i := 0; i < bottom; i += 4 {
+		if err := verify(tester.roots[i]); err != nil {
+			t.Fatalf("state %d: failed to read indexed state: %v", i, err)
+		}
+	}
+	// States in the layer tree are not served as historical ones
+	if _, err := tester.db.HistoricReader(disk, loader); err == nil {
+		t.Fatal("disk layer state served as historical")
+	}
+	// Revert a few states, the truncated histories must be dropped from the
+	// index and the remaining historical states must be served correctly.
+	for i := bottom; i > bottom-8; i-- {
+		root := tester.roots[i]
+		loader := newHashLoader(tester.snapAccounts[root], tester.snapStorages[root])
+		if err := tester.db.Recover(tester.roots[i-1], loader); err != nil {
+			t.Fatalf("Failed to revert db, err: %v", err)
+		}
+	}
+	bottom -= 8
+	if head := readIndexHead(tester.db.diskdb); head != uint64(bottom+1) {
+		t.Fatalf("unexpected index head after revert: have %d, want %d", head, bottom+1)
+	}
+	disk = tester.roots[bottom]
+	loader = newHashLoader(tester.snapAccounts[disk], tester.snapStorages[disk])
+	for i := 0; i < bottom; i += 4 {
+		if err := verify(tester.roots[i]); err != nil {
+			t.Fatalf("state %d: failed to read reverted state: %v", i, err)
+		}
+	}
+}
+
 func copyAccounts(set map[common.Hash][]byte) map[common.Hash][]byte {
 	copied := make(map[common.Hash][]byte, len(set))
 	for key, val := range set {
@@ -181,6 +181,9 @@ func (dl *diskLayer) commit(bottom *diffLayer, force bool) (*diskLayer, error) {
 		if err != nil {
 			return nil, err
 		}
+		if dl.db.indexer != nil {
+			dl.db.indexer.notify()
+		}
 	}
 	// Mark the diskLayer as stale before applying any mutations on top.
 	dl.stale = true
@@ -576,7 +576,7 @@ func checkHistories(freezer *rawdb.ResettableFreezer, start, count uint64, check
 
 // truncateFromHead removes the extra state histories from the head with the given
 // parameters. It returns the number of items removed from the head.
-func truncateFromHead(db ethdb.Batcher, freezer *rawdb.ResettableFreezer, nhead uint64) (int, error) {
+func truncateFromHead(db ethdb.KeyValueStore, freezer *rawdb.ResettableFreezer, nhead uint64) (int, error) {
 	ohead, err := freezer.Ancients()
 	if err != nil {
 		return 0, err
@@ -600,6 +600,10 @@ func truncateFromHead(db ethdb.Batcher, freezer *rawdb.ResettableFreezer, nhead
 	if err := batch.Write(); err != nil {
 		return 0, err
 	}
+	// Drop the truncated histories from the index before they are gone.
+	if err := unindexHistories(db, freezer, nhead+1, ohead); err != nil {
+		return 0, err
+	}
 	ohead, err = freezer.TruncateHead(nhead)
 	if err != nil {
 		return 0, err
@@ -609,7 +613,7 @@ func truncateFromHead(db ethdb.Batcher, freezer *rawdb.ResettableFreezer, nhead
 
 // truncateFromTail removes the extra state histories from the tail with the given
 // parameters. It returns the number of items removed from the tail.
-func truncateFromTail(db ethdb.Batcher, freezer *rawdb.ResettableFreezer, ntail uint64) (int, error) {
+func truncateFromTail(db ethdb.KeyValueStore, freezer *rawdb.ResettableFreezer, ntail uint64) (int, error) {
 	otail, err := freezer.Tail()
 	if err != nil {
 		return 0, err
@@ -633,6 +637,10 @@ func truncateFromTail(db ethdb.Batcher, freezer *rawdb.ResettableFreezer, ntail
 	if err := batch.Write(); err != nil {
 		return 0, err
 	}
+	// Drop the truncated histories from the index before they are gone.
+	if err := unindexHistories(db, freezer, otail+1, ntail); err != nil {
+		return 0, err
+	}
 	otail, err = freezer.TruncateTail(ntail)
 	if err != nil {
 		return 0, err
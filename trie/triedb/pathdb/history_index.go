@@ -0,0 +1,408 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>
+
+package pathdb
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// State history index maps every state element (account, storage slot) to the
+// list of state histories in which it was modified. It is used to locate the
+// history which holds the value of an element at a given historical state:
+// the original value recorded in the first history after that state.
+//
+// The ids of the histories modifying an element are split into chunks, each
+// covering historyIndexChunkSize consecutive ids, so that appending a new id
+// only rewrites a bounded amount of data.
+//
+// Besides accounts and storage slots, the histories in which the storage of an
+// account was wiped without being fully recorded (see meta.incomplete) are
+// indexed per account as well. Storage reads spanning such a history can't be
+// served.
+
+const (
+	// historyIndexChunkSize is the number of consecutive state history ids
+	// covered by a single index chunk.
+	historyIndexChunkSize = 4096
+
+	// historyIndexBatch is the maximum number of state histories indexed in
+	// a single database batch.
+	historyIndexBatch = 128
+)
+
+// indexKind is the type of an indexed state element.
+type indexKind uint8
+
+const (
+	accountIndexKind indexKind = iota // Account modifications
+	storageIndexKind                  // Storage slot modifications
+	wipeIndexKind                     // Incompletely recorded storage wipes
+)
+
+// stateIdent is the identifier of an indexed state element.
+type stateIdent struct {
+	kind    indexKind
+	address common.Address
+	slot    common.Hash // Only set for storage slots
+}
+
+// read retrieves the index chunk of the state element.
+func (s stateIdent) read(db ethdb.KeyValueReader, chunk uint32) []byte {
+	switch s.kind {
+	case accountIndexKind:
+		return rawdb.ReadAccountHistoryIndex(db, s.address, chunk)
+	case storageIndexKind:
+		return rawdb.ReadStorageHistoryIndex(db, s.address, s.slot, chunk)
+	default:
+		return rawdb.ReadWipeHistoryIndex(db, s.address, chunk)
+	}
+}
+
+// write stores the index chunk of the state element, or deletes it if empty.
+func (s stateIdent) write(db ethdb.KeyValueWriter, chunk uint32, ids []uint64) {
+	if len(ids) == 0 {
+		switch s.kind {
+		case accountIndexKind:
+			rawdb.DeleteAccountHistoryIndex(db, s.address, chunk)
+		case storageIndexKind:
+			rawdb.DeleteStorageHistoryIndex(db, s.address, s.slot, chunk)
+		default:
+			rawdb.DeleteWipeHistoryIndex(db, s.address, chunk)
+		}
+		return
+	}
+	blob := encodeHistoryIDs(ids)
+	switch s.kind {
+	case accountIndexKind:
+		rawdb.WriteAccountHistoryIndex(db, s.address, chunk, blob)
+	case storageIndexKind:
+		rawdb.WriteStorageHistoryIndex(db, s.address, s.slot, chunk, blob)
+	default:
+		rawdb.WriteWipeHistoryIndex(db, s.address, chunk, blob)
+	}
+}
+
+// iterate returns an iterator over the index chunks of the state element,
+// starting from the given chunk.
+func (s stateIdent) iterate(db ethdb.Iteratee, chunk uint32) ethdb.Iterator {
+	switch s.kind {
+	case accountIndexKind:
+		return rawdb.IterateAccountHistoryIndex(db, s.address, chunk)
+	case storageIndexKind:
+		return rawdb.IterateStorageHistoryIndex(db, s.address, s.slot, chunk)
+	default:
+		return rawdb.IterateWipeHistoryIndex(db, s.address, chunk)
+	}
+}
+
+// historyChunk returns the index chunk which the given state history id
+// belongs to.
+func historyChunk(id uint64) uint32 {
+	return uint32(id / historyIndexChunkSize)
+}
+
+// encodeHistoryIDs packs the sorted list of state history ids into byte stream.
+func encodeHistoryIDs(ids []uint64) []byte {
+	buf := make([]byte, 8*len(ids))
+	for i, id := range ids {
+		binary.BigEndian.PutUint64(buf[8*i:], id)
+	}
+	return buf
+}
+
+// decodeHistoryIDs unpacks the list of state history ids from byte stream.
+func decodeHistoryIDs(blob []byte) ([]uint64, error) {
+	if len(blob)%8 != 0 {
+		return nil, fmt.Errorf("invalid history index, len: %d", len(blob))
+	}
+	ids := make([]uint64, len(blob)/8)
+	for i := range ids {
+		ids[i] = binary.BigEndian.Uint64(blob[8*i:])
+	}
+	return ids, nil
+}
+
+// readIndexHead returns the id of the latest indexed state history, zero if
+// none is indexed yet.
+func readIndexHead(db ethdb.KeyValueReader) uint64 {
+	head := rawdb.ReadStateHistoryIndexHead(db)
+	if head == nil {
+		return 0
+	}
+	return *head
+}
+
+// chunkKey identifies an index chunk of a state element.
+type chunkKey struct {
+	ident stateIdent
+	chunk uint32
+}
+
+// indexBatch accumulates the index mutations of a batch of state histories.
+// The touched chunks are cached in memory, so that every chunk is read and
+// written only once, no matter how many histories in the batch modify it.
+type indexBatch struct {
+	db     ethdb.KeyValueReader
+	chunks map[chunkKey][]uint64
+}
+
+func newIndexBatch(db ethdb.KeyValueReader) *indexBatch {
+	return &indexBatch{db: db, chunks: make(map[chunkKey][]uint64)}
+}
+
+// load retrieves the ids within the index chunk the given history id
+// belongs to.
+func (b *indexBatch) load(ident stateIdent, id uint64) (chunkKey, []uint64, error) {
+	key := chunkKey{ident: ident, chunk: historyChunk(id)}
+	if ids, ok := b.chunks[key]; ok {
+		return key, ids, nil
+	}
+	ids, err := decodeHistoryIDs(ident.read(b.db, key.chunk))
+	if err != nil {
+		return chunkKey{}, nil, err
+	}
+	return key, ids, nil
+}
+
+// add inserts the history id into the index of the state element.
+func (b *indexBatch) add(ident stateIdent, id uint64) error {
+	key, ids, err := b.load(ident, id)
+	if err != nil {
+		return err
+	}
+	pos := sort.Search(len(ids), func(i int) bool { return ids[i] >= id })
+	if pos < len(ids) && ids[pos] == id {
+		return nil // already indexed
+	}
+	ids = append(ids, 0)
+	copy(ids[pos+1:], ids[pos:])
+	ids[pos] = id
+	b.chunks[key] = ids
+	return nil
+}
+
+// remove deletes the history id from the index of the state element.
+func (b *indexBatch) remove(ident stateIdent, id uint64) error {
+	key, ids, err := b.load(ident, id)
+	if err != nil {
+		return err
+	}
+	pos := sort.Search(len(ids), func(i int) bool { return ids[i] >= id })
+	if pos == len(ids) || ids[pos] != id {
+		return nil // not indexed
+	}
+	b.chunks[key] = append(ids[:pos], ids[pos+1:]...)
+	return nil
+}
+
+// forEach calls the callback for every state element modified in the history.
+func (h *history) forEach(fn func(ident stateIdent) error) error {
+	for _, addr := range h.accountList {
+		if err := fn(stateIdent{kind: accountIndexKind, address: addr}); err != nil {
+			return err
+		}
+		for _, slot := range h.storageList[addr] {
+			if err := fn(stateIdent{kind: storageIndexKind, address: addr, slot: slot}); err != nil {
+				return err
+			}
+		}
+	}
+	for _, addr := range h.meta.incomplete {
+		if err := fn(stateIdent{kind: wipeIndexKind, address: addr}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addHistory indexes all the state elements modified in the history.
+func (b *indexBatch) addHistory(id uint64, h *history) error {
+	return h.forEach(func(ident stateIdent) error { return b.add(ident, id) })
+}
+
+// removeHistory unindexes all the state elements modified in the history.
+func (b *indexBatch) removeHistory(id uint64, h *history) error {
+	return h.forEach(func(ident stateIdent) error { return b.remove(ident, id) })
+}
+
+// write flushes all the accumulated index chunks into the given writer.
+func (b *indexBatch) write(w ethdb.KeyValueWriter) {
+	for key, ids := range b.chunks {
+		key.ident.write(w, key.chunk, ids)
+	}
+}
+
+// unindexHistories removes the state histories in range [start, end] from
+// the index and rewinds the index head if it's within the range. It must be
+// called before the histories are truncated from the freezer.
+func unindexHistories(db ethdb.KeyValueStore, freezer *rawdb.ResettableFreezer, start, end uint64) error {
+	head := readIndexHead(db)
+	if head < start {
+		return nil // Nothing indexed in the range
+	}
+	if end > head {
+		end = head
+	}
+	batch := newIndexBatch(db)
+	for id := start; id <= end; id++ {
+		h, err := readHistory(freezer, id)
+		if err != nil {
+			return err
+		}
+		if err := batch.removeHistory(id, h); err != nil {
+			return err
+		}
+	}
+	w := db.NewBatch()
+	batch.write(w)
+	if end == head {
+		rawdb.WriteStateHistoryIndexHead(w, start-1)
+	}
+	return w.Write()
+}
+
+// historyIndexer is responsible for indexing the state histories in the
+// background, catching up with the newly written ones.
+type historyIndexer struct {
+	db      *Database
+	trigger chan struct{}
+	closed  chan struct{}
+	once    sync.Once
+	wg      sync.WaitGroup
+}
+
+// newHistoryIndexer creates the history indexer and starts indexing.
+func newHistoryIndexer(db *Database) *historyIndexer {
+	indexer := &historyIndexer{
+		db:      db,
+		trigger: make(chan struct{}, 1),
+		closed:  make(chan struct{}),
+	}
+	indexer.wg.Add(1)
+	go indexer.loop()
+	indexer.notify()
+	return indexer
+}
+
+// notify signals the indexer that new state histories are available.
+func (i *historyIndexer) notify() {
+	select {
+	case i.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// close terminates the indexer and waits until it exits.
+func (i *historyIndexer) close() {
+	i.once.Do(func() { close(i.closed) })
+	i.wg.Wait()
+}
+
+func (i *historyIndexer) loop() {
+	defer i.wg.Done()
+
+	var (
+		logged  time.Time
+		indexed int
+	)
+	for {
+		select {
+		case <-i.trigger:
+			for {
+				n, done, err := i.run()
+				if err != nil {
+					log.Error("Failed to index state histories", "err", err)
+					break
+				}
+				indexed += n
+				if time.Since(logged) > 8*time.Second && indexed > 0 {
+					log.Info("Indexed state histories", "count", indexed, "head", readIndexHead(i.db.diskdb))
+					logged, indexed = time.Now(), 0
+				}
+				if done {
+					break
+				}
+				select {
+				case <-i.closed:
+					return
+				default:
+				}
+			}
+		case <-i.closed:
+			return
+		}
+	}
+}
+
+// run indexes the next batch of unindexed state histories. It returns the
+// number of indexed histories and the flag whether the indexer caught up.
+func (i *historyIndexer) run() (int, bool, error) {
+	// Hold the read lock to prevent the histories being truncated during
+	// the indexing, and the reads to observe a half-written index.
+	i.db.lock.RLock()
+	defer i.db.lock.RUnlock()
+
+	if i.db.readOnly {
+		return 0, true, nil
+	}
+	tail, err := i.db.freezer.Tail()
+	if err != nil {
+		return 0, false, err
+	}
+	last, err := i.db.freezer.Ancients()
+	if err != nil {
+		return 0, false, err
+	}
+	start := readIndexHead(i.db.diskdb)
+	if start < tail {
+		start = tail // Histories below the tail are already pruned
+	}
+	start += 1
+	if start > last {
+		return 0, true, nil
+	}
+	end := start + historyIndexBatch - 1
+	if end > last {
+		end = last
+	}
+	batch := newIndexBatch(i.db.diskdb)
+	for id := start; id <= end; id++ {
+		h, err := readHistory(i.db.freezer, id)
+		if err != nil {
+			return 0, false, err
+		}
+		if err := batch.addHistory(id, h); err != nil {
+			return 0, false, err
+		}
+	}
+	w := i.db.diskdb.NewBatch()
+	batch.write(w)
+	rawdb.WriteStateHistoryIndexHead(w, end)
+	if err := w.Write(); err != nil {
+		return 0, false, err
+	}
+	return int(end - start + 1), end == last, nil
+}
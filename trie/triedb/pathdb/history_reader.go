@@ -0,0 +1,334 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>
+
+package pathdb
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/trie/triestate"
+)
+
+// HistoricalStateReader is a reader for accessing the accounts and storage
+// slots of a historical state, which is no longer maintained in the layer tree
+// but still covered by the state histories.
+//
+// The value of a state element at historical state n is the original value
+// recorded in the first state history after n which modifies the element. If
+// no such history exists, the element is unchanged since then and is resolved
+// from the persistent disk layer.
+type HistoricalStateReader struct {
+	db     *Database
+	id     uint64               // State id of the historical state
+	root   common.Hash          // State root of the historical state
+	loader triestate.TrieLoader // Loader for resolving unchanged elements from the disk layer
+}
+
+// HistoricReader returns a reader for accessing the historical state with the
+// given root. An error is returned if the state histories are not indexed or
+// the required histories are not available.
+func (db *Database) HistoricReader(root common.Hash, loader triestate.TrieLoader) (*HistoricalStateReader, error) {
+	if !db.config.StateHistoryIndex || db.freezer == nil {
+		return nil, errors.New("historical state reads are not enabled")
+	}
+	root = types.TrieRootHash(root)
+	id := rawdb.ReadStateID(db.diskdb, root)
+	if id == nil {
+		return nil, fmt.Errorf("state %#x is not available", root)
+	}
+	r := &HistoricalStateReader{
+		db:     db,
+		id:     *id,
+		root:   root,
+		loader: loader,
+	}
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	if err := r.check(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Root returns the state root of the historical state.
+func (r *HistoricalStateReader) Root() common.Hash {
+	return r.root
+}
+
+// check ensures the historical state is still servable, that all the state
+// histories after it are still available. The database lock must be held.
+func (r *HistoricalStateReader) check() error {
+	if r.db.readOnly {
+		return errSnapshotReadOnly
+	}
+	if r.id >= r.db.tree.bottom().stateID() {
+		return fmt.Errorf("state %#x is not historical", r.root)
+	}
+	tail, err := r.db.freezer.Tail()
+	if err != nil {
+		return err
+	}
+	if r.id < tail {
+		return fmt.Errorf("%w: state %#x is pruned", errStateUnrecoverable, r.root)
+	}
+	return nil
+}
+
+// Account retrieves the account with the given address in the 'slim RLP'
+// format. Nil is returned if the account was not present.
+func (r *HistoricalStateReader) Account(address common.Address) ([]byte, error) {
+	r.db.lock.RLock()
+	defer r.db.lock.RUnlock()
+
+	if err := r.check(); err != nil {
+		return nil, err
+	}
+	id, err := r.db.nextModification(stateIdent{kind: accountIndexKind, address: address}, r.id)
+	if err != nil {
+		return nil, err
+	}
+	if id != 0 {
+		blob, _, err := readAccountFromHistory(r.db.freezer, id, address)
+		return blob, err
+	}
+	acct, err := r.diskAccount(address)
+	if err != nil || acct == nil {
+		return nil, err
+	}
+	return types.SlimAccountRLP(*acct), nil
+}
+
+// Storage retrieves the storage slot with the given slot hash of the specified
+// account, in the prefix-zero trimmed RLP format. Nil is returned if the slot
+// was not present.
+func (r *HistoricalStateReader) Storage(address common.Address, slot common.Hash) ([]byte, error) {
+	r.db.lock.RLock()
+	defer r.db.lock.RUnlock()
+
+	if err := r.check(); err != nil {
+		return nil, err
+	}
+	id, err := r.db.nextModification(stateIdent{kind: storageIndexKind, address: address, slot: slot}, r.id)
+	if err != nil {
+		return nil, err
+	}
+	// Refuse to serve the slot if the storage was wiped in between without
+	// being recorded, the slot may have been deleted there.
+	wiped, err := r.db.nextModification(stateIdent{kind: wipeIndexKind, address: address}, r.id)
+	if err != nil {
+		return nil, err
+	}
+	if wiped != 0 && (id == 0 || wiped <= id) {
+		return nil, fmt.Errorf("incomplete state history %d for account %#x", wiped, address)
+	}
+	if id != 0 {
+		return readStorageFromHistory(r.db.freezer, id, address, slot)
+	}
+	acct, err := r.diskAccount(address)
+	if err != nil || acct == nil {
+		return nil, err
+	}
+	dl := r.db.tree.bottom()
+	tr, err := r.loader.OpenStorageTrie(dl.rootHash(), crypto.Keccak256Hash(address.Bytes()), acct.Root)
+	if err != nil {
+		return nil, err
+	}
+	return tr.Get(slot.Bytes())
+}
+
+// diskAccount resolves the account from the persistent disk layer.
+func (r *HistoricalStateReader) diskAccount(address common.Address) (*types.StateAccount, error) {
+	tr, err := r.loader.OpenTrie(r.db.tree.bottom().rootHash())
+	if err != nil {
+		return nil, err
+	}
+	blob, err := tr.Get(crypto.Keccak256(address.Bytes()))
+	if err != nil || len(blob) == 0 {
+		return nil, err
+	}
+	// The account is decoded leniently, accepting the slim format as well.
+	return types.FullAccount(blob)
+}
+
+// nextModification returns the id of the first state history after the given
+// state id in which the state element was modified, or zero if the element is
+// unchanged since then. The database lock must be held.
+func (db *Database) nextModification(ident stateIdent, id uint64) (uint64, error) {
+	var (
+		disk = db.tree.bottom().stateID()
+		head = readIndexHead(db.diskdb)
+	)
+	// Look up the indexed histories first
+	if head > id {
+		it := ident.iterate(db.diskdb, historyChunk(id+1))
+		defer it.Release()
+
+		for it.Next() {
+			ids, err := decodeHistoryIDs(it.Value())
+			if err != nil {
+				return 0, err
+			}
+			pos := sort.Search(len(ids), func(i int) bool { return ids[i] > id })
+			if pos < len(ids) {
+				if ids[pos] > head || ids[pos] > disk {
+					break
+				}
+				return ids[pos], nil
+			}
+		}
+		if err := it.Error(); err != nil {
+			return 0, err
+		}
+		id = head
+	}
+	// Scan the histories which are not indexed yet
+	for n := id + 1; n <= disk; n++ {
+		found, err := historyModifies(db.freezer, n, ident)
+		if err != nil {
+			return 0, err
+		}
+		if found {
+			return n, nil
+		}
+	}
+	return 0, nil
+}
+
+// historyModifies reports whether the state element was modified in the
+// state history with the given id.
+func historyModifies(freezer *rawdb.ResettableFreezer, id uint64, ident stateIdent) (bool, error) {
+	if ident.kind == wipeIndexKind {
+		blob := rawdb.ReadStateHistoryMeta(freezer, id)
+		if len(blob) == 0 {
+			return false, fmt.Errorf("state history not found %d", id)
+		}
+		var m meta
+		if err := m.decode(blob); err != nil {
+			return false, err
+		}
+		for _, addr := range m.incomplete {
+			if addr == ident.address {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	_, index, err := readAccountFromHistory(freezer, id, ident.address)
+	if err != nil || index == nil {
+		return false, err
+	}
+	if ident.kind == accountIndexKind {
+		return true, nil
+	}
+	_, found, err := findSlotIndex(freezer, id, index, ident.slot)
+	return found, err
+}
+
+// readAccountFromHistory resolves the original value of the account from the
+// state history with the given id. The returned index is nil if the account
+// is not modified in the history.
+func readAccountFromHistory(freezer *rawdb.ResettableFreezer, id uint64, address common.Address) ([]byte, *accountIndex, error) {
+	indexes := rawdb.ReadStateAccountIndex(freezer, id)
+	if len(indexes)%accountIndexSize != 0 {
+		return nil, nil, fmt.Errorf("invalid account index, len: %d", len(indexes))
+	}
+	n := len(indexes) / accountIndexSize
+	pos := sort.Search(n, func(i int) bool {
+		return bytes.Compare(indexes[i*accountIndexSize:i*accountIndexSize+common.AddressLength], address.Bytes()) >= 0
+	})
+	if pos == n {
+		return nil, nil, nil
+	}
+	var index accountIndex
+	index.decode(indexes[pos*accountIndexSize : (pos+1)*accountIndexSize])
+	if index.address != address {
+		return nil, nil, nil
+	}
+	if index.length == 0 {
+		return nil, &index, nil
+	}
+	data := rawdb.ReadStateAccountHistory(freezer, id)
+	last := index.offset + uint32(index.length)
+	if uint32(len(data)) < last {
+		return nil, nil, errors.New("account data buffer is corrupted")
+	}
+	return common.CopyBytes(data[index.offset:last]), &index, nil
+}
+
+// findSlotIndex locates the storage slot among the slots of the account
+// modified in the state history with the given id.
+func findSlotIndex(freezer *rawdb.ResettableFreezer, id uint64, acct *accountIndex, slot common.Hash) (slotIndex, bool, error) {
+	if acct.storageSlots == 0 {
+		return slotIndex{}, false, nil
+	}
+	var (
+		indexes = rawdb.ReadStateStorageIndex(freezer, id)
+		start   = int(acct.storageOffset) * slotIndexSize
+		end     = int(acct.storageOffset+acct.storageSlots) * slotIndexSize
+	)
+	if len(indexes) < end {
+		return slotIndex{}, false, errors.New("storage index buffer is corrupted")
+	}
+	indexes = indexes[start:end]
+	pos := sort.Search(int(acct.storageSlots), func(i int) bool {
+		return bytes.Compare(indexes[i*slotIndexSize:i*slotIndexSize+common.HashLength], slot.Bytes()) >= 0
+	})
+	if pos == int(acct.storageSlots) {
+		return slotIndex{}, false, nil
+	}
+	var index slotIndex
+	index.decode(indexes[pos*slotIndexSize : (pos+1)*slotIndexSize])
+	if index.hash != slot {
+		return slotIndex{}, false, nil
+	}
+	return index, true, nil
+}
+
+// readStorageFromHistory resolves the original value of the storage slot from
+// the state history with the given id.
+func readStorageFromHistory(freezer *rawdb.ResettableFreezer, id uint64, address common.Address, slot common.Hash) ([]byte, error) {
+	_, acct, err := readAccountFromHistory(freezer, id, address)
+	if err != nil {
+		return nil, err
+	}
+	if acct == nil {
+		return nil, fmt.Errorf("account %#x is not in state history %d", address, id)
+	}
+	index, found, err := findSlotIndex(freezer, id, acct, slot)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("slot %#x is not in state history %d", slot, id)
+	}
+	if index.length == 0 {
+		return nil, nil
+	}
+	data := rawdb.ReadStateStorageHistory(freezer, id)
+	last := index.offset + uint32(index.length)
+	if uint32(len(data)) < last {
+		return nil, errors.New("storage data buffer is corrupted")
+	}
+	return common.CopyBytes(data[index.offset:last]), nil
+}